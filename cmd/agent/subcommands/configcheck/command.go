@@ -10,6 +10,8 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
+	"os"
 
 	"github.com/fatih/color"
 	"github.com/spf13/cobra"
@@ -22,6 +24,8 @@ import (
 	log "github.com/DataDog/datadog-agent/comp/core/log/def"
 	"github.com/DataDog/datadog-agent/comp/core/secrets"
 	apiutil "github.com/DataDog/datadog-agent/pkg/api/util"
+	pkgconfigmodel "github.com/DataDog/datadog-agent/pkg/config/model"
+	pkgconfigsetup "github.com/DataDog/datadog-agent/pkg/config/setup"
 	"github.com/DataDog/datadog-agent/pkg/flare"
 	"github.com/DataDog/datadog-agent/pkg/util/fxutil"
 )
@@ -30,7 +34,8 @@ import (
 type cliParams struct {
 	*command.GlobalParams
 
-	verbose bool
+	verbose  bool
+	validate bool
 }
 
 // Commands returns a slice of subcommands for the 'agent' command.
@@ -43,12 +48,13 @@ func Commands(globalParams *command.GlobalParams) []*cobra.Command {
 		Use:     "configcheck",
 		Aliases: []string{"checkconfig"},
 		Short:   "Print all configurations loaded & resolved of a running agent",
-		Long:    ``,
+		Long:    `Print all configurations loaded & resolved of a running agent. With --validate, check the agent configuration files and environment variables against the configuration schema instead.`,
 		RunE: func(_ *cobra.Command, _ []string) error {
 			return fxutil.OneShot(run,
 				fx.Supply(cliParams),
 				fx.Supply(core.BundleParams{
-					ConfigParams: config.NewAgentParams(globalParams.ConfFilePath, config.WithExtraConfFiles(cliParams.ExtraConfFilePath), config.WithFleetPoliciesDirPath(cliParams.FleetPoliciesDirPath)),
+					// the configuration is validated even when it can't be loaded, like when 'strict_config_validation' is enabled
+					ConfigParams: config.NewAgentParams(globalParams.ConfFilePath, config.WithExtraConfFiles(cliParams.ExtraConfFilePath), config.WithFleetPoliciesDirPath(cliParams.FleetPoliciesDirPath), config.WithIgnoreErrors(cliParams.validate)),
 					SecretParams: secrets.NewEnabledParams(),
 					LogParams:    log.ForOneShot("CORE", "off", true)}),
 				core.Bundle(),
@@ -56,11 +62,16 @@ func Commands(globalParams *command.GlobalParams) []*cobra.Command {
 		},
 	}
 	configCheckCommand.Flags().BoolVarP(&cliParams.verbose, "verbose", "v", false, "print additional debug info")
+	configCheckCommand.Flags().BoolVar(&cliParams.validate, "validate", false, "validate the agent configuration instead of printing the check configurations")
 
 	return []*cobra.Command{configCheckCommand}
 }
 
 func run(config config.Component, cliParams *cliParams, _ log.Component) error {
+	if cliParams.validate {
+		return validateConfig(os.Stdout, config)
+	}
+
 	endpoint, err := apiutil.NewIPCEndpoint(config, "/agent/config-check")
 	if err != nil {
 		return err
@@ -84,3 +95,27 @@ func run(config config.Component, cliParams *cliParams, _ log.Component) error {
 	fmt.Println(b.String())
 	return nil
 }
+
+// validateConfig prints the errors found in the configuration, and fails if any of them would prevent the agent from
+// starting with 'strict_config_validation' enabled.
+func validateConfig(w io.Writer, config pkgconfigmodel.Reader) error {
+	errs := pkgconfigsetup.ValidateConfig(config)
+	if len(errs) == 0 {
+		fmt.Fprintln(w, color.GreenString("The configuration is valid"))
+		return nil
+	}
+
+	blocking := 0
+	for _, err := range errs {
+		if pkgconfigsetup.IsBlockingValidationError(err) {
+			blocking++
+			fmt.Fprintf(w, "%s %s\n", color.RedString("error:"), err)
+		} else {
+			fmt.Fprintf(w, "%s %s\n", color.YellowString("warning:"), err)
+		}
+	}
+	if blocking > 0 {
+		return fmt.Errorf("found %d error(s) in the configuration", blocking)
+	}
+	return nil
+}
@@ -6,12 +6,16 @@
 package configcheck
 
 import (
+	"bytes"
 	"testing"
 
+	"github.com/fatih/color"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/DataDog/datadog-agent/cmd/agent/command"
 	"github.com/DataDog/datadog-agent/comp/core"
+	"github.com/DataDog/datadog-agent/comp/core/config"
 	"github.com/DataDog/datadog-agent/comp/core/secrets"
 	"github.com/DataDog/datadog-agent/pkg/util/fxutil"
 )
@@ -26,3 +30,33 @@ func TestCommand(t *testing.T) {
 			require.Equal(t, true, secretParams.Enabled)
 		})
 }
+
+func TestCommandValidate(t *testing.T) {
+	fxutil.TestOneShotSubcommand(t,
+		Commands(&command.GlobalParams{}),
+		[]string{"configcheck", "--validate"},
+		run,
+		func(cliParams *cliParams, _ core.BundleParams) {
+			require.Equal(t, true, cliParams.validate)
+		})
+}
+
+func TestValidateConfig(t *testing.T) {
+	color.NoColor = true
+
+	var b bytes.Buffer
+	cfg := config.NewMockFromYAML(t, `
+log_enabled: true
+api_kye: fakeapikey
+`)
+	err := validateConfig(&b, cfg)
+	assert.EqualError(t, err, "found 1 error(s) in the configuration")
+	assert.Equal(t, `error: unknown key 'api_kye', did you mean 'api_key'?
+warning: 'log_enabled' from file is deprecated, use 'logs_enabled' instead
+`, b.String())
+
+	b.Reset()
+	cfg = config.NewMockFromYAML(t, "log_level: debug")
+	assert.NoError(t, validateConfig(&b, cfg))
+	assert.Equal(t, "The configuration is valid\n", b.String())
+}
@@ -0,0 +1,91 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// Package capture implements 'trace-agent capture' cli.
+package capture
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/fx"
+
+	"github.com/DataDog/datadog-agent/cmd/trace-agent/subcommands"
+	"github.com/DataDog/datadog-agent/comp/core/config"
+	"github.com/DataDog/datadog-agent/comp/core/secrets"
+	apiutil "github.com/DataDog/datadog-agent/pkg/api/util"
+	"github.com/DataDog/datadog-agent/pkg/util/fxutil"
+	"github.com/DataDog/datadog-agent/pkg/util/option"
+)
+
+const defaultCaptureDuration = time.Minute
+
+// cliParams are the command-line arguments for this subcommand
+type cliParams struct {
+	duration time.Duration
+	path     string
+}
+
+// MakeCommand returns a command for the `capture` CLI command
+func MakeCommand(globalParamsGetter func() *subcommands.GlobalParams) *cobra.Command {
+	cliParams := &cliParams{}
+	cmd := &cobra.Command{
+		Use:   "capture",
+		Short: "Start a capture of the trace payloads received by a running trace-agent",
+		Long: `Records the raw trace payloads received by the running trace-agent, along with their headers,
+to a compressed file for the given duration. The file can be fed back to a trace-agent with
+'trace-agent replay'.`,
+		RunE: func(*cobra.Command, []string) error {
+			return fxutil.OneShot(startCapture,
+				fx.Supply(cliParams),
+				fx.Supply(config.NewAgentParams(globalParamsGetter().ConfPath, config.WithFleetPoliciesDirPath(globalParamsGetter().FleetPoliciesDirPath))),
+				fx.Supply(option.None[secrets.Component]()),
+				config.Module(),
+			)
+		},
+		SilenceUsage: true,
+	}
+	cmd.Flags().DurationVarP(&cliParams.duration, "duration", "d", defaultCaptureDuration, "Duration the capture should span.")
+	cmd.Flags().StringVarP(&cliParams.path, "path", "p", "", "Directory path to write the capture to, defaults to the trace_capture directory of run_path.")
+	return cmd
+}
+
+func startCapture(config config.Component, cliParams *cliParams) error {
+	if err := apiutil.SetAuthToken(config); err != nil {
+		return err
+	}
+	port := config.GetInt("apm_config.debug.port")
+	if port <= 0 {
+		return fmt.Errorf("invalid apm_config.debug.port -- %d", port)
+	}
+	path := cliParams.path
+	if path == "" {
+		path = filepath.Join(config.GetString("run_path"), "trace_capture")
+	}
+
+	c := apiutil.GetClient(false)
+	c.Timeout = config.GetDuration("server_timeout") * time.Second
+
+	query := url.Values{
+		"duration": {cliParams.duration.String()},
+		"path":     {path},
+	}
+	res, err := apiutil.DoPost(c, fmt.Sprintf("https://127.0.0.1:%d/capture?%s", port, query.Encode()), "application/json", nil)
+	if err != nil {
+		return fmt.Errorf("could not start the trace capture: %s", err)
+	}
+	var resp struct {
+		Path string `json:"path"`
+	}
+	if err := json.Unmarshal(res, &resp); err != nil {
+		return fmt.Errorf("invalid response from the trace-agent: %s", err)
+	}
+	fmt.Printf("Capture started, capture file being written to: %s\n", resp.Path)
+	return nil
+}
@@ -0,0 +1,230 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// Package replay implements 'trace-agent replay' cli.
+package replay
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/fx"
+
+	"github.com/DataDog/datadog-agent/cmd/trace-agent/subcommands"
+	coreconfig "github.com/DataDog/datadog-agent/comp/core/config"
+	"github.com/DataDog/datadog-agent/comp/core/secrets"
+	"github.com/DataDog/datadog-agent/comp/core/secrets/secretsimpl"
+	nooptagger "github.com/DataDog/datadog-agent/comp/core/tagger/fx-noop"
+	"github.com/DataDog/datadog-agent/comp/trace/config"
+	"github.com/DataDog/datadog-agent/pkg/trace/api"
+	traceconfig "github.com/DataDog/datadog-agent/pkg/trace/config"
+	"github.com/DataDog/datadog-agent/pkg/util/fxutil"
+	"github.com/DataDog/datadog-agent/pkg/util/option"
+)
+
+const (
+	defaultIterations = 1
+	requestTimeout    = 10 * time.Second
+)
+
+// cliParams are the command-line arguments for this subcommand
+type cliParams struct {
+	file       string
+	target     string
+	verbose    bool
+	iterations int
+}
+
+// MakeCommand returns a command for the `replay` CLI command
+func MakeCommand(globalParamsGetter func() *subcommands.GlobalParams) *cobra.Command {
+	cliParams := &cliParams{}
+	cmd := &cobra.Command{
+		Use:   "replay",
+		Short: "Replay trace payloads captured with 'trace-agent capture'",
+		Long: `Sends the trace payloads of a capture file, along with their headers, to the receiver of the
+local trace-agent, so that their processing can be reproduced. The payloads are sent to the
+listener configured for the receiver: its TCP port, or else its Unix Domain Socket or Windows
+named pipe, unless another receiver is given with --target.`,
+		RunE: func(*cobra.Command, []string) error {
+			return fxutil.OneShot(replay,
+				fx.Supply(cliParams),
+				config.Module(),
+				fx.Supply(coreconfig.NewAgentParams(globalParamsGetter().ConfPath, coreconfig.WithFleetPoliciesDirPath(globalParamsGetter().FleetPoliciesDirPath))),
+				fx.Supply(option.None[secrets.Component]()),
+				fx.Supply(secrets.NewEnabledParams()),
+				coreconfig.Module(),
+				secretsimpl.Module(),
+				nooptagger.Module(),
+			)
+		},
+		SilenceUsage: true,
+	}
+	cmd.Flags().StringVarP(&cliParams.file, "file", "f", "", "Input file with payloads captured with 'trace-agent capture'.")
+	cmd.Flags().StringVarP(&cliParams.target, "target", "t", "", "Receiver to send the payloads to, as http://host:port or unix:///path/to/socket. Defaults to the configured receiver.")
+	cmd.Flags().BoolVarP(&cliParams.verbose, "verbose", "v", false, "Verbose replay.")
+	cmd.Flags().IntVarP(&cliParams.iterations, "loops", "l", defaultIterations, "Number of iterations to replay.")
+	return cmd
+}
+
+func replay(config config.Component, cliParams *cliParams) error {
+	if cliParams.file == "" {
+		return errors.New("a capture file must be specified with --file")
+	}
+	var (
+		t   *target
+		err error
+	)
+	if cliParams.target != "" {
+		t, err = parseTarget(cliParams.target)
+	} else {
+		tracecfg := config.Object()
+		if tracecfg == nil {
+			return errors.New("unable to successfully parse config")
+		}
+		t, err = receiverTarget(tracecfg)
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Replaying trace payloads to %s...\n", t.name)
+	var sent, failed int
+	for i := 0; i < cliParams.iterations; i++ {
+		s, f, err := replayFile(t.client, t.url, cliParams.file, cliParams.verbose)
+		sent += s
+		failed += f
+		if err != nil {
+			return err
+		}
+	}
+	fmt.Printf("Replay done, %d payloads sent, %d rejected.\n", sent, failed)
+	return nil
+}
+
+// target is a receiver the payloads are sent to.
+type target struct {
+	client *http.Client
+	url    string // base URL of the requests
+	name   string // the listener, for display
+}
+
+// receiverTarget returns the receiver configured by cfg, reached through the first of its
+// listeners which is enabled, in the order the receiver starts them.
+func receiverTarget(cfg *traceconfig.AgentConfig) (*target, error) {
+	if !cfg.ReceiverEnabled {
+		return nil, errors.New("the trace receiver is disabled (apm_config.receiver_enabled: false)")
+	}
+	switch {
+	case cfg.ReceiverPort > 0:
+		host := cfg.ReceiverHost
+		if ip := net.ParseIP(host); host == "" || ip != nil && ip.IsUnspecified() {
+			// the receiver listens on all the interfaces
+			host = "localhost"
+		}
+		addr := "http://" + net.JoinHostPort(host, strconv.Itoa(cfg.ReceiverPort))
+		return &target{client: &http.Client{Timeout: requestTimeout}, url: addr, name: addr}, nil
+	case cfg.ReceiverSocket != "":
+		return unixTarget(cfg.ReceiverSocket), nil
+	case cfg.WindowsPipeName != "":
+		path := `\\.\pipe\` + cfg.WindowsPipeName
+		return &target{
+			client: &http.Client{
+				Timeout: requestTimeout,
+				Transport: &http.Transport{
+					DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+						return dialPipe(ctx, path)
+					},
+				},
+			},
+			url:  "http://pipe",
+			name: fmt.Sprintf("Windows pipe %q", path),
+		}, nil
+	}
+	return nil, errors.New("all the listeners of the trace receiver are disabled")
+}
+
+// parseTarget returns the receiver s, of the form http://host:port or unix:///path/to/socket.
+func parseTarget(s string) (*target, error) {
+	u, err := url.Parse(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid target %q: %s", s, err)
+	}
+	switch {
+	case u.Scheme == "http" && u.Host != "":
+		addr := "http://" + u.Host
+		return &target{client: &http.Client{Timeout: requestTimeout}, url: addr, name: addr}, nil
+	case u.Scheme == "unix" && u.Path != "":
+		return unixTarget(u.Path), nil
+	}
+	return nil, fmt.Errorf("invalid target %q: must be of the form http://host:port or unix:///path/to/socket", s)
+}
+
+// unixTarget returns the receiver listening on the Unix Domain Socket path.
+func unixTarget(path string) *target {
+	return &target{
+		client: &http.Client{
+			Timeout: requestTimeout,
+			Transport: &http.Transport{
+				DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+					var d net.Dialer
+					return d.DialContext(ctx, "unix", path)
+				},
+			},
+		},
+		url:  "http://unix",
+		name: "unix://" + path,
+	}
+}
+
+// replayFile sends the records of the capture file path to the receiver at addr. It
+// returns the number of payloads sent, and the number of those rejected by the receiver.
+func replayFile(client *http.Client, addr, path string, verbose bool) (sent, failed int, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer f.Close()
+	r, err := api.NewCaptureReader(f)
+	if err != nil {
+		return 0, 0, err
+	}
+	for {
+		rec, err := r.Next()
+		if err == io.EOF {
+			return sent, failed, nil
+		}
+		if err != nil {
+			return sent, failed, fmt.Errorf("could not read %s: %s", path, err)
+		}
+		req, err := http.NewRequest(http.MethodPost, addr+rec.Path, bytes.NewReader(rec.Body))
+		if err != nil {
+			return sent, failed, err
+		}
+		req.Header = rec.Header
+		resp, err := client.Do(req)
+		if err != nil {
+			return sent, failed, fmt.Errorf("could not send payload to the trace-agent: %s", err)
+		}
+		io.Copy(io.Discard, resp.Body) //nolint:errcheck
+		resp.Body.Close()
+		sent++
+		if resp.StatusCode >= 400 {
+			failed++
+		}
+		if verbose {
+			fmt.Printf("Sent %d bytes to %s, captured at %s: %s\n", len(rec.Body), rec.Path, rec.Time.Format(time.RFC3339), resp.Status)
+		}
+	}
+}
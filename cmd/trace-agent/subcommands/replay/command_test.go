@@ -0,0 +1,161 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package replay
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/cmd/trace-agent/subcommands"
+	"github.com/DataDog/datadog-agent/pkg/trace/api"
+	"github.com/DataDog/datadog-agent/pkg/trace/config"
+	"github.com/DataDog/datadog-agent/pkg/util/fxutil"
+)
+
+func TestReplayCommand(t *testing.T) {
+	fxutil.TestOneShotSubcommand(t,
+		[]*cobra.Command{MakeCommand(func() *subcommands.GlobalParams {
+			return &subcommands.GlobalParams{}
+		})},
+		[]string{"replay", "-f", "capture.gz", "-l", "3", "-t", "unix:///tmp/apm.socket"},
+		replay,
+		func(cliParams *cliParams) {
+			require.Equal(t, "capture.gz", cliParams.file)
+			require.Equal(t, "unix:///tmp/apm.socket", cliParams.target)
+			require.Equal(t, 3, cliParams.iterations)
+		})
+}
+
+func TestReplayFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "capture.gz")
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	w, err := api.NewCaptureWriter(f)
+	require.NoError(t, err)
+	for _, p := range []string{"/v0.4/traces", "/v0.5/traces"} {
+		require.NoError(t, w.Write(&api.CaptureRecord{
+			Time:   time.Now(),
+			Path:   p,
+			Header: http.Header{"Content-Type": {"application/msgpack"}, "Datadog-Meta-Lang": {"go"}},
+			Body:   []byte(p),
+		}))
+	}
+	require.NoError(t, w.Close())
+	require.NoError(t, f.Close())
+
+	var paths []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+		assert.Equal(t, req.URL.Path, string(body))
+		assert.Equal(t, "go", req.Header.Get("Datadog-Meta-Lang"))
+		paths = append(paths, req.URL.Path)
+		if req.URL.Path == "/v0.5/traces" {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer srv.Close()
+
+	sent, failed, err := replayFile(srv.Client(), srv.URL, path, false)
+	require.NoError(t, err)
+	assert.Equal(t, 2, sent)
+	assert.Equal(t, 1, failed)
+	assert.Equal(t, []string{"/v0.4/traces", "/v0.5/traces"}, paths)
+}
+
+func TestReceiverTarget(t *testing.T) {
+	for name, tt := range map[string]struct {
+		host, socket, pipe string
+		port               int
+		name               string
+	}{
+		"localhost":    {host: "localhost", port: 8126, socket: "/var/run/datadog/apm.socket", name: "http://localhost:8126"},
+		"bind host":    {host: "10.0.0.1", port: 8126, name: "http://10.0.0.1:8126"},
+		"any IPv4":     {host: "0.0.0.0", port: 8126, name: "http://localhost:8126"},
+		"any IPv6":     {host: "::", port: 8126, name: "http://localhost:8126"},
+		"socket":       {host: "localhost", socket: "/var/run/datadog/apm.socket", pipe: "dd-apm", name: "unix:///var/run/datadog/apm.socket"},
+		"windows pipe": {host: "localhost", pipe: "dd-apm", name: `Windows pipe "\\\\.\\pipe\\dd-apm"`},
+	} {
+		t.Run(name, func(t *testing.T) {
+			cfg := config.New()
+			cfg.ReceiverHost = tt.host
+			cfg.ReceiverPort = tt.port
+			cfg.ReceiverSocket = tt.socket
+			cfg.WindowsPipeName = tt.pipe
+			target, err := receiverTarget(cfg)
+			require.NoError(t, err)
+			assert.Equal(t, tt.name, target.name)
+		})
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		cfg := config.New()
+		cfg.ReceiverPort = 0
+		_, err := receiverTarget(cfg)
+		assert.Error(t, err)
+
+		cfg = config.New()
+		cfg.ReceiverEnabled = false
+		_, err = receiverTarget(cfg)
+		assert.Error(t, err)
+	})
+}
+
+func TestParseTarget(t *testing.T) {
+	target, err := parseTarget("http://127.0.0.1:8126")
+	require.NoError(t, err)
+	assert.Equal(t, "http://127.0.0.1:8126", target.url)
+
+	target, err = parseTarget("unix:///var/run/datadog/apm.socket")
+	require.NoError(t, err)
+	assert.Equal(t, "unix:///var/run/datadog/apm.socket", target.name)
+
+	for _, s := range []string{"localhost:8126", "https://localhost:8126", "unix://", "%"} {
+		_, err := parseTarget(s)
+		assert.Error(t, err, s)
+	}
+}
+
+func TestReplayFileUnix(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Unix Domain Sockets are not used by the receiver on Windows")
+	}
+	path := filepath.Join(t.TempDir(), "capture.gz")
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	w, err := api.NewCaptureWriter(f)
+	require.NoError(t, err)
+	require.NoError(t, w.Write(&api.CaptureRecord{Time: time.Now(), Path: "/v0.4/traces", Header: http.Header{}, Body: []byte("body")}))
+	require.NoError(t, w.Close())
+	require.NoError(t, f.Close())
+
+	socket := filepath.Join(t.TempDir(), "apm.socket")
+	ln, err := net.Listen("unix", socket)
+	require.NoError(t, err)
+	var paths []string
+	srv := &http.Server{Handler: http.HandlerFunc(func(_ http.ResponseWriter, req *http.Request) {
+		paths = append(paths, req.URL.Path)
+	})}
+	go srv.Serve(ln) //nolint:errcheck
+	defer srv.Close()
+
+	target := unixTarget(socket)
+	sent, failed, err := replayFile(target.client, target.url, path, false)
+	require.NoError(t, err)
+	assert.Equal(t, 1, sent)
+	assert.Equal(t, 0, failed)
+	assert.Equal(t, []string{"/v0.4/traces"}, paths)
+}
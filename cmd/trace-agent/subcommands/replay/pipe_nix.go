@@ -0,0 +1,19 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build !windows
+
+package replay
+
+import (
+	"context"
+	"errors"
+	"net"
+)
+
+// dialPipe returns an error on non-Windows operating systems.
+func dialPipe(context.Context, string) (net.Conn, error) {
+	return nil, errors.New("Windows named pipes are only supported on Windows operating systems")
+}
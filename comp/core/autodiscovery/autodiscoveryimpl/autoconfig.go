@@ -94,6 +94,13 @@ type AutoConfig struct {
 
 	// ranOnce is set to 1 once the AutoConfig has been executed
 	ranOnce *atomic.Bool
+
+	// secretsChangedConfigs holds the names of the configs whose secrets
+	// changed, until serviceListening reschedules them. secretsChanged is
+	// signaled when a name is added.
+	secretsChangedConfigs map[string]struct{}
+	secretsChanged        chan struct{}
+	secretsChangedMutex   sync.Mutex
 }
 
 const (
@@ -211,6 +218,11 @@ func createNewAutoConfig(schedulerController *scheduler.Controller, secretResolv
 		taggerComp:               taggerComp,
 		logs:                     logs,
 		telemetryStore:           acTelemetry.NewStore(telemetryComp),
+		secretsChangedConfigs:    make(map[string]struct{}),
+		secretsChanged:           make(chan struct{}, 1),
+	}
+	if secretResolver != nil {
+		secretResolver.SubscribeToChanges(ac.onSecretChange)
 	}
 	return ac
 }
@@ -234,6 +246,8 @@ func (ac *AutoConfig) serviceListening() {
 			ac.processNewService(ctx, svc)
 		case svc := <-ac.delService:
 			ac.processDelService(ctx, svc)
+		case <-ac.secretsChanged:
+			ac.processSecretsChanges()
 		}
 	}
 }
@@ -645,6 +659,44 @@ func (ac *AutoConfig) RemoveScheduler(name string) {
 	ac.schedulerController.Deregister(name)
 }
 
+// onSecretChange is notified by the secrets component when a secret is
+// resolved or refreshed. It's called with the lock of the secrets component
+// held, so the configs using the secret are rescheduled asynchronously by
+// serviceListening.
+func (ac *AutoConfig) onSecretChange(_, origin string, _ []string, oldValue, newValue any) {
+	if oldValue == "" || oldValue == newValue {
+		// the secret was resolved for the first time, or didn't change
+		return
+	}
+	ac.secretsChangedMutex.Lock()
+	ac.secretsChangedConfigs[origin] = struct{}{}
+	ac.secretsChangedMutex.Unlock()
+	select {
+	case ac.secretsChanged <- struct{}{}:
+	default:
+	}
+}
+
+// processSecretsChanges reschedules the configs whose secrets changed, so
+// that their checks use the new values, such as rotated database credentials.
+func (ac *AutoConfig) processSecretsChanges() {
+	ac.secretsChangedMutex.Lock()
+	configNames := ac.secretsChangedConfigs
+	ac.secretsChangedConfigs = make(map[string]struct{})
+	ac.secretsChangedMutex.Unlock()
+
+	for name := range configNames {
+		changes, changedIDsOfSecretsWithConfigs := ac.cfgMgr.processSecretsChange(name)
+		if changes.IsEmpty() {
+			continue
+		}
+		log.Infof("Secrets of %s changed, rescheduling %d configs", name, len(changes.Schedule))
+		ac.deleteMappingsOfCheckIDsWithSecrets(changes.Unschedule)
+		ac.store.setIDsOfChecksWithSecrets(changedIDsOfSecretsWithConfigs)
+		ac.applyChanges(changes)
+	}
+}
+
 func (ac *AutoConfig) processRemovedConfigs(configs []integration.Config) {
 	changes := ac.cfgMgr.processDelConfigs(configs)
 	ac.applyChanges(changes)
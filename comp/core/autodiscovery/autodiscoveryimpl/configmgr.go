@@ -41,6 +41,11 @@ type configManager interface {
 	// interface apply to only one config.
 	processDelConfigs(configs []integration.Config) integration.ConfigChanges
 
+	// processSecretsChange resolves again the secrets of the configs with the
+	// given name, after the value of one of their secrets changed, and
+	// reschedules the configs that changed.
+	processSecretsChange(configName string) (integration.ConfigChanges, map[checkid.ID]checkid.ID)
+
 	// mapOverLoadedConfigs calls the given function with a map of all
 	// loaded configs (those which have been scheduled but not unscheduled).
 	// The call is made with the manager's lock held, so callers should perform
@@ -98,6 +103,10 @@ type reconcilingConfigManager struct {
 	// methods correspond exactly to changes in this map.
 	scheduledConfigs map[string]integration.Config
 
+	// decryptedDigests maps the digest of each non-template config to the
+	// digest of the config scheduled for it, with its secrets decrypted.
+	decryptedDigests map[string]string
+
 	secretResolver secrets.Component
 }
 
@@ -112,6 +121,7 @@ func newReconcilingConfigManager(secretResolver secrets.Component) configManager
 		servicesByADID:     newMultimap(),
 		serviceResolutions: map[string]map[string]string{},
 		scheduledConfigs:   map[string]integration.Config{},
+		decryptedDigests:   map[string]string{},
 		secretResolver:     secretResolver,
 	}
 }
@@ -228,6 +238,7 @@ func (cm *reconcilingConfigManager) processNewConfig(config integration.Config)
 		}
 
 		changes.ScheduleConfig(decryptedConfig)
+		cm.decryptedDigests[digest] = decryptedConfig.Digest()
 	}
 
 	//  4. update scheduledConfigs
@@ -276,6 +287,7 @@ func (cm *reconcilingConfigManager) processDelConfigs(configs []integration.Conf
 			}
 
 			changes.UnscheduleConfig(config)
+			delete(cm.decryptedDigests, digest)
 		}
 
 		//  4. update scheduledConfigs
@@ -285,6 +297,63 @@ func (cm *reconcilingConfigManager) processDelConfigs(configs []integration.Conf
 	return allChanges
 }
 
+// processSecretsChange implements configManager#processSecretsChange.
+func (cm *reconcilingConfigManager) processSecretsChange(configName string) (integration.ConfigChanges, map[checkid.ID]checkid.ID) {
+	cm.m.Lock()
+	defer cm.m.Unlock()
+
+	changedIDsOfSecretsWithConfigs := make(map[checkid.ID]checkid.ID)
+
+	// only the scheduled configs change: activeConfigs, templatesByADID and
+	// servicesByADID stay the same.
+	var changes integration.ConfigChanges
+	for digest, config := range cm.activeConfigs {
+		if config.Name != configName {
+			continue
+		}
+
+		if config.IsTemplate() {
+			for svcID, resolutions := range cm.serviceResolutions {
+				resolvedDigest, found := resolutions[digest]
+				if !found {
+					continue
+				}
+				resolved, ok := cm.resolveTemplateForService(config, cm.activeServices[svcID].svc)
+				if !ok || resolved.Digest() == resolvedDigest {
+					continue
+				}
+				changes.UnscheduleConfig(cm.scheduledConfigs[resolvedDigest])
+				changes.ScheduleConfig(resolved)
+				resolutions[digest] = resolved.Digest()
+			}
+			continue
+		}
+
+		scheduledDigest, found := cm.decryptedDigests[digest]
+		if !found {
+			continue
+		}
+		decryptedConfig, err := decryptConfig(config, cm.secretResolver)
+		if err != nil {
+			log.Errorf("Unable to resolve secrets for config '%s', keeping the scheduled check configuration, err: %s", config.Name, err.Error())
+			continue
+		}
+		if decryptedConfig.Digest() == scheduledDigest {
+			continue
+		}
+		if config.Provider == names.ClusterChecks {
+			for newID, originalID := range changedCheckIDs(config, decryptedConfig) {
+				changedIDsOfSecretsWithConfigs[newID] = originalID
+			}
+		}
+		changes.UnscheduleConfig(cm.scheduledConfigs[scheduledDigest])
+		changes.ScheduleConfig(decryptedConfig)
+		cm.decryptedDigests[digest] = decryptedConfig.Digest()
+	}
+
+	return cm.applyChanges(changes), changedIDsOfSecretsWithConfigs
+}
+
 // mapOverLoadedConfigs implements configManager#mapOverLoadedConfigs.
 func (cm *reconcilingConfigManager) mapOverLoadedConfigs(f func(map[string]integration.Config)) {
 	cm.m.Lock()
@@ -227,6 +227,55 @@ func (suite *ConfigManagerSuite) TestNewClusterCheckWithSecretsScheduled() {
 	require.True(suite.T(), strings.Contains(string(changes.Unschedule[0].Instances[0]), "barDecoded"))
 }
 
+// A non-template config with secrets is rescheduled when the value of its
+// secrets changes
+func (suite *ConfigManagerSuite) TestNonTemplateWithSecretsChanged() {
+	resolverReturning := func(decoded string) *MockSecretResolver {
+		return &MockSecretResolver{suite.T(), []mockSecretScenario{
+			{
+				expectedData:   []byte("foo: ENC[bar]"),
+				expectedOrigin: nonTemplateConfigWithSecrets.Name,
+				returnedData:   []byte("foo: " + decoded),
+				returnedError:  nil,
+			},
+			{
+				expectedData:   []byte{},
+				expectedOrigin: nonTemplateConfigWithSecrets.Name,
+				returnedData:   []byte{},
+				returnedError:  nil,
+			},
+		}}
+	}
+	cm := suite.cm.(*reconcilingConfigManager)
+	cm.secretResolver = resolverReturning("barDecoded")
+
+	inputNewConfig := deepcopy.Copy(nonTemplateConfigWithSecrets).(integration.Config)
+	changes, _ := suite.cm.processNewConfig(inputNewConfig)
+	assertConfigsMatch(suite.T(), changes.Schedule, matchName(nonTemplateConfigWithSecrets.Name))
+	oldConfigDigest := changes.Schedule[0].Digest()
+
+	// the secret still has the same value
+	changes, _ = suite.cm.processSecretsChange(nonTemplateConfigWithSecrets.Name)
+	assert.True(suite.T(), changes.IsEmpty())
+
+	cm.secretResolver = resolverReturning("barRotated")
+	changes, _ = suite.cm.processSecretsChange("other-config")
+	assert.True(suite.T(), changes.IsEmpty())
+
+	changes, _ = suite.cm.processSecretsChange(nonTemplateConfigWithSecrets.Name)
+	assertConfigsMatch(suite.T(), changes.Unschedule, matchDigest(oldConfigDigest))
+	assertConfigsMatch(suite.T(), changes.Schedule, matchName(nonTemplateConfigWithSecrets.Name))
+	require.True(suite.T(), strings.Contains(string(changes.Schedule[0].Instances[0]), "barRotated"))
+	newConfigDigest := changes.Schedule[0].Digest()
+	assertLoadedConfigsMatch(suite.T(), suite.cm, matchDigest(newConfigDigest))
+
+	// the config is unscheduled with its new secrets
+	inputDelConfig := deepcopy.Copy(nonTemplateConfigWithSecrets).(integration.Config)
+	changes = suite.cm.processDelConfigs([]integration.Config{inputDelConfig})
+	assertConfigsMatch(suite.T(), changes.Unschedule, matchDigest(newConfigDigest))
+	assertLoadedConfigsMatch(suite.T(), suite.cm)
+}
+
 // A new template config is not scheduled when there is no matching service, and
 // not unscheduled when removed
 func (suite *ConfigManagerSuite) TestNewTemplateNotScheduled() {
@@ -23,11 +23,16 @@ type ConfigParams struct {
 	RemoveLinebreak  bool
 	RunPath          string
 	AuditFileMaxSize int
+	// Type selects a built-in secret backend, such as "hashicorp.vault", used instead of
+	// the executable Command.
+	Type string
+	// BackendConfig holds the settings of the built-in secret backend.
+	BackendConfig map[string]interface{}
 }
 
 // Component is the component type.
 type Component interface {
-	// Configure the executable command that is used for decoding secrets
+	// Configure the executable command, or the built-in backend, that is used for decoding secrets
 	Configure(config ConfigParams)
 	// Get debug information and write it to the parameter
 	GetDebugInfo(w io.Writer)
@@ -0,0 +1,176 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package secretsimpl
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	yaml "gopkg.in/yaml.v2"
+
+	"github.com/DataDog/datadog-agent/comp/core/secrets"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// backend is a built-in secret backend, resolving secrets in process instead of executing
+// the secret_backend_command.
+type backend interface {
+	// fetch returns the values of handles. The handles it can't resolve are returned with an
+	// error message; an error is only returned if the backend can't be used at all.
+	fetch(ctx context.Context, handles []string) (map[string]secrets.SecretVal, error)
+}
+
+// backendFactory creates a backend from the secret_backend_config settings. maxSize is the
+// maximum size of the responses of the backend.
+type backendFactory func(conf map[string]interface{}, maxSize int) (backend, error)
+
+// backendFactories are the built-in backends, by secret_backend_type.
+var backendFactories = map[string]backendFactory{
+	secrets.BackendTypeHashiCorpVault:    newVaultBackend,
+	secrets.BackendTypeAWSSecretsManager: newAWSSecretsManagerBackend,
+	secrets.BackendTypeAWSSSM:            newAWSSSMBackend,
+	secrets.BackendTypeHTTP:              newHTTPBackend,
+}
+
+// newBackend creates the built-in backend of the given type.
+func newBackend(typ string, conf map[string]interface{}, maxSize int) (backend, error) {
+	factory, ok := backendFactories[typ]
+	if !ok {
+		return nil, fmt.Errorf("unknown secret_backend_type %q, supported types are: %s", typ, strings.Join(secrets.BackendTypes, ", "))
+	}
+	return factory(conf, maxSize)
+}
+
+// decodeBackendConfig decodes the secret_backend_config settings into out, a pointer to a
+// struct with yaml tags. Unknown settings are rejected.
+func decodeBackendConfig(conf map[string]interface{}, out interface{}) error {
+	b, err := yaml.Marshal(conf)
+	if err != nil {
+		return err
+	}
+	if err := yaml.UnmarshalStrict(b, out); err != nil {
+		return fmt.Errorf("invalid secret_backend_config: %v", err)
+	}
+	return nil
+}
+
+// fetchFromBackend fetches the values of handles from the built-in backend.
+func (r *secretResolver) fetchFromBackend(handles []string) (map[string]secrets.SecretVal, error) {
+	if r.backendErr != nil {
+		return nil, fmt.Errorf("invalid %s secret backend: %v", r.backendType, r.backendErr)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(r.backendTimeout)*time.Second)
+	defer cancel()
+
+	log.Debugf("%s | fetching %d secrets from the %s secret backend", time.Now().String(), len(handles), r.backendType)
+	start := time.Now()
+	res, err := r.backend.fetch(ctx, handles)
+	elapsed := time.Since(start)
+	log.Debugf("%s | %s secret backend completed in %s", time.Now().String(), r.backendType, elapsed)
+
+	status := "0"
+	if ctx.Err() == context.DeadlineExceeded {
+		status = "timeout"
+		err = fmt.Errorf("%s secret backend timeout", r.backendType)
+	} else if err != nil {
+		status = "error"
+	}
+	r.tlmSecretBackendElapsed.Add(float64(elapsed.Milliseconds()), r.backendType, status)
+	if err != nil {
+		return nil, fmt.Errorf("error while fetching secrets from the %s secret backend: %v", r.backendType, err)
+	}
+	return res, nil
+}
+
+// newHTTPClient returns the client of the backends talking HTTP. caFile is the PEM file of
+// the CAs trusted in addition to the system ones.
+func newHTTPClient(caFile string) (*http.Client, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if caFile != "" {
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, err
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificate found in %s", caFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	return &http.Client{Transport: transport}, nil
+}
+
+// checkBackendURL checks that u is an absolute URL using HTTPS, or HTTP to a loopback address,
+// so that secrets never go over the network in clear text.
+func checkBackendURL(u string) error {
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return err
+	}
+	switch parsed.Scheme {
+	case "https":
+		return nil
+	case "http":
+		host := parsed.Hostname()
+		if ip := net.ParseIP(host); host == "localhost" || (ip != nil && ip.IsLoopback()) {
+			return nil
+		}
+		return fmt.Errorf("%s must use https", u)
+	default:
+		return fmt.Errorf("%q is not a valid http(s) URL", u)
+	}
+}
+
+// readResponse reads the body of resp, up to maxSize bytes.
+func readResponse(resp *http.Response, maxSize int) ([]byte, error) {
+	defer resp.Body.Close()
+	b, err := io.ReadAll(io.LimitReader(resp.Body, int64(maxSize)+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(b) > maxSize {
+		return nil, fmt.Errorf("response was too long: exceeded %d bytes", maxSize)
+	}
+	return b, nil
+}
+
+// readSecretFile returns the content of a file holding a credential, without its trailing
+// line breaks.
+func readSecretFile(path string) (string, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	s := strings.TrimRight(string(b), "\r\n")
+	if s == "" {
+		return "", errors.New(path + " is empty")
+	}
+	return s, nil
+}
+
+// splitHandle splits handles of the form "<secret>#<key>", where the key selects a field of the
+// secret.
+func splitHandle(handle string) (secret, key string) {
+	if i := strings.LastIndexByte(handle, '#'); i >= 0 {
+		return handle[:i], handle[i+1:]
+	}
+	return handle, ""
+}
@@ -0,0 +1,270 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package secretsimpl
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+
+	"github.com/DataDog/datadog-agent/comp/core/secrets"
+)
+
+// awsConfig holds the settings of the AWS backends.
+type awsConfig struct {
+	// Region is the region of the secrets. It defaults to the region of the default AWS
+	// configuration: AWS_REGION, AWS_DEFAULT_REGION or the region of the profile.
+	Region string `yaml:"region"`
+	// Endpoint overrides the endpoint of the service, such as a VPC endpoint.
+	Endpoint string `yaml:"endpoint"`
+	// Profile selects a profile of the shared AWS configuration and credentials files, instead
+	// of AWS_PROFILE.
+	Profile string `yaml:"profile"`
+	// AccessKeyID, SecretAccessKey and SessionToken are static credentials. If they aren't set,
+	// the credentials come from the default credential chain of the AWS SDK: the AWS_
+	// environment variables, the shared configuration and credentials files, a web identity
+	// token (EKS), the ECS container credentials or the EC2 instance profile.
+	AccessKeyID     string `yaml:"access_key_id"`
+	SecretAccessKey string `yaml:"secret_access_key"`
+	SessionToken    string `yaml:"session_token"`
+}
+
+// awsClient calls the JSON APIs of an AWS service, signing the requests with Signature Version 4.
+type awsClient struct {
+	service      string
+	targetPrefix string
+	region       string
+	endpoint     string
+	client       *http.Client
+	maxSize      int
+
+	// credentials caches the credentials signing the requests, renewing them before they expire.
+	credentials aws.CredentialsProvider
+	signer      *v4.Signer
+}
+
+// awsLoadOptions are added to the options loading the AWS configuration, for the tests to
+// point the SDK to fake services.
+var awsLoadOptions []func(*awsconfig.LoadOptions) error
+
+func newAWSClient(conf map[string]interface{}, maxSize int, service, targetPrefix string) (*awsClient, error) {
+	var cfg awsConfig
+	if err := decodeBackendConfig(conf, &cfg); err != nil {
+		return nil, err
+	}
+	opts := append([]func(*awsconfig.LoadOptions) error{}, awsLoadOptions...)
+	if cfg.Region != "" {
+		opts = append(opts, awsconfig.WithRegion(cfg.Region))
+	}
+	if cfg.Profile != "" {
+		opts = append(opts, awsconfig.WithSharedConfigProfile(cfg.Profile))
+	}
+	if cfg.AccessKeyID != "" || cfg.SecretAccessKey != "" {
+		if cfg.AccessKeyID == "" || cfg.SecretAccessKey == "" {
+			return nil, errors.New("both access_key_id and secret_access_key are required")
+		}
+		opts = append(opts, awsconfig.WithCredentialsProvider(credentials.NewStaticCredentialsProvider(cfg.AccessKeyID, cfg.SecretAccessKey, cfg.SessionToken)))
+	}
+	awsCfg, err := awsconfig.LoadDefaultConfig(context.Background(), opts...)
+	if err != nil {
+		return nil, fmt.Errorf("invalid AWS configuration: %v", err)
+	}
+	if awsCfg.Region == "" {
+		awsCfg.Region = os.Getenv("AWS_DEFAULT_REGION")
+	}
+	if awsCfg.Region == "" {
+		return nil, errors.New("the AWS region is required")
+	}
+
+	c := &awsClient{
+		service:      service,
+		targetPrefix: targetPrefix,
+		region:       awsCfg.Region,
+		endpoint:     strings.TrimSuffix(cfg.Endpoint, "/"),
+		client:       &http.Client{},
+		maxSize:      maxSize,
+		credentials:  awsCfg.Credentials,
+		signer:       v4.NewSigner(),
+	}
+	if c.endpoint == "" {
+		c.endpoint = fmt.Sprintf("https://%s.%s.amazonaws.com", service, c.region)
+	}
+	if err := checkBackendURL(c.endpoint); err != nil {
+		return nil, err
+	}
+	if c.credentials == nil {
+		return nil, errors.New("no AWS credentials provider")
+	}
+	return c, nil
+}
+
+// call calls action with the input in, decoding the response in out.
+func (c *awsClient) call(ctx context.Context, action string, in, out interface{}) error {
+	creds, err := c.credentials.Retrieve(ctx)
+	if err != nil {
+		return fmt.Errorf("no AWS credentials: %v", err)
+	}
+	body, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint+"/", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", c.targetPrefix+"."+action)
+	payloadHash := sha256.Sum256(body)
+	if err := c.signer.SignHTTP(ctx, creds, req, hex.EncodeToString(payloadHash[:]), c.service, c.region, time.Now()); err != nil {
+		return fmt.Errorf("could not sign the request: %v", err)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	respBody, err := readResponse(resp, c.maxSize)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		var awsErr struct {
+			Type string `json:"__type"`
+			// matches both "message" and "Message", depending on the service
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(respBody, &awsErr) == nil && awsErr.Type != "" {
+			// the type may be prefixed by a namespace, such as "com.amazonaws.kms#KMSException"
+			_, typ := splitHandle(awsErr.Type)
+			if typ == "" {
+				typ = awsErr.Type
+			}
+			if awsErr.Message == "" {
+				return errors.New(typ)
+			}
+			return fmt.Errorf("%s: %s", typ, awsErr.Message)
+		}
+		return fmt.Errorf("%s returned %s", c.service, resp.Status)
+	}
+	if err := json.Unmarshal(respBody, out); err != nil {
+		return fmt.Errorf("invalid response from %s: %v", c.service, err)
+	}
+	return nil
+}
+
+// fetchJSONSecrets resolves handles of the form "<secret>[#<key>]", reading each secret once. The key
+// selects a field of secrets holding a JSON object.
+func fetchJSONSecrets(ctx context.Context, handles []string, read func(ctx context.Context, secret string) (string, error)) (map[string]secrets.SecretVal, error) {
+	type result struct {
+		value string
+		err   error
+	}
+	res := make(map[string]secrets.SecretVal, len(handles))
+	values := make(map[string]result)
+	for _, handle := range handles {
+		secret, key := splitHandle(handle)
+		r, ok := values[secret]
+		if !ok {
+			r.value, r.err = read(ctx, secret)
+			if r.err != nil && ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			values[secret] = r
+		}
+		if r.err != nil {
+			res[handle] = secretError(r.err)
+			continue
+		}
+		if key == "" {
+			res[handle] = secrets.SecretVal{Value: r.value}
+			continue
+		}
+		var fields map[string]interface{}
+		if err := json.Unmarshal([]byte(r.value), &fields); err != nil {
+			res[handle] = secretError(fmt.Errorf("secret %q isn't a JSON object", secret))
+			continue
+		}
+		v, ok := fields[key]
+		if !ok {
+			res[handle] = secretError(fmt.Errorf("key %q not found in secret %q", key, secret))
+			continue
+		}
+		res[handle] = secrets.SecretVal{Value: stringValue(v)}
+	}
+	return res, nil
+}
+
+// awsSecretsManagerBackend reads secrets from AWS Secrets Manager. Handles are the names or ARNs of
+// the secrets, followed by "#<key>" to read a key of a JSON secret.
+type awsSecretsManagerBackend struct {
+	client *awsClient
+}
+
+func newAWSSecretsManagerBackend(conf map[string]interface{}, maxSize int) (backend, error) {
+	client, err := newAWSClient(conf, maxSize, "secretsmanager", "secretsmanager")
+	if err != nil {
+		return nil, err
+	}
+	return &awsSecretsManagerBackend{client: client}, nil
+}
+
+func (b *awsSecretsManagerBackend) fetch(ctx context.Context, handles []string) (map[string]secrets.SecretVal, error) {
+	return fetchJSONSecrets(ctx, handles, func(ctx context.Context, id string) (string, error) {
+		var out struct {
+			SecretString string `json:"SecretString"`
+			SecretBinary []byte `json:"SecretBinary"`
+		}
+		if err := b.client.call(ctx, "GetSecretValue", map[string]string{"SecretId": id}, &out); err != nil {
+			return "", err
+		}
+		if out.SecretString == "" && len(out.SecretBinary) > 0 {
+			return "", errors.New("binary secrets aren't supported")
+		}
+		return out.SecretString, nil
+	})
+}
+
+// awsSSMBackend reads parameters from the AWS Systems Manager Parameter Store, decrypting
+// SecureString parameters. Handles are the names or ARNs of the parameters, followed by "#<key>"
+// to read a key of a JSON parameter.
+type awsSSMBackend struct {
+	client *awsClient
+}
+
+func newAWSSSMBackend(conf map[string]interface{}, maxSize int) (backend, error) {
+	client, err := newAWSClient(conf, maxSize, "ssm", "AmazonSSM")
+	if err != nil {
+		return nil, err
+	}
+	return &awsSSMBackend{client: client}, nil
+}
+
+func (b *awsSSMBackend) fetch(ctx context.Context, handles []string) (map[string]secrets.SecretVal, error) {
+	return fetchJSONSecrets(ctx, handles, func(ctx context.Context, name string) (string, error) {
+		var out struct {
+			Parameter struct {
+				Value string `json:"Value"`
+			} `json:"Parameter"`
+		}
+		in := map[string]interface{}{"Name": name, "WithDecryption": true}
+		if err := b.client.call(ctx, "GetParameter", in, &out); err != nil {
+			return "", err
+		}
+		return out.Parameter.Value, nil
+	})
+}
@@ -0,0 +1,234 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package secretsimpl
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/comp/core/secrets"
+)
+
+// isolateAWSConfig keeps the tests from reading the AWS configuration of the host, which the
+// default credential chain would otherwise use.
+func isolateAWSConfig(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("AWS_CONFIG_FILE", filepath.Join(dir, "config"))
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(dir, "credentials"))
+	for _, env := range []string{
+		"AWS_PROFILE", "AWS_REGION", "AWS_DEFAULT_REGION",
+		"AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY", "AWS_SESSION_TOKEN",
+		"AWS_WEB_IDENTITY_TOKEN_FILE", "AWS_ROLE_ARN",
+		"AWS_CONTAINER_CREDENTIALS_RELATIVE_URI", "AWS_CONTAINER_CREDENTIALS_FULL_URI",
+		"AWS_CONTAINER_AUTHORIZATION_TOKEN", "AWS_CONTAINER_AUTHORIZATION_TOKEN_FILE",
+		"AWS_EC2_METADATA_DISABLED", "AWS_EC2_METADATA_SERVICE_ENDPOINT",
+	} {
+		t.Setenv(env, "")
+	}
+}
+
+// newFakeAWS returns a server answering the calls of the AWS backends with values, by secret
+// name. It checks that the requests are signed by accessKeyID.
+func newFakeAWS(t *testing.T, accessKeyID string, values map[string]string) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential="+accessKeyID+"/") {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"__type":"UnrecognizedClientException","message":"The security token included in the request is invalid."}`))
+			return
+		}
+		var in struct {
+			SecretID string `json:"SecretId"`
+			Name     string `json:"Name"`
+		}
+		json.NewDecoder(r.Body).Decode(&in)
+		switch r.Header.Get("X-Amz-Target") {
+		case "secretsmanager.GetSecretValue":
+			v, ok := values[in.SecretID]
+			if !ok {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"__type":"ResourceNotFoundException","Message":"Secrets Manager can't find the specified secret."}`))
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]string{"Name": in.SecretID, "SecretString": v})
+		case "AmazonSSM.GetParameter":
+			v, ok := values[in.Name]
+			if !ok {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"__type":"ParameterNotFound"}`))
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{"Parameter": map[string]string{"Name": in.Name, "Value": v}})
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestAWSSecretsManagerBackend(t *testing.T) {
+	isolateAWSConfig(t)
+	server := newFakeAWS(t, "AKID", map[string]string{
+		"datadog/api_key": "abcdef",
+		"datadog/db":      `{"user":"datadog","password":"s3cr3t"}`,
+	})
+	b, err := newAWSSecretsManagerBackend(map[string]interface{}{
+		"region":            "us-east-1",
+		"endpoint":          server.URL,
+		"access_key_id":     "AKID",
+		"secret_access_key": "secret",
+	}, 1024)
+	require.NoError(t, err)
+
+	res, err := b.fetch(context.Background(), []string{"datadog/api_key", "datadog/db#password", "datadog/db#port", "datadog/api_key#user", "datadog/app_key"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]secrets.SecretVal{
+		"datadog/api_key":      {Value: "abcdef"},
+		"datadog/db#password":  {Value: "s3cr3t"},
+		"datadog/db#port":      {ErrorMsg: `key "port" not found in secret "datadog/db"`},
+		"datadog/api_key#user": {ErrorMsg: `secret "datadog/api_key" isn't a JSON object`},
+		"datadog/app_key":      {ErrorMsg: "ResourceNotFoundException: Secrets Manager can't find the specified secret."},
+	}, res)
+}
+
+func TestAWSSSMBackend(t *testing.T) {
+	isolateAWSConfig(t)
+	server := newFakeAWS(t, "AKID", map[string]string{"/datadog/api_key": "abcdef"})
+	t.Setenv("AWS_REGION", "eu-west-1")
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKID")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	b, err := newAWSSSMBackend(map[string]interface{}{"endpoint": server.URL}, 1024)
+	require.NoError(t, err)
+
+	res, err := b.fetch(context.Background(), []string{"/datadog/api_key", "/datadog/app_key"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]secrets.SecretVal{
+		"/datadog/api_key": {Value: "abcdef"},
+		"/datadog/app_key": {ErrorMsg: "ParameterNotFound"},
+	}, res)
+}
+
+func TestAWSProfileCredentials(t *testing.T) {
+	isolateAWSConfig(t)
+	require.NoError(t, os.WriteFile(os.Getenv("AWS_SHARED_CREDENTIALS_FILE"), []byte(`[default]
+aws_access_key_id = AKIDDEFAULT
+aws_secret_access_key = secret
+
+[agent]
+aws_access_key_id = AKIDAGENT
+aws_secret_access_key = secret
+`), 0600))
+	require.NoError(t, os.WriteFile(os.Getenv("AWS_CONFIG_FILE"), []byte(`[profile agent]
+region = eu-west-3
+`), 0600))
+
+	server := newFakeAWS(t, "AKIDAGENT", map[string]string{"datadog/api_key": "abcdef"})
+	b, err := newAWSSecretsManagerBackend(map[string]interface{}{"profile": "agent", "endpoint": server.URL}, 1024)
+	require.NoError(t, err)
+	assert.Equal(t, "eu-west-3", b.(*awsSecretsManagerBackend).client.region)
+	res, err := b.fetch(context.Background(), []string{"datadog/api_key"})
+	require.NoError(t, err)
+	assert.Equal(t, "abcdef", res["datadog/api_key"].Value)
+
+	_, err = newAWSSecretsManagerBackend(map[string]interface{}{"profile": "unknown", "region": "us-east-1"}, 1024)
+	assert.Error(t, err)
+}
+
+func TestAWSContainerCredentials(t *testing.T) {
+	isolateAWSConfig(t)
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(tokenFile, []byte("ecs-token"), 0600))
+
+	requests := 0
+	ecs := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("Authorization") != "ecs-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"AccessKeyId":     "ASIACONTAINER",
+			"SecretAccessKey": "secret",
+			"Token":           "session",
+			"Expiration":      time.Now().Add(time.Hour),
+		})
+	}))
+	defer ecs.Close()
+	t.Setenv("AWS_CONTAINER_CREDENTIALS_FULL_URI", ecs.URL+"/credentials")
+	t.Setenv("AWS_CONTAINER_AUTHORIZATION_TOKEN_FILE", tokenFile)
+
+	server := newFakeAWS(t, "ASIACONTAINER", map[string]string{"datadog/api_key": "abcdef"})
+	b, err := newAWSSecretsManagerBackend(map[string]interface{}{"region": "us-east-1", "endpoint": server.URL}, 1024)
+	require.NoError(t, err)
+	for i := 0; i < 2; i++ {
+		res, err := b.fetch(context.Background(), []string{"datadog/api_key"})
+		require.NoError(t, err)
+		assert.Equal(t, "abcdef", res["datadog/api_key"].Value)
+	}
+	// the credentials are only fetched once, until they expire
+	assert.Equal(t, 1, requests)
+
+	// the full URI must be a loopback or ECS address. 192.0.2.1 is reserved for documentation:
+	// being an IP, it is rejected without any DNS lookup.
+	t.Setenv("AWS_CONTAINER_CREDENTIALS_FULL_URI", "http://192.0.2.1/credentials")
+	_, err = newAWSSecretsManagerBackend(map[string]interface{}{"region": "us-east-1", "endpoint": server.URL}, 1024)
+	assert.ErrorContains(t, err, "only loopback/ecs/eks hosts are allowed")
+	assert.Equal(t, 1, requests)
+}
+
+func TestAWSInstanceCredentials(t *testing.T) {
+	isolateAWSConfig(t)
+
+	requests := 0
+	imds := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPut && r.URL.Path == "/latest/api/token":
+			w.Header().Set("X-Aws-Ec2-Metadata-Token-Ttl-Seconds", r.Header.Get("X-Aws-Ec2-Metadata-Token-Ttl-Seconds"))
+			w.Write([]byte("imds-token"))
+		case r.Header.Get("X-aws-ec2-metadata-token") != "imds-token":
+			w.WriteHeader(http.StatusUnauthorized)
+		case r.URL.Path == "/latest/meta-data/iam/security-credentials/":
+			w.Write([]byte("agent-role"))
+		case r.URL.Path == "/latest/meta-data/iam/security-credentials/agent-role":
+			requests++
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"Code":            "Success",
+				"AccessKeyId":     "ASIAINSTANCE",
+				"SecretAccessKey": "secret",
+				"Token":           "session",
+				"Expiration":      time.Now().Add(time.Hour),
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer imds.Close()
+	awsLoadOptions = []func(*awsconfig.LoadOptions) error{awsconfig.WithEC2IMDSEndpoint(imds.URL)}
+	t.Cleanup(func() { awsLoadOptions = nil })
+
+	server := newFakeAWS(t, "ASIAINSTANCE", map[string]string{"datadog/api_key": "abcdef"})
+	b, err := newAWSSecretsManagerBackend(map[string]interface{}{"region": "us-east-1", "endpoint": server.URL}, 1024)
+	require.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		res, err := b.fetch(context.Background(), []string{"datadog/api_key"})
+		require.NoError(t, err)
+		assert.Equal(t, "abcdef", res["datadog/api_key"].Value)
+	}
+	// the credentials are only fetched once, until they expire
+	assert.Equal(t, 1, requests)
+}
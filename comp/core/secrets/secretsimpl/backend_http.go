@@ -0,0 +1,98 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package secretsimpl
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/DataDog/datadog-agent/comp/core/secrets"
+)
+
+// httpConfig holds the settings of the HTTP backend.
+type httpConfig struct {
+	// URL is the URL the requests are sent to.
+	URL string `yaml:"url"`
+	// Headers are added to the requests, such as an API key.
+	Headers map[string]string `yaml:"headers"`
+	// BearerTokenFile is a file holding a token sent in the Authorization header. It's read
+	// before each request, so that the token can be rotated.
+	BearerTokenFile string `yaml:"bearer_token_file"`
+	// TLSCAFile is the PEM file of the CAs used to verify the certificate of the server.
+	TLSCAFile string `yaml:"tls_ca_file"`
+}
+
+// httpBackend fetches secrets from an HTTP service speaking the protocol of the
+// secret_backend_command: it POSTs the JSON payload the command reads on its standard input,
+// and expects the JSON output of the command in response.
+type httpBackend struct {
+	conf    httpConfig
+	client  *http.Client
+	maxSize int
+}
+
+func newHTTPBackend(conf map[string]interface{}, maxSize int) (backend, error) {
+	b := &httpBackend{maxSize: maxSize}
+	if err := decodeBackendConfig(conf, &b.conf); err != nil {
+		return nil, err
+	}
+	if b.conf.URL == "" {
+		return nil, errors.New("url is required")
+	}
+	if err := checkBackendURL(b.conf.URL); err != nil {
+		return nil, err
+	}
+	var err error
+	if b.client, err = newHTTPClient(b.conf.TLSCAFile); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+func (b *httpBackend) fetch(ctx context.Context, handles []string) (map[string]secrets.SecretVal, error) {
+	payload, err := json.Marshal(map[string]interface{}{
+		"version": secrets.PayloadVersion,
+		"secrets": handles,
+	})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, b.conf.URL, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range b.conf.Headers {
+		req.Header.Set(k, v)
+	}
+	if b.conf.BearerTokenFile != "" {
+		token, err := readSecretFile(b.conf.BearerTokenFile)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := readResponse(resp, b.maxSize)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned %s", b.conf.URL, resp.Status)
+	}
+	res := make(map[string]secrets.SecretVal)
+	if err := json.Unmarshal(body, &res); err != nil {
+		return nil, fmt.Errorf("invalid response from %s: %v", b.conf.URL, err)
+	}
+	return res, nil
+}
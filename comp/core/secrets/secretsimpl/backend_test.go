@@ -0,0 +1,163 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package secretsimpl
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/comp/core/secrets"
+	"github.com/DataDog/datadog-agent/comp/core/telemetry"
+	nooptelemetry "github.com/DataDog/datadog-agent/comp/core/telemetry/noopsimpl"
+	"github.com/DataDog/datadog-agent/pkg/util/fxutil"
+)
+
+// newTestSecretsServer returns a server speaking the protocol of the secret_backend_command,
+// resolving the secrets from values.
+func newTestSecretsServer(t *testing.T, values map[string]string) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer s3cr3t" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var payload struct {
+			Version string   `json:"version"`
+			Secrets []string `json:"secrets"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil || payload.Version != secrets.PayloadVersion {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		res := make(map[string]secrets.SecretVal)
+		for _, handle := range payload.Secrets {
+			if v, ok := values[handle]; ok {
+				res[handle] = secrets.SecretVal{Value: v}
+			} else {
+				res[handle] = secrets.SecretVal{ErrorMsg: "not found"}
+			}
+		}
+		json.NewEncoder(w).Encode(res)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestHTTPBackend(t *testing.T) {
+	server := newTestSecretsServer(t, map[string]string{"pass1": "password1", "pass2": "password2"})
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(tokenFile, []byte("s3cr3t\n"), 0o600))
+
+	b, err := newHTTPBackend(map[string]interface{}{"url": server.URL, "bearer_token_file": tokenFile}, 1024)
+	require.NoError(t, err)
+	res, err := b.fetch(context.Background(), []string{"pass1", "pass3"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]secrets.SecretVal{
+		"pass1": {Value: "password1"},
+		"pass3": {ErrorMsg: "not found"},
+	}, res)
+
+	// the token is read before each request
+	require.NoError(t, os.WriteFile(tokenFile, []byte("expired"), 0o600))
+	_, err = b.fetch(context.Background(), []string{"pass1"})
+	assert.EqualError(t, err, server.URL+" returned 401 Unauthorized")
+
+	b, err = newHTTPBackend(map[string]interface{}{"url": server.URL, "headers": map[string]string{"Authorization": "Bearer s3cr3t"}}, 10)
+	require.NoError(t, err)
+	_, err = b.fetch(context.Background(), []string{"pass1"})
+	assert.EqualError(t, err, "response was too long: exceeded 10 bytes")
+}
+
+func TestNewBackendErrors(t *testing.T) {
+	for _, tt := range []struct {
+		typ  string
+		conf map[string]interface{}
+		err  string
+	}{
+		{"keepass", nil, `unknown secret_backend_type "keepass", supported types are: aws.secrets, aws.ssm, hashicorp.vault, http`},
+		{"http", nil, "url is required"},
+		{"http", map[string]interface{}{"url": "http://secrets.example.com"}, "http://secrets.example.com must use https"},
+		{"http", map[string]interface{}{"url": "https://secrets.example.com", "timeout": 10}, "invalid secret_backend_config: yaml: unmarshal errors:\n  line 1: field timeout not found in type secretsimpl.httpConfig"},
+		{"hashicorp.vault", map[string]interface{}{"address": "https://vault:8200", "token": "t", "approle": map[string]interface{}{"secret_id": "s"}}, "approle requires a role_id or a role_id_file"},
+		{"aws.secrets", map[string]interface{}{"region": "us-east-1", "access_key_id": "AKID"}, "both access_key_id and secret_access_key are required"},
+	} {
+		_, err := newBackend(tt.typ, tt.conf, 1024)
+		assert.EqualError(t, err, tt.err)
+	}
+}
+
+func TestBackendTypes(t *testing.T) {
+	// the types validated by the configuration are the ones built in
+	assert.Len(t, backendFactories, len(secrets.BackendTypes))
+	for _, typ := range secrets.BackendTypes {
+		assert.Contains(t, backendFactories, typ)
+	}
+}
+
+func TestCheckBackendURL(t *testing.T) {
+	assert.NoError(t, checkBackendURL("https://vault.example.com:8200"))
+	assert.NoError(t, checkBackendURL("http://127.0.0.1:8200"))
+	assert.NoError(t, checkBackendURL("http://localhost:8200"))
+	assert.NoError(t, checkBackendURL("http://[::1]:8200"))
+	assert.Error(t, checkBackendURL("http://10.0.0.1:8200"))
+	assert.Error(t, checkBackendURL("vault:8200"))
+}
+
+func TestResolveWithBackend(t *testing.T) {
+	values := map[string]string{"pass1": "password1", "pass2": "password2", "pass3": "password3"}
+	server := newTestSecretsServer(t, values)
+	tel := fxutil.Test[telemetry.Component](t, nooptelemetry.Module())
+	resolver := newEnabledSecretResolver(tel)
+	resolver.Configure(secrets.ConfigParams{
+		Type:          "http",
+		BackendConfig: map[string]interface{}{"url": server.URL, "headers": map[string]interface{}{"Authorization": "Bearer s3cr3t"}},
+		RunPath:       t.TempDir(),
+	})
+
+	resolved, err := resolver.Resolve(testConf, "test")
+	require.NoError(t, err)
+	assert.Contains(t, string(resolved), "password1")
+	assert.NotContains(t, string(resolved), "ENC[")
+
+	// refreshes go through the backend, with the allowlist and the audit file
+	originalAllowlistPaths := allowlistPaths
+	defer func() { allowlistPaths = originalAllowlistPaths }()
+	allowlistPaths = []string{"instances"}
+	values["pass1"] = "password4"
+	res, err := resolver.Refresh()
+	require.NoError(t, err)
+	assert.Contains(t, res, "pass1")
+	assert.NotContains(t, res, "pass2")
+	audit, err := os.ReadFile(resolver.auditFilename)
+	require.NoError(t, err)
+	assert.Contains(t, string(audit), `"handle":"pass1"`)
+
+	var info bytes.Buffer
+	resolver.GetDebugInfo(&info)
+	assert.True(t, strings.HasPrefix(info.String(), "=== Secret backend ===\nBackend type: http\n\n=== Secrets stats ===\n"), info.String())
+}
+
+func TestResolveWithInvalidBackend(t *testing.T) {
+	tel := fxutil.Test[telemetry.Component](t, nooptelemetry.Module())
+	resolver := newEnabledSecretResolver(tel)
+	resolver.Configure(secrets.ConfigParams{Type: "http"})
+
+	_, err := resolver.Resolve(testConf, "test")
+	assert.EqualError(t, err, "invalid http secret backend: url is required")
+
+	var info bytes.Buffer
+	resolver.GetDebugInfo(&info)
+	assert.True(t, strings.HasPrefix(info.String(), "=== Secret backend ===\nBackend type: http\nBackend error: url is required\n\n"), info.String())
+}
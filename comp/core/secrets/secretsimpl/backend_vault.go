@@ -0,0 +1,305 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package secretsimpl
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/DataDog/datadog-agent/comp/core/secrets"
+)
+
+// vaultConfig holds the settings of the HashiCorp Vault backend.
+type vaultConfig struct {
+	// Address is the address of the Vault server, VAULT_ADDR by default.
+	Address string `yaml:"address"`
+	// Namespace is the Vault Enterprise namespace of the secrets, VAULT_NAMESPACE by default.
+	Namespace string `yaml:"namespace"`
+	// Mount is the path of the KV v2 secrets engine, "secret" by default.
+	Mount string `yaml:"mount"`
+	// Token, or TokenFile, authenticates the requests. VAULT_TOKEN is used if no token nor
+	// AppRole is configured.
+	Token     string `yaml:"token"`
+	TokenFile string `yaml:"token_file"`
+	// AppRole authenticates the Agent with its role ID and secret ID.
+	AppRole *vaultAppRoleConfig `yaml:"approle"`
+	// TLSCAFile is the PEM file of the CAs used to verify the certificate of the server.
+	TLSCAFile string `yaml:"tls_ca_file"`
+}
+
+type vaultAppRoleConfig struct {
+	RoleID       string `yaml:"role_id"`
+	RoleIDFile   string `yaml:"role_id_file"`
+	SecretID     string `yaml:"secret_id"`
+	SecretIDFile string `yaml:"secret_id_file"`
+	// Mount is the path of the AppRole auth method, "approle" by default.
+	Mount string `yaml:"mount"`
+}
+
+// vaultBackend reads secrets from the KV v2 secrets engine of HashiCorp Vault. Handles are of the
+// form "<path>#<key>", reading the key of the secret at path, such as "datadog/agent#api_key".
+// It isn't safe for concurrent use, the resolver serializes the fetches.
+type vaultBackend struct {
+	conf    vaultConfig
+	client  *http.Client
+	maxSize int
+
+	// token is the token obtained with the AppRole, until tokenExpiry if it's set.
+	token       string
+	tokenExpiry time.Time
+}
+
+func newVaultBackend(conf map[string]interface{}, maxSize int) (backend, error) {
+	b := &vaultBackend{maxSize: maxSize}
+	if err := decodeBackendConfig(conf, &b.conf); err != nil {
+		return nil, err
+	}
+	if b.conf.Address == "" {
+		b.conf.Address = os.Getenv("VAULT_ADDR")
+	}
+	if b.conf.Address == "" {
+		return nil, errors.New("the address of the Vault server is required")
+	}
+	b.conf.Address = strings.TrimSuffix(b.conf.Address, "/")
+	if err := checkBackendURL(b.conf.Address); err != nil {
+		return nil, err
+	}
+	if b.conf.Namespace == "" {
+		b.conf.Namespace = os.Getenv("VAULT_NAMESPACE")
+	}
+	if b.conf.Mount == "" {
+		b.conf.Mount = "secret"
+	}
+	b.conf.Mount = strings.Trim(b.conf.Mount, "/")
+	if b.conf.AppRole != nil {
+		if b.conf.AppRole.RoleID == "" && b.conf.AppRole.RoleIDFile == "" {
+			return nil, errors.New("approle requires a role_id or a role_id_file")
+		}
+		if b.conf.AppRole.Mount == "" {
+			b.conf.AppRole.Mount = "approle"
+		}
+	} else if b.conf.Token == "" && b.conf.TokenFile == "" {
+		b.conf.Token = os.Getenv("VAULT_TOKEN")
+		if b.conf.Token == "" {
+			return nil, errors.New("a token, a token_file or an approle is required to authenticate to Vault")
+		}
+	}
+	var err error
+	if b.client, err = newHTTPClient(b.conf.TLSCAFile); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+func (b *vaultBackend) fetch(ctx context.Context, handles []string) (map[string]secrets.SecretVal, error) {
+	res := make(map[string]secrets.SecretVal, len(handles))
+	// read holds the secrets read, which may hold several handles
+	read := make(map[string]*vaultSecret)
+	errs := make(map[string]error)
+	for _, handle := range handles {
+		path, key := splitHandle(handle)
+		if path == "" || key == "" {
+			res[handle] = secretError(fmt.Errorf("invalid handle, expected <path>#<key>"))
+			continue
+		}
+		secret, ok := read[path]
+		if !ok && errs[path] == nil {
+			var err error
+			if secret, err = b.read(ctx, path); err != nil {
+				if ctx.Err() != nil {
+					return nil, ctx.Err()
+				}
+				errs[path] = err
+			}
+			read[path] = secret
+		}
+		if err := errs[path]; err != nil {
+			res[handle] = secretError(err)
+			continue
+		}
+		v, ok := secret.Data.Data[key]
+		if !ok {
+			res[handle] = secretError(fmt.Errorf("key %q not found in secret %q", key, path))
+			continue
+		}
+		res[handle] = secrets.SecretVal{Value: stringValue(v), TTL: secret.ttl()}
+	}
+	return res, nil
+}
+
+// vaultSecret is the response of Vault to a read of a KV v2 secret.
+type vaultSecret struct {
+	// LeaseDuration is the TTL of the secret in seconds, if it's leased. KV v2 secrets aren't
+	// leased, Vault always returns 0 for them.
+	LeaseDuration int64 `json:"lease_duration"`
+	Data          struct {
+		Data map[string]interface{} `json:"data"`
+	} `json:"data"`
+}
+
+// ttl returns the number of seconds the secret is valid for: its lease duration if it's leased,
+// or else the "ttl" key of its data, which Vault also uses as the refresh interval of the KV
+// secrets. It's either a number of seconds or a duration such as "1h".
+func (s *vaultSecret) ttl() int64 {
+	if s.LeaseDuration > 0 {
+		return s.LeaseDuration
+	}
+	switch v := s.Data.Data["ttl"].(type) {
+	case float64:
+		if v > 0 {
+			return int64(v)
+		}
+	case string:
+		if seconds, err := strconv.ParseInt(v, 10, 64); err == nil && seconds > 0 {
+			return seconds
+		}
+		if d, err := time.ParseDuration(v); err == nil && d >= time.Second {
+			return int64(d / time.Second)
+		}
+	}
+	return 0
+}
+
+// read returns the latest version of the secret at path.
+func (b *vaultBackend) read(ctx context.Context, path string) (*vaultSecret, error) {
+	url := fmt.Sprintf("%s/v1/%s/data/%s", b.conf.Address, b.conf.Mount, strings.TrimPrefix(path, "/"))
+	var resp vaultSecret
+	status, err := b.request(ctx, http.MethodGet, url, nil, true, &resp)
+	if status == http.StatusForbidden && b.conf.AppRole != nil {
+		// the token may have been revoked, log in again
+		b.token = ""
+		_, err = b.request(ctx, http.MethodGet, url, nil, true, &resp)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if resp.Data.Data == nil {
+		return nil, fmt.Errorf("secret %q has no data, is %q a KV v2 secrets engine?", path, b.conf.Mount)
+	}
+	return &resp, nil
+}
+
+// request sends a request to Vault, decoding the JSON response in out. It returns the status
+// of the response along with an error if it isn't successful.
+func (b *vaultBackend) request(ctx context.Context, method, url string, body interface{}, auth bool, out interface{}) (int, error) {
+	var reqBody bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&reqBody).Encode(body); err != nil {
+			return 0, err
+		}
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, &reqBody)
+	if err != nil {
+		return 0, err
+	}
+	if b.conf.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", b.conf.Namespace)
+	}
+	if auth {
+		token, err := b.getToken(ctx)
+		if err != nil {
+			return 0, err
+		}
+		req.Header.Set("X-Vault-Token", token)
+	}
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	respBody, err := readResponse(resp, b.maxSize)
+	if err != nil {
+		return resp.StatusCode, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		var vaultErr struct {
+			Errors []string `json:"errors"`
+		}
+		if json.Unmarshal(respBody, &vaultErr) == nil && len(vaultErr.Errors) > 0 {
+			return resp.StatusCode, fmt.Errorf("vault returned %s: %s", resp.Status, strings.Join(vaultErr.Errors, ", "))
+		}
+		return resp.StatusCode, fmt.Errorf("vault returned %s", resp.Status)
+	}
+	if err := json.Unmarshal(respBody, out); err != nil {
+		return resp.StatusCode, fmt.Errorf("invalid response from vault: %v", err)
+	}
+	return resp.StatusCode, nil
+}
+
+// getToken returns the token authenticating the requests, logging in with the AppRole if needed.
+func (b *vaultBackend) getToken(ctx context.Context) (string, error) {
+	if b.conf.AppRole == nil {
+		if b.conf.TokenFile != "" {
+			// the file is read every time, as it may be renewed by a Vault agent
+			return readSecretFile(b.conf.TokenFile)
+		}
+		return b.conf.Token, nil
+	}
+	if b.token != "" && (b.tokenExpiry.IsZero() || time.Now().Before(b.tokenExpiry)) {
+		return b.token, nil
+	}
+	roleID, secretID := b.conf.AppRole.RoleID, b.conf.AppRole.SecretID
+	var err error
+	if b.conf.AppRole.RoleIDFile != "" {
+		if roleID, err = readSecretFile(b.conf.AppRole.RoleIDFile); err != nil {
+			return "", err
+		}
+	}
+	if b.conf.AppRole.SecretIDFile != "" {
+		if secretID, err = readSecretFile(b.conf.AppRole.SecretIDFile); err != nil {
+			return "", err
+		}
+	}
+	login := map[string]string{"role_id": roleID}
+	if secretID != "" {
+		login["secret_id"] = secretID
+	}
+	var resp struct {
+		Auth struct {
+			ClientToken   string `json:"client_token"`
+			LeaseDuration int    `json:"lease_duration"`
+		} `json:"auth"`
+	}
+	url := fmt.Sprintf("%s/v1/auth/%s/login", b.conf.Address, strings.Trim(b.conf.AppRole.Mount, "/"))
+	if _, err := b.request(ctx, http.MethodPost, url, login, false, &resp); err != nil {
+		return "", fmt.Errorf("approle login failed: %v", err)
+	}
+	if resp.Auth.ClientToken == "" {
+		return "", errors.New("approle login failed: no token returned")
+	}
+	b.token = resp.Auth.ClientToken
+	b.tokenExpiry = time.Time{}
+	if ttl := time.Duration(resp.Auth.LeaseDuration) * time.Second; ttl > 0 {
+		// log in again a bit before the token expires
+		b.tokenExpiry = time.Now().Add(ttl - ttl/10)
+	}
+	return b.token, nil
+}
+
+// secretError returns the value of a handle that couldn't be resolved.
+func secretError(err error) secrets.SecretVal {
+	return secrets.SecretVal{ErrorMsg: err.Error()}
+}
+
+// stringValue returns the value of a field of a JSON secret as a string. Values other than
+// strings are JSON encoded.
+func stringValue(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
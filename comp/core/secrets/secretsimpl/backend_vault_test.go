@@ -0,0 +1,166 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package secretsimpl
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/comp/core/secrets"
+)
+
+// fakeVault is a Vault server with a KV v2 secrets engine mounted at "kv" and the AppRole auth
+// method enabled.
+type fakeVault struct {
+	*httptest.Server
+	tokens map[string]bool
+	logins int
+	reads  int
+}
+
+func newFakeVault(t *testing.T) *fakeVault {
+	v := &fakeVault{tokens: map[string]bool{"root": true}}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/auth/approle/login", func(w http.ResponseWriter, r *http.Request) {
+		var login map[string]string
+		json.NewDecoder(r.Body).Decode(&login)
+		if login["role_id"] != "agent" || login["secret_id"] != "s3cr3t" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"errors":["invalid role or secret ID"]}`))
+			return
+		}
+		v.logins++
+		v.tokens["approle"] = true
+		w.Write([]byte(`{"auth":{"client_token":"approle","lease_duration":3600}}`))
+	})
+	mux.HandleFunc("/v1/kv/data/", func(w http.ResponseWriter, r *http.Request) {
+		if !v.tokens[r.Header.Get("X-Vault-Token")] {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"errors":["permission denied"]}`))
+			return
+		}
+		v.reads++
+		switch r.URL.Path {
+		case "/v1/kv/data/datadog/agent":
+			w.Write([]byte(`{"lease_duration":0,"data":{"data":{"api_key":"abcdef","port":8125},"metadata":{"version":3}}}`))
+		case "/v1/kv/data/datadog/db":
+			// KV v2 secrets aren't leased, their refresh interval is the ttl key of their data
+			w.Write([]byte(`{
+  "request_id": "c7f2d0a4-5a9e-8b36-1f0e-3d2c6b1a9e57",
+  "lease_id": "",
+  "renewable": false,
+  "lease_duration": 0,
+  "data": {
+    "data": {
+      "password": "s3cr3t",
+      "ttl": "10m"
+    },
+    "metadata": {
+      "created_time": "2024-05-02T09:21:33.118344Z",
+      "custom_metadata": null,
+      "deletion_time": "",
+      "destroyed": false,
+      "version": 4
+    }
+  },
+  "wrap_info": null,
+  "warnings": null,
+  "auth": null,
+  "mount_type": "kv"
+}`))
+		case "/v1/kv/data/datadog/cache":
+			w.Write([]byte(`{"lease_duration":0,"data":{"data":{"password":"c4ch3","ttl":300},"metadata":{"version":1}}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"errors":[]}`))
+		}
+	})
+	v.Server = httptest.NewServer(mux)
+	t.Cleanup(v.Close)
+	return v
+}
+
+func TestVaultBackendToken(t *testing.T) {
+	vault := newFakeVault(t)
+	b, err := newVaultBackend(map[string]interface{}{"address": vault.URL, "mount": "kv", "token": "root"}, 1024)
+	require.NoError(t, err)
+
+	res, err := b.fetch(context.Background(), []string{"datadog/agent#api_key", "datadog/agent#port", "datadog/agent#app_key", "datadog/other#api_key", "datadog/db#password", "datadog/cache#password", "api_key"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]secrets.SecretVal{
+		"datadog/agent#api_key":  {Value: "abcdef"},
+		"datadog/db#password":    {Value: "s3cr3t", TTL: 600},
+		"datadog/cache#password": {Value: "c4ch3", TTL: 300},
+		"datadog/agent#port":     {Value: "8125"},
+		"datadog/agent#app_key":  {ErrorMsg: `key "app_key" not found in secret "datadog/agent"`},
+		"datadog/other#api_key":  {ErrorMsg: "vault returned 404 Not Found"},
+		"api_key":                {ErrorMsg: "invalid handle, expected <path>#<key>"},
+	}, res)
+	// each secret is only read once
+	assert.Equal(t, 4, vault.reads)
+}
+
+func TestVaultBackendAppRole(t *testing.T) {
+	vault := newFakeVault(t)
+	conf := map[string]interface{}{
+		"address": vault.URL,
+		"mount":   "kv",
+		"approle": map[string]interface{}{"role_id": "agent", "secret_id": "s3cr3t"},
+	}
+	b, err := newVaultBackend(conf, 1024)
+	require.NoError(t, err)
+
+	res, err := b.fetch(context.Background(), []string{"datadog/agent#api_key"})
+	require.NoError(t, err)
+	assert.Equal(t, "abcdef", res["datadog/agent#api_key"].Value)
+	res, err = b.fetch(context.Background(), []string{"datadog/agent#api_key"})
+	require.NoError(t, err)
+	assert.Equal(t, "abcdef", res["datadog/agent#api_key"].Value)
+	assert.Equal(t, 1, vault.logins)
+
+	// the token is revoked, the backend logs in again
+	delete(vault.tokens, "approle")
+	res, err = b.fetch(context.Background(), []string{"datadog/agent#api_key"})
+	require.NoError(t, err)
+	assert.Equal(t, "abcdef", res["datadog/agent#api_key"].Value)
+	assert.Equal(t, 2, vault.logins)
+
+	conf["approle"] = map[string]interface{}{"role_id": "agent", "secret_id": "wrong"}
+	b, err = newVaultBackend(conf, 1024)
+	require.NoError(t, err)
+	res, err = b.fetch(context.Background(), []string{"datadog/agent#api_key"})
+	require.NoError(t, err)
+	assert.Equal(t, "approle login failed: vault returned 400 Bad Request: invalid role or secret ID", res["datadog/agent#api_key"].ErrorMsg)
+}
+
+func TestVaultBackendConfig(t *testing.T) {
+	t.Setenv("VAULT_ADDR", "")
+	t.Setenv("VAULT_TOKEN", "")
+
+	_, err := newVaultBackend(nil, 1024)
+	assert.EqualError(t, err, "the address of the Vault server is required")
+
+	t.Setenv("VAULT_ADDR", "https://vault.example.com:8200/")
+	_, err = newVaultBackend(nil, 1024)
+	assert.EqualError(t, err, "a token, a token_file or an approle is required to authenticate to Vault")
+
+	t.Setenv("VAULT_TOKEN", "root")
+	t.Setenv("VAULT_NAMESPACE", "team")
+	b, err := newVaultBackend(nil, 1024)
+	require.NoError(t, err)
+	assert.Equal(t, vaultConfig{
+		Address:   "https://vault.example.com:8200",
+		Namespace: "team",
+		Mount:     "secret",
+		Token:     "root",
+	}, b.(*vaultBackend).conf)
+}
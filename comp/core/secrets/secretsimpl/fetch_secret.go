@@ -103,34 +103,29 @@ func (r *secretResolver) execCommand(inputPayload string) ([]byte, error) {
 }
 
 // fetchSecret receives a list of secrets name to fetch, exec a custom
-// executable to fetch the actual secrets and returns them.
+// executable, or queries the built-in backend, to fetch the actual secrets
+// and returns them.
 func (r *secretResolver) fetchSecret(secretsHandle []string) (map[string]string, error) {
-	payload := map[string]interface{}{
-		"version": secrets.PayloadVersion,
-		"secrets": secretsHandle,
+	var secrets map[string]secrets.SecretVal
+	var err error
+	source := "secret_backend_command"
+	if r.backendType != "" {
+		source = r.backendType + " secret backend"
+		secrets, err = r.fetchFromBackend(secretsHandle)
+	} else {
+		secrets, err = r.fetchFromCommand(secretsHandle)
 	}
-	jsonPayload, err := json.Marshal(payload)
-	if err != nil {
-		return nil, fmt.Errorf("could not serialize secrets IDs to fetch password: %s", err)
-	}
-	output, err := r.execCommand(string(jsonPayload))
 	if err != nil {
 		return nil, err
 	}
 
-	secrets := map[string]secrets.SecretVal{}
-	err = json.Unmarshal(output, &secrets)
-	if err != nil {
-		r.tlmSecretUnmarshalError.Inc()
-		return nil, fmt.Errorf("could not unmarshal 'secret_backend_command' output: %s", err)
-	}
-
 	res := map[string]string{}
+	ttls := map[string]int64{}
 	for _, sec := range secretsHandle {
 		v, ok := secrets[sec]
 		if !ok {
 			r.tlmSecretResolveError.Inc("missing", sec)
-			return nil, fmt.Errorf("secret handle '%s' was not resolved by the secret_backend_command", sec)
+			return nil, fmt.Errorf("secret handle '%s' was not resolved by the %s", sec, source)
 		}
 
 		if v.ErrorMsg != "" {
@@ -147,6 +142,32 @@ func (r *secretResolver) fetchSecret(secretsHandle []string) (map[string]string,
 			return nil, fmt.Errorf("resolved secret for '%s' is empty", sec)
 		}
 		res[sec] = v.Value
+		ttls[sec] = v.TTL
 	}
+	r.updateLeases(ttls)
 	return res, nil
 }
+
+// fetchFromCommand executes the secret_backend_command to fetch the given secrets.
+func (r *secretResolver) fetchFromCommand(secretsHandle []string) (map[string]secrets.SecretVal, error) {
+	payload := map[string]interface{}{
+		"version": secrets.PayloadVersion,
+		"secrets": secretsHandle,
+	}
+	jsonPayload, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("could not serialize secrets IDs to fetch password: %s", err)
+	}
+	output, err := r.execCommand(string(jsonPayload))
+	if err != nil {
+		return nil, err
+	}
+
+	secrets := map[string]secrets.SecretVal{}
+	err = json.Unmarshal(output, &secrets)
+	if err != nil {
+		r.tlmSecretUnmarshalError.Inc()
+		return nil, fmt.Errorf("could not unmarshal 'secret_backend_command' output: %s", err)
+	}
+	return secrets, nil
+}
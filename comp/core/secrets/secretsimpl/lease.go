@@ -0,0 +1,163 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package secretsimpl
+
+import (
+	"sort"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	// leaseRefreshRatio is the fraction of their TTL after which leased secrets are refreshed
+	leaseRefreshRatio = 0.8
+	// leaseRetryMinBackoff and leaseRetryMaxBackoff bound the delay between the attempts to
+	// refresh a leased secret
+	leaseRetryMinBackoff = 5 * time.Second
+	leaseRetryMaxBackoff = 5 * time.Minute
+)
+
+// secretLease tracks a secret returned with a TTL by the backend.
+type secretLease struct {
+	expiration time.Time
+	// refreshAt is when the secret is fetched again
+	refreshAt time.Time
+	// failures is the number of consecutive failed refreshes
+	failures int
+}
+
+// updateLeases records the TTLs of the secrets that were just fetched, and schedules their
+// refresh. Secrets fetched without a TTL are no longer leased.
+//
+// This method must be called with r.lock held.
+func (r *secretResolver) updateLeases(ttls map[string]int64) {
+	now := r.clk.Now()
+	for handle, ttl := range ttls {
+		if ttl <= 0 {
+			delete(r.leases, handle)
+			continue
+		}
+		d := time.Duration(ttl) * time.Second
+		r.leases[handle] = &secretLease{
+			expiration: now.Add(d),
+			refreshAt:  now.Add(time.Duration(float64(d) * leaseRefreshRatio)),
+		}
+	}
+	r.scheduleLeaseRefresh()
+}
+
+// scheduleLeaseRefresh sets the lease timer to the next refresh of a leased secret.
+//
+// This method must be called with r.lock held.
+func (r *secretResolver) scheduleLeaseRefresh() {
+	var next time.Time
+	for _, lease := range r.leases {
+		if next.IsZero() || lease.refreshAt.Before(next) {
+			next = lease.refreshAt
+		}
+	}
+	if next.IsZero() {
+		if r.leaseTimer != nil {
+			r.leaseTimer.Stop()
+		}
+		return
+	}
+	d := next.Sub(r.clk.Now())
+	if r.leaseTimer == nil {
+		r.leaseTimer = r.clk.AfterFunc(d, r.refreshLeases)
+	} else {
+		r.leaseTimer.Reset(d)
+	}
+}
+
+// refreshLeases fetches again the leased secrets that are due, before they expire. Unlike
+// Refresh, the new values are sent to every subscriber regardless of the allowlist, since the
+// previous values are about to stop working. Failed refreshes are retried with an exponential
+// backoff.
+func (r *secretResolver) refreshLeases() {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	now := r.clk.Now()
+	handles := []string{}
+	for handle, lease := range r.leases {
+		if !lease.refreshAt.After(now) {
+			handles = append(handles, handle)
+		}
+	}
+	if len(handles) == 0 {
+		r.scheduleLeaseRefresh()
+		return
+	}
+	sort.Strings(handles)
+
+	log.Infof("Refreshing %d leased secrets", len(handles))
+	secretResponse, err := r.fetchSecret(handles)
+	if err != nil {
+		for _, handle := range handles {
+			lease := r.leases[handle]
+			lease.failures++
+			backoff := leaseRetryMaxBackoff
+			if lease.failures < 16 {
+				backoff = min(leaseRetryMinBackoff<<(lease.failures-1), leaseRetryMaxBackoff)
+			}
+			lease.refreshAt = now.Add(backoff)
+			if now.After(lease.expiration) {
+				log.Errorf("Leased secret '%s' expired at %s and could not be refreshed: %s", handle, lease.expiration.Format(time.RFC3339), err)
+			} else {
+				log.Warnf("Could not refresh leased secret '%s' expiring at %s, retrying in %s: %s", handle, lease.expiration.Format(time.RFC3339), backoff, err)
+			}
+		}
+		r.scheduleLeaseRefresh()
+		return
+	}
+
+	refreshResult := r.processSecretResponse(secretResponse, false)
+	if len(refreshResult.Handles) > 0 {
+		if err := r.addToAuditFile(secretResponse); err != nil {
+			log.Error(err)
+		}
+	}
+	r.scheduleLeaseRefresh()
+}
+
+type leaseInfo struct {
+	Handle     string
+	Expiration string
+	ExpiresIn  string
+	RefreshAt  string
+	Failures   int
+}
+
+// getLeasesInfo returns the leased secrets, by expiration.
+func (r *secretResolver) getLeasesInfo() []leaseInfo {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	handles := make([]string, 0, len(r.leases))
+	for handle := range r.leases {
+		handles = append(handles, handle)
+	}
+	sort.Slice(handles, func(i, j int) bool {
+		ei, ej := r.leases[handles[i]].expiration, r.leases[handles[j]].expiration
+		return ei.Before(ej) || (ei.Equal(ej) && handles[i] < handles[j])
+	})
+
+	now := r.clk.Now()
+	info := make([]leaseInfo, 0, len(handles))
+	for _, handle := range handles {
+		lease := r.leases[handle]
+		info = append(info, leaseInfo{
+			Handle:     handle,
+			Expiration: lease.expiration.UTC().Format(time.RFC3339),
+			ExpiresIn:  lease.expiration.Sub(now).Truncate(time.Second).String(),
+			RefreshAt:  lease.refreshAt.UTC().Format(time.RFC3339),
+			Failures:   lease.failures,
+		})
+	}
+	return info
+}
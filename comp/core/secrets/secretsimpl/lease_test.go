@@ -0,0 +1,163 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package secretsimpl
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/comp/core/secrets"
+	"github.com/DataDog/datadog-agent/comp/core/telemetry"
+	nooptelemetry "github.com/DataDog/datadog-agent/comp/core/telemetry/noopsimpl"
+	"github.com/DataDog/datadog-agent/pkg/util/fxutil"
+)
+
+// fakeBackend returns values, or err if it's set.
+type fakeBackend struct {
+	sync.Mutex
+	values map[string]secrets.SecretVal
+	err    error
+	calls  int
+}
+
+func (b *fakeBackend) fetch(_ context.Context, handles []string) (map[string]secrets.SecretVal, error) {
+	b.Lock()
+	defer b.Unlock()
+	b.calls++
+	if b.err != nil {
+		return nil, b.err
+	}
+	res := make(map[string]secrets.SecretVal)
+	for _, handle := range handles {
+		res[handle] = b.values[handle]
+	}
+	return res, nil
+}
+
+func (b *fakeBackend) set(handle string, v secrets.SecretVal) {
+	b.Lock()
+	defer b.Unlock()
+	b.values[handle] = v
+}
+
+func newLeaseTestResolver(t *testing.T, b *fakeBackend) (*secretResolver, *clock.Mock) {
+	tel := fxutil.Test[telemetry.Component](t, nooptelemetry.Module())
+	resolver := newEnabledSecretResolver(tel)
+	resolver.Configure(secrets.ConfigParams{Type: "http", BackendConfig: map[string]interface{}{"url": "https://localhost"}, RunPath: t.TempDir()})
+	resolver.backend = b
+	mockClock := clock.NewMock()
+	resolver.clk = mockClock
+	return resolver, mockClock
+}
+
+func TestLeaseRefresh(t *testing.T) {
+	b := &fakeBackend{values: map[string]secrets.SecretVal{
+		"pass1": {Value: "password1", TTL: 100},
+		"pass2": {Value: "password2"},
+		"key1":  {Value: "apikey1", TTL: 100},
+	}}
+	resolver, mockClock := newLeaseTestResolver(t, b)
+	start := mockClock.Now()
+
+	_, err := resolver.Resolve(testConf, "test")
+	require.NoError(t, err)
+	_, err = resolver.Resolve([]byte("api_key: ENC[key1]\n"), "datadog.yaml")
+	require.NoError(t, err)
+	require.Len(t, resolver.leases, 2)
+	assert.Equal(t, start.Add(100*time.Second), resolver.leases["pass1"].expiration)
+	assert.Equal(t, start.Add(80*time.Second), resolver.leases["pass1"].refreshAt)
+
+	var lock sync.Mutex
+	changes := map[string]any{}
+	resolver.SubscribeToChanges(func(handle, _ string, _ []string, _, newValue any) {
+		lock.Lock()
+		defer lock.Unlock()
+		changes[handle] = newValue
+	})
+
+	// the secrets are refreshed before they expire, and subscribers are notified even though
+	// "instances" isn't in the allowlist
+	b.set("pass1", secrets.SecretVal{Value: "password3", TTL: 100})
+	b.set("key1", secrets.SecretVal{Value: "apikey2", TTL: 100})
+	mockClock.Add(80 * time.Second)
+	assert.Eventually(t, func() bool {
+		lock.Lock()
+		defer lock.Unlock()
+		return changes["pass1"] == "password3" && changes["key1"] == "apikey2"
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.NotContains(t, changes, "pass2")
+
+	resolver.lock.Lock()
+	defer resolver.lock.Unlock()
+	assert.Equal(t, "password3", resolver.cache["pass1"])
+	assert.Equal(t, start.Add(160*time.Second), resolver.leases["pass1"].refreshAt)
+}
+
+func TestLeaseRefreshBackoff(t *testing.T) {
+	b := &fakeBackend{values: map[string]secrets.SecretVal{
+		"pass1": {Value: "password1", TTL: 100},
+		"pass2": {Value: "password2", TTL: 100},
+	}}
+	resolver, mockClock := newLeaseTestResolver(t, b)
+	start := mockClock.Now()
+	_, err := resolver.Resolve(testConf, "test")
+	require.NoError(t, err)
+
+	b.Lock()
+	b.err = errors.New("unavailable")
+	b.Unlock()
+	// call refreshLeases directly so that the timer doesn't race with the test
+	resolver.leaseTimer.Stop()
+	mockClock.Set(start.Add(80 * time.Second))
+	for i, backoff := range []time.Duration{5 * time.Second, 10 * time.Second, 20 * time.Second} {
+		resolver.refreshLeases()
+		resolver.leaseTimer.Stop()
+		lease := resolver.leases["pass1"]
+		assert.Equal(t, i+1, lease.failures)
+		assert.Equal(t, mockClock.Now().Add(backoff), lease.refreshAt)
+		mockClock.Set(lease.refreshAt)
+	}
+	assert.Equal(t, 4, b.calls)
+
+	b.Lock()
+	b.err = nil
+	b.values["pass1"] = secrets.SecretVal{Value: "password3"}
+	b.Unlock()
+	resolver.refreshLeases()
+	// the secret isn't leased anymore
+	assert.NotContains(t, resolver.leases, "pass1")
+	assert.Equal(t, 0, resolver.leases["pass2"].failures)
+	assert.Equal(t, "password3", resolver.cache["pass1"])
+}
+
+func TestLeaseDebugInfo(t *testing.T) {
+	b := &fakeBackend{values: map[string]secrets.SecretVal{
+		"pass1": {Value: "password1", TTL: 3600},
+		"pass2": {Value: "password2", TTL: 600},
+	}}
+	resolver, mockClock := newLeaseTestResolver(t, b)
+	mockClock.Set(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	_, err := resolver.Resolve(testConf, "test")
+	require.NoError(t, err)
+	resolver.leases["pass1"].failures = 2
+
+	var buffer bytes.Buffer
+	resolver.GetDebugInfo(&buffer)
+	assert.Contains(t, buffer.String(), `
+=== Leased secrets ===
+
+- 'pass2': expires at 2024-01-01T00:10:00Z (in 10m0s), refreshed at 2024-01-01T00:08:00Z
+- 'pass1': expires at 2024-01-01T01:00:00Z (in 1h0m0s), refreshed at 2024-01-01T00:48:00Z after 2 failed attempts
+`)
+}
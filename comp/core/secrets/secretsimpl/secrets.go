@@ -24,6 +24,7 @@ import (
 	"text/template"
 	"time"
 
+	"github.com/benbjohnson/clock"
 	"go.uber.org/fx"
 	"golang.org/x/exp/maps"
 	yaml "gopkg.in/yaml.v2"
@@ -80,8 +81,13 @@ type secretResolver struct {
 	// list of handles and where they were found
 	origin handleToContext
 
-	backendCommand          string
-	backendArguments        []string
+	backendCommand   string
+	backendArguments []string
+	// backendType is the type of the built-in backend used instead of the command, if any.
+	// backendErr is set if the backend couldn't be created.
+	backendType             string
+	backend                 backend
+	backendErr              error
 	backendTimeout          int
 	commandAllowGroupExec   bool
 	removeTrailingLinebreak bool
@@ -90,6 +96,10 @@ type secretResolver struct {
 	// refresh secrets at a regular interval
 	refreshInterval time.Duration
 	ticker          *time.Ticker
+	// leases of the secrets returned with a TTL, refreshed before they expire
+	leases     map[string]*secretLease
+	leaseTimer *clock.Timer
+	clk        clock.Clock
 	// filename to write audit records to
 	auditFilename    string
 	auditFileMaxSize int
@@ -114,6 +124,8 @@ func newEnabledSecretResolver(telemetry telemetry.Component) *secretResolver {
 	return &secretResolver{
 		cache:                   make(map[string]string),
 		origin:                  make(handleToContext),
+		leases:                  make(map[string]*secretLease),
+		clk:                     clock.New(),
 		enabled:                 true,
 		tlmSecretBackendElapsed: telemetry.NewGauge("secret_backend", "elapsed_ms", []string{"command", "exit_code"}, "Elapsed time of secret backend invocation"),
 		tlmSecretUnmarshalError: telemetry.NewCounter("secret_backend", "unmarshal_errors_count", []string{}, "Count of errors when unmarshalling the output of the secret binary"),
@@ -215,6 +227,17 @@ func (r *secretResolver) Configure(params secrets.ConfigParams) {
 	if r.responseMaxSize == 0 {
 		r.responseMaxSize = SecretBackendOutputMaxSizeDefault
 	}
+	r.backendType = params.Type
+	if r.backendType != "" {
+		if r.backendCommand != "" {
+			log.Warnf("Both secret_backend_type and secret_backend_command are set, the %s secret backend is used", r.backendType)
+		}
+		// errors are reported when resolving secrets, as Configure can't fail
+		r.backend, r.backendErr = newBackend(r.backendType, params.BackendConfig, r.responseMaxSize)
+		if r.backendErr != nil {
+			log.Errorf("Invalid %s secret backend: %v", r.backendType, r.backendErr)
+		}
+	}
 	r.refreshInterval = time.Duration(params.RefreshInterval) * time.Second
 	r.commandAllowGroupExec = params.GroupExecPerm
 	r.removeTrailingLinebreak = params.RemoveLinebreak
@@ -228,6 +251,11 @@ func (r *secretResolver) Configure(params secrets.ConfigParams) {
 	}
 }
 
+// backendConfigured returns whether a command or a built-in backend is configured to resolve secrets.
+func (r *secretResolver) backendConfigured() bool {
+	return r.backendCommand != "" || r.backendType != ""
+}
+
 func isEnc(str string) (bool, string) {
 	// trimming space and tabs
 	str = strings.Trim(str, " 	")
@@ -261,8 +289,8 @@ func (r *secretResolver) SubscribeToChanges(cb secrets.SecretChangeCallback) {
 	r.subscriptions = append(r.subscriptions, cb)
 }
 
-// Resolve replaces all encoded secrets in data by executing "secret_backend_command" once if all secrets aren't
-// present in the cache.
+// Resolve replaces all encoded secrets in data by executing "secret_backend_command", or querying the built-in
+// backend, once if all secrets aren't present in the cache.
 func (r *secretResolver) Resolve(data []byte, origin string) ([]byte, error) {
 	r.lock.Lock()
 	defer r.lock.Unlock()
@@ -271,7 +299,7 @@ func (r *secretResolver) Resolve(data []byte, origin string) ([]byte, error) {
 		log.Infof("Agent secrets is disabled by caller")
 		return nil, nil
 	}
-	if data == nil || r.backendCommand == "" {
+	if data == nil || !r.backendConfigured() {
 		return data, nil
 	}
 
@@ -572,11 +600,14 @@ func isLikelyAPIOrAppKey(handle, secretValue string, origin handleToContext) boo
 }
 
 type secretInfo struct {
+	BackendType                  string
+	BackendError                 string
 	Executable                   string
 	ExecutablePermissions        string
 	ExecutablePermissionsDetails interface{}
 	ExecutablePermissionsError   string
 	Handles                      map[string][][]string
+	Leases                       []leaseInfo
 }
 
 type secretRefreshInfo struct {
@@ -605,7 +636,7 @@ func (r *secretResolver) GetDebugInfo(w io.Writer) {
 		fmt.Fprintf(w, "Agent secrets is disabled by caller")
 		return
 	}
-	if r.backendCommand == "" {
+	if !r.backendConfigured() {
 		fmt.Fprintf(w, "No secret_backend_command set: secrets feature is not enabled")
 		return
 	}
@@ -623,22 +654,30 @@ func (r *secretResolver) GetDebugInfo(w io.Writer) {
 		return
 	}
 
-	err = checkRights(r.backendCommand, r.commandAllowGroupExec)
-
-	permissions := "OK, the executable has the correct permissions"
-	if err != nil {
-		permissions = fmt.Sprintf("error: %s", err)
-	}
-
-	details, err := r.getExecutablePermissions()
 	info := secretInfo{
-		Executable:                   r.backendCommand,
-		ExecutablePermissions:        permissions,
-		ExecutablePermissionsDetails: details,
-		Handles:                      map[string][][]string{},
+		BackendType: r.backendType,
+		Handles:     map[string][][]string{},
+		Leases:      r.getLeasesInfo(),
 	}
-	if err != nil {
-		info.ExecutablePermissionsError = err.Error()
+	if r.backendType != "" {
+		if r.backendErr != nil {
+			info.BackendError = r.backendErr.Error()
+		}
+	} else {
+		err = checkRights(r.backendCommand, r.commandAllowGroupExec)
+
+		permissions := "OK, the executable has the correct permissions"
+		if err != nil {
+			permissions = fmt.Sprintf("error: %s", err)
+		}
+
+		details, err := r.getExecutablePermissions()
+		info.Executable = r.backendCommand
+		info.ExecutablePermissions = permissions
+		info.ExecutablePermissionsDetails = details
+		if err != nil {
+			info.ExecutablePermissionsError = err.Error()
+		}
 	}
 
 	// we sort handles so the output is consistent and testable
@@ -5,10 +5,24 @@
 
 package secrets
 
+// Built-in secret backends, selected with the secret_backend_type setting
+const (
+	BackendTypeAWSSecretsManager = "aws.secrets"
+	BackendTypeAWSSSM            = "aws.ssm"
+	BackendTypeHashiCorpVault    = "hashicorp.vault"
+	BackendTypeHTTP              = "http"
+)
+
+// BackendTypes are the built-in secret backends, sorted
+var BackendTypes = []string{BackendTypeAWSSecretsManager, BackendTypeAWSSSM, BackendTypeHashiCorpVault, BackendTypeHTTP}
+
 // SecretVal defines the structure for secrets in JSON output
 type SecretVal struct {
 	Value    string `json:"value,omitempty"`
 	ErrorMsg string `json:"error,omitempty"`
+	// TTL is the number of seconds the secret is valid for, if it's leased. Leased secrets are
+	// refreshed before they expire.
+	TTL int64 `json:"ttl,omitempty"`
 }
 
 // SecretChangeCallback is the callback type used by SubscribeToChanges to send notifications
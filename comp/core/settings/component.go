@@ -32,6 +32,26 @@ type RuntimeSettingResponse struct {
 	Hidden      bool
 }
 
+// SettingLayer is the value of a setting in one of the configuration sources
+type SettingLayer struct {
+	Source model.Source `json:"source"`
+	Value  interface{}  `json:"value"`
+	// Origin is where the value was read from: the file and line for the configuration files, or the environment
+	// variables that are set for the environment
+	Origin string `json:"origin,omitempty"`
+	// Secret is the handle of the secret the value was resolved from, if any
+	Secret string `json:"secret,omitempty"`
+}
+
+// SettingExplanation describes how the value of a setting was resolved from the configuration sources
+type SettingExplanation struct {
+	Setting string       `json:"setting"`
+	Value   interface{}  `json:"value"`
+	Source  model.Source `json:"source"`
+	// Layers are the sources that set the setting, from the lowest priority to the highest
+	Layers []SettingLayer `json:"layers"`
+}
+
 // Params that the settings component need
 type Params struct {
 	// Settings define the runtime settings the component would understand
@@ -64,6 +84,8 @@ type Component interface {
 	SetValue(w http.ResponseWriter, r *http.Request)
 	// ListConfigurable returns the list of configurable setting at runtime
 	ListConfigurable(w http.ResponseWriter, r *http.Request)
+	// ExplainValue returns the value of any setting in every configuration source, and where it comes from
+	ExplainValue(w http.ResponseWriter, r *http.Request)
 }
 
 // RuntimeSetting represents a setting that can be changed and read at runtime.
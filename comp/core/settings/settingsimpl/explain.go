@@ -0,0 +1,169 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2024-present Datadog, Inc.
+
+package settingsimpl
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/gorilla/mux"
+	json "github.com/json-iterator/go"
+	"github.com/mohae/deepcopy"
+	yamlv3 "gopkg.in/yaml.v3"
+
+	"github.com/DataDog/datadog-agent/comp/core/settings"
+	"github.com/DataDog/datadog-agent/pkg/config/model"
+	"github.com/DataDog/datadog-agent/pkg/util/scrubber"
+)
+
+const secretReplacement = "********"
+
+// ExplainValue returns the value of a setting in every configuration source, which one is in use, and where each of
+// them comes from.
+func (s *settingsRegistry) ExplainValue(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	setting := strings.ToLower(vars["setting"])
+
+	s.log.Infof("Got a request to explain a setting value: %s", setting)
+
+	if !s.config.IsKnown(setting) {
+		body, _ := json.Marshal(map[string]string{"error": (&settings.SettingNotFoundError{Name: setting}).Error()})
+		http.Error(w, string(body), http.StatusBadRequest)
+		return
+	}
+
+	body, err := json.Marshal(s.explain(setting))
+	if err != nil {
+		s.log.Errorf("Unable to marshal setting explanation response: %s", err)
+		body, _ := json.Marshal(map[string]string{"error": err.Error()})
+		http.Error(w, string(body), http.StatusInternalServerError)
+		return
+	}
+	_, _ = w.Write(body)
+}
+
+func (s *settingsRegistry) explain(setting string) settings.SettingExplanation {
+	explanation := settings.SettingExplanation{
+		Setting: setting,
+		Value:   scrubSettingValue(setting, s.config.Get(setting)),
+		Source:  s.config.GetSource(setting),
+		Layers:  []settings.SettingLayer{},
+	}
+
+	// secretHandle is the handle of the secret found in the layers below agent-runtime, if any
+	secretHandle := ""
+	for _, layer := range s.config.GetAllSources(setting) {
+		if layer.Value == nil {
+			continue
+		}
+		l := settings.SettingLayer{
+			Source: layer.Source,
+			Value:  scrubSettingValue(setting, layer.Value),
+		}
+
+		switch layer.Source {
+		case model.SourceFile:
+			l.Origin = s.fileOrigin(setting)
+		case model.SourceEnvVar:
+			l.Origin = envOrigin(s.config.GetEnvVarsForKey(setting))
+		case model.SourceAgentRuntime:
+			// resolved secrets are written to the agent-runtime layer, on top of the layer holding the handle.
+			// Their value is never returned, whatever the name of the setting.
+			if secretHandle != "" {
+				l.Secret = secretHandle
+				l.Value = secretReplacement
+				if explanation.Source == model.SourceAgentRuntime {
+					explanation.Value = secretReplacement
+				}
+			}
+		}
+
+		if layer.Source != model.SourceAgentRuntime {
+			secretHandle = getSecretHandle(layer.Value)
+		}
+		explanation.Layers = append(explanation.Layers, l)
+	}
+	return explanation
+}
+
+// getSecretHandle returns the handle of the secret if the value is a 'ENC[<handle>]' string, the same way the secrets
+// resolver detects them.
+func getSecretHandle(value interface{}) string {
+	str, ok := value.(string)
+	if !ok || !strings.HasPrefix(str, "ENC[") || !strings.HasSuffix(str, "]") {
+		return ""
+	}
+	return str[4 : len(str)-1]
+}
+
+// fileOrigin returns the file and line at which the setting is defined. Additional configuration files are merged
+// on top of the main one, so the last one defining the setting wins.
+func (s *settingsRegistry) fileOrigin(setting string) string {
+	files := append([]string{s.config.ConfigFileUsed()}, s.config.ExtraConfigFilesUsed()...)
+	for i := len(files) - 1; i >= 0; i-- {
+		if files[i] == "" {
+			continue
+		}
+		if line := findSettingLine(files[i], setting); line > 0 {
+			return fmt.Sprintf("%s:%d", files[i], line)
+		}
+	}
+	return ""
+}
+
+// findSettingLine returns the line at which the setting is defined in a YAML file, or 0 if it isn't.
+func findSettingLine(path string, setting string) int {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0
+	}
+	var root yamlv3.Node
+	if err := yamlv3.Unmarshal(data, &root); err != nil || len(root.Content) == 0 {
+		return 0
+	}
+
+	node := root.Content[0]
+	line := 0
+	for _, part := range strings.Split(setting, ".") {
+		if node.Kind != yamlv3.MappingNode {
+			return 0
+		}
+		found := false
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if strings.EqualFold(node.Content[i].Value, part) {
+				line = node.Content[i].Line
+				node = node.Content[i+1]
+				found = true
+				break
+			}
+		}
+		if !found {
+			return 0
+		}
+	}
+	return line
+}
+
+// envOrigin returns the environment variables that are set among the ones bound to a setting.
+func envOrigin(envVars []string) string {
+	set := []string{}
+	for _, envVar := range envVars {
+		if _, found := os.LookupEnv(envVar); found {
+			set = append(set, envVar)
+		}
+	}
+	return strings.Join(set, ", ")
+}
+
+// scrubSettingValue scrubs a setting value, using the name of the setting to detect the sensitive ones.
+func scrubSettingValue(setting string, value interface{}) interface{} {
+	parts := strings.Split(setting, ".")
+	var data interface{} = map[string]interface{}{parts[len(parts)-1]: deepcopy.Copy(value)}
+	scrubber.ScrubDataObj(&data)
+	return data.(map[string]interface{})[parts[len(parts)-1]]
+}
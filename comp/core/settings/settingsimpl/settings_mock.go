@@ -82,3 +82,6 @@ func (m mock) SetValue(http.ResponseWriter, *http.Request) {}
 
 // ListConfigurable returns the list of configurable setting at runtime
 func (m mock) ListConfigurable(http.ResponseWriter, *http.Request) {}
+
+// ExplainValue returns the value of a setting in every configuration source
+func (m mock) ExplainValue(http.ResponseWriter, *http.Request) {}
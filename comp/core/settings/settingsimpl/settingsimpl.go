@@ -37,11 +37,13 @@ func Module() fxutil.Module {
 type provides struct {
 	fx.Out
 
-	Comp         settings.Component
-	FullEndpoint api.AgentEndpointProvider
-	ListEndpoint api.AgentEndpointProvider
-	GetEndpoint  api.AgentEndpointProvider
-	SetEndpoint  api.AgentEndpointProvider
+	Comp             settings.Component
+	FullEndpoint     api.AgentEndpointProvider
+	BySourceEndpoint api.AgentEndpointProvider
+	ListEndpoint     api.AgentEndpointProvider
+	GetEndpoint      api.AgentEndpointProvider
+	SetEndpoint      api.AgentEndpointProvider
+	ExplainEndpoint  api.AgentEndpointProvider
 }
 
 type dependencies struct {
@@ -248,10 +250,12 @@ func newSettings(deps dependencies) provides {
 		config:   deps.Params.Config,
 	}
 	return provides{
-		Comp:         s,
-		FullEndpoint: api.NewAgentEndpointProvider(s.GetFullConfig(deps.Params.Namespaces...), "/config", "GET"),
-		ListEndpoint: api.NewAgentEndpointProvider(s.ListConfigurable, "/config/list-runtime", "GET"),
-		GetEndpoint:  api.NewAgentEndpointProvider(s.GetValue, "/config/{setting}", "GET"),
-		SetEndpoint:  api.NewAgentEndpointProvider(s.SetValue, "/config/{setting}", "POST"),
+		Comp:             s,
+		FullEndpoint:     api.NewAgentEndpointProvider(s.GetFullConfig(deps.Params.Namespaces...), "/config", "GET"),
+		BySourceEndpoint: api.NewAgentEndpointProvider(s.GetFullConfigBySource(), "/config/by-source", "GET"),
+		ListEndpoint:     api.NewAgentEndpointProvider(s.ListConfigurable, "/config/list-runtime", "GET"),
+		GetEndpoint:      api.NewAgentEndpointProvider(s.GetValue, "/config/{setting}", "GET"),
+		SetEndpoint:      api.NewAgentEndpointProvider(s.SetValue, "/config/{setting}", "POST"),
+		ExplainEndpoint:  api.NewAgentEndpointProvider(s.ExplainValue, "/config/explain/{setting}", "GET"),
 	}
 }
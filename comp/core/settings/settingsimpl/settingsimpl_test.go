@@ -13,6 +13,8 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -338,3 +340,69 @@ func TestRuntimeSettings(t *testing.T) {
 		})
 	}
 }
+
+func TestExplainValue(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "datadog.yaml")
+	err := os.WriteFile(configFile, []byte("hostname: ENC[hostname]\nlogs_config:\n  batch_wait: 10\n"), 0600)
+	require.NoError(t, err)
+
+	t.Setenv("DD_LOGS_CONFIG_BATCH_WAIT", "15")
+	cfg := config.NewMockFromYAMLFile(t, configFile)
+	cfg.Set("logs_config.batch_wait", 15, model.SourceEnvVar)
+	// the secrets resolver stores the decrypted values in the agent-runtime layer
+	cfg.Set("hostname", "my-secret-host", model.SourceAgentRuntime)
+
+	deps := fxutil.Test[dependencies](t, fx.Options(
+		fx.Provide(func() log.Component { return logmock.New(t) }),
+		fx.Supply(settings.Params{Config: cfg}),
+	))
+	comp := newSettings(deps).Comp
+
+	router := mux.NewRouter()
+	router.HandleFunc("/config/explain/{setting}", comp.ExplainValue).Methods("GET")
+	ts := httptest.NewServer(router)
+	defer ts.Close()
+
+	explain := func(setting string) (int, []byte) {
+		resp, err := ts.Client().Get(ts.URL + "/config/explain/" + setting)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, body
+	}
+
+	code, body := explain("logs_config.batch_wait")
+	require.Equal(t, 200, code)
+	var explanation settings.SettingExplanation
+	require.NoError(t, json.Unmarshal(body, &explanation))
+	assert.Equal(t, settings.SettingExplanation{
+		Setting: "logs_config.batch_wait",
+		Value:   float64(15),
+		Source:  model.SourceEnvVar,
+		Layers: []settings.SettingLayer{
+			{Source: model.SourceDefault, Value: float64(5)},
+			{Source: model.SourceFile, Value: float64(10), Origin: configFile + ":3"},
+			{Source: model.SourceEnvVar, Value: float64(15), Origin: "DD_LOGS_CONFIG_BATCH_WAIT"},
+		},
+	}, explanation)
+
+	code, body = explain("hostname")
+	require.Equal(t, 200, code)
+	explanation = settings.SettingExplanation{}
+	require.NoError(t, json.Unmarshal(body, &explanation))
+	assert.Equal(t, settings.SettingExplanation{
+		Setting: "hostname",
+		Value:   "********",
+		Source:  model.SourceAgentRuntime,
+		Layers: []settings.SettingLayer{
+			{Source: model.SourceDefault, Value: ""},
+			{Source: model.SourceFile, Value: "ENC[hostname]", Origin: configFile + ":1"},
+			{Source: model.SourceAgentRuntime, Value: "********", Secret: "hostname"},
+		},
+	}, explanation)
+	assert.NotContains(t, string(body), "my-secret-host")
+
+	code, body = explain("non_existing")
+	assert.Equal(t, 400, code)
+	assert.Equal(t, "{\"error\":\"setting non_existing not found\"}\n", string(body))
+}
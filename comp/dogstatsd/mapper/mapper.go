@@ -16,10 +16,12 @@ import (
 
 var (
 	allowedWildcardMatchPattern = regexp.MustCompile(`^[a-zA-Z0-9\-_*.]+$`)
+	allowedGlobMatchPattern     = regexp.MustCompile(`^[a-zA-Z0-9\-_*.{},]+$`)
 )
 
 const (
 	matchTypeWildcard = "wildcard"
+	matchTypeGlob     = "glob"
 	matchTypeRegex    = "regex"
 )
 
@@ -40,10 +42,12 @@ type MappingProfileConfig struct {
 
 // MetricMapping represent one mapping rule
 type MetricMappingConfig struct {
-	Match     string            `mapstructure:"match" json:"match" yaml:"match"`
-	MatchType string            `mapstructure:"match_type" json:"match_type" yaml:"match_type"`
-	Name      string            `mapstructure:"name" json:"name" yaml:"name"`
-	Tags      map[string]string `mapstructure:"tags" json:"tags" yaml:"tags"`
+	Match     string                `mapstructure:"match" json:"match" yaml:"match"`
+	MatchType string                `mapstructure:"match_type" json:"match_type" yaml:"match_type"`
+	Name      string                `mapstructure:"name" json:"name" yaml:"name"`
+	Tags      map[string]string     `mapstructure:"tags" json:"tags" yaml:"tags"`
+	Actions   []MappingActionConfig `mapstructure:"actions" json:"actions" yaml:"actions"`
+	Continue  bool                  `mapstructure:"continue" json:"continue" yaml:"continue"`
 }
 
 // MetricMapper contains mappings and cache instance
@@ -61,16 +65,22 @@ type MappingProfile struct {
 
 // MetricMapping represent one mapping rule
 type MetricMapping struct {
-	name  string
-	tags  map[string]string
-	regex *regexp.Regexp
+	name            string
+	tags            map[string]string
+	regex           *regexp.Regexp
+	actions         []*mappingAction
+	continueMapping bool
 }
 
 // MapResult represent the outcome of the mapping
 type MapResult struct {
-	Name    string
-	Tags    []string
+	Name string
+	Tags []string
+	// Drop is true if the metric must be dropped
+	Drop    bool
 	matched bool
+	// actions are the tag rewrite actions left to apply to the tags of the metric
+	actions []*mappingAction
 }
 
 // NewMetricMapper creates, validates, prepares a new MetricMapper
@@ -93,10 +103,11 @@ func NewMetricMapper(configProfiles []MappingProfileConfig, cacheSize int) (*Met
 			if matchType == "" {
 				matchType = matchTypeWildcard
 			}
-			if matchType != matchTypeWildcard && matchType != matchTypeRegex {
-				return nil, fmt.Errorf("profile: %s, mapping num %d: invalid match type, must be `wildcard` or `regex`", profile.Name, i)
+			if matchType != matchTypeWildcard && matchType != matchTypeGlob && matchType != matchTypeRegex {
+				return nil, fmt.Errorf("profile: %s, mapping num %d: invalid match type, must be `wildcard`, `glob` or `regex`", profile.Name, i)
 			}
-			if currentMapping.Name == "" {
+			// the metric name is kept as is when the mapping only rewrites it with actions
+			if currentMapping.Name == "" && len(currentMapping.Actions) == 0 {
 				return nil, fmt.Errorf("profile: %s, mapping num %d: name is required", profile.Name, i)
 			}
 			if currentMapping.Match == "" {
@@ -106,7 +117,17 @@ func NewMetricMapper(configProfiles []MappingProfileConfig, cacheSize int) (*Met
 			if err != nil {
 				return nil, err
 			}
-			profile.Mappings = append(profile.Mappings, &MetricMapping{name: currentMapping.Name, tags: currentMapping.Tags, regex: regex})
+			actions, err := buildActions(currentMapping.Actions)
+			if err != nil {
+				return nil, fmt.Errorf("profile: %s, mapping num %d: %v", profile.Name, i, err)
+			}
+			profile.Mappings = append(profile.Mappings, &MetricMapping{
+				name:            currentMapping.Name,
+				tags:            currentMapping.Tags,
+				regex:           regex,
+				actions:         actions,
+				continueMapping: currentMapping.Continue,
+			})
 		}
 		profiles = append(profiles, profile)
 	}
@@ -128,6 +149,12 @@ func buildRegex(matchRe string, matchType string) (*regexp.Regexp, error) {
 		matchRe = strings.Replace(matchRe, ".", "\\.", -1)
 		matchRe = strings.Replace(matchRe, "*", "([^.]*)", -1)
 	}
+	if matchType == matchTypeGlob {
+		var err error
+		if matchRe, err = globToRegex(matchRe); err != nil {
+			return nil, err
+		}
+	}
 	regex, err := regexp.Compile("^" + matchRe + "$")
 	if err != nil {
 		return nil, fmt.Errorf("invalid match `%s`. cannot compile regex: %v", matchRe, err)
@@ -135,45 +162,168 @@ func buildRegex(matchRe string, matchType string) (*regexp.Regexp, error) {
 	return regex, nil
 }
 
-// Map returns a MapResult
+// globToRegex converts a glob pattern to a regex, each `*`, `**` and `{...}` being a capture group:
+// - `*` matches a single segment of the metric name (or part of it)
+// - `**` matches one or more segments
+// - `{a,b}` matches any of the comma separated alternatives
+func globToRegex(glob string) (string, error) {
+	if !allowedGlobMatchPattern.MatchString(glob) {
+		return "", fmt.Errorf("invalid glob match pattern `%s`, it does not match allowed match regex `%s`", glob, allowedGlobMatchPattern)
+	}
+	if strings.Contains(glob, "***") {
+		return "", fmt.Errorf("invalid glob match pattern `%s`, it should not contain more than two consecutive `*`", glob)
+	}
+	var re strings.Builder
+	for i := 0; i < len(glob); i++ {
+		switch c := glob[i]; c {
+		case '*':
+			if i+1 < len(glob) && glob[i+1] == '*' {
+				re.WriteString(`([^.]+(?:\.[^.]+)*)`)
+				i++
+			} else {
+				re.WriteString(`([^.]*)`)
+			}
+		case '{':
+			end := strings.IndexByte(glob[i:], '}')
+			if end < 0 {
+				return "", fmt.Errorf("invalid glob match pattern `%s`, unclosed `{`", glob)
+			}
+			alternatives := strings.Split(glob[i+1:i+end], ",")
+			for j, alternative := range alternatives {
+				if alternative == "" || strings.ContainsAny(alternative, "{*") {
+					return "", fmt.Errorf("invalid glob match pattern `%s`, invalid alternatives `%s`", glob, glob[i:i+end+1])
+				}
+				alternatives[j] = regexp.QuoteMeta(alternative)
+			}
+			re.WriteString("(" + strings.Join(alternatives, "|") + ")")
+			i += end
+		case '}', ',':
+			return "", fmt.Errorf("invalid glob match pattern `%s`, unexpected `%c`", glob, c)
+		default:
+			re.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return re.String(), nil
+}
+
+// Map returns a MapResult, or nil if the metric isn't mapped.
+//
+// A mapping with `continue` set keeps mapping the resulting metric name with the following mappings of its profile,
+// and then with the following profiles.
 func (m *MetricMapper) Map(metricName string) *MapResult {
-	for _, profile := range m.Profiles {
-		if !strings.HasPrefix(metricName, profile.Prefix) && profile.Prefix != "*" {
+	result, cached := m.cache.get(metricName)
+	if cached {
+		if result.matched {
+			return result
+		}
+		return nil
+	}
+	result, prefixMatched := m.mapName(metricName)
+	if result != nil {
+		m.cache.add(metricName, result)
+		return result
+	}
+	// only cache the names matching a profile prefix to avoid filling the cache with unrelated metrics
+	if prefixMatched {
+		m.cache.add(metricName, &MapResult{matched: false})
+	}
+	return nil
+}
+
+func (m *MetricMapper) mapName(metricName string) (result *MapResult, prefixMatched bool) {
+	name := metricName
+	// first is the index of the first mapping to try in the current profile, it's only
+	// greater than zero when continuing with the following mappings of a profile
+	p, first := 0, 0
+	for p < len(m.Profiles) {
+		profile := m.Profiles[p]
+		if first == 0 && !strings.HasPrefix(name, profile.Prefix) && profile.Prefix != "*" {
+			p++
 			continue
 		}
-		result, cached := m.cache.get(metricName)
-		if cached {
-			if result.matched {
-				return result
+		prefixMatched = true
+
+		index, matches := -1, []int(nil)
+		for i := first; i < len(profile.Mappings); i++ {
+			if matches = profile.Mappings[i].regex.FindStringSubmatchIndex(name); len(matches) > 0 {
+				index = i
+				break
 			}
-			return nil
 		}
-		for _, mapping := range profile.Mappings {
-			matches := mapping.regex.FindStringSubmatchIndex(metricName)
-			if len(matches) == 0 {
-				continue
+		if index < 0 {
+			if first == 0 {
+				// if a profile prefix is matched, other profiles are not tried
+				return result, prefixMatched
 			}
+			p, first = p+1, 0
+			continue
+		}
 
-			name := string(mapping.regex.ExpandString(
-				[]byte{},
-				mapping.name,
-				metricName,
-				matches,
-			))
-
-			tags := make([]string, 0, len(mapping.tags))
-			for tagKey, tagValueExpr := range mapping.tags {
-				tagValue := string(mapping.regex.ExpandString([]byte{}, tagValueExpr, metricName, matches))
-				tags = append(tags, tagKey+":"+tagValue)
-			}
+		mapping := profile.Mappings[index]
+		result = mapping.apply(result, name, matches)
+		if result.Drop || !mapping.continueMapping {
+			return result, prefixMatched
+		}
+		name = result.Name
+		if first = index + 1; first == len(profile.Mappings) {
+			p, first = p+1, 0
+		}
+	}
+	return result, prefixMatched
+}
 
-			mapResult := &MapResult{Name: name, matched: true, Tags: tags}
-			m.cache.add(metricName, mapResult)
-			return mapResult
+// apply adds the outcome of the mapping of name to result
+func (m *MetricMapping) apply(result *MapResult, name string, matches []int) *MapResult {
+	if result == nil {
+		result = &MapResult{matched: true, Tags: make([]string, 0, len(m.tags))}
+	}
+
+	mapped := name
+	if m.name != "" {
+		mapped = string(m.regex.ExpandString([]byte{}, m.name, name, matches))
+	}
+
+	for tagKey, tagValueExpr := range m.tags {
+		tag := tagKey + ":" + string(m.regex.ExpandString([]byte{}, tagValueExpr, name, matches))
+		if len(result.actions) == 0 {
+			result.Tags = append(result.Tags, tag)
+		} else {
+			// keep the order of the operations when the tags of a previous mapping are rewritten
+			result.actions = append(result.actions, &mappingAction{actionType: actionAddTag, value: tag})
 		}
-		mapResult := &MapResult{matched: false}
-		m.cache.add(metricName, mapResult)
-		return nil
 	}
-	return nil
+
+	for _, action := range m.actions {
+		switch {
+		case action.actionType == actionDrop:
+			result.Name = mapped
+			result.Drop = true
+			return result
+		case action.actionType == actionReplace && action.tag == "":
+			mapped = action.regex.ReplaceAllString(mapped, action.replacement)
+		case action.actionType == actionSetTag:
+			resolved := *action
+			resolved.value = string(m.regex.ExpandString([]byte{}, action.value, name, matches))
+			result.actions = append(result.actions, &resolved)
+		default:
+			result.actions = append(result.actions, action)
+		}
+	}
+	result.Name = mapped
+	return result
+}
+
+// ApplyTags returns the tags of a mapped metric: tags with the tags of the mapping
+// added and the tag rewrite actions of the mapping applied.
+func (r *MapResult) ApplyTags(tags []string) []string {
+	if len(r.actions) == 0 {
+		return append(tags, r.Tags...)
+	}
+	rewritten := make([]string, 0, len(tags)+len(r.Tags)+len(r.actions))
+	rewritten = append(rewritten, tags...)
+	rewritten = append(rewritten, r.Tags...)
+	for _, action := range r.actions {
+		rewritten = action.applyTags(rewritten)
+	}
+	return rewritten
 }
@@ -9,11 +9,14 @@
 package mapper
 
 import (
+	"os"
+	"path/filepath"
 	"sort"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
 
 	configComponent "github.com/DataDog/datadog-agent/comp/core/config"
 	"github.com/DataDog/datadog-agent/pkg/config/structure"
@@ -504,6 +507,75 @@ dogstatsd_mapper_profiles:
 			},
 			expectedError: "missing prefix for profile",
 		},
+		{
+			name: "Invalid glob alternatives",
+			config: `
+dogstatsd_mapper_profiles:
+  - name: test
+    prefix: 'test.'
+    mappings:
+      - match: "test.{job,task.*"
+        match_type: glob
+        name: "test.duration"
+`,
+			expectedError: "unclosed `{`",
+		},
+		{
+			name: "Too many consecutive * in glob",
+			config: `
+dogstatsd_mapper_profiles:
+  - name: test
+    prefix: 'test.'
+    mappings:
+      - match: "test.***"
+        match_type: glob
+        name: "test.duration"
+`,
+			expectedError: "it should not contain more than two consecutive `*`",
+		},
+		{
+			name: "Invalid action type",
+			config: `
+dogstatsd_mapper_profiles:
+  - name: test
+    prefix: 'test.'
+    mappings:
+      - match: "test.*"
+        name: "test"
+        actions:
+          - type: invalid
+`,
+			expectedError: "invalid action type `invalid`",
+		},
+		{
+			name: "Missing rename target",
+			config: `
+dogstatsd_mapper_profiles:
+  - name: test
+    prefix: 'test.'
+    mappings:
+      - match: "test.*"
+        name: "test"
+        actions:
+          - type: rename_tag
+            tag: foo
+`,
+			expectedError: "tag and target are required to rename a tag",
+		},
+		{
+			name: "Invalid replace regex",
+			config: `
+dogstatsd_mapper_profiles:
+  - name: test
+    prefix: 'test.'
+    mappings:
+      - match: "test.*"
+        actions:
+          - type: replace
+            regex: "("
+`,
+			expectedError: "invalid regex `(`",
+		},
 	}
 
 	for _, scenario := range scenarios {
@@ -515,6 +587,56 @@ dogstatsd_mapper_profiles:
 	}
 }
 
+// mapperFixture is a test case of the testdata folder: the profiles are loaded from
+// `dogstatsd_mapper_profiles` and each case is mapped with them.
+type mapperFixture struct {
+	Cases []struct {
+		Metric       string   `yaml:"metric"`
+		Tags         []string `yaml:"tags"`
+		Dropped      bool     `yaml:"dropped"`
+		Unmapped     bool     `yaml:"unmapped"`
+		ExpectedName string   `yaml:"expected_name"`
+		ExpectedTags []string `yaml:"expected_tags"`
+	} `yaml:"cases"`
+}
+
+func TestMappingFixtures(t *testing.T) {
+	fixtures, err := filepath.Glob("testdata/*.yaml")
+	require.NoError(t, err)
+	require.NotEmpty(t, fixtures)
+
+	for _, fixture := range fixtures {
+		t.Run(filepath.Base(fixture), func(t *testing.T) {
+			content, err := os.ReadFile(fixture)
+			require.NoError(t, err)
+			var f mapperFixture
+			require.NoError(t, yaml.Unmarshal(content, &f))
+
+			mapper, err := getMapper(t, string(content))
+			require.NoError(t, err)
+
+			// map everything twice to check cached results too
+			for i := 0; i < 2; i++ {
+				for _, c := range f.Cases {
+					result := mapper.Map(c.Metric)
+					if c.Unmapped {
+						assert.Nil(t, result, c.Metric)
+						continue
+					}
+					require.NotNil(t, result, c.Metric)
+					assert.Equal(t, c.Dropped, result.Drop, c.Metric)
+					if c.Dropped {
+						continue
+					}
+					assert.Equal(t, c.ExpectedName, result.Name, c.Metric)
+					tags := append([]string{}, c.Tags...)
+					assert.ElementsMatch(t, c.ExpectedTags, result.ApplyTags(tags), c.Metric)
+				}
+			}
+		})
+	}
+}
+
 func getMapper(t *testing.T, configString string) (*MetricMapper, error) {
 	var profiles []MappingProfileConfig
 
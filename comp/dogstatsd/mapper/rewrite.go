@@ -0,0 +1,163 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package mapper
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	actionDrop      = "drop"
+	actionRenameTag = "rename_tag"
+	actionSplitTag  = "split_tag"
+	actionSetTag    = "set_tag"
+	actionReplace   = "replace"
+
+	// actionAddTag adds the tags of a chained mapping, it can't be configured
+	actionAddTag = "add_tag"
+)
+
+// MappingActionConfig represent one rewrite action applied to the metrics matched by a mapping
+type MappingActionConfig struct {
+	Type        string   `mapstructure:"type" json:"type" yaml:"type"`
+	Tag         string   `mapstructure:"tag" json:"tag" yaml:"tag"`
+	Target      string   `mapstructure:"target" json:"target" yaml:"target"`
+	Targets     []string `mapstructure:"targets" json:"targets" yaml:"targets"`
+	Separator   string   `mapstructure:"separator" json:"separator" yaml:"separator"`
+	Value       string   `mapstructure:"value" json:"value" yaml:"value"`
+	Regex       string   `mapstructure:"regex" json:"regex" yaml:"regex"`
+	Replacement string   `mapstructure:"replacement" json:"replacement" yaml:"replacement"`
+}
+
+// mappingAction is a validated MappingActionConfig
+type mappingAction struct {
+	actionType  string
+	tag         string
+	target      string
+	targets     []string
+	separator   string
+	value       string
+	regex       *regexp.Regexp
+	replacement string
+}
+
+func buildActions(configs []MappingActionConfig) ([]*mappingAction, error) {
+	actions := make([]*mappingAction, 0, len(configs))
+	for i, config := range configs {
+		action, err := buildAction(config)
+		if err != nil {
+			return nil, fmt.Errorf("action num %d: %v", i, err)
+		}
+		actions = append(actions, action)
+	}
+	return actions, nil
+}
+
+func buildAction(config MappingActionConfig) (*mappingAction, error) {
+	action := &mappingAction{
+		actionType:  config.Type,
+		tag:         config.Tag,
+		target:      config.Target,
+		targets:     config.Targets,
+		separator:   config.Separator,
+		value:       config.Value,
+		replacement: config.Replacement,
+	}
+	switch config.Type {
+	case actionDrop:
+	case actionRenameTag:
+		if config.Tag == "" || config.Target == "" {
+			return nil, errors.New("tag and target are required to rename a tag")
+		}
+	case actionSplitTag:
+		if config.Tag == "" || config.Separator == "" || len(config.Targets) == 0 {
+			return nil, errors.New("tag, separator and targets are required to split a tag")
+		}
+	case actionSetTag:
+		if config.Tag == "" || config.Value == "" {
+			return nil, errors.New("tag and value are required to set a tag")
+		}
+	case actionReplace:
+		if config.Regex == "" {
+			return nil, errors.New("regex is required to replace")
+		}
+		regex, err := regexp.Compile(config.Regex)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regex `%s`: %v", config.Regex, err)
+		}
+		action.regex = regex
+	default:
+		return nil, fmt.Errorf("invalid action type `%s`, must be `%s`, `%s`, `%s`, `%s` or `%s`", config.Type, actionDrop, actionRenameTag, actionSplitTag, actionSetTag, actionReplace)
+	}
+	return action, nil
+}
+
+// applyTags applies a tag rewrite action to tags
+func (a *mappingAction) applyTags(tags []string) []string {
+	switch a.actionType {
+	case actionAddTag:
+		return append(tags, a.value)
+	case actionSetTag:
+		return append(removeTag(tags, a.tag), a.tag+":"+a.value)
+	case actionRenameTag:
+		for i, tag := range tags {
+			if key, value, ok := splitTag(tag); key == a.tag {
+				tags[i] = joinTag(a.target, value, ok)
+			}
+		}
+	case actionSplitTag:
+		var split []string
+		for _, tag := range tags {
+			if key, value, ok := splitTag(tag); key == a.tag && ok {
+				for i, part := range strings.SplitN(value, a.separator, len(a.targets)) {
+					split = append(split, a.targets[i]+":"+part)
+				}
+			}
+		}
+		if split != nil {
+			return append(removeTag(tags, a.tag), split...)
+		}
+	case actionReplace:
+		rewritten := tags[:0]
+		for _, tag := range tags {
+			if key, value, ok := splitTag(tag); key == a.tag && ok {
+				// tags whose value is entirely removed are dropped
+				if value = a.regex.ReplaceAllString(value, a.replacement); value == "" {
+					continue
+				}
+				tag = key + ":" + value
+			}
+			rewritten = append(rewritten, tag)
+		}
+		return rewritten
+	}
+	return tags
+}
+
+// removeTag removes the tags with the given key, rewriting tags in place
+func removeTag(tags []string, key string) []string {
+	kept := tags[:0]
+	for _, tag := range tags {
+		if k, _, _ := splitTag(tag); k != key {
+			kept = append(kept, tag)
+		}
+	}
+	return kept
+}
+
+func splitTag(tag string) (key string, value string, hasValue bool) {
+	return strings.Cut(tag, ":")
+}
+
+func joinTag(key string, value string, hasValue bool) string {
+	if !hasValue {
+		return key
+	}
+	return key + ":" + value
+}
@@ -0,0 +1,371 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package server
+
+import (
+	"expvar"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/DataDog/datadog-agent/comp/core/telemetry"
+	"github.com/DataDog/datadog-agent/pkg/config/model"
+	"github.com/DataDog/datadog-agent/pkg/config/structure"
+)
+
+const (
+	cardinalityLimitActionCollapse = "collapse"
+	cardinalityLimitActionDrop     = "drop"
+
+	// collapsedTagValue replaces the values of the collapsed tags
+	collapsedTagValue = "other"
+	// maxCardinalityOffenders is the number of offenders reported in the status
+	maxCardinalityOffenders = 10
+	// cardinalityLimiterShards is the number of shards the tag sets are tracked in, so that
+	// the samples of different metrics don't contend on the same lock
+	cardinalityLimiterShards = 32
+)
+
+var (
+	dogstatsdCardinalityLimitedSamples = expvar.Int{}
+
+	// currentCardinalityLimiter is the limiter reported by the `dogstatsd-cardinality` expvar
+	currentCardinalityLimiter     atomic.Pointer[cardinalityLimiter]
+	publishCardinalityLimiterOnce sync.Once
+)
+
+// cardinalityLimitOverride is the limit of a given metric name, or namespace
+type cardinalityLimitOverride struct {
+	Name  string `mapstructure:"name" json:"name" yaml:"name"`
+	Limit int    `mapstructure:"limit" json:"limit" yaml:"limit"`
+}
+
+// cardinalityOffender is a metric (or namespace) whose tags were limited during the last window
+type cardinalityOffender struct {
+	Name           string   `json:"name"`
+	Limit          int      `json:"limit"`
+	TagSets        int      `json:"tag_sets"`
+	LimitedSamples int      `json:"limited_samples"`
+	OffendingTags  []string `json:"offending_tags"`
+}
+
+// cardinalityState tracks the tag sets seen for a metric (or namespace) during the current window
+type cardinalityState struct {
+	limit   int
+	tagSets map[uint64]struct{}
+	// values are the distinct values of each tag key, up to the limit
+	values         map[string]map[uint64]struct{}
+	limitedSamples int
+	// offendingTags counts how many times each tag key was collapsed or dropped
+	offendingTags map[string]int
+}
+
+// cardinalityShard tracks the tag sets of the metrics (or namespaces) whose name hashes to it
+type cardinalityShard struct {
+	mu          sync.Mutex
+	windowStart time.Time
+	states      map[string]*cardinalityState
+	// lastOffenders are the offenders of the last complete window
+	lastOffenders []cardinalityOffender
+}
+
+// cardinalityLimiter caps the number of unique tag sets of each metric name, or of each
+// namespace, during a window. Once the limit of a metric is reached, the new tag sets are
+// rewritten by collapsing the values of (or dropping) the tag keys with the most distinct
+// values, until the tag set is one already seen during the window, or all its tags are
+// collapsed.
+type cardinalityLimiter struct {
+	defaultLimit int
+	overrides    map[string]int
+	byNamespace  bool
+	drop         bool
+	window       time.Duration
+
+	shards [cardinalityLimiterShards]cardinalityShard
+	now    func() time.Time
+
+	tlmLimitedSamples telemetry.SimpleCounter
+}
+
+// newCardinalityLimiter returns a limiter built from the configuration, or nil if no limit is set.
+func newCardinalityLimiter(cfg model.Reader, telemetrycomp telemetry.Component) (*cardinalityLimiter, error) {
+	var overrides []cardinalityLimitOverride
+	if cfg.IsSet("dogstatsd_cardinality_limit_overrides") {
+		if err := structure.UnmarshalKey(cfg, "dogstatsd_cardinality_limit_overrides", &overrides); err != nil {
+			return nil, fmt.Errorf("could not parse dogstatsd_cardinality_limit_overrides: %v", err)
+		}
+	}
+	defaultLimit := cfg.GetInt("dogstatsd_cardinality_limit")
+	if defaultLimit <= 0 && len(overrides) == 0 {
+		return nil, nil
+	}
+
+	action := cfg.GetString("dogstatsd_cardinality_limit_action")
+	if action != cardinalityLimitActionCollapse && action != cardinalityLimitActionDrop {
+		return nil, fmt.Errorf("invalid dogstatsd_cardinality_limit_action %q, must be %q or %q", action, cardinalityLimitActionCollapse, cardinalityLimitActionDrop)
+	}
+
+	window := cfg.GetDuration("dogstatsd_cardinality_limit_window")
+	if window <= 0 {
+		return nil, fmt.Errorf("invalid dogstatsd_cardinality_limit_window %s, must be positive", window)
+	}
+
+	l := &cardinalityLimiter{
+		defaultLimit: defaultLimit,
+		overrides:    make(map[string]int, len(overrides)),
+		byNamespace:  cfg.GetBool("dogstatsd_cardinality_limit_by_namespace"),
+		drop:         action == cardinalityLimitActionDrop,
+		window:       window,
+		now:          time.Now,
+		tlmLimitedSamples: telemetrycomp.NewSimpleCounter("dogstatsd", "cardinality_limited_samples",
+			"Count of metric samples whose tags were collapsed or dropped by the cardinality limiter"),
+	}
+	for _, override := range overrides {
+		if override.Name == "" {
+			return nil, fmt.Errorf("missing name in dogstatsd_cardinality_limit_overrides")
+		}
+		l.overrides[override.Name] = override.Limit
+	}
+	now := l.now()
+	for i := range l.shards {
+		l.shards[i].windowStart = now
+		l.shards[i].states = make(map[string]*cardinalityState)
+	}
+	return l, nil
+}
+
+// publishExpvar reports the offenders of the limiter in the `dogstatsd-cardinality` expvar
+func (l *cardinalityLimiter) publishExpvar() {
+	currentCardinalityLimiter.Store(l)
+	publishCardinalityLimiterOnce.Do(func() {
+		expvar.Publish("dogstatsd-cardinality", expvar.Func(func() interface{} {
+			return currentCardinalityLimiter.Load().offenders()
+		}))
+	})
+}
+
+// limit returns the tags of a sample of the metric name, rewritten if the metric is over its limit.
+func (l *cardinalityLimiter) limit(name string, tags []string) []string {
+	key := name
+	if l.byNamespace {
+		if i := strings.IndexByte(name, '.'); i > 0 {
+			key = name[:i]
+		}
+	}
+	limit, ok := l.overrides[key]
+	if !ok {
+		limit = l.defaultLimit
+	}
+	if limit <= 0 {
+		return tags
+	}
+
+	shard := &l.shards[hashString(key)%cardinalityLimiterShards]
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	l.rotate(shard)
+
+	state, ok := shard.states[key]
+	if !ok {
+		state = &cardinalityState{
+			limit:   limit,
+			tagSets: make(map[uint64]struct{}),
+			values:  make(map[string]map[uint64]struct{}),
+		}
+		shard.states[key] = state
+	}
+
+	hash := hashTagSet(tags)
+	if _, seen := state.tagSets[hash]; seen {
+		return tags
+	}
+	if len(state.tagSets) < limit {
+		state.add(hash, tags)
+		return tags
+	}
+
+	state.limitedSamples++
+	dogstatsdCardinalityLimitedSamples.Add(1)
+	l.tlmLimitedSamples.Inc()
+	return l.rewrite(state, tags)
+}
+
+// rewrite collapses or drops the tag keys of tags with the most distinct values, until
+// the resulting tag set is known.
+func (l *cardinalityLimiter) rewrite(state *cardinalityState, tags []string) []string {
+	keys := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		if key, _, hasValue := strings.Cut(tag, ":"); hasValue {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if ci, cj := len(state.values[keys[i]]), len(state.values[keys[j]]); ci != cj {
+			return ci > cj
+		}
+		return keys[i] < keys[j]
+	})
+
+	if state.offendingTags == nil {
+		state.offendingTags = make(map[string]int)
+	}
+	rewritten := tags
+	for i, key := range keys {
+		if i > 0 && key == keys[i-1] {
+			continue
+		}
+		state.offendingTags[key]++
+		rewritten = l.rewriteKey(rewritten, key)
+		if _, seen := state.tagSets[hashTagSet(rewritten)]; seen {
+			return rewritten
+		}
+	}
+	// all the tags are collapsed, this tag set is always accepted
+	state.tagSets[hashTagSet(rewritten)] = struct{}{}
+	return rewritten
+}
+
+// rewriteKey returns a copy of tags with the values of the key collapsed, or the key dropped.
+func (l *cardinalityLimiter) rewriteKey(tags []string, key string) []string {
+	rewritten := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		if k, _, hasValue := strings.Cut(tag, ":"); hasValue && k == key {
+			if l.drop {
+				continue
+			}
+			tag = key + ":" + collapsedTagValue
+		}
+		rewritten = append(rewritten, tag)
+	}
+	return rewritten
+}
+
+func (s *cardinalityState) add(hash uint64, tags []string) {
+	s.tagSets[hash] = struct{}{}
+	for _, tag := range tags {
+		key, value, hasValue := strings.Cut(tag, ":")
+		if !hasValue {
+			continue
+		}
+		values, ok := s.values[key]
+		if !ok {
+			values = make(map[uint64]struct{})
+			s.values[key] = values
+		}
+		// the exact count doesn't matter past the limit
+		if len(values) <= s.limit {
+			values[hashString(value)] = struct{}{}
+		}
+	}
+}
+
+// rotate starts a new window in the shard if the current one is over. shard.mu must be held.
+func (l *cardinalityLimiter) rotate(shard *cardinalityShard) {
+	if now := l.now(); now.Sub(shard.windowStart) >= l.window {
+		shard.lastOffenders = shard.currentOffenders()
+		shard.states = make(map[string]*cardinalityState)
+		shard.windowStart = now
+	}
+}
+
+// currentOffenders returns the metrics of the shard limited during the current window, most
+// limited first. s.mu must be held.
+func (s *cardinalityShard) currentOffenders() []cardinalityOffender {
+	offenders := []cardinalityOffender{}
+	for name, state := range s.states {
+		if state.limitedSamples == 0 {
+			continue
+		}
+		offendingTags := make([]string, 0, len(state.offendingTags))
+		for key := range state.offendingTags {
+			offendingTags = append(offendingTags, key)
+		}
+		sort.Slice(offendingTags, func(i, j int) bool {
+			if ci, cj := state.offendingTags[offendingTags[i]], state.offendingTags[offendingTags[j]]; ci != cj {
+				return ci > cj
+			}
+			return offendingTags[i] < offendingTags[j]
+		})
+		offenders = append(offenders, cardinalityOffender{
+			Name:           name,
+			Limit:          state.limit,
+			TagSets:        len(state.tagSets),
+			LimitedSamples: state.limitedSamples,
+			OffendingTags:  offendingTags,
+		})
+	}
+	return topCardinalityOffenders(offenders)
+}
+
+// topCardinalityOffenders sorts the offenders, most limited first, and keeps the top ones
+func topCardinalityOffenders(offenders []cardinalityOffender) []cardinalityOffender {
+	sort.Slice(offenders, func(i, j int) bool {
+		if offenders[i].LimitedSamples != offenders[j].LimitedSamples {
+			return offenders[i].LimitedSamples > offenders[j].LimitedSamples
+		}
+		return offenders[i].Name < offenders[j].Name
+	})
+	if len(offenders) > maxCardinalityOffenders {
+		offenders = offenders[:maxCardinalityOffenders]
+	}
+	return offenders
+}
+
+// offenders returns the top offenders of the last complete window, or of the current
+// one if it's the first window.
+func (l *cardinalityLimiter) offenders() []cardinalityOffender {
+	offenders := []cardinalityOffender{}
+	for i := range l.shards {
+		shard := &l.shards[i]
+		shard.mu.Lock()
+		l.rotate(shard)
+		if shard.lastOffenders == nil {
+			offenders = append(offenders, shard.currentOffenders()...)
+		} else {
+			offenders = append(offenders, shard.lastOffenders...)
+		}
+		shard.mu.Unlock()
+	}
+	return topCardinalityOffenders(offenders)
+}
+
+const (
+	fnvOffset64 = 14695981039346656037
+	fnvPrime64  = 1099511628211
+)
+
+// hashTagSet returns a hash of tags which doesn't depend on their order: the tags are
+// hashed in sorted order, each one followed by a comma which can't be part of a tag.
+func hashTagSet(tags []string) uint64 {
+	if !slices.IsSorted(tags) {
+		tags = slices.Sorted(slices.Values(tags))
+	}
+	hash := uint64(fnvOffset64)
+	for _, tag := range tags {
+		hash = fnvAdd(hash, tag)
+		hash ^= ','
+		hash *= fnvPrime64
+	}
+	return hash
+}
+
+// hashString returns the FNV-1a hash of s
+func hashString(s string) uint64 {
+	return fnvAdd(fnvOffset64, s)
+}
+
+func fnvAdd(hash uint64, s string) uint64 {
+	for i := 0; i < len(s); i++ {
+		hash ^= uint64(s[i])
+		hash *= fnvPrime64
+	}
+	return hash
+}
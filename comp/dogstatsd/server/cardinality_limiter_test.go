@@ -0,0 +1,184 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build test
+
+package server
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/comp/core/telemetry"
+	"github.com/DataDog/datadog-agent/comp/core/telemetry/telemetryimpl"
+	configmock "github.com/DataDog/datadog-agent/pkg/config/mock"
+	"github.com/DataDog/datadog-agent/pkg/util/fxutil"
+)
+
+func newTestCardinalityLimiter(t *testing.T, yaml string) *cardinalityLimiter {
+	telemetryComp := fxutil.Test[telemetry.Component](t, telemetryimpl.MockModule())
+	limiter, err := newCardinalityLimiter(configmock.NewFromYAML(t, yaml), telemetryComp)
+	require.NoError(t, err)
+	require.NotNil(t, limiter)
+	return limiter
+}
+
+func TestCardinalityLimiterDisabled(t *testing.T) {
+	telemetryComp := fxutil.Test[telemetry.Component](t, telemetryimpl.MockModule())
+	limiter, err := newCardinalityLimiter(configmock.New(t), telemetryComp)
+	assert.NoError(t, err)
+	assert.Nil(t, limiter)
+}
+
+func TestCardinalityLimiterInvalidAction(t *testing.T) {
+	telemetryComp := fxutil.Test[telemetry.Component](t, telemetryimpl.MockModule())
+	cfg := configmock.NewFromYAML(t, `
+dogstatsd_cardinality_limit: 10
+dogstatsd_cardinality_limit_action: ignore
+`)
+	_, err := newCardinalityLimiter(cfg, telemetryComp)
+	assert.ErrorContains(t, err, "invalid dogstatsd_cardinality_limit_action")
+}
+
+func TestCardinalityLimiterCollapse(t *testing.T) {
+	limiter := newTestCardinalityLimiter(t, `
+dogstatsd_cardinality_limit: 3
+`)
+
+	for i := 0; i < 3; i++ {
+		tags := []string{"env:prod", fmt.Sprintf("request_id:%d", i)}
+		assert.Equal(t, tags, limiter.limit("http.requests", tags))
+	}
+	// known tag sets are kept, in any order
+	assert.Equal(t, []string{"request_id:1", "env:prod"}, limiter.limit("http.requests", []string{"request_id:1", "env:prod"}))
+
+	// the tag with the most values is collapsed first, then the other ones until the tag set is known
+	assert.Equal(t, []string{"env:other", "request_id:other"}, limiter.limit("http.requests", []string{"env:prod", "request_id:3"}))
+	assert.Equal(t, []string{"env:other", "request_id:other"}, limiter.limit("http.requests", []string{"env:prod", "request_id:4"}))
+	assert.Equal(t, []string{"env:other", "request_id:other"}, limiter.limit("http.requests", []string{"env:staging", "request_id:5"}))
+
+	// other metrics have their own limit
+	assert.Equal(t, []string{"request_id:3"}, limiter.limit("http.latency", []string{"request_id:3"}))
+
+	offenders := limiter.offenders()
+	require.Len(t, offenders, 1)
+	assert.Equal(t, cardinalityOffender{
+		Name:           "http.requests",
+		Limit:          3,
+		TagSets:        4,
+		LimitedSamples: 3,
+		OffendingTags:  []string{"env", "request_id"},
+	}, offenders[0])
+}
+
+func TestCardinalityLimiterCollapseToKnownTagSet(t *testing.T) {
+	limiter := newTestCardinalityLimiter(t, `
+dogstatsd_cardinality_limit: 2
+`)
+
+	assert.Equal(t, []string{"env:prod", "request_id:1"}, limiter.limit("http.requests", []string{"env:prod", "request_id:1"}))
+	assert.Equal(t, []string{"env:prod", "request_id:other"}, limiter.limit("http.requests", []string{"env:prod", "request_id:other"}))
+
+	// collapsing request_id is enough to get a known tag set
+	assert.Equal(t, []string{"env:prod", "request_id:other"}, limiter.limit("http.requests", []string{"env:prod", "request_id:2"}))
+	assert.Equal(t, []string{"request_id"}, limiter.offenders()[0].OffendingTags)
+}
+
+func TestCardinalityLimiterDrop(t *testing.T) {
+	limiter := newTestCardinalityLimiter(t, `
+dogstatsd_cardinality_limit: 1
+dogstatsd_cardinality_limit_action: drop
+`)
+
+	assert.Equal(t, []string{"host:a", "user:1"}, limiter.limit("logins", []string{"host:a", "user:1"}))
+	assert.Equal(t, []string{}, limiter.limit("logins", []string{"host:a", "user:2"}))
+}
+
+func TestCardinalityLimiterNamespaceAndOverrides(t *testing.T) {
+	limiter := newTestCardinalityLimiter(t, `
+dogstatsd_cardinality_limit_by_namespace: true
+dogstatsd_cardinality_limit_overrides:
+  - name: myapp
+    limit: 2
+`)
+
+	assert.Equal(t, []string{"id:1"}, limiter.limit("myapp.requests", []string{"id:1"}))
+	assert.Equal(t, []string{"id:2"}, limiter.limit("myapp.errors", []string{"id:2"}))
+	// the limit is shared by all the metrics of the namespace
+	assert.Equal(t, []string{"id:other"}, limiter.limit("myapp.latency", []string{"id:3"}))
+
+	// no limit for the other namespaces
+	for i := 0; i < 10; i++ {
+		tags := []string{fmt.Sprintf("id:%d", i)}
+		assert.Equal(t, tags, limiter.limit("otherapp.requests", tags))
+	}
+	assert.Equal(t, "myapp", limiter.offenders()[0].Name)
+}
+
+func TestCardinalityLimiterWindow(t *testing.T) {
+	limiter := newTestCardinalityLimiter(t, `
+dogstatsd_cardinality_limit: 1
+dogstatsd_cardinality_limit_window: 10s
+`)
+	now := time.Now()
+	limiter.now = func() time.Time { return now }
+	for i := range limiter.shards {
+		limiter.shards[i].windowStart = now
+	}
+
+	assert.Equal(t, []string{"id:1"}, limiter.limit("requests", []string{"id:1"}))
+	assert.Equal(t, []string{"id:other"}, limiter.limit("requests", []string{"id:2"}))
+
+	// a new window starts, the offenders of the previous one are reported
+	now = now.Add(10 * time.Second)
+	assert.Equal(t, []string{"id:2"}, limiter.limit("requests", []string{"id:2"}))
+	offenders := limiter.offenders()
+	require.Len(t, offenders, 1)
+	assert.Equal(t, 1, offenders[0].LimitedSamples)
+
+	// and no offender once a window without limited samples is over
+	now = now.Add(10 * time.Second)
+	assert.Equal(t, []string{"id:3"}, limiter.limit("requests", []string{"id:3"}))
+	assert.Empty(t, limiter.offenders())
+}
+
+func TestHashTagSet(t *testing.T) {
+	assert.Equal(t, hashTagSet([]string{"a:1", "b:2", "c:3"}), hashTagSet([]string{"c:3", "a:1", "b:2"}))
+	assert.Equal(t, hashTagSet(nil), hashTagSet([]string{}))
+
+	// tag sets with the same tags hashes added up, or with the same characters, are different
+	assert.NotEqual(t, hashTagSet([]string{"a:1", "a:1", "b:2", "b:2"}), hashTagSet([]string{"a:1", "a:1"}))
+	assert.NotEqual(t, hashTagSet([]string{"a:1", "b:2"}), hashTagSet([]string{"a:1b:2"}))
+	assert.NotEqual(t, hashTagSet([]string{"a:1"}), hashTagSet([]string{"a:1", ""}))
+
+	// the tags are only sorted in a copy
+	tags := []string{"b:2", "a:1"}
+	hashTagSet(tags)
+	assert.Equal(t, []string{"b:2", "a:1"}, tags)
+}
+
+func TestCardinalityLimiterOffendersAcrossShards(t *testing.T) {
+	limiter := newTestCardinalityLimiter(t, `
+dogstatsd_cardinality_limit: 1
+`)
+
+	for i := 0; i < 2*maxCardinalityOffenders; i++ {
+		name := fmt.Sprintf("metric.%d", i)
+		for j := 0; j <= i+1; j++ {
+			limiter.limit(name, []string{fmt.Sprintf("id:%d", j)})
+		}
+	}
+
+	// the offenders of all the shards are reported, most limited first
+	offenders := limiter.offenders()
+	require.Len(t, offenders, maxCardinalityOffenders)
+	for i, offender := range offenders {
+		assert.Equal(t, fmt.Sprintf("metric.%d", 2*maxCardinalityOffenders-1-i), offender.Name)
+	}
+}
@@ -0,0 +1,579 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package server
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang/snappy"
+	"github.com/prometheus/prometheus/model/value"
+	"github.com/prometheus/prometheus/prompb"
+
+	"github.com/DataDog/datadog-agent/comp/core/tagger/origindetection"
+	pkgconfigsetup "github.com/DataDog/datadog-agent/pkg/config/setup"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+)
+
+const (
+	remoteWritePath = "/api/v1/write"
+	// remoteWriteMaxBodySize is the maximum size of a compressed remote write request
+	remoteWriteMaxBodySize = 32 << 20
+	// remoteWriteSeriesExpiry is how long the last value of a cumulative series is kept
+	// without receiving any new sample
+	remoteWriteSeriesExpiry = 15 * time.Minute
+
+	remoteWriteNameLabel   = "__name__"
+	remoteWriteBucketLabel = "le"
+	// labels used for origin detection, they can be added to the series by relabeling
+	remoteWriteContainerIDLabel = "container_id"
+	remoteWritePodUIDLabel      = "pod_uid"
+
+	// remoteWriteMaxBuckets is the highest number of buckets of a range of a native
+	// histogram, counting the empty ones between its spans
+	remoteWriteMaxBuckets = 1 << 16
+)
+
+var (
+	dogstatsdRemoteWriteRequests      = expvar.Int{}
+	dogstatsdRemoteWriteRequestErrors = expvar.Int{}
+	dogstatsdRemoteWriteSamples       = expvar.Int{}
+	dogstatsdRemoteWriteSkipped       = expvar.Int{}
+)
+
+// remoteWriteKind is how the samples of a series are submitted
+type remoteWriteKind int
+
+const (
+	// remoteWriteGauge samples are submitted as they are
+	remoteWriteGauge remoteWriteKind = iota
+	// remoteWriteCumulative samples are monotonic counters submitted as the delta with
+	// their previous value
+	remoteWriteCumulative
+	// remoteWriteBucket samples are cumulative histogram buckets
+	remoteWriteBucket
+)
+
+// remoteWriteSeries is the last sample received for a cumulative series
+type remoteWriteSeries struct {
+	value float64
+	// histogram is set instead of value for native histograms
+	histogram *metrics.ExponentialHistogram
+	timestamp int64
+	lastSeen  time.Time
+}
+
+// remoteWriteReceiver is an HTTP server implementing the receiving end of the Prometheus
+// remote write protocol (version 1). Received samples are converted to metric samples and
+// sent to the aggregator, counters and histograms being submitted as the delta with their
+// previous value, the same way the OpenMetrics check does.
+//
+// Native histograms are submitted as exponential histograms, aggregated into distributions.
+type remoteWriteReceiver struct {
+	server     *server
+	newBatcher func() dogstatsdBatcher
+	httpServer *http.Server
+	listener   net.Listener
+
+	typesMu sync.RWMutex
+	// types are the types of the metric families, as sent in the metadata of the requests
+	types map[string]prompb.MetricMetadata_MetricType
+
+	// mu only guards the series map and its expiry, requests are processed concurrently
+	mu sync.Mutex
+	// series are the last samples of the cumulative series, by series key
+	series     map[string]*remoteWriteSeries
+	lastExpiry time.Time
+	now        func() time.Time
+}
+
+func newRemoteWriteReceiver(s *server, newBatcher func() dogstatsdBatcher) *remoteWriteReceiver {
+	r := &remoteWriteReceiver{
+		server:     s,
+		newBatcher: newBatcher,
+		types:      make(map[string]prompb.MetricMetadata_MetricType),
+		series:     make(map[string]*remoteWriteSeries),
+		now:        time.Now,
+	}
+	r.lastExpiry = r.now()
+	return r
+}
+
+// start listens on the configured address and serves the remote write requests.
+func (r *remoteWriteReceiver) start() error {
+	port := strconv.Itoa(r.server.config.GetInt("dogstatsd_remote_write.port"))
+	addr := ":" + port
+	if !r.server.config.GetBool("dogstatsd_non_local_traffic") {
+		addr = net.JoinHostPort(pkgconfigsetup.GetBindHostFromConfig(r.server.config), port)
+	}
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("can't listen on %s: %v", addr, err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(remoteWritePath, r)
+	r.listener = listener
+	r.httpServer = &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		if err := r.httpServer.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			r.server.log.Errorf("Prometheus remote write receiver stopped: %v", err)
+		}
+	}()
+	r.server.log.Infof("dogstatsd: Prometheus remote write receiver listening on %s", listener.Addr())
+	return nil
+}
+
+func (r *remoteWriteReceiver) stop() {
+	if r.httpServer == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := r.httpServer.Shutdown(ctx); err != nil {
+		r.server.log.Warnf("Could not stop the Prometheus remote write receiver: %v", err)
+	}
+}
+
+// ServeHTTP handles a remote write request.
+func (r *remoteWriteReceiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	dogstatsdRemoteWriteRequests.Add(1)
+	if req.Method != http.MethodPost {
+		dogstatsdRemoteWriteRequestErrors.Add(1)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	writeRequest, err := decodeRemoteWriteRequest(http.MaxBytesReader(w, req.Body, remoteWriteMaxBodySize))
+	if err != nil {
+		dogstatsdRemoteWriteRequestErrors.Add(1)
+		r.server.errLog("dogstatsd: invalid Prometheus remote write request: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	batcher := r.newBatcher()
+	r.process(writeRequest, remoteWriteOrigin(req), batcher)
+	batcher.flush()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// remoteWriteOrigin returns the origin of a request from the headers also used by the
+// trace-agent.
+func remoteWriteOrigin(req *http.Request) dogstatsdMetricSample {
+	var origin dogstatsdMetricSample
+	localData := req.Header.Get("Datadog-Entity-ID")
+	if localData == "" {
+		if containerID := req.Header.Get("Datadog-Container-ID"); containerID != "" {
+			localData = origindetection.LocalDataContainerIDPrefix + containerID
+		}
+	}
+	// origin detection is best effort, invalid values are ignored
+	origin.localData, _ = origindetection.ParseLocalData(localData)
+	origin.externalData, _ = origindetection.ParseExternalData(req.Header.Get("Datadog-External-Env"))
+	return origin
+}
+
+func decodeRemoteWriteRequest(body io.Reader) (*prompb.WriteRequest, error) {
+	compressed, err := io.ReadAll(body)
+	if err != nil {
+		return nil, fmt.Errorf("can't read request body: %v", err)
+	}
+	data, err := snappy.Decode(nil, compressed)
+	if err != nil {
+		return nil, fmt.Errorf("can't decompress request body: %v", err)
+	}
+	var writeRequest prompb.WriteRequest
+	if err := writeRequest.Unmarshal(data); err != nil {
+		return nil, fmt.Errorf("can't decode request body: %v", err)
+	}
+	return &writeRequest, nil
+}
+
+// process converts the series of a request into metric samples sent to the batcher.
+func (r *remoteWriteReceiver) process(writeRequest *prompb.WriteRequest, origin dogstatsdMetricSample, batcher dogstatsdBatcher) {
+	if len(writeRequest.Metadata) > 0 {
+		r.typesMu.Lock()
+		for _, metadata := range writeRequest.Metadata {
+			if metadata.MetricFamilyName != "" {
+				r.types[metadata.MetricFamilyName] = metadata.Type
+			}
+		}
+		r.typesMu.Unlock()
+	}
+
+	now := r.now()
+	for i := range writeRequest.Timeseries {
+		r.processSeries(&writeRequest.Timeseries[i], origin, now, batcher)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if now.Sub(r.lastExpiry) >= remoteWriteSeriesExpiry {
+		for key, series := range r.series {
+			if now.Sub(series.lastSeen) >= remoteWriteSeriesExpiry {
+				delete(r.series, key)
+			}
+		}
+		r.lastExpiry = now
+	}
+}
+
+// forget removes a series, after a stale marker.
+func (r *remoteWriteReceiver) forget(key string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.series, key)
+}
+
+// appendRemoteWriteSample sends a sample to the batcher with the timestamp of the remote write sample.
+// Gauges go through the no-aggregation pipeline, like the DogStatsD samples with a timestamp,
+// the other types aren't supported there and are aggregated in the bucket of their timestamp.
+func appendRemoteWriteSample(batcher dogstatsdBatcher, sample metrics.MetricSample, timestamp int64) {
+	if timestamp > 0 {
+		sample.Timestamp = float64(timestamp) / 1000
+	}
+	dogstatsdRemoteWriteSamples.Add(1)
+	if sample.Timestamp > 0 && sample.Mtype == metrics.GaugeType {
+		batcher.appendLateSample(sample)
+		return
+	}
+	batcher.appendSample(sample)
+}
+
+func (r *remoteWriteReceiver) processSeries(ts *prompb.TimeSeries, origin dogstatsdMetricSample, now time.Time, batcher dogstatsdBatcher) {
+	var name, bucket string
+	var hasBucket bool
+	var key strings.Builder
+	tags := make([]string, 0, len(ts.Labels))
+	for _, label := range ts.Labels {
+		// labels are sorted by name, the key doesn't depend on the sender
+		key.WriteString(label.Name)
+		key.WriteByte('=')
+		key.WriteString(label.Value)
+		key.WriteByte(',')
+
+		switch label.Name {
+		case remoteWriteNameLabel:
+			name = label.Value
+			continue
+		case remoteWriteBucketLabel:
+			bucket, hasBucket = label.Value, true
+			continue
+		case remoteWriteContainerIDLabel:
+			if origin.localData.ContainerID == "" {
+				origin.localData.ContainerID = label.Value
+			}
+		case remoteWritePodUIDLabel:
+			if origin.localData.PodUID == "" {
+				origin.localData.PodUID = label.Value
+			}
+		}
+		tags = append(tags, label.Name+":"+label.Value)
+	}
+	if name == "" {
+		dogstatsdRemoteWriteSkipped.Add(int64(len(ts.Samples) + len(ts.Histograms)))
+		return
+	}
+	seriesKey := key.String()
+
+	if len(ts.Samples) > 0 {
+		kind, metricName := r.resolve(name, hasBucket)
+		sampleTags := tags
+		if kind == remoteWriteBucket {
+			sampleTags = append(sampleTags, "upper_bound:"+formatUpperBound(bucket))
+		} else if hasBucket {
+			sampleTags = append(sampleTags, remoteWriteBucketLabel+":"+bucket)
+		}
+		if template, ok := r.newSample(metricName, sampleTags, origin); ok {
+			r.processSamples(ts.Samples, seriesKey, kind, template, now, batcher)
+		}
+	}
+
+	if len(ts.Histograms) > 0 {
+		if hasBucket {
+			tags = append(tags, remoteWriteBucketLabel+":"+bucket)
+		}
+		if template, ok := r.newSample(name, tags, origin); ok {
+			r.processHistograms(ts.Histograms, seriesKey, template, now, batcher)
+		}
+	}
+}
+
+// newSample returns the template of the samples of a metric, with its name and tags
+// enriched like the DogStatsD samples, or false if the metric is blocklisted.
+func (r *remoteWriteReceiver) newSample(name string, tags []string, origin dogstatsdMetricSample) (metrics.MetricSample, bool) {
+	tags = append(make([]string, 0, len(tags)+len(r.server.extraTags)), tags...)
+	if r.server.cardinalityLimiter != nil {
+		tags = r.server.cardinalityLimiter.limit(name, tags)
+	}
+	tags, host, originInfo, _ := extractTagsMetadata(tags, "", 0, origin.localData, origin.externalData, "", r.server.enrichConfig)
+	if !isExcluded(name, r.server.enrichConfig.metricPrefix, r.server.enrichConfig.metricPrefixBlacklist) {
+		name = r.server.enrichConfig.metricPrefix + name
+	}
+	if r.server.enrichConfig.metricBlocklist.test(name) {
+		return metrics.MetricSample{}, false
+	}
+	if r.server.enrichConfig.serverlessMode {
+		host = ""
+	}
+	return metrics.MetricSample{
+		Host:       host,
+		Name:       name,
+		Tags:       append(tags, r.server.extraTags...),
+		SampleRate: 1,
+		OriginInfo: originInfo,
+		Source:     metrics.MetricSourcePrometheus,
+	}, true
+}
+
+func (r *remoteWriteReceiver) processSamples(samples []prompb.Sample, seriesKey string, kind remoteWriteKind, template metrics.MetricSample, now time.Time, batcher dogstatsdBatcher) {
+	for _, sample := range samples {
+		if math.IsNaN(sample.Value) || math.IsInf(sample.Value, 0) {
+			if value.IsStaleNaN(sample.Value) {
+				r.forget(seriesKey)
+			}
+			dogstatsdRemoteWriteSkipped.Add(1)
+			continue
+		}
+
+		metricSample := template
+		metricSample.Mtype = metrics.GaugeType
+		metricSample.Value = sample.Value
+		if kind != remoteWriteGauge {
+			delta, ok := r.delta(seriesKey, sample, now)
+			if !ok {
+				continue
+			}
+			metricSample.Mtype = metrics.CountType
+			metricSample.Value = delta
+		}
+
+		appendRemoteWriteSample(batcher, metricSample, sample.Timestamp)
+	}
+}
+
+// processHistograms submits native histograms as exponential histograms, which are
+// aggregated into distributions. Cumulative histograms are submitted as the delta with
+// their previous value, gauge histograms as they are.
+func (r *remoteWriteReceiver) processHistograms(histograms []prompb.Histogram, seriesKey string, template metrics.MetricSample, now time.Time, batcher dogstatsdBatcher) {
+	for i := range histograms {
+		h := &histograms[i]
+		if value.IsStaleNaN(h.Sum) {
+			r.forget(seriesKey)
+			dogstatsdRemoteWriteSkipped.Add(1)
+			continue
+		}
+		current, err := nativeHistogram(h)
+		if err != nil {
+			r.server.errLog("dogstatsd: skipping Prometheus native histogram %s: %v", template.Name, err)
+			dogstatsdRemoteWriteSkipped.Add(1)
+			continue
+		}
+
+		histogram := current
+		if h.ResetHint != prompb.Histogram_GAUGE {
+			var ok bool
+			if histogram, ok = r.histogramDelta(seriesKey, current, h.Timestamp, now); !ok {
+				continue
+			}
+		}
+
+		metricSample := template
+		metricSample.Mtype = metrics.ExponentialHistogramType
+		metricSample.ExponentialHistogram = histogram
+		appendRemoteWriteSample(batcher, metricSample, h.Timestamp)
+	}
+}
+
+// delta returns the increase of a cumulative series since its previous sample. The first
+// sample of a series is only used as a reference, and samples older than the last one
+// are ignored.
+func (r *remoteWriteReceiver) delta(key string, sample prompb.Sample, now time.Time) (float64, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	series, ok := r.series[key]
+	if !ok || series.histogram != nil {
+		r.series[key] = &remoteWriteSeries{value: sample.Value, timestamp: sample.Timestamp, lastSeen: now}
+		return 0, false
+	}
+	if sample.Timestamp <= series.timestamp {
+		return 0, false
+	}
+	delta := sample.Value - series.value
+	if delta < 0 {
+		// the counter was reset
+		delta = sample.Value
+	}
+	series.value, series.timestamp, series.lastSeen = sample.Value, sample.Timestamp, now
+	return delta, true
+}
+
+// histogramDelta returns the increase of a cumulative native histogram since its previous
+// sample, with the same rules as delta. A change of schema starts a new reference.
+func (r *remoteWriteReceiver) histogramDelta(key string, current *metrics.ExponentialHistogram, timestamp int64, now time.Time) (*metrics.ExponentialHistogram, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	series, ok := r.series[key]
+	if !ok || series.histogram == nil || series.histogram.Scale != current.Scale {
+		r.series[key] = &remoteWriteSeries{histogram: current, timestamp: timestamp, lastSeen: now}
+		return nil, false
+	}
+	if timestamp <= series.timestamp {
+		return nil, false
+	}
+	delta, ok := current.DeltaFrom(series.histogram)
+	if !ok {
+		// the histogram was reset
+		delta = current
+	}
+	series.histogram, series.timestamp, series.lastSeen = current, timestamp, now
+	return delta, true
+}
+
+// nativeHistogram converts the spans and deltas of a native histogram into contiguous buckets.
+// The Prometheus bucket of index i counts the values in (base^(i-1), base^i], which is the
+// bucket of index i-1 of exponential histograms.
+func nativeHistogram(h *prompb.Histogram) (*metrics.ExponentialHistogram, error) {
+	if h.IsFloatHistogram() {
+		return nil, errors.New("float histograms are not supported")
+	}
+	if h.Schema < metrics.MinExponentialHistogramScale || h.Schema > metrics.MaxExponentialHistogramScale {
+		return nil, fmt.Errorf("unsupported schema %d", h.Schema)
+	}
+	positive, err := nativeBuckets(h.PositiveSpans, h.PositiveDeltas)
+	if err != nil {
+		return nil, err
+	}
+	negative, err := nativeBuckets(h.NegativeSpans, h.NegativeDeltas)
+	if err != nil {
+		return nil, err
+	}
+	return &metrics.ExponentialHistogram{
+		Scale:     h.Schema,
+		ZeroCount: h.GetZeroCountInt(),
+		Positive:  positive,
+		Negative:  negative,
+		Sum:       h.Sum,
+	}, nil
+}
+
+func nativeBuckets(spans []prompb.BucketSpan, deltas []int64) (metrics.ExponentialHistogramBuckets, error) {
+	var buckets metrics.ExponentialHistogramBuckets
+	// the index is computed on 64 bits for the offsets and lengths not to overflow it
+	var index int64
+	var count int64
+	next := 0
+	for i, span := range spans {
+		// the offset of the first span is the index of its first bucket, the other ones
+		// are relative to the end of the previous span
+		if i == 0 {
+			if span.Offset == math.MinInt32 {
+				return buckets, errors.New("bucket index out of range")
+			}
+			index = int64(span.Offset)
+			buckets.Offset = span.Offset - 1
+		} else {
+			if span.Offset < 0 {
+				return buckets, errors.New("overlapping bucket spans")
+			}
+			index += int64(span.Offset)
+		}
+		// the buckets between the spans are empty, they're stored too
+		if index-1-int64(buckets.Offset)+int64(span.Length) > remoteWriteMaxBuckets {
+			return buckets, fmt.Errorf("more than %d buckets", remoteWriteMaxBuckets)
+		}
+		for j := uint32(0); j < span.Length; j++ {
+			if next >= len(deltas) {
+				return buckets, errors.New("more buckets in the spans than deltas")
+			}
+			count += deltas[next]
+			next++
+			if count < 0 {
+				return buckets, errors.New("negative bucket count")
+			}
+			for int64(len(buckets.Counts)) < index-1-int64(buckets.Offset) {
+				buckets.Counts = append(buckets.Counts, 0)
+			}
+			buckets.Counts = append(buckets.Counts, uint64(count))
+			index++
+		}
+	}
+	return buckets, nil
+}
+
+// resolve returns how the samples of a series are submitted, and the name of the metric,
+// using the same naming as the OpenMetrics check. The type of the series is read from the
+// metadata previously received, or guessed from its name.
+func (r *remoteWriteReceiver) resolve(name string, hasBucket bool) (remoteWriteKind, string) {
+	r.typesMu.RLock()
+	defer r.typesMu.RUnlock()
+	if t, ok := r.types[name]; ok {
+		if t == prompb.MetricMetadata_COUNTER {
+			return remoteWriteCumulative, strings.TrimSuffix(name, "_total") + ".count"
+		}
+		// gauges, and summary quantiles
+		return remoteWriteGauge, name
+	}
+
+	for _, suffix := range []string{"_bucket", "_sum", "_count", "_total"} {
+		family, ok := strings.CutSuffix(name, suffix)
+		if !ok {
+			continue
+		}
+		metricName := family + "." + suffix[1:]
+		if suffix == "_total" {
+			metricName = family + ".count"
+		}
+		kind := remoteWriteCumulative
+		if suffix == "_bucket" {
+			if !hasBucket {
+				break
+			}
+			kind = remoteWriteBucket
+		}
+
+		t, ok := r.types[family]
+		switch {
+		case !ok:
+			// no metadata, guess from the suffix
+			return kind, metricName
+		case t == prompb.MetricMetadata_GAUGEHISTOGRAM && suffix != "_total":
+			return remoteWriteGauge, metricName
+		case t == prompb.MetricMetadata_COUNTER && suffix == "_total",
+			t == prompb.MetricMetadata_HISTOGRAM && suffix != "_total",
+			t == prompb.MetricMetadata_SUMMARY && (suffix == "_sum" || suffix == "_count"):
+			return kind, metricName
+		}
+		break
+	}
+	return remoteWriteGauge, name
+}
+
+// formatUpperBound formats the upper bound of a histogram bucket like the OpenMetrics check.
+func formatUpperBound(bound string) string {
+	f, err := strconv.ParseFloat(bound, 64)
+	if err != nil {
+		return bound
+	}
+	if math.IsInf(f, 1) {
+		return "none"
+	}
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
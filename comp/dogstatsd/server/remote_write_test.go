@@ -0,0 +1,300 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build test
+
+package server
+
+import (
+	"bytes"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/snappy"
+	"github.com/prometheus/prometheus/model/value"
+	"github.com/prometheus/prometheus/prompb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+)
+
+func newTestRemoteWriteReceiver(t *testing.T, cfg map[string]interface{}) (*remoteWriteReceiver, *batcherMock) {
+	_, s := fulfillDepsWithInactiveServer(t, cfg)
+	batcher := &batcherMock{}
+	return newRemoteWriteReceiver(s, func() dogstatsdBatcher { return batcher }), batcher
+}
+
+func postRemoteWrite(t *testing.T, r *remoteWriteReceiver, writeRequest *prompb.WriteRequest, headers map[string]string) int {
+	data, err := writeRequest.Marshal()
+	require.NoError(t, err)
+	req := httptest.NewRequest(http.MethodPost, remoteWritePath, bytes.NewReader(snappy.Encode(nil, data)))
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	return rec.Code
+}
+
+func newRemoteWriteSeries(labels []string, samples ...prompb.Sample) prompb.TimeSeries {
+	ts := prompb.TimeSeries{Samples: samples}
+	for i := 0; i+1 < len(labels); i += 2 {
+		ts.Labels = append(ts.Labels, prompb.Label{Name: labels[i], Value: labels[i+1]})
+	}
+	return ts
+}
+
+type remoteWriteResult struct {
+	name  string
+	mtype metrics.MetricType
+	value float64
+	tags  []string
+}
+
+func remoteWriteResults(samples []metrics.MetricSample) []remoteWriteResult {
+	results := make([]remoteWriteResult, 0, len(samples))
+	for _, sample := range samples {
+		results = append(results, remoteWriteResult{sample.Name, sample.Mtype, sample.Value, sample.Tags})
+	}
+	return results
+}
+
+func TestRemoteWriteGauge(t *testing.T) {
+	r, batcher := newTestRemoteWriteReceiver(t, map[string]interface{}{"dogstatsd_tags": []string{"team:infra"}})
+
+	code := postRemoteWrite(t, r, &prompb.WriteRequest{Timeseries: []prompb.TimeSeries{
+		newRemoteWriteSeries([]string{"__name__", "temperature", "room", "kitchen"}, prompb.Sample{Value: 21.5, Timestamp: 1000}),
+	}}, nil)
+	assert.Equal(t, http.StatusNoContent, code)
+
+	// gauges with a timestamp go through the no-aggregation pipeline
+	require.Len(t, batcher.lateSamples, 1)
+	sample := batcher.lateSamples[0]
+	assert.Equal(t, "temperature", sample.Name)
+	assert.Equal(t, metrics.GaugeType, sample.Mtype)
+	assert.Equal(t, 21.5, sample.Value)
+	assert.Equal(t, 1.0, sample.SampleRate)
+	assert.Equal(t, []string{"room:kitchen", "team:infra"}, sample.Tags)
+	assert.Equal(t, metrics.MetricSourcePrometheus, sample.Source)
+	assert.Equal(t, 1.0, sample.Timestamp)
+
+	// samples without a timestamp are aggregated on arrival
+	batcher.clear()
+	postRemoteWrite(t, r, &prompb.WriteRequest{Timeseries: []prompb.TimeSeries{
+		newRemoteWriteSeries([]string{"__name__", "temperature", "room", "kitchen"}, prompb.Sample{Value: 21.5}),
+	}}, nil)
+	require.Len(t, batcher.samples, 1)
+	assert.Zero(t, batcher.samples[0].Timestamp)
+}
+
+func TestRemoteWriteCounter(t *testing.T) {
+	r, batcher := newTestRemoteWriteReceiver(t, nil)
+	metadata := []prompb.MetricMetadata{{MetricFamilyName: "http_requests", Type: prompb.MetricMetadata_COUNTER}}
+	send := func(value float64, timestamp int64) {
+		postRemoteWrite(t, r, &prompb.WriteRequest{Metadata: metadata, Timeseries: []prompb.TimeSeries{
+			newRemoteWriteSeries([]string{"__name__", "http_requests_total", "code", "200"}, prompb.Sample{Value: value, Timestamp: timestamp}),
+		}}, nil)
+	}
+
+	// the first sample is only the reference of the next ones
+	send(10, 1000)
+	assert.Empty(t, batcher.samples)
+
+	send(15, 2000)
+	// samples already received are ignored
+	send(15, 2000)
+	// the counter was reset
+	send(3, 3000)
+	assert.Equal(t, []remoteWriteResult{
+		{"http_requests.count", metrics.CountType, 5, []string{"code:200"}},
+		{"http_requests.count", metrics.CountType, 3, []string{"code:200"}},
+	}, remoteWriteResults(batcher.samples))
+	// counts are aggregated in the bucket of their timestamp
+	assert.Equal(t, 2.0, batcher.samples[0].Timestamp)
+	assert.Equal(t, 3.0, batcher.samples[1].Timestamp)
+
+	// a stale marker removes the series
+	batcher.clear()
+	send(math.Float64frombits(value.StaleNaN), 4000)
+	send(5, 5000)
+	assert.Empty(t, batcher.samples)
+}
+
+func TestRemoteWriteHistogram(t *testing.T) {
+	r, batcher := newTestRemoteWriteReceiver(t, nil)
+	send := func(values [4]float64, timestamp int64) {
+		postRemoteWrite(t, r, &prompb.WriteRequest{Timeseries: []prompb.TimeSeries{
+			newRemoteWriteSeries([]string{"__name__", "latency_bucket", "le", "0.5"}, prompb.Sample{Value: values[0], Timestamp: timestamp}),
+			newRemoteWriteSeries([]string{"__name__", "latency_bucket", "le", "+Inf"}, prompb.Sample{Value: values[1], Timestamp: timestamp}),
+			newRemoteWriteSeries([]string{"__name__", "latency_count"}, prompb.Sample{Value: values[2], Timestamp: timestamp}),
+			newRemoteWriteSeries([]string{"__name__", "latency_sum"}, prompb.Sample{Value: values[3], Timestamp: timestamp}),
+		}}, nil)
+	}
+
+	send([4]float64{1, 2, 2, 1.2}, 1000)
+	send([4]float64{4, 6, 6, 3.7}, 2000)
+	assert.Equal(t, []remoteWriteResult{
+		{"latency.bucket", metrics.CountType, 3, []string{"upper_bound:0.5"}},
+		{"latency.bucket", metrics.CountType, 4, []string{"upper_bound:none"}},
+		{"latency.count", metrics.CountType, 4, []string{}},
+		{"latency.sum", metrics.CountType, 2.5, []string{}},
+	}, remoteWriteResults(batcher.samples))
+}
+
+func TestRemoteWriteSummary(t *testing.T) {
+	r, batcher := newTestRemoteWriteReceiver(t, nil)
+	send := func(quantile, count float64, timestamp int64) {
+		postRemoteWrite(t, r, &prompb.WriteRequest{
+			Metadata: []prompb.MetricMetadata{{MetricFamilyName: "rpc_duration", Type: prompb.MetricMetadata_SUMMARY}},
+			Timeseries: []prompb.TimeSeries{
+				newRemoteWriteSeries([]string{"__name__", "rpc_duration", "quantile", "0.99"}, prompb.Sample{Value: quantile, Timestamp: timestamp}),
+				newRemoteWriteSeries([]string{"__name__", "rpc_duration_count"}, prompb.Sample{Value: count, Timestamp: timestamp}),
+				// no histogram buckets in a summary
+				newRemoteWriteSeries([]string{"__name__", "rpc_duration_bucket", "le", "1"}, prompb.Sample{Value: count, Timestamp: timestamp}),
+			},
+		}, nil)
+	}
+
+	send(0.2, 10, 1000)
+	send(0.3, 12, 2000)
+	assert.Equal(t, []remoteWriteResult{
+		{"rpc_duration", metrics.GaugeType, 0.2, []string{"quantile:0.99"}},
+		{"rpc_duration_bucket", metrics.GaugeType, 10, []string{"le:1"}},
+		{"rpc_duration", metrics.GaugeType, 0.3, []string{"quantile:0.99"}},
+		{"rpc_duration_bucket", metrics.GaugeType, 12, []string{"le:1"}},
+	}, remoteWriteResults(batcher.lateSamples))
+	assert.Equal(t, []remoteWriteResult{
+		{"rpc_duration.count", metrics.CountType, 2, []string{}},
+	}, remoteWriteResults(batcher.samples))
+}
+
+func TestRemoteWriteNativeHistogram(t *testing.T) {
+	r, batcher := newTestRemoteWriteReceiver(t, nil)
+	send := func(name string, histogram prompb.Histogram) {
+		ts := newRemoteWriteSeries([]string{"__name__", name, "service", "api"})
+		ts.Histograms = []prompb.Histogram{histogram}
+		postRemoteWrite(t, r, &prompb.WriteRequest{Timeseries: []prompb.TimeSeries{ts}}, nil)
+	}
+	nativeHistogram := func(zeroCount uint64, sum float64, deltas []int64, timestamp int64) prompb.Histogram {
+		return prompb.Histogram{
+			Schema:         0,
+			ZeroCount:      &prompb.Histogram_ZeroCountInt{ZeroCountInt: zeroCount},
+			Sum:            sum,
+			PositiveSpans:  []prompb.BucketSpan{{Offset: 0, Length: 2}, {Offset: 1, Length: 1}},
+			PositiveDeltas: deltas,
+			Timestamp:      timestamp,
+		}
+	}
+
+	// the first histogram is only the reference of the next ones
+	send("latency", nativeHistogram(1, 10, []int64{2, 1, -1}, 1000))
+	assert.Empty(t, batcher.samples)
+
+	send("latency", nativeHistogram(2, 20, []int64{4, -1, 0}, 2000))
+	// the histogram was reset
+	send("latency", nativeHistogram(0, 2, []int64{1, 0, 0}, 3000))
+	// gauge histograms are submitted as they are
+	gauge := nativeHistogram(0, 4, []int64{1, 0, 0}, 3000)
+	gauge.ResetHint = prompb.Histogram_GAUGE
+	send("queue_size", gauge)
+	// float histograms aren't supported
+	send("float", prompb.Histogram{Count: &prompb.Histogram_CountFloat{CountFloat: 1}, ZeroCount: &prompb.Histogram_ZeroCountFloat{ZeroCountFloat: 1}})
+
+	require.Len(t, batcher.samples, 3)
+	for _, sample := range batcher.samples {
+		assert.Equal(t, metrics.ExponentialHistogramType, sample.Mtype)
+		assert.Equal(t, []string{"service:api"}, sample.Tags)
+	}
+	assert.Equal(t, "latency", batcher.samples[0].Name)
+	assert.Equal(t, &metrics.ExponentialHistogram{
+		ZeroCount: 1,
+		Positive:  metrics.ExponentialHistogramBuckets{Offset: -1, Counts: []uint64{2, 0, 0, 1}},
+		Negative:  metrics.ExponentialHistogramBuckets{Counts: []uint64{}},
+		Sum:       10,
+	}, batcher.samples[0].ExponentialHistogram)
+	assert.Equal(t, "latency", batcher.samples[1].Name)
+	assert.Equal(t, &metrics.ExponentialHistogram{
+		Positive: metrics.ExponentialHistogramBuckets{Offset: -1, Counts: []uint64{1, 1, 0, 1}},
+		Sum:      2,
+	}, batcher.samples[1].ExponentialHistogram)
+	assert.Equal(t, 2.0, batcher.samples[0].Timestamp)
+	assert.Equal(t, "queue_size", batcher.samples[2].Name)
+	assert.Equal(t, uint64(3), batcher.samples[2].ExponentialHistogram.Count())
+}
+
+func TestNativeBucketsLimits(t *testing.T) {
+	buckets, err := nativeBuckets([]prompb.BucketSpan{{Offset: -2, Length: 1}, {Offset: 3, Length: 1}}, []int64{1, 1})
+	require.NoError(t, err)
+	assert.Equal(t, metrics.ExponentialHistogramBuckets{Offset: -3, Counts: []uint64{1, 0, 0, 0, 2}}, buckets)
+
+	// a gap between the spans too large to be filled with empty buckets
+	_, err = nativeBuckets([]prompb.BucketSpan{{Offset: 0, Length: 1}, {Offset: math.MaxInt32, Length: 1}}, []int64{1, 0})
+	assert.Error(t, err)
+	_, err = nativeBuckets([]prompb.BucketSpan{{Offset: 0, Length: 1}, {Offset: remoteWriteMaxBuckets, Length: 1}}, []int64{1, 0})
+	assert.Error(t, err)
+	// overlapping spans
+	_, err = nativeBuckets([]prompb.BucketSpan{{Offset: 0, Length: 2}, {Offset: -1, Length: 1}}, []int64{1, 0, 0})
+	assert.Error(t, err)
+	_, err = nativeBuckets([]prompb.BucketSpan{{Offset: math.MinInt32, Length: 1}}, []int64{1})
+	assert.Error(t, err)
+}
+
+func TestRemoteWriteOrigin(t *testing.T) {
+	r, batcher := newTestRemoteWriteReceiver(t, nil)
+
+	postRemoteWrite(t, r, &prompb.WriteRequest{Timeseries: []prompb.TimeSeries{
+		newRemoteWriteSeries([]string{"__name__", "up", "host", "web-1", "pod_uid", "1234"}, prompb.Sample{Value: 1, Timestamp: 1000}),
+	}}, map[string]string{
+		"Datadog-Entity-ID":    "ci-abcdef",
+		"Datadog-External-Env": "it-false,cn-nginx,pu-5678",
+	})
+
+	require.Len(t, batcher.lateSamples, 1)
+	sample := batcher.lateSamples[0]
+	assert.Equal(t, "web-1", sample.Host)
+	assert.Equal(t, []string{"pod_uid:1234"}, sample.Tags)
+	assert.Equal(t, "abcdef", sample.OriginInfo.LocalData.ContainerID)
+	assert.Equal(t, "1234", sample.OriginInfo.LocalData.PodUID)
+	assert.Equal(t, "nginx", sample.OriginInfo.ExternalData.ContainerName)
+	assert.Equal(t, "5678", sample.OriginInfo.ExternalData.PodUID)
+}
+
+func TestRemoteWriteNamespaceAndBlocklist(t *testing.T) {
+	r, batcher := newTestRemoteWriteReceiver(t, map[string]interface{}{
+		"statsd_metric_namespace": "prom",
+		"statsd_metric_blocklist": []string{"prom.ignored"},
+	})
+
+	postRemoteWrite(t, r, &prompb.WriteRequest{Timeseries: []prompb.TimeSeries{
+		newRemoteWriteSeries([]string{"__name__", "ignored"}, prompb.Sample{Value: 1, Timestamp: 1000}),
+		newRemoteWriteSeries([]string{"__name__", "kept"}, prompb.Sample{Value: 1, Timestamp: 1000}),
+		// no metric name
+		newRemoteWriteSeries([]string{"job", "node"}, prompb.Sample{Value: 1, Timestamp: 1000}),
+	}}, nil)
+
+	require.Len(t, batcher.lateSamples, 1)
+	assert.Equal(t, "prom.kept", batcher.lateSamples[0].Name)
+}
+
+func TestRemoteWriteInvalidRequest(t *testing.T) {
+	r, batcher := newTestRemoteWriteReceiver(t, nil)
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, remoteWritePath, nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, remoteWritePath, bytes.NewReader([]byte("not snappy"))))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, remoteWritePath, bytes.NewReader(snappy.Encode(nil, []byte{0xff, 0xff}))))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	assert.Empty(t, batcher.samples)
+}
@@ -132,6 +132,8 @@ type server struct {
 	tCapture                replay.Component
 	pidMap                  pidmap.Component
 	mapper                  *mapper.MetricMapper
+	cardinalityLimiter      *cardinalityLimiter
+	remoteWrite             *remoteWriteReceiver
 	eolTerminationUDP       bool
 	eolTerminationUDS       bool
 	eolTerminationNamedPipe bool
@@ -179,6 +181,7 @@ func initTelemetry() {
 	dogstatsdExpvars.Set("MetricParseErrors", &dogstatsdMetricParseErrors)
 	dogstatsdExpvars.Set("MetricPackets", &dogstatsdMetricPackets)
 	dogstatsdExpvars.Set("UnterminatedMetricErrors", &dogstatsdUnterminatedMetricErrors)
+	dogstatsdExpvars.Set("CardinalityLimitedSamples", &dogstatsdCardinalityLimitedSamples)
 }
 
 // TODO: (components) - merge with newServerCompat once NewServerlessServer is removed
@@ -320,6 +323,14 @@ func newServerCompat(cfg model.Reader, log log.Component, capture replay.Compone
 		"Time in nanosecond to push metrics to the aggregator input buffer",
 		buckets)
 
+	cardinalityLimiter, err := newCardinalityLimiter(cfg, telemetrycomp)
+	if err != nil {
+		log.Errorf("Dogstatsd: the cardinality of the metrics won't be limited: %v", err)
+	} else if cardinalityLimiter != nil {
+		s.cardinalityLimiter = cardinalityLimiter
+		cardinalityLimiter.publishExpvar()
+	}
+
 	s.listernersTelemetry = listeners.NewTelemetryStore(getBuckets(cfg, log, "telemetry.dogstatsd.listeners_latency_buckets"), telemetrycomp)
 	s.packetsTelemetry = packets.NewTelemetryStore(getBuckets(cfg, log, "telemetry.dogstatsd.listeners_channel_latency_buckets"), telemetrycomp)
 
@@ -460,6 +471,25 @@ func (s *server) start(context.Context) error {
 		}
 	}
 
+	// receive the Prometheus remote write requests
+	// ----------------------
+
+	if s.config.GetBool("dogstatsd_remote_write.enabled") && !s.ServerlessMode {
+		dogstatsdExpvars.Set("RemoteWriteRequests", &dogstatsdRemoteWriteRequests)
+		dogstatsdExpvars.Set("RemoteWriteRequestErrors", &dogstatsdRemoteWriteRequestErrors)
+		dogstatsdExpvars.Set("RemoteWriteSamples", &dogstatsdRemoteWriteSamples)
+		dogstatsdExpvars.Set("RemoteWriteSkippedSamples", &dogstatsdRemoteWriteSkipped)
+
+		receiver := newRemoteWriteReceiver(s, func() dogstatsdBatcher {
+			return newBatcher(s.demultiplexer.(aggregator.DemultiplexerWithAggregator), s.tlmChannel)
+		})
+		if err := receiver.start(); err != nil {
+			s.log.Errorf("Could not start the Prometheus remote write receiver: %v", err)
+		} else {
+			s.remoteWrite = receiver
+		}
+	}
+
 	// start the workers processing the packets read on the socket
 	// ----------------------
 
@@ -476,6 +506,10 @@ func (s *server) stop(context.Context) error {
 	for _, l := range s.listeners {
 		l.Stop()
 	}
+	if s.remoteWrite != nil {
+		s.remoteWrite.stop()
+		s.remoteWrite = nil
+	}
 	if s.Statistics != nil {
 		s.Statistics.Stop()
 	}
@@ -752,13 +786,24 @@ func (s *server) parseMetricMessage(metricSamples []metrics.MetricSample, parser
 
 	if s.mapper != nil {
 		mapResult := s.mapper.Map(sample.name)
+		if mapResult != nil && mapResult.Drop {
+			s.log.Tracef("Dogstatsd mapper: metric %q dropped", sample.name)
+			if len(sample.values) > 0 {
+				s.sharedFloat64List.put(sample.values)
+			}
+			return metricSamples, nil
+		}
 		if mapResult != nil {
 			s.log.Tracef("Dogstatsd mapper: metric mapped from %q to %q with tags %v", sample.name, mapResult.Name, mapResult.Tags)
 			sample.name = mapResult.Name
-			sample.tags = append(sample.tags, mapResult.Tags...)
+			sample.tags = mapResult.ApplyTags(sample.tags)
 		}
 	}
 
+	if s.cardinalityLimiter != nil {
+		sample.tags = s.cardinalityLimiter.limit(sample.name, sample.tags)
+	}
+
 	metricSamples = enrichMetricSample(metricSamples, sample, origin, processID, listenerID, s.enrichConfig)
 
 	if len(sample.values) > 0 {
@@ -351,6 +351,38 @@ dogstatsd_mapper_profiles:
 			expectedSamples:   nil,
 			expectedCacheSize: 999,
 		},
+		{
+			name: "Rewrite actions",
+			config: `
+dogstatsd_port: __random__
+dogstatsd_mapper_profiles:
+  - name: test
+    prefix: 'test.'
+    mappings:
+      - match: "test.debug.**"
+        match_type: glob
+        actions:
+          - type: drop
+      - match: "test.*.{get,post}.requests"
+        match_type: glob
+        name: "test.requests"
+        tags:
+          service: "$1"
+          method: "$2"
+        actions:
+          - type: rename_tag
+            tag: hostname
+            target: node
+`,
+			packets: [][]byte{
+				[]byte("test.debug.gc.pause:666|g"),
+				[]byte("test.web.get.requests:666|g|#hostname:web01"),
+			},
+			expectedSamples: []*tMetricSample{
+				defaultMetric().withName("test.requests").withTags([]string{"service:web", "method:get", "node:web01"}),
+			},
+			expectedCacheSize: 1000,
+		},
 	}
 
 	for _, scenario := range scenarios {
@@ -367,6 +399,7 @@ dogstatsd_mapper_profiles:
 			var b batcherMock
 			s.parsePackets(&b, parser, genTestPackets(scenario.packets...), metrics.MetricSampleBatch{})
 
+			require.Len(t, b.samples, len(scenario.expectedSamples))
 			for idx, sample := range b.samples {
 				scenario.expectedSamples[idx].testMetric(t, sample)
 			}
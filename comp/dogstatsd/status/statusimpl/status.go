@@ -98,4 +98,11 @@ func (s statusProvider) populateStatus(stats map[string]interface{}) {
 		}
 		stats["dogstatsdStats"] = dogstatsdStats
 	}
+	if cardinalityVar := expvar.Get("dogstatsd-cardinality"); cardinalityVar != nil {
+		var offenders []interface{}
+		json.Unmarshal([]byte(cardinalityVar.String()), &offenders) //nolint:errcheck
+		if len(offenders) > 0 {
+			stats["dogstatsdCardinalityOffenders"] = offenders
+		}
+	}
 }
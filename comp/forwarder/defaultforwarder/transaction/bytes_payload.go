@@ -5,12 +5,29 @@
 
 package transaction
 
+import "net/http"
+
+// PayloadEncoder re-encodes payloads for the domains they're sent to, like when a domain is configured with another
+// compression or doesn't accept the content encoding of a payload.
+type PayloadEncoder interface {
+	// Encode returns the content to send to the domain, and updates the headers of the request accordingly, like
+	// Content-Encoding.
+	Encode(domain string, content []byte, headers http.Header) ([]byte, error)
+	// Reject records that the domain answered a request with a 415 Unsupported Media Type response, and returns
+	// whether the payload will be encoded differently for it. The response may list the content encodings the domain
+	// accepts in its Accept-Encoding header (RFC 7694).
+	Reject(domain string, content []byte, requestHeaders http.Header, responseHeaders http.Header) bool
+}
+
 // BytesPayload is a payload stored as bytes.
 // It contains metadata about the payload.
 type BytesPayload struct {
 	content     []byte
 	pointCount  int
 	Destination Destination
+	// Encoder re-encodes the payload for the domains it's sent to, when set. It isn't kept when the transaction is
+	// stored on disk.
+	Encoder PayloadEncoder
 }
 
 // NewBytesPayload creates a new instance of BytesPayload.
@@ -371,11 +371,24 @@ func (t *HTTPTransaction) Process(ctx context.Context, config config.Component,
 // This will return  (http status code, response body, error).
 func (t *HTTPTransaction) internalProcess(ctx context.Context, config config.Component, log log.Component, client *http.Client) (int, []byte, error) {
 	payload := t.Payload.GetContent()
-	reader := bytes.NewReader(payload)
 	url := t.Domain + t.Endpoint.Route
 	transactionEndpointName := t.GetEndpointName()
 	logURL := scrubber.ScrubLine(url) // sanitized url that can be logged
 
+	headers := t.Headers
+	encoder := t.Payload.Encoder
+	if encoder != nil {
+		// the headers of the transaction are kept as is, so that the payload can be encoded again on retries
+		encodedHeaders := t.Headers.Clone()
+		encoded, err := encoder.Encode(t.Domain, payload, encodedHeaders)
+		if err != nil {
+			log.Warnf("Could not encode the payload of the transaction to %q, sending it as is: %s", logURL, err)
+		} else {
+			payload, headers = encoded, encodedHeaders
+		}
+	}
+	reader := bytes.NewReader(payload)
+
 	req, err := http.NewRequestWithContext(ctx, "POST", url, reader)
 	if err != nil {
 		log.Errorf("Could not create request for transaction to invalid URL %q (dropping transaction): %s", logURL, err)
@@ -384,7 +397,7 @@ func (t *HTTPTransaction) internalProcess(ctx context.Context, config config.Com
 		transactionsSentRequestErrors.Add(1)
 		return 0, nil, nil
 	}
-	req.Header = t.Headers
+	req.Header = headers
 	log.Tracef("Sending %s request to %s with body size %d and headers %v", req.Method, logURL, len(payload), req.Header)
 	resp, err := client.Do(req)
 
@@ -420,6 +433,13 @@ func (t *HTTPTransaction) internalProcess(ctx context.Context, config config.Com
 		tlmTxHTTPErrors.Inc(t.Domain, transactionEndpointName, statusCode)
 	}
 
+	// the domain doesn't accept the content encoding of the payload, like a zstd dictionary it doesn't have, so the
+	// payload is sent again right away with another one
+	if resp.StatusCode == http.StatusUnsupportedMediaType && encoder != nil && encoder.Reject(t.Domain, payload, headers, resp.Header) {
+		log.Infof("%q doesn't accept the content encoding %q, sending the payload again with another one", logURL, headers.Get("Content-Encoding"))
+		return t.internalProcess(ctx, config, log, client)
+	}
+
 	// We want to retry 404s even if that means that the agent would retry
 	// payloads on endpoints that don’t exist at the intake it’s sending data
 	// to (example: a specific DD region, or a http proxy)
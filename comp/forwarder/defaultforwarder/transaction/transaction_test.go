@@ -6,7 +6,9 @@
 package transaction
 
 import (
+	"bytes"
 	"context"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"reflect"
@@ -125,6 +127,62 @@ func TestProcessHTTPError(t *testing.T) {
 	assert.Equal(t, transaction.ErrorCount, 1)
 }
 
+// fakeEncoder upper-cases the payloads, unless the domain rejected that encoding
+type fakeEncoder struct {
+	rejected bool
+}
+
+func (e *fakeEncoder) Encode(_ string, content []byte, headers http.Header) ([]byte, error) {
+	if e.rejected {
+		headers.Set("Content-Encoding", "identity")
+		return content, nil
+	}
+	headers.Set("Content-Encoding", "upper")
+	return bytes.ToUpper(content), nil
+}
+
+func (e *fakeEncoder) Reject(_ string, _ []byte, requestHeaders http.Header, responseHeaders http.Header) bool {
+	if e.rejected || requestHeaders.Get("Content-Encoding") != "upper" || responseHeaders.Get("Accept-Encoding") != "identity" {
+		return false
+	}
+	e.rejected = true
+	return true
+}
+
+func TestProcessPayloadEncoder(t *testing.T) {
+	var received []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received = append(received, r.Header.Get("Content-Encoding")+":"+string(body))
+		if r.Header.Get("Content-Encoding") != "identity" {
+			w.Header().Set("Accept-Encoding", "identity")
+			w.WriteHeader(http.StatusUnsupportedMediaType)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	encoder := &fakeEncoder{}
+	transaction := NewHTTPTransaction()
+	transaction.Domain = ts.URL
+	transaction.Endpoint.Route = "/endpoint/test"
+	transaction.Payload = NewBytesPayloadWithoutMetaData([]byte("test payload"))
+	transaction.Payload.Encoder = encoder
+	transaction.Headers.Set("Content-Encoding", "identity")
+
+	mockConfig := configmock.New(t)
+	log := logmock.New(t)
+	err := transaction.Process(context.Background(), mockConfig, log, &http.Client{})
+	assert.NoError(t, err)
+
+	// the payload is sent again right away once the encoding is rejected, and the transaction is left unchanged
+	assert.Equal(t, []string{"upper:TEST PAYLOAD", "identity:test payload"}, received)
+	assert.True(t, encoder.rejected)
+	assert.Equal(t, "identity", transaction.Headers.Get("Content-Encoding"))
+	assert.Equal(t, []byte("test payload"), transaction.Payload.GetContent())
+}
+
 func TestProcessCancel(t *testing.T) {
 	transaction := NewHTTPTransaction()
 	transaction.Domain = "example.com"
@@ -173,6 +173,35 @@ func (l *LogsConfigKeys) getAdditionalEndpoints() ([]unmarshalEndpoint, string)
 	return endpoints, configKey
 }
 
+func (l *LogsConfigKeys) getOTLPEndpoints() []OTLPEndpoint {
+	var endpoints []OTLPEndpoint
+	var err error
+	configKey := l.getConfigKey("otlp_endpoints")
+	raw := l.getConfig().Get(configKey)
+	if raw == nil {
+		return nil
+	}
+	if s, ok := raw.(string); ok && s != "" {
+		err = json.Unmarshal([]byte(s), &endpoints)
+	} else {
+		err = structure.UnmarshalKey(l.getConfig(), configKey, &endpoints)
+	}
+	if err != nil {
+		log.Warnf("Could not parse otlp_endpoints for logs: %v", err)
+		return nil
+	}
+
+	valid := endpoints[:0]
+	for _, endpoint := range endpoints {
+		if endpoint.Endpoint == "" {
+			log.Warnf("Ignoring an entry of %s without endpoint", configKey)
+			continue
+		}
+		valid = append(valid, endpoint)
+	}
+	return valid
+}
+
 func (l *LogsConfigKeys) expectedTagsDuration() time.Duration {
 	return l.getConfig().GetDuration(l.getConfigKey("expected_tags_duration"))
 }
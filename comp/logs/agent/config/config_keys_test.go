@@ -112,3 +112,42 @@ func TestGetAdditionalEndpoints(t *testing.T) {
 	assert.Equal(t, expected, endpoints)
 	assert.Equal(t, "logs_config.additional_endpoints", path)
 }
+
+func TestGetOTLPEndpoints(t *testing.T) {
+	expected := []OTLPEndpoint{
+		{
+			Endpoint: "localhost:4317",
+			Insecure: true,
+		},
+		{
+			Endpoint: "collector:4317",
+			Headers:  map[string]string{"x-scope": "logs"},
+		},
+	}
+
+	configMock, l := getLogsConfigKeys(t)
+	assert.Empty(t, l.getOTLPEndpoints())
+
+	// Test with a JSON directly set
+	configMock.SetWithoutSource("logs_config.otlp_endpoints", `[
+		{"endpoint": "localhost:4317", "insecure": true},
+		{"endpoint": "collector:4317", "headers": {"x-scope": "logs"}},
+		{"insecure": true}
+	]`)
+	assert.Equal(t, expected, l.getOTLPEndpoints())
+
+	// Test with a regular setup from the configuration file
+	configMock.UnsetForSource("logs_config.otlp_endpoints", model.SourceUnknown)
+	configMock.SetWithoutSource("logs_config.otlp_endpoints",
+		[]map[string]interface{}{
+			{
+				"endpoint": "localhost:4317",
+				"insecure": true,
+			},
+			{
+				"endpoint": "collector:4317",
+				"headers":  map[string]interface{}{"x-scope": "logs"},
+			},
+		})
+	assert.Equal(t, expected, l.getOTLPEndpoints())
+}
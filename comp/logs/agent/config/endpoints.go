@@ -83,6 +83,23 @@ type unmarshalEndpoint struct {
 	Endpoint `mapstructure:",squash"`
 }
 
+// OTLPEndpoint holds the parameters to send logs to an OpenTelemetry collector over OTLP/gRPC.
+type OTLPEndpoint struct {
+	// Endpoint is the `host:port` address of the OTLP/gRPC receiver
+	Endpoint string            `mapstructure:"endpoint" json:"endpoint"`
+	Insecure bool              `mapstructure:"insecure" json:"insecure"`
+	Headers  map[string]string `mapstructure:"headers" json:"headers"`
+}
+
+// GetStatus returns the OTLP endpoint status
+func (e *OTLPEndpoint) GetStatus(prefix string) string {
+	transport := "TLS encrypted OTLP/gRPC"
+	if e.Insecure {
+		transport = "OTLP/gRPC"
+	}
+	return fmt.Sprintf("%sSending logs in %s to %s", prefix, transport, e.Endpoint)
+}
+
 // NewEndpoint returns a new Endpoint with the minimal field initialized.
 func NewEndpoint(apiKey string, apiKeyConfigPath string, host string, port int, useSSL bool) Endpoint {
 	apiKey = pkgconfigutils.SanitizeAPIKey(apiKey)
@@ -329,6 +346,9 @@ type Endpoints struct {
 	BatchMaxSize           int
 	BatchMaxContentSize    int
 	InputChanSize          int
+	// OTLPEndpoints are additional unreliable endpoints receiving logs over OTLP/gRPC. They are only used when
+	// logs are sent over HTTP.
+	OTLPEndpoints []OTLPEndpoint
 }
 
 // GetStatus returns the endpoints status, one line per endpoint
@@ -340,6 +360,9 @@ func (e *Endpoints) GetStatus() []string {
 	for _, endpoint := range e.GetUnReliableEndpoints() {
 		result = append(result, endpoint.GetStatus("Unreliable: ", e.UseHTTP))
 	}
+	for _, endpoint := range e.OTLPEndpoints {
+		result = append(result, endpoint.GetStatus("Unreliable: "))
+	}
 	return result
 }
 
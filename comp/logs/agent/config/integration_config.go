@@ -25,6 +25,7 @@ const (
 	IntegrationType   = "integration"
 	WindowsEventType  = "windows_event"
 	StringChannelType = "string_channel"
+	KafkaType         = "kafka"
 
 	// UTF16BE for UTF-16 Big endian encoding
 	UTF16BE string = "utf-16-be"
@@ -32,6 +33,9 @@ const (
 	UTF16LE string = "utf-16-le"
 	// SHIFTJIS for Shift JIS (Japanese) encoding
 	SHIFTJIS string = "shift-jis"
+
+	// SyslogFormat for network sources receiving syslog (RFC 5424 or RFC 3164) messages
+	SyslogFormat string = "syslog"
 )
 
 // LogsConfig represents a log source config, which can be for instance
@@ -43,11 +47,12 @@ type LogsConfig struct {
 
 	Port        int    // Network
 	IdleTimeout string `mapstructure:"idle_timeout" json:"idle_timeout" yaml:"idle_timeout"` // Network
+	Format      string `mapstructure:"format" json:"format" yaml:"format"`                   // Network
 	Path        string // File, Journald
 
 	Encoding     string           `mapstructure:"encoding" json:"encoding" yaml:"encoding"`                   // File
 	ExcludePaths StringSliceField `mapstructure:"exclude_paths" json:"exclude_paths" yaml:"exclude_paths"`    // File
-	TailingMode  string           `mapstructure:"start_position" json:"start_position" yaml:"start_position"` // File
+	TailingMode  string           `mapstructure:"start_position" json:"start_position" yaml:"start_position"` // File, Kafka
 
 	//nolint:revive // TODO(AML) Fix revive linter
 	ConfigId           string           `mapstructure:"config_id" json:"config_id" yaml:"config_id"`                            // Journald
@@ -70,6 +75,10 @@ type LogsConfig struct {
 	ChannelPath string `mapstructure:"channel_path" json:"channel_path" yaml:"channel_path"` // Windows Event
 	Query       string // Windows Event
 
+	Brokers       StringSliceField `mapstructure:"brokers" json:"brokers" yaml:"brokers"`                      // Kafka
+	Topics        StringSliceField `mapstructure:"topics" json:"topics" yaml:"topics"`                         // Kafka
+	ConsumerGroup string           `mapstructure:"consumer_group" json:"consumer_group" yaml:"consumer_group"` // Kafka
+
 	// used as input only by the Channel tailer.
 	// could have been unidirectional but the tailer could not close it in this case.
 	Channel chan *ChannelMessage
@@ -141,9 +150,11 @@ func (c *LogsConfig) Dump(multiline bool) string {
 	case TCPType:
 		fmt.Fprintf(&b, ws("Port: %d,"), c.Port)
 		fmt.Fprintf(&b, ws("IdleTimeout: %#v,"), c.IdleTimeout)
+		fmt.Fprintf(&b, ws("Format: %#v,"), c.Format)
 	case UDPType:
 		fmt.Fprintf(&b, ws("Port: %d,"), c.Port)
 		fmt.Fprintf(&b, ws("IdleTimeout: %#v,"), c.IdleTimeout)
+		fmt.Fprintf(&b, ws("Format: %#v,"), c.Format)
 	case FileType:
 		fmt.Fprintf(&b, ws("Path: %#v,"), c.Path)
 		fmt.Fprintf(&b, ws("Encoding: %#v,"), c.Encoding)
@@ -166,6 +177,11 @@ func (c *LogsConfig) Dump(multiline bool) string {
 	case WindowsEventType:
 		fmt.Fprintf(&b, ws("ChannelPath: %#v,"), c.ChannelPath)
 		fmt.Fprintf(&b, ws("Query: %#v,"), c.Query)
+	case KafkaType:
+		fmt.Fprintf(&b, ws("Brokers: %#v,"), c.Brokers)
+		fmt.Fprintf(&b, ws("Topics: %#v,"), c.Topics)
+		fmt.Fprintf(&b, ws("ConsumerGroup: %#v,"), c.ConsumerGroup)
+		fmt.Fprintf(&b, ws("TailingMode: %#v,"), c.TailingMode)
 	case StringChannelType:
 		fmt.Fprintf(&b, ws("Channel: %p,"), c.Channel)
 		c.ChannelTagsMutex.Lock()
@@ -287,6 +303,21 @@ func (c *LogsConfig) Validate() error {
 		return fmt.Errorf("tcp source must have a port")
 	case c.Type == UDPType && c.Port == 0:
 		return fmt.Errorf("udp source must have a port")
+	case (c.Type == TCPType || c.Type == UDPType) && c.Format != "" && c.Format != SyslogFormat:
+		return fmt.Errorf("invalid format '%v' for %v source", c.Format, c.Type)
+	case c.Type == KafkaType:
+		if len(c.Brokers) == 0 {
+			return fmt.Errorf("kafka source must have brokers")
+		}
+		if len(c.Topics) == 0 {
+			return fmt.Errorf("kafka source must have topics")
+		}
+		if c.ConsumerGroup == "" {
+			return fmt.Errorf("kafka source must have a consumer_group")
+		}
+		if _, found := TailingModeFromString(c.TailingMode); !found && c.TailingMode != "" {
+			return fmt.Errorf("invalid tailing mode '%v' for kafka source", c.TailingMode)
+		}
 	}
 	err := ValidateProcessingRules(c.ProcessingRules)
 	if err != nil {
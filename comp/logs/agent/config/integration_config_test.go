@@ -21,8 +21,11 @@ func TestValidateShouldSucceedWithValidConfigs(t *testing.T) {
 		{Type: FileType, Path: "/var/log/foo.log"},
 		{Type: TCPType, Port: 1234},
 		{Type: UDPType, Port: 5678},
+		{Type: TCPType, Port: 514, Format: SyslogFormat},
+		{Type: UDPType, Port: 514, Format: SyslogFormat},
 		{Type: DockerType},
 		{Type: JournaldType, ProcessingRules: []*ProcessingRule{{Name: "foo", Type: ExcludeAtMatch, Pattern: ".*"}}},
+		{Type: KafkaType, Brokers: []string{"localhost:9092"}, Topics: []string{"logs"}, ConsumerGroup: "datadog-agent"},
 	}
 
 	for _, config := range validConfigs {
@@ -37,6 +40,7 @@ func TestValidateShouldFailWithInvalidConfigs(t *testing.T) {
 		{Type: FileType},
 		{Type: TCPType},
 		{Type: UDPType},
+		{Type: TCPType, Port: 514, Format: "gelf"},
 		{Type: DockerType, ProcessingRules: []*ProcessingRule{{Name: "foo"}}},
 		{Type: DockerType, ProcessingRules: []*ProcessingRule{{Name: "foo", Type: "bar"}}},
 		{Type: DockerType, ProcessingRules: []*ProcessingRule{{Name: "foo", Type: ExcludeAtMatch}}},
@@ -44,6 +48,10 @@ func TestValidateShouldFailWithInvalidConfigs(t *testing.T) {
 		{Type: DockerType, ProcessingRules: []*ProcessingRule{{Type: ExcludeAtMatch, Pattern: ".*"}}},
 		{Type: DockerType, ProcessingRules: []*ProcessingRule{{Type: ExcludeAtMatch}}},
 		{Type: DockerType, ProcessingRules: []*ProcessingRule{{Pattern: ".*"}}},
+		{Type: KafkaType, Topics: []string{"logs"}, ConsumerGroup: "datadog-agent"},
+		{Type: KafkaType, Brokers: []string{"localhost:9092"}, ConsumerGroup: "datadog-agent"},
+		{Type: KafkaType, Brokers: []string{"localhost:9092"}, Topics: []string{"logs"}},
+		{Type: KafkaType, Brokers: []string{"localhost:9092"}, Topics: []string{"logs"}, ConsumerGroup: "datadog-agent", TailingMode: "middle"},
 	}
 
 	for _, config := range invalidConfigs {
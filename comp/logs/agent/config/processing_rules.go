@@ -8,6 +8,9 @@ package config
 import (
 	"fmt"
 	"regexp"
+	"strconv"
+	"strings"
+	"sync/atomic"
 )
 
 // Processing rule types
@@ -16,8 +19,24 @@ const (
 	IncludeAtMatch = "include_at_match"
 	MaskSequences  = "mask_sequences"
 	MultiLine      = "multi_line"
+	SampleAtMatch  = "sample_at_match"
 )
 
+// Attribute processing rule types, applied on the attributes of JSON or logfmt
+// formatted log lines.
+const (
+	DropAttribute    = "drop_attribute"
+	RenameAttribute  = "rename_attribute"
+	HashAttribute    = "hash_attribute"
+	MaskAttribute    = "mask_attribute"
+	AddAttribute     = "add_attribute"
+	RouteOnAttribute = "route_on_attribute"
+)
+
+// MinSampleRate is the smallest sample_rate of a sample_at_match rule, the
+// precision at which the sample rate is applied.
+const MinSampleRate = 0.000001
+
 // ProcessingRule defines an exclusion or a masking rule to
 // be applied on log lines
 type ProcessingRule struct {
@@ -25,9 +44,47 @@ type ProcessingRule struct {
 	Name               string
 	ReplacePlaceholder string `mapstructure:"replace_placeholder" json:"replace_placeholder" yaml:"replace_placeholder"`
 	Pattern            string
+	// Attribute is the dot-separated path of the attribute an attribute rule applies to.
+	Attribute string `mapstructure:"attribute" json:"attribute" yaml:"attribute"`
+	// TargetAttribute is the new path of the attribute for a rename_attribute rule.
+	TargetAttribute string `mapstructure:"target_attribute" json:"target_attribute" yaml:"target_attribute"`
+	// Value is the value set by an add_attribute rule, `%{path}` references are
+	// replaced with the value of the referenced attribute.
+	Value string `mapstructure:"value" json:"value" yaml:"value"`
+	// HashKey is the secret key of the HMAC-SHA256 computed by a hash_attribute
+	// rule. Without it, values are hashed with a plain SHA-256 which can be
+	// reversed by brute force for low-entropy values like emails or IPs. It is
+	// scrubbed by pkg/util/scrubber.
+	HashKey string `mapstructure:"hash_key" json:"hash_key" yaml:"hash_key"`
+	// Tags are added to the message when a route_on_attribute rule matches.
+	Tags []string `mapstructure:"tags" json:"tags" yaml:"tags"`
+	// SampleRate is the fraction of the matching lines kept by a sample_at_match rule.
+	SampleRate float64 `mapstructure:"sample_rate" json:"sample_rate" yaml:"sample_rate"`
+	// RateLimit is the number of matching lines per second kept by a sample_at_match rule.
+	RateLimit int `mapstructure:"rate_limit" json:"rate_limit" yaml:"rate_limit"`
+	// GroupBy is the name or the index of the pattern capture group on which the
+	// budget of a sample_at_match rule is keyed.
+	GroupBy string `mapstructure:"group_by" json:"group_by" yaml:"group_by"`
 	// TODO: should be moved out
-	Regex       *regexp.Regexp
-	Placeholder []byte
+	Regex         *regexp.Regexp
+	Placeholder   []byte
+	AttributePath []string
+	TargetPath    []string
+	// GroupIndex is the index of the GroupBy capture group, -1 without GroupBy.
+	GroupIndex int
+	// Sampler holds the state of a sample_at_match rule, shared by the pipelines
+	// applying the rule. It is managed by pkg/logs/processor.
+	Sampler atomic.Value
+}
+
+// IsAttributeRule returns true if the rule operates on the attributes of a
+// structured (JSON or logfmt) log line instead of the raw line.
+func (r *ProcessingRule) IsAttributeRule() bool {
+	switch r.Type {
+	case DropAttribute, RenameAttribute, HashAttribute, MaskAttribute, AddAttribute, RouteOnAttribute:
+		return true
+	}
+	return false
 }
 
 // ValidateProcessingRules validates the rules and raises an error if one is misconfigured.
@@ -35,6 +92,8 @@ type ProcessingRule struct {
 // - a valid name
 // - a valid type
 // - a valid pattern that compiles
+// Attribute rules must have an attribute path, the pattern is then only required
+// for route_on_attribute rules.
 func ValidateProcessingRules(rules []*ProcessingRule) error {
 	for _, rule := range rules {
 		if rule.Name == "" {
@@ -44,6 +103,15 @@ func ValidateProcessingRules(rules []*ProcessingRule) error {
 		switch rule.Type {
 		case ExcludeAtMatch, IncludeAtMatch, MaskSequences, MultiLine:
 			break
+		case SampleAtMatch:
+			if err := validateSamplingRule(rule); err != nil {
+				return err
+			}
+		case DropAttribute, RenameAttribute, HashAttribute, MaskAttribute, AddAttribute, RouteOnAttribute:
+			if err := validateAttributeRule(rule); err != nil {
+				return err
+			}
+			continue
 		case "":
 			return fmt.Errorf("type must be set for processing rule `%s`", rule.Name)
 		default:
@@ -61,9 +129,97 @@ func ValidateProcessingRules(rules []*ProcessingRule) error {
 	return nil
 }
 
+func validateSamplingRule(rule *ProcessingRule) error {
+	if rule.SampleRate == 0 && rule.RateLimit == 0 {
+		return fmt.Errorf("sample_rate or rate_limit must be set for processing rule: %s", rule.Name)
+	}
+	if rule.SampleRate < 0 || rule.SampleRate > 1 {
+		return fmt.Errorf("sample_rate must be between 0 and 1 for processing rule: %s", rule.Name)
+	}
+	if rule.SampleRate > 0 && rule.SampleRate < MinSampleRate {
+		return fmt.Errorf("sample_rate must be at least %g for processing rule: %s", MinSampleRate, rule.Name)
+	}
+	if rule.RateLimit < 0 {
+		return fmt.Errorf("rate_limit must be positive for processing rule: %s", rule.Name)
+	}
+	if rule.GroupBy != "" {
+		re, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return fmt.Errorf("invalid pattern %s for processing rule: %s", rule.Pattern, rule.Name)
+		}
+		if groupIndex(re, rule.GroupBy) < 0 {
+			return fmt.Errorf("group_by %s is not a capture group of the pattern for processing rule: %s", rule.GroupBy, rule.Name)
+		}
+	}
+	return nil
+}
+
+// groupIndex returns the index of the capture group designated by its name or its
+// index, -1 is returned if there is no such group.
+func groupIndex(re *regexp.Regexp, group string) int {
+	if index, err := strconv.Atoi(group); err == nil {
+		if index < 1 || index > re.NumSubexp() {
+			return -1
+		}
+		return index
+	}
+	return re.SubexpIndex(group)
+}
+
+func validateAttributeRule(rule *ProcessingRule) error {
+	if !isValidAttributePath(rule.Attribute) {
+		return fmt.Errorf("invalid or missing attribute %q for processing rule: %s", rule.Attribute, rule.Name)
+	}
+
+	switch rule.Type {
+	case RenameAttribute:
+		if !isValidAttributePath(rule.TargetAttribute) {
+			return fmt.Errorf("invalid or missing target_attribute %q for processing rule: %s", rule.TargetAttribute, rule.Name)
+		}
+	case AddAttribute:
+		if rule.Value == "" {
+			return fmt.Errorf("no value provided for processing rule: %s", rule.Name)
+		}
+	case RouteOnAttribute:
+		if rule.Pattern == "" {
+			return fmt.Errorf("no pattern provided for processing rule: %s", rule.Name)
+		}
+		if len(rule.Tags) == 0 {
+			return fmt.Errorf("no tags provided for processing rule: %s", rule.Name)
+		}
+	}
+
+	if rule.Pattern != "" {
+		if _, err := regexp.Compile(rule.Pattern); err != nil {
+			return fmt.Errorf("invalid pattern %s for processing rule: %s", rule.Pattern, rule.Name)
+		}
+	}
+	return nil
+}
+
+// isValidAttributePath returns true if the path is not empty and doesn't contain
+// any empty segment.
+func isValidAttributePath(path string) bool {
+	if path == "" {
+		return false
+	}
+	for _, segment := range strings.Split(path, ".") {
+		if segment == "" {
+			return false
+		}
+	}
+	return true
+}
+
 // CompileProcessingRules compiles all processing rule regular expressions.
 func CompileProcessingRules(rules []*ProcessingRule) error {
 	for _, rule := range rules {
+		if rule.IsAttributeRule() {
+			if err := compileAttributeRule(rule); err != nil {
+				return err
+			}
+			continue
+		}
 		re, err := regexp.Compile(rule.Pattern)
 		if err != nil {
 			return err
@@ -71,6 +227,14 @@ func CompileProcessingRules(rules []*ProcessingRule) error {
 		switch rule.Type {
 		case ExcludeAtMatch, IncludeAtMatch:
 			rule.Regex = re
+		case SampleAtMatch:
+			rule.Regex = re
+			rule.GroupIndex = -1
+			if rule.GroupBy != "" {
+				if rule.GroupIndex = groupIndex(re, rule.GroupBy); rule.GroupIndex < 0 {
+					return fmt.Errorf("group_by %s is not a capture group of the pattern %s", rule.GroupBy, rule.Pattern)
+				}
+			}
 		case MaskSequences:
 			rule.Regex = re
 			rule.Placeholder = []byte(rule.ReplacePlaceholder)
@@ -83,3 +247,19 @@ func CompileProcessingRules(rules []*ProcessingRule) error {
 	}
 	return nil
 }
+
+func compileAttributeRule(rule *ProcessingRule) error {
+	if rule.Pattern != "" {
+		re, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return err
+		}
+		rule.Regex = re
+	}
+	rule.AttributePath = strings.Split(rule.Attribute, ".")
+	if rule.TargetAttribute != "" {
+		rule.TargetPath = strings.Split(rule.TargetAttribute, ".")
+	}
+	rule.Placeholder = []byte(rule.ReplacePlaceholder)
+	return nil
+}
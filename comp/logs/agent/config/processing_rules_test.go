@@ -33,3 +33,81 @@ func TestCompileShouldFailWithInvalidRules(t *testing.T) {
 		assert.Nil(t, rule.Regex)
 	}
 }
+
+func TestValidateAttributeRules(t *testing.T) {
+	validRules := []*ProcessingRule{
+		{Name: "drop", Type: DropAttribute, Attribute: "user.password"},
+		{Name: "rename", Type: RenameAttribute, Attribute: "lvl", TargetAttribute: "level"},
+		{Name: "hash", Type: HashAttribute, Attribute: "user.email"},
+		{Name: "mask", Type: MaskAttribute, Attribute: "card", Pattern: `\d{12}`},
+		{Name: "add", Type: AddAttribute, Attribute: "summary", Value: "%{method} %{url}"},
+		{Name: "route", Type: RouteOnAttribute, Attribute: "tenant", Pattern: "^acme$", Tags: []string{"team:acme"}},
+	}
+	for _, rule := range validRules {
+		assert.NoError(t, ValidateProcessingRules([]*ProcessingRule{rule}), rule.Name)
+	}
+
+	invalidRules := []*ProcessingRule{
+		{Name: "no attribute", Type: DropAttribute},
+		{Name: "empty segment", Type: DropAttribute, Attribute: "user..password"},
+		{Name: "no target", Type: RenameAttribute, Attribute: "lvl"},
+		{Name: "no value", Type: AddAttribute, Attribute: "summary"},
+		{Name: "no pattern", Type: RouteOnAttribute, Attribute: "tenant", Tags: []string{"team:acme"}},
+		{Name: "no tags", Type: RouteOnAttribute, Attribute: "tenant", Pattern: "^acme$"},
+		{Name: "invalid pattern", Type: MaskAttribute, Attribute: "card", Pattern: "(?=abf)"},
+	}
+	for _, rule := range invalidRules {
+		assert.Error(t, ValidateProcessingRules([]*ProcessingRule{rule}), rule.Name)
+	}
+}
+
+func TestCompileAttributeRules(t *testing.T) {
+	rules := []*ProcessingRule{
+		{Type: RenameAttribute, Attribute: "http.status", TargetAttribute: "http.status_code"},
+		{Type: MaskAttribute, Attribute: "card", Pattern: `\d{12}`},
+	}
+	err := CompileProcessingRules(rules)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"http", "status"}, rules[0].AttributePath)
+	assert.Equal(t, []string{"http", "status_code"}, rules[0].TargetPath)
+	assert.Nil(t, rules[0].Regex)
+	assert.NotNil(t, rules[1].Regex)
+}
+
+func TestValidateSamplingRules(t *testing.T) {
+	validRules := []*ProcessingRule{
+		{Name: "rate", Type: SampleAtMatch, Pattern: "DEBUG", SampleRate: 0.1},
+		{Name: "smallest rate", Type: SampleAtMatch, Pattern: "DEBUG", SampleRate: MinSampleRate},
+		{Name: "limit", Type: SampleAtMatch, Pattern: "DEBUG", RateLimit: 10},
+		{Name: "named group", Type: SampleAtMatch, Pattern: `error=(?P<sig>\w+)`, RateLimit: 10, GroupBy: "sig"},
+		{Name: "indexed group", Type: SampleAtMatch, Pattern: `error=(\w+)`, RateLimit: 10, GroupBy: "1"},
+	}
+	for _, rule := range validRules {
+		assert.NoError(t, ValidateProcessingRules([]*ProcessingRule{rule}), rule.Name)
+	}
+
+	invalidRules := []*ProcessingRule{
+		{Name: "no budget", Type: SampleAtMatch, Pattern: "DEBUG"},
+		{Name: "rate too high", Type: SampleAtMatch, Pattern: "DEBUG", SampleRate: 2},
+		// it would be truncated to 0 by the sampler, keeping every line
+		{Name: "rate too low", Type: SampleAtMatch, Pattern: "DEBUG", SampleRate: 0.0000001},
+		{Name: "negative limit", Type: SampleAtMatch, Pattern: "DEBUG", RateLimit: -1},
+		{Name: "no pattern", Type: SampleAtMatch, SampleRate: 0.1},
+		{Name: "unknown group", Type: SampleAtMatch, Pattern: `error=(\w+)`, RateLimit: 10, GroupBy: "sig"},
+		{Name: "group out of range", Type: SampleAtMatch, Pattern: `error=(\w+)`, RateLimit: 10, GroupBy: "2"},
+	}
+	for _, rule := range invalidRules {
+		assert.Error(t, ValidateProcessingRules([]*ProcessingRule{rule}), rule.Name)
+	}
+}
+
+func TestCompileSamplingRule(t *testing.T) {
+	rules := []*ProcessingRule{
+		{Type: SampleAtMatch, Pattern: `error=(?P<sig>\w+)`, RateLimit: 1, GroupBy: "sig"},
+		{Type: SampleAtMatch, Pattern: `error=(\w+)`, RateLimit: 1},
+	}
+	assert.NoError(t, CompileProcessingRules(rules))
+	assert.NotNil(t, rules[0].Regex)
+	assert.Equal(t, 1, rules[0].GroupIndex)
+	assert.Equal(t, -1, rules[1].GroupIndex)
+}
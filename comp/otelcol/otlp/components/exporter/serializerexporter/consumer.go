@@ -79,6 +79,7 @@ type SerializerConsumer interface {
 	Send(s serializer.MetricSerializer) error
 	addRuntimeTelemetryMetric(hostname string, languageTags []string)
 	addTelemetryMetric(hostname string)
+	consumeExponentialHistogram(h exponentialHistogram)
 }
 
 type serializerConsumer struct {
@@ -119,6 +120,30 @@ func (c *serializerConsumer) ConsumeSketch(ctx context.Context, dimensions *otlp
 	})
 }
 
+// consumeExponentialHistogram converts a data point of an OTLP exponential histogram into a
+// sketch, mapping its buckets onto the sketch bins instead of going through the translator.
+func (c *serializerConsumer) consumeExponentialHistogram(h exponentialHistogram) {
+	qsketch, err := h.histogram.Sketch()
+	if err != nil {
+		log.Debugf("Dropping exponential histogram %q: %v", h.name, err)
+		return
+	}
+	if qsketch == nil {
+		return
+	}
+	c.sketches = append(c.sketches, &metrics.SketchSeries{
+		Name:     h.name,
+		Tags:     tagset.CompositeTagsFromSlice(append(append([]string{}, c.extraTags...), h.tags...)),
+		Host:     h.host,
+		Interval: 0, // OTLP metrics do not have an interval.
+		Points: []metrics.SketchPoint{{
+			Ts:     int64(h.timestamp / 1e9),
+			Sketch: qsketch,
+		}},
+		Source: metrics.MetricSourceOpenTelemetryCollectorUnknown,
+	})
+}
+
 func apiTypeFromTranslatorType(typ otlpmetrics.DataType) metrics.APIMetricType {
 	switch typ {
 	case otlpmetrics.Count:
@@ -60,6 +60,13 @@ func (c *collectorConsumer) addRuntimeTelemetryMetric(_ string, languageTags []s
 func (c *collectorConsumer) addTelemetryMetric(_ string) {
 }
 
+func (c *collectorConsumer) consumeExponentialHistogram(h exponentialHistogram) {
+	if h.host != "" {
+		c.seenHosts[h.host] = struct{}{}
+	}
+	c.serializerConsumer.consumeExponentialHistogram(h)
+}
+
 // ConsumeHost implements the metrics.HostConsumer interface.
 func (c *collectorConsumer) ConsumeHost(host string) {
 	c.seenHosts[host] = struct{}{}
@@ -0,0 +1,141 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package serializerexporter
+
+import (
+	"context"
+
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+)
+
+// hostAttributes are the resource attributes holding the host of the exponential histograms,
+// by order of precedence.
+var hostAttributes = []string{"datadog.host.name", "host.name"}
+
+// exponentialHistogram is a data point of an OTLP exponential histogram, converted into the
+// agent exponential histogram type.
+type exponentialHistogram struct {
+	name      string
+	host      string
+	tags      []string
+	timestamp uint64
+	histogram *metrics.ExponentialHistogram
+}
+
+// extractExponentialHistograms returns the data points of the delta exponential histograms of
+// md, and md without them, for the translator to map the rest. md isn't modified: it's copied
+// if it has such data points. The data points without a sum are left to the translator, since
+// the sketches built from the agent type carry an exact sum.
+func extractExponentialHistograms(ctx context.Context, md pmetric.Metrics, hostGetter SourceProviderFunc) (pmetric.Metrics, []exponentialHistogram, error) {
+	if !hasDeltaExponentialHistograms(md) {
+		return md, nil, nil
+	}
+	rest := pmetric.NewMetrics()
+	md.CopyTo(rest)
+
+	var histograms []exponentialHistogram
+	var err error
+	rms := rest.ResourceMetrics()
+	for i := 0; i < rms.Len(); i++ {
+		rm := rms.At(i)
+		host, ok := hostFromResource(rm.Resource())
+		if !ok {
+			if host, err = hostGetter(ctx); err != nil {
+				return md, nil, err
+			}
+		}
+		sms := rm.ScopeMetrics()
+		for j := 0; j < sms.Len(); j++ {
+			sms.At(j).Metrics().RemoveIf(func(m pmetric.Metric) bool {
+				if !isDeltaExponentialHistogram(m) {
+					return false
+				}
+				m.ExponentialHistogram().DataPoints().RemoveIf(func(dp pmetric.ExponentialHistogramDataPoint) bool {
+					if !dp.HasSum() {
+						return false
+					}
+					if !dp.Flags().NoRecordedValue() {
+						histograms = append(histograms, exponentialHistogram{
+							name:      m.Name(),
+							host:      host,
+							tags:      tagsFromAttributes(dp.Attributes()),
+							timestamp: uint64(dp.Timestamp()),
+							histogram: exponentialHistogramFromDataPoint(dp),
+						})
+					}
+					return true
+				})
+				return m.ExponentialHistogram().DataPoints().Len() == 0
+			})
+		}
+	}
+	return rest, histograms, nil
+}
+
+func hasDeltaExponentialHistograms(md pmetric.Metrics) bool {
+	rms := md.ResourceMetrics()
+	for i := 0; i < rms.Len(); i++ {
+		sms := rms.At(i).ScopeMetrics()
+		for j := 0; j < sms.Len(); j++ {
+			ms := sms.At(j).Metrics()
+			for k := 0; k < ms.Len(); k++ {
+				if isDeltaExponentialHistogram(ms.At(k)) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// isDeltaExponentialHistogram returns true for the exponential histograms with the delta
+// aggregation temporality, the cumulative ones are left to the translator.
+func isDeltaExponentialHistogram(m pmetric.Metric) bool {
+	return m.Type() == pmetric.MetricTypeExponentialHistogram &&
+		m.ExponentialHistogram().AggregationTemporality() == pmetric.AggregationTemporalityDelta
+}
+
+func hostFromResource(res pcommon.Resource) (string, bool) {
+	for _, attr := range hostAttributes {
+		if v, ok := res.Attributes().Get(attr); ok && v.Str() != "" {
+			return v.Str(), true
+		}
+	}
+	return "", false
+}
+
+func tagsFromAttributes(attrs pcommon.Map) []string {
+	tags := make([]string, 0, attrs.Len())
+	attrs.Range(func(k string, v pcommon.Value) bool {
+		tags = append(tags, k+":"+v.AsString())
+		return true
+	})
+	return tags
+}
+
+func exponentialHistogramFromDataPoint(dp pmetric.ExponentialHistogramDataPoint) *metrics.ExponentialHistogram {
+	h := &metrics.ExponentialHistogram{
+		Scale:     dp.Scale(),
+		ZeroCount: dp.ZeroCount(),
+		Positive: metrics.ExponentialHistogramBuckets{
+			Offset: dp.Positive().Offset(),
+			Counts: dp.Positive().BucketCounts().AsRaw(),
+		},
+		Negative: metrics.ExponentialHistogramBuckets{
+			Offset: dp.Negative().Offset(),
+			Counts: dp.Negative().BucketCounts().AsRaw(),
+		},
+		Sum:       dp.Sum(),
+		HasMinMax: dp.HasMin() && dp.HasMax(),
+	}
+	if h.HasMinMax {
+		h.Min = dp.Min()
+		h.Max = dp.Max()
+	}
+	return h
+}
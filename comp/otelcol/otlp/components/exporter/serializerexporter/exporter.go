@@ -185,6 +185,13 @@ func (e *Exporter) ConsumeMetrics(ctx context.Context, ld pmetric.Metrics) error
 		}
 	}
 	consumer := e.createConsumer(e.enricher, e.extraTags, e.apmReceiverAddr, e.params.BuildInfo)
+	ld, histograms, err := extractExponentialHistograms(ctx, ld, e.hostGetter)
+	if err != nil {
+		return err
+	}
+	for _, h := range histograms {
+		consumer.consumeExponentialHistogram(h)
+	}
 	rmt, err := e.tr.MapMetrics(ctx, ld, consumer, nil)
 	if err != nil {
 		return err
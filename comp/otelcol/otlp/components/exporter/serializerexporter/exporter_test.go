@@ -383,6 +383,61 @@ func testMetricPrefixWithFeatureGates(t *testing.T, disablePrefix bool, inName s
 	t.Errorf("%s not found in metrics", outName)
 }
 
+func TestConsumeMetricsExponentialHistogram(t *testing.T) {
+	rec := &metricRecorder{}
+	ctx := context.Background()
+	f := NewFactoryForOTelAgent(rec, &MockTagEnricher{}, func(context.Context) (string, error) {
+		return "fallback-host", nil
+	}, nil, nil)
+	cfg := f.CreateDefaultConfig().(*ExporterConfig)
+	cfg.Metrics.Tags = "extra:tag"
+	exp, err := f.CreateMetrics(
+		ctx,
+		exportertest.NewNopSettings(component.MustNewType("datadog")),
+		cfg,
+	)
+	require.NoError(t, err)
+	require.NoError(t, exp.Start(ctx, componenttest.NewNopHost()))
+
+	md := pmetric.NewMetrics()
+	rm := md.ResourceMetrics().AppendEmpty()
+	rm.Resource().Attributes().PutStr("host.name", "otel-host")
+	met := rm.ScopeMetrics().AppendEmpty().Metrics().AppendEmpty()
+	met.SetName("test.exponential")
+	met.SetEmptyExponentialHistogram()
+	met.ExponentialHistogram().SetAggregationTemporality(pmetric.AggregationTemporalityDelta)
+	dp := met.ExponentialHistogram().DataPoints().AppendEmpty()
+	dp.SetTimestamp(1700000000 * 1e9)
+	dp.SetScale(3)
+	dp.SetZeroCount(1)
+	dp.Positive().SetOffset(-2)
+	dp.Positive().BucketCounts().FromRaw([]uint64{2, 0, 3})
+	dp.SetCount(6)
+	dp.SetSum(4.5)
+	dp.SetMin(0)
+	dp.SetMax(1.2)
+	dp.Attributes().PutStr("service", "checkout")
+
+	require.NoError(t, exp.ConsumeMetrics(ctx, md))
+	require.NoError(t, exp.Shutdown(ctx))
+
+	// the data point isn't removed from the metrics given to the exporter
+	assert.Equal(t, 1, md.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(0).ExponentialHistogram().DataPoints().Len())
+
+	require.Len(t, rec.sketchSeriesList, 1)
+	sketchSeries := rec.sketchSeriesList[0]
+	assert.Equal(t, "test.exponential", sketchSeries.Name)
+	assert.Equal(t, "otel-host", sketchSeries.Host)
+	assert.Equal(t, tagset.NewCompositeTags([]string{"extra:tag", "service:checkout"}, nil), sketchSeries.Tags)
+	require.Len(t, sketchSeries.Points, 1)
+	assert.Equal(t, int64(1700000000), sketchSeries.Points[0].Ts)
+	basic := sketchSeries.Points[0].Sketch.Basic
+	assert.Equal(t, int64(6), basic.Cnt)
+	assert.Equal(t, 4.5, basic.Sum)
+	assert.Equal(t, 0.0, basic.Min)
+	assert.Equal(t, 1.2, basic.Max)
+}
+
 func newMetrics(
 	histogramMetricName string,
 	histogramDataPoint pmetric.HistogramDataPoint,
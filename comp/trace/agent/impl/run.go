@@ -74,6 +74,14 @@ func runAgentSidekicks(ag component) error {
 	} else {
 		ag.Agent.DebugServer.AddRoute("/config", ag.config.GetConfigHandler())
 		ag.Agent.DebugServer.AddRoute("/config/set", ag.config.SetHandler())
+		// Triggers a capture of the received trace payloads from the CLI.
+		captureHandler := ag.Agent.Receiver.CaptureHandler()
+		ag.Agent.DebugServer.AddRoute("/capture", http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if apiutil.Validate(w, req) != nil {
+				return
+			}
+			captureHandler.ServeHTTP(w, req)
+		}))
 		// The below endpoint is deprecated and has been replaced with /config/set on the debug server.
 		// It will be removed in a future version.
 		api.AttachEndpoint(api.Endpoint{
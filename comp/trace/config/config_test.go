@@ -13,6 +13,7 @@ import (
 	_ "embed"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"os"
@@ -977,6 +978,136 @@ func TestLoadEnv(t *testing.T) {
 		assert.Equal(t, true, cfg.ErrorTrackingStandalone)
 	})
 
+	env = "DD_APM_TAIL_SAMPLING_ENABLED"
+	t.Run(env, func(t *testing.T) {
+		t.Setenv(env, "true")
+		t.Setenv("DD_APM_TAIL_SAMPLING_DECISION_WAIT", "30s")
+		t.Setenv("DD_APM_TAIL_SAMPLING_MAX_TRACES", "100")
+		t.Setenv("DD_APM_TAIL_SAMPLING_POLICIES", `[{"name":"slow","type":"latency","threshold_ms":250},{"name":"sampled","type":"rate","service":"web","target_tps":5}]`)
+
+		config := buildConfigComponent(t, true, fx.Replace(corecomp.MockParams{
+			Params: corecomp.Params{ConfFilePath: "./testdata/undocumented.yaml"},
+		}))
+		cfg := config.Object()
+
+		assert.NotNil(t, cfg)
+		assert.True(t, cfg.TailSampling.Enabled)
+		assert.Equal(t, 30*time.Second, cfg.TailSampling.DecisionWait)
+		assert.Equal(t, 100, cfg.TailSampling.MaxTraces)
+		assert.Equal(t, 1000000, cfg.TailSampling.MaxSpans)
+		assert.Equal(t, []*traceconfig.TailSamplingPolicy{
+			{Name: "slow", Type: traceconfig.TailSamplingPolicyLatency, LatencyThresholdMs: 250},
+			{Name: "sampled", Type: traceconfig.TailSamplingPolicyRate, Service: "web", TargetTPS: 5},
+		}, cfg.TailSampling.Policies)
+	})
+
+	env = "DD_APM_ROUTE_TEMPLATING_ENABLED"
+	t.Run(env, func(t *testing.T) {
+		t.Setenv(env, "true")
+		t.Setenv("DD_APM_ROUTE_TEMPLATING_CARDINALITY_THRESHOLD", "20")
+
+		config := buildConfigComponent(t, true, fx.Replace(corecomp.MockParams{
+			Params: corecomp.Params{ConfFilePath: "./testdata/undocumented.yaml"},
+		}))
+		cfg := config.Object()
+
+		assert.NotNil(t, cfg)
+		assert.Equal(t, traceconfig.RouteTemplatingConfig{
+			Enabled:              true,
+			CardinalityThreshold: 20,
+			MaxNodes:             5000,
+		}, cfg.RouteTemplating)
+	})
+
+	env = "DD_APM_SPAN_METRICS"
+	t.Run(env, func(t *testing.T) {
+		t.Setenv(env, `[{"name":"checkout.amount","type":"distribution","service":"checkout","value":"cart.amount","group_by":["payment.method"]}]`)
+
+		config := buildConfigComponent(t, true, fx.Replace(corecomp.MockParams{
+			Params: corecomp.Params{ConfFilePath: "./testdata/undocumented.yaml"},
+		}))
+		cfg := config.Object()
+
+		assert.NotNil(t, cfg)
+		assert.Equal(t, []*traceconfig.SpanMetric{
+			{Name: "checkout.amount", Type: traceconfig.SpanMetricDistribution, Service: "checkout", Value: "cart.amount", GroupBy: []string{"payment.method"}},
+		}, cfg.SpanMetrics)
+	})
+
+	env = "DD_APM_SPAN_FILTERS"
+	t.Run(env, func(t *testing.T) {
+		t.Setenv(env, `[{"name":"drop-health","action":"drop_trace","expression":"resource =~ \"^GET /health\""}]`)
+
+		config := buildConfigComponent(t, true, fx.Replace(corecomp.MockParams{
+			Params: corecomp.Params{ConfFilePath: "./testdata/undocumented.yaml"},
+		}))
+		cfg := config.Object()
+
+		assert.NotNil(t, cfg)
+		assert.Equal(t, []*traceconfig.SpanFilterRule{
+			{Name: "drop-health", Action: traceconfig.SpanFilterDropTrace, Expression: `resource =~ "^GET /health"`},
+		}, cfg.SpanFilters)
+	})
+
+	env = "DD_APM_STATS_DIMENSIONS"
+	t.Run(env, func(t *testing.T) {
+		t.Setenv(env, `[{"tag":"tenant","max_cardinality":500},{"tag":"region"}]`)
+
+		config := buildConfigComponent(t, true, fx.Replace(corecomp.MockParams{
+			Params: corecomp.Params{ConfFilePath: "./testdata/undocumented.yaml"},
+		}))
+		cfg := config.Object()
+
+		assert.NotNil(t, cfg)
+		assert.Equal(t, []*traceconfig.StatsDimension{
+			{Tag: "tenant", MaxCardinality: 500},
+			{Tag: "region"},
+		}, cfg.StatsDimensions)
+	})
+
+	env = "DD_APM_NORMALIZATION_MAX_RESOURCE_LEN"
+	t.Run(env, func(t *testing.T) {
+		t.Setenv(env, "20000")
+		t.Setenv("DD_APM_NORMALIZATION_MAX_META_VALUE_LEN", "50000")
+		t.Setenv("DD_APM_NORMALIZATION_KEEP_TRUNCATED_OVERFLOW", "true")
+
+		config := buildConfigComponent(t, true, fx.Replace(corecomp.MockParams{
+			Params: corecomp.Params{ConfFilePath: "./testdata/undocumented.yaml"},
+		}))
+		cfg := config.Object()
+
+		assert.NotNil(t, cfg)
+		assert.Equal(t, 20000, cfg.MaxResourceLen)
+		assert.Equal(t, traceconfig.NormalizationConfig{
+			MaxServiceLen:         100,
+			MaxNameLen:            100,
+			MaxTypeLen:            100,
+			MaxMetaKeyLen:         200,
+			MaxMetaValueLen:       50000,
+			MaxMetricsKeyLen:      200,
+			KeepTruncatedOverflow: true,
+		}, cfg.Normalization)
+	})
+
+	env = "DD_APM_DISK_RETRY_ENABLED"
+	t.Run(env, func(t *testing.T) {
+		t.Setenv(env, "true")
+		t.Setenv("DD_APM_DISK_RETRY_PATH", "/var/lib/datadog/apm-retry")
+		t.Setenv("DD_APM_DISK_RETRY_MAX_SIZE_BYTES", "1048576")
+
+		config := buildConfigComponent(t, true, fx.Replace(corecomp.MockParams{
+			Params: corecomp.Params{ConfFilePath: "./testdata/undocumented.yaml"},
+		}))
+		cfg := config.Object()
+
+		assert.NotNil(t, cfg)
+		assert.Equal(t, traceconfig.DiskRetryConfig{
+			Enabled:      true,
+			Path:         "/var/lib/datadog/apm-retry",
+			MaxSizeBytes: 1048576,
+		}, cfg.DiskRetry)
+	})
+
 	for _, envKey := range []string{
 		"DD_IGNORE_RESOURCE", // deprecated
 		"DD_APM_IGNORE_RESOURCES",
@@ -1573,6 +1704,20 @@ func TestLoadEnv(t *testing.T) {
 		assert.True(t, cfg.Obfuscation.Memcached.Enabled)
 	})
 
+	env = "DD_APM_OBFUSCATION_CQL_ENABLED"
+	t.Run(env, func(t *testing.T) {
+		t.Setenv(env, "false")
+
+		c := buildConfigComponent(t, true, fx.Replace(corecomp.MockParams{
+			Params: corecomp.Params{ConfFilePath: "./testdata/full.yaml"},
+		}))
+		cfg := c.Object()
+
+		assert.NotNil(t, cfg)
+		assert.False(t, cfg.Obfuscation.CQL.Enabled)
+		assert.True(t, cfg.Obfuscation.GraphQL.Enabled)
+	})
+
 	env = "DD_APM_OBFUSCATION_MEMCACHED_KEEP_COMMAND"
 	t.Run(env, func(t *testing.T) {
 		t.Setenv(env, "true")
@@ -2243,6 +2388,130 @@ func TestMockConfig(t *testing.T) {
 	assert.Equal(t, "datadoghq.eu", cfg.Site)
 }
 
+func TestValidateTailSamplingPolicies(t *testing.T) {
+	for _, tt := range []struct {
+		policy *traceconfig.TailSamplingPolicy
+		err    string
+	}{
+		{&traceconfig.TailSamplingPolicy{Name: "errors", Type: traceconfig.TailSamplingPolicyError}, ""},
+		{&traceconfig.TailSamplingPolicy{Type: traceconfig.TailSamplingPolicyError}, `policy 0: all policies must have a "name"`},
+		{&traceconfig.TailSamplingPolicy{Name: "slow", Type: traceconfig.TailSamplingPolicyLatency}, `policy "slow": latency policies must have a positive "threshold_ms"`},
+		{&traceconfig.TailSamplingPolicy{Name: "vip", Type: traceconfig.TailSamplingPolicyAttribute}, `policy "vip": attribute policies must have a "key"`},
+		{&traceconfig.TailSamplingPolicy{Name: "some", Type: traceconfig.TailSamplingPolicyRate, SampleRate: 1.5}, `policy "some": "sample_rate" must be between 0 and 1`},
+		{&traceconfig.TailSamplingPolicy{Name: "some", Type: traceconfig.TailSamplingPolicyRate}, `policy "some": rate policies must have a "sample_rate" or a positive "target_tps"`},
+		{&traceconfig.TailSamplingPolicy{Name: "other", Type: "other"}, `policy "other": unknown type "other"`},
+	} {
+		err := validateTailSamplingPolicies([]*traceconfig.TailSamplingPolicy{tt.policy})
+		if tt.err == "" {
+			assert.NoError(t, err)
+		} else {
+			assert.EqualError(t, err, tt.err)
+		}
+	}
+}
+
+func TestValidateTailSamplingLimits(t *testing.T) {
+	valid := traceconfig.TailSamplingConfig{DecisionWait: 10 * time.Second, MaxTraces: 10, MaxSpans: 100}
+	assert.NoError(t, validateTailSamplingLimits(valid))
+
+	c := valid
+	c.DecisionWait = 0
+	assert.EqualError(t, validateTailSamplingLimits(c), `"decision_wait" must be positive, got 0s`)
+	c = valid
+	c.MaxTraces = -1
+	assert.EqualError(t, validateTailSamplingLimits(c), `"max_traces" must be positive, got -1`)
+	c = valid
+	c.MaxSpans = 0
+	assert.EqualError(t, validateTailSamplingLimits(c), `"max_spans" must be positive, got 0`)
+}
+
+func TestValidateSpanMetrics(t *testing.T) {
+	for _, tt := range []struct {
+		metric *traceconfig.SpanMetric
+		err    string
+	}{
+		{&traceconfig.SpanMetric{Name: "checkout.count", Type: traceconfig.SpanMetricCount}, ""},
+		{&traceconfig.SpanMetric{Name: "checkout.amount", Type: traceconfig.SpanMetricDistribution, Value: "cart.amount"}, ""},
+		{&traceconfig.SpanMetric{Type: traceconfig.SpanMetricCount}, `metric 0: all metrics must have a "name"`},
+		{&traceconfig.SpanMetric{Name: "checkout.count", Type: traceconfig.SpanMetricCount, Value: "duration"}, `metric "checkout.count": counts can't have a "value"`},
+		{&traceconfig.SpanMetric{Name: "checkout.amount", Type: traceconfig.SpanMetricDistribution}, `metric "checkout.amount": distributions must have a "value"`},
+		{&traceconfig.SpanMetric{Name: "checkout.rate", Type: "gauge"}, `metric "checkout.rate": unknown type "gauge"`},
+	} {
+		err := validateSpanMetrics([]*traceconfig.SpanMetric{tt.metric})
+		if tt.err == "" {
+			assert.NoError(t, err)
+		} else {
+			assert.EqualError(t, err, tt.err)
+		}
+	}
+}
+
+func TestValidateSpanFilters(t *testing.T) {
+	for _, tt := range []struct {
+		rule *traceconfig.SpanFilterRule
+		err  string
+	}{
+		{&traceconfig.SpanFilterRule{Name: "drop-health", Action: traceconfig.SpanFilterDropTrace, Expression: `resource == "GET /health"`}, ""},
+		{&traceconfig.SpanFilterRule{Name: "keep-errors", Action: traceconfig.SpanFilterKeepSpan, Expression: `error`}, ""},
+		{&traceconfig.SpanFilterRule{Action: traceconfig.SpanFilterDropSpan, Expression: `error`}, `rule 0: all rules must have a "name"`},
+		{&traceconfig.SpanFilterRule{Name: "sample", Action: "sample", Expression: `error`}, `rule "sample": unknown action "sample"`},
+		{&traceconfig.SpanFilterRule{Name: "drop-health", Action: traceconfig.SpanFilterDropSpan, Expression: `host == "a"`}, `rule "drop-health": invalid expression: unknown field "host" at position 0`},
+	} {
+		err := validateSpanFilters([]*traceconfig.SpanFilterRule{tt.rule})
+		if tt.err == "" {
+			assert.NoError(t, err)
+		} else {
+			assert.EqualError(t, err, tt.err)
+		}
+	}
+}
+
+func TestValidateStatsDimensions(t *testing.T) {
+	tooMany := make([]*traceconfig.StatsDimension, 11)
+	for i := range tooMany {
+		tooMany[i] = &traceconfig.StatsDimension{Tag: fmt.Sprintf("tag%d", i)}
+	}
+	for _, tt := range []struct {
+		dims []*traceconfig.StatsDimension
+		err  string
+	}{
+		{[]*traceconfig.StatsDimension{{Tag: "tenant"}, {Tag: "region", MaxCardinality: 10}}, ""},
+		{[]*traceconfig.StatsDimension{{MaxCardinality: 10}}, `dimension 0: all dimensions must have a "tag"`},
+		{[]*traceconfig.StatsDimension{{Tag: "tenant"}, {Tag: "tenant"}}, `dimension "tenant": duplicate tag`},
+		{[]*traceconfig.StatsDimension{{Tag: "tenant", MaxCardinality: -1}}, `dimension "tenant": "max_cardinality" can't be negative, got -1`},
+		{tooMany, "at most 10 dimensions can be configured, got 11"},
+	} {
+		err := validateStatsDimensions(tt.dims)
+		if tt.err == "" {
+			assert.NoError(t, err)
+		} else {
+			assert.EqualError(t, err, tt.err)
+		}
+	}
+}
+
+func TestValidateNormalization(t *testing.T) {
+	for _, tt := range []struct {
+		set func(c *traceconfig.AgentConfig)
+		err string
+	}{
+		{func(_ *traceconfig.AgentConfig) {}, ""},
+		{func(c *traceconfig.AgentConfig) { c.Normalization.MaxServiceLen = 200 }, ""},
+		{func(c *traceconfig.AgentConfig) { c.Normalization.MaxServiceLen = 201 }, "max_service_len can't be greater than 200, got 201"},
+		{func(c *traceconfig.AgentConfig) { c.MaxResourceLen = 0 }, "max_resource_len must be positive, got 0"},
+		{func(c *traceconfig.AgentConfig) { c.Normalization.MaxMetaValueLen = -1 }, "max_meta_value_len must be positive, got -1"},
+	} {
+		c := traceconfig.New()
+		tt.set(c)
+		err := validateNormalization(c)
+		if tt.err == "" {
+			assert.NoError(t, err)
+		} else {
+			assert.EqualError(t, err, tt.err)
+		}
+	}
+}
+
 func TestMockDefaultConfig(t *testing.T) {
 	config := buildConfigComponent(t, true, fx.Supply(corecomp.Params{}))
 	cfg := config.Object()
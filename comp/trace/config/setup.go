@@ -12,6 +12,7 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"path/filepath"
 	"regexp"
 	"strconv"
 	"strings"
@@ -33,6 +34,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/config/structure"
 	"github.com/DataDog/datadog-agent/pkg/config/utils"
 	"github.com/DataDog/datadog-agent/pkg/trace/config"
+	"github.com/DataDog/datadog-agent/pkg/trace/filters"
 	"github.com/DataDog/datadog-agent/pkg/trace/traceutil"
 	"github.com/DataDog/datadog-agent/pkg/util/fargate"
 	httputils "github.com/DataDog/datadog-agent/pkg/util/http"
@@ -272,6 +274,16 @@ func applyDatadogConfig(c *config.AgentConfig, core corecompcfg.Component) error
 	if core.IsSet("apm_config.peer_tags") {
 		c.PeerTags = core.GetStringSlice("apm_config.peer_tags")
 	}
+	if k := "apm_config.stats_dimensions"; core.IsSet(k) {
+		dims := make([]*config.StatsDimension, 0)
+		if err := structure.UnmarshalKey(core, k, &dims); err != nil {
+			return fmt.Errorf("bad format for %q: %v", k, err)
+		}
+		if err := validateStatsDimensions(dims); err != nil {
+			return fmt.Errorf("stats_dimensions: %s", err)
+		}
+		c.StatsDimensions = dims
+	}
 
 	if core.IsSet("apm_config.extra_sample_rate") {
 		c.ExtraSampleRate = core.GetFloat64("apm_config.extra_sample_rate")
@@ -316,6 +328,64 @@ func applyDatadogConfig(c *config.AgentConfig, core corecompcfg.Component) error
 		c.ErrorTrackingStandalone = core.GetBool("apm_config.error_tracking_standalone.enabled")
 	}
 
+	if core.IsSet("apm_config.tail_sampling.enabled") {
+		c.TailSampling.Enabled = core.GetBool("apm_config.tail_sampling.enabled")
+	}
+	if core.IsSet("apm_config.tail_sampling.decision_wait") {
+		c.TailSampling.DecisionWait = core.GetDuration("apm_config.tail_sampling.decision_wait")
+	}
+	if core.IsSet("apm_config.tail_sampling.max_traces") {
+		c.TailSampling.MaxTraces = core.GetInt("apm_config.tail_sampling.max_traces")
+	}
+	if core.IsSet("apm_config.tail_sampling.max_spans") {
+		c.TailSampling.MaxSpans = core.GetInt("apm_config.tail_sampling.max_spans")
+	}
+	if k := "apm_config.tail_sampling.policies"; core.IsSet(k) {
+		policies := make([]*config.TailSamplingPolicy, 0)
+		if err := structure.UnmarshalKey(core, k, &policies); err != nil {
+			return fmt.Errorf("bad format for %q: %v", k, err)
+		}
+		if err := validateTailSamplingPolicies(policies); err != nil {
+			return fmt.Errorf("tail_sampling: %s", err)
+		}
+		c.TailSampling.Policies = policies
+	}
+	if c.TailSampling.Enabled {
+		if err := validateTailSamplingLimits(c.TailSampling); err != nil {
+			return fmt.Errorf("tail_sampling: %s", err)
+		}
+	}
+	if core.IsSet("apm_config.route_templating.enabled") {
+		c.RouteTemplating.Enabled = core.GetBool("apm_config.route_templating.enabled")
+	}
+	if core.IsSet("apm_config.route_templating.cardinality_threshold") {
+		c.RouteTemplating.CardinalityThreshold = core.GetInt("apm_config.route_templating.cardinality_threshold")
+	}
+	if core.IsSet("apm_config.route_templating.max_nodes") {
+		c.RouteTemplating.MaxNodes = core.GetInt("apm_config.route_templating.max_nodes")
+	}
+	if k := "apm_config.span_metrics"; core.IsSet(k) {
+		metrics := make([]*config.SpanMetric, 0)
+		if err := structure.UnmarshalKey(core, k, &metrics); err != nil {
+			return fmt.Errorf("bad format for %q: %v", k, err)
+		}
+		if err := validateSpanMetrics(metrics); err != nil {
+			return fmt.Errorf("span_metrics: %s", err)
+		}
+		c.SpanMetrics = metrics
+	}
+
+	if k := "apm_config.span_filters"; core.IsSet(k) {
+		rules := make([]*config.SpanFilterRule, 0)
+		if err := structure.UnmarshalKey(core, k, &rules); err != nil {
+			return fmt.Errorf("bad format for %q: %v", k, err)
+		}
+		if err := validateSpanFilters(rules); err != nil {
+			return fmt.Errorf("span_filters: %s", err)
+		}
+		c.SpanFilters = rules
+	}
+
 	if core.IsSet("apm_config.max_remote_traces_per_second") {
 		c.MaxRemoteTPS = core.GetFloat64("apm_config.max_remote_traces_per_second")
 	}
@@ -329,6 +399,17 @@ func applyDatadogConfig(c *config.AgentConfig, core corecompcfg.Component) error
 		}
 		log.Infof("Found APM feature flags: %s", feats)
 	}
+	for _, l := range normalizationLimits(c) {
+		if k := "apm_config.normalization." + l.name; core.IsSet(k) {
+			*l.limit = core.GetInt(k)
+		}
+	}
+	if core.IsSet("apm_config.normalization.keep_truncated_overflow") {
+		c.Normalization.KeepTruncatedOverflow = core.GetBool("apm_config.normalization.keep_truncated_overflow")
+	}
+	if err := validateNormalization(c); err != nil {
+		return fmt.Errorf("normalization: %s", err)
+	}
 
 	if k := "apm_config.ignore_resources"; core.IsSet(k) {
 		c.Ignore["resource"] = core.GetStringSlice(k)
@@ -411,6 +492,15 @@ func applyDatadogConfig(c *config.AgentConfig, core corecompcfg.Component) error
 		AttributesTranslator:       attributesTranslator,
 	}
 
+	c.ZipkinReceiver = config.ZipkinReceiver{
+		Enabled:         core.GetBool("apm_config.zipkin.enabled"),
+		ParseStringTags: core.GetBool("apm_config.zipkin.parse_string_tags"),
+	}
+	c.JaegerReceiver = config.JaegerReceiver{
+		Enabled:  core.GetBool("apm_config.jaeger.enabled"),
+		GRPCPort: core.GetInt("apm_config.jaeger.grpc_port"),
+	}
+
 	if core.IsSet("apm_config.install_id") {
 		c.InstallSignature.Found = true
 		c.InstallSignature.InstallID = core.GetString("apm_config.install_id")
@@ -466,6 +556,8 @@ func applyDatadogConfig(c *config.AgentConfig, core corecompcfg.Component) error
 	c.Obfuscation.Redis.RemoveAllArgs = pkgconfigsetup.Datadog().GetBool("apm_config.obfuscation.redis.remove_all_args")
 	c.Obfuscation.Valkey.Enabled = pkgconfigsetup.Datadog().GetBool("apm_config.obfuscation.valkey.enabled")
 	c.Obfuscation.Valkey.RemoveAllArgs = pkgconfigsetup.Datadog().GetBool("apm_config.obfuscation.valkey.remove_all_args")
+	c.Obfuscation.GraphQL.Enabled = pkgconfigsetup.Datadog().GetBool("apm_config.obfuscation.graphql.enabled")
+	c.Obfuscation.CQL.Enabled = pkgconfigsetup.Datadog().GetBool("apm_config.obfuscation.cql.enabled")
 	c.Obfuscation.CreditCards.Enabled = pkgconfigsetup.Datadog().GetBool("apm_config.obfuscation.credit_cards.enabled")
 	c.Obfuscation.CreditCards.Luhn = pkgconfigsetup.Datadog().GetBool("apm_config.obfuscation.credit_cards.luhn")
 	c.Obfuscation.CreditCards.KeepValues = pkgconfigsetup.Datadog().GetStringSlice("apm_config.obfuscation.credit_cards.keep_values")
@@ -527,6 +619,14 @@ func applyDatadogConfig(c *config.AgentConfig, core corecompcfg.Component) error
 		// Default of 4 was chosen through experimentation, but may not be the optimal value.
 		c.MaxSenderRetries = 4
 	}
+	c.DiskRetry = config.DiskRetryConfig{
+		Enabled:      core.GetBool("apm_config.disk_retry.enabled"),
+		Path:         core.GetString("apm_config.disk_retry.path"),
+		MaxSizeBytes: core.GetInt64("apm_config.disk_retry.max_size_bytes"),
+	}
+	if c.DiskRetry.Path == "" {
+		c.DiskRetry.Path = filepath.Join(core.GetString("run_path"), "apm-retry")
+	}
 	if core.IsSet("apm_config.sync_flushing") {
 		c.SynchronousFlushing = core.GetBool("apm_config.sync_flushing")
 	}
@@ -718,6 +818,156 @@ func compileReplaceRules(rules []*config.ReplaceRule) error {
 	return nil
 }
 
+// validateTailSamplingLimits checks that the decision window and the memory bounds of the tail
+// sampler are positive. If it fails it returns the first error.
+func validateTailSamplingLimits(c config.TailSamplingConfig) error {
+	if c.DecisionWait <= 0 {
+		return fmt.Errorf("\"decision_wait\" must be positive, got %s", c.DecisionWait)
+	}
+	if c.MaxTraces <= 0 {
+		return fmt.Errorf("\"max_traces\" must be positive, got %d", c.MaxTraces)
+	}
+	if c.MaxSpans <= 0 {
+		return fmt.Errorf("\"max_spans\" must be positive, got %d", c.MaxSpans)
+	}
+	return nil
+}
+
+// validateTailSamplingPolicies checks that the tail sampling policies have a name and the
+// settings required by their type. If it fails it returns the first error.
+func validateTailSamplingPolicies(policies []*config.TailSamplingPolicy) error {
+	for i, p := range policies {
+		if p.Name == "" {
+			return fmt.Errorf("policy %d: all policies must have a \"name\"", i)
+		}
+		switch p.Type {
+		case config.TailSamplingPolicyLatency:
+			if p.LatencyThresholdMs <= 0 {
+				return fmt.Errorf("policy %q: latency policies must have a positive \"threshold_ms\"", p.Name)
+			}
+		case config.TailSamplingPolicyError:
+		case config.TailSamplingPolicyAttribute:
+			if p.Key == "" {
+				return fmt.Errorf("policy %q: attribute policies must have a \"key\"", p.Name)
+			}
+		case config.TailSamplingPolicyRate:
+			if p.SampleRate < 0 || p.SampleRate > 1 {
+				return fmt.Errorf("policy %q: \"sample_rate\" must be between 0 and 1", p.Name)
+			}
+			if p.SampleRate == 0 && p.TargetTPS <= 0 {
+				return fmt.Errorf("policy %q: rate policies must have a \"sample_rate\" or a positive \"target_tps\"", p.Name)
+			}
+		default:
+			return fmt.Errorf("policy %q: unknown type %q", p.Name, p.Type)
+		}
+	}
+	return nil
+}
+
+// validateSpanMetrics checks that the span-derived metrics have a name and a type, and that
+// only distributions have a value. If it fails it returns the first error.
+func validateSpanMetrics(metrics []*config.SpanMetric) error {
+	for i, m := range metrics {
+		if m.Name == "" {
+			return fmt.Errorf("metric %d: all metrics must have a \"name\"", i)
+		}
+		switch m.Type {
+		case config.SpanMetricCount:
+			if m.Value != "" {
+				return fmt.Errorf("metric %q: counts can't have a \"value\"", m.Name)
+			}
+		case config.SpanMetricDistribution:
+			if m.Value == "" {
+				return fmt.Errorf("metric %q: distributions must have a \"value\"", m.Name)
+			}
+		default:
+			return fmt.Errorf("metric %q: unknown type %q", m.Name, m.Type)
+		}
+	}
+	return nil
+}
+
+// validateSpanFilters checks that the span filtering rules have a name, a known action and a
+// valid expression. If it fails it returns the first error.
+func validateSpanFilters(rules []*config.SpanFilterRule) error {
+	for i, r := range rules {
+		if r.Name == "" {
+			return fmt.Errorf("rule %d: all rules must have a \"name\"", i)
+		}
+		switch r.Action {
+		case config.SpanFilterDropSpan, config.SpanFilterKeepSpan, config.SpanFilterDropTrace, config.SpanFilterKeepTrace:
+		default:
+			return fmt.Errorf("rule %q: unknown action %q", r.Name, r.Action)
+		}
+		if _, err := filters.ParseExpression(r.Expression); err != nil {
+			return fmt.Errorf("rule %q: invalid expression: %v", r.Name, err)
+		}
+	}
+	return nil
+}
+
+// maxStatsDimensions is the maximum number of extra dimensions of the aggregation of stats.
+const maxStatsDimensions = 10
+
+// validateStatsDimensions checks that the stats dimensions have a distinct tag and a
+// non-negative maximum cardinality. If it fails it returns the first error.
+func validateStatsDimensions(dims []*config.StatsDimension) error {
+	if len(dims) > maxStatsDimensions {
+		return fmt.Errorf("at most %d dimensions can be configured, got %d", maxStatsDimensions, len(dims))
+	}
+	seen := make(map[string]struct{}, len(dims))
+	for i, d := range dims {
+		if d.Tag == "" {
+			return fmt.Errorf("dimension %d: all dimensions must have a \"tag\"", i)
+		}
+		if _, ok := seen[d.Tag]; ok {
+			return fmt.Errorf("dimension %q: duplicate tag", d.Tag)
+		}
+		seen[d.Tag] = struct{}{}
+		if d.MaxCardinality < 0 {
+			return fmt.Errorf("dimension %q: \"max_cardinality\" can't be negative, got %d", d.Tag, d.MaxCardinality)
+		}
+	}
+	return nil
+}
+
+// normalizationLimit is a maximum length applied when normalizing spans, along with the name
+// of its setting in apm_config.normalization.
+type normalizationLimit struct {
+	name  string
+	limit *int
+}
+
+// normalizationLimits returns the maximum lengths applied when normalizing spans with c.
+func normalizationLimits(c *config.AgentConfig) []normalizationLimit {
+	return []normalizationLimit{
+		{"max_service_len", &c.Normalization.MaxServiceLen},
+		{"max_name_len", &c.Normalization.MaxNameLen},
+		{"max_resource_len", &c.MaxResourceLen},
+		{"max_type_len", &c.Normalization.MaxTypeLen},
+		{"max_meta_key_len", &c.Normalization.MaxMetaKeyLen},
+		{"max_meta_value_len", &c.Normalization.MaxMetaValueLen},
+		{"max_metrics_key_len", &c.Normalization.MaxMetricsKeyLen},
+	}
+}
+
+// maxServiceLen is the maximum length of services, which are normalized as tag values.
+const maxServiceLen = 200
+
+// validateNormalization checks that the maximum lengths applied when normalizing spans are
+// positive. If it fails it returns the first error.
+func validateNormalization(c *config.AgentConfig) error {
+	for _, l := range normalizationLimits(c) {
+		if *l.limit <= 0 {
+			return fmt.Errorf("%s must be positive, got %d", l.name, *l.limit)
+		}
+	}
+	if c.Normalization.MaxServiceLen > maxServiceLen {
+		return fmt.Errorf("max_service_len can't be greater than %d, got %d", maxServiceLen, c.Normalization.MaxServiceLen)
+	}
+	return nil
+}
+
 // getDuration returns the duration of the provided value in seconds
 func getDuration(seconds int) time.Duration {
 	return time.Duration(seconds) * time.Second
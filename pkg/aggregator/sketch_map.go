@@ -6,6 +6,7 @@
 package aggregator
 
 import (
+	"errors"
 	"math"
 
 	"github.com/DataDog/datadog-agent/pkg/aggregator/ckey"
@@ -15,6 +16,8 @@ import (
 
 type sketchMap map[int64]map[ckey.ContextKey]*quantile.Agent
 
+var sketchConfig = quantile.Default()
+
 // Len returns the number of sketches stored
 func (m sketchMap) Len() int {
 	l := 0
@@ -48,6 +51,21 @@ func (m sketchMap) insertInterp(ts int64, ck ckey.ContextKey, lower float64, upp
 	return true
 }
 
+// insertExponential merges an exponential histogram into the sketch for the given (ts, contextKey)
+// NOTE: ts is truncated to bucketSize
+func (m sketchMap) insertExponential(ts int64, ck ckey.ContextKey, h *metrics.ExponentialHistogram) error {
+	if h == nil {
+		return errors.New("missing exponential histogram buckets")
+	}
+	sketch, err := h.Sketch()
+	if err != nil || sketch == nil {
+		return err
+	}
+
+	m.getOrCreate(ts, ck).Sketch.Merge(sketchConfig, sketch)
+	return nil
+}
+
 func (m sketchMap) getOrCreate(ts int64, ck ckey.ContextKey) *quantile.Agent {
 	// level 1: ts -> ctx
 	byCtx, ok := m[ts]
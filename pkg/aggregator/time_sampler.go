@@ -90,6 +90,10 @@ func (s *TimeSampler) sample(metricSample *metrics.MetricSample, timestamp float
 	switch metricSample.Mtype {
 	case metrics.DistributionType:
 		s.sketchMap.insert(bucketStart, contextKey, metricSample.Value, metricSample.SampleRate)
+	case metrics.ExponentialHistogramType:
+		if err := s.sketchMap.insertExponential(bucketStart, contextKey, metricSample.ExponentialHistogram); err != nil {
+			log.Debugf("TimeSampler #%d Ignoring sample '%s' on host '%s' and tags '%s': %s", s.id, metricSample.Name, metricSample.Host, metricSample.Tags, err)
+		}
 	default:
 		// If it's a new bucket, initialize it
 		bucketMetrics, ok := s.metricsByTimestamp[bucketStart]
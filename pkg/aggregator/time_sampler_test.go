@@ -393,6 +393,63 @@ func TestSketchBucketSampling(t *testing.T) {
 	testWithTagsStore(t, testSketchBucketSampling)
 }
 
+func testExponentialHistogramSampling(t *testing.T, store *tags.Store) {
+	sampler := testTimeSampler(store)
+
+	h1 := &metrics.ExponentialHistogram{
+		Scale:     2,
+		ZeroCount: 1,
+		Positive:  metrics.ExponentialHistogramBuckets{Offset: 3, Counts: []uint64{2, 0, 1}},
+		Sum:       6,
+	}
+	h2 := &metrics.ExponentialHistogram{
+		Scale:    0,
+		Negative: metrics.ExponentialHistogramBuckets{Offset: 1, Counts: []uint64{3}},
+		Sum:      -9,
+	}
+	mSample1 := metrics.MetricSample{
+		Name:                 "test.metric.name",
+		Mtype:                metrics.ExponentialHistogramType,
+		ExponentialHistogram: h1,
+		Tags:                 []string{"a", "b"},
+		SampleRate:           1,
+	}
+	mSample2 := mSample1
+	mSample2.ExponentialHistogram = h2
+	// samples without buckets are ignored
+	mSample3 := mSample1
+	mSample3.ExponentialHistogram = nil
+
+	sampler.sample(&mSample1, 10001)
+	sampler.sample(&mSample2, 10002)
+	sampler.sample(&mSample3, 10003)
+	sampler.sample(&mSample1, 10011)
+
+	_, flushed := flushSerie(sampler, 10020.0)
+	sketch1, err := h1.Sketch()
+	require.NoError(t, err)
+	sketch2, err := h2.Sketch()
+	require.NoError(t, err)
+	expSketch := &quantile.Sketch{}
+	expSketch.Merge(quantile.Default(), sketch1)
+	expSketch.Merge(quantile.Default(), sketch2)
+
+	assert.Equal(t, 1, len(flushed))
+	metrics.AssertSketchSeriesEqual(t, &metrics.SketchSeries{
+		Name:     "test.metric.name",
+		Tags:     tagset.CompositeTagsFromSlice([]string{"a", "b"}),
+		Interval: 10,
+		Points: []metrics.SketchPoint{
+			{Ts: 10000, Sketch: expSketch},
+			{Ts: 10010, Sketch: sketch1},
+		},
+		ContextKey: generateContextKey(&mSample1),
+	}, flushed[0])
+}
+func TestExponentialHistogramSampling(t *testing.T) {
+	testWithTagsStore(t, testExponentialHistogramSampling)
+}
+
 func testSketchContextSampling(t *testing.T, store *tags.Store) {
 	sampler := testTimeSampler(store)
 
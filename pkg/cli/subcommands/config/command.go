@@ -10,6 +10,8 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"os"
+	"strings"
 
 	"go.uber.org/fx"
 
@@ -31,6 +33,9 @@ type cliParams struct {
 	// source enables detailed information about each source and its value
 	source bool
 
+	// diff compares two configuration dumps instead of querying the agent
+	diff bool
+
 	// args are the positional command line args
 	args []string
 }
@@ -76,6 +81,14 @@ func MakeCommand(globalParamsGetter func() GlobalParams) *cobra.Command {
 		RunE:  oneShotRunE(showRuntimeConfiguration),
 	}
 
+	bySourceCmd := &cobra.Command{
+		Use:   "by-source",
+		Short: "Show the runtime configuration by source (ie: default, config file, env vars, ...)",
+		Long:  ``,
+		RunE:  oneShotRunE(showRuntimeConfigurationBySource),
+	}
+	cmd.AddCommand(bySourceCmd)
+
 	listRuntimeCmd := &cobra.Command{
 		Use:   "list-runtime",
 		Short: "List settings that can be changed at runtime",
@@ -101,6 +114,20 @@ func MakeCommand(globalParamsGetter func() GlobalParams) *cobra.Command {
 	cmd.AddCommand(getCmd)
 	getCmd.Flags().BoolVarP(&cliParams.source, "source", "s", false, "print every source and its value")
 
+	explainCmd := &cobra.Command{
+		Use:   "explain [setting]",
+		Short: "Explain where the value of any configuration setting comes from",
+		Long: `Print the value of a configuration setting in every source (defaults, configuration files, environment
+variables, remote configuration, ...), which one is in use, where it was read from and whether it was resolved
+from a secret.
+
+With --diff, compare two configurations dumped with 'config by-source' instead, for instance from two agents:
+  config explain --diff agent1.json agent2.json [setting]`,
+		RunE: oneShotRunE(explainConfigValue),
+	}
+	cmd.AddCommand(explainCmd)
+	explainCmd.Flags().BoolVarP(&cliParams.diff, "diff", "d", false, "compare two configuration dumps, optionally only for the settings under the given one")
+
 	otelCmd := &cobra.Command{
 		Use:   "otel-agent",
 		Short: "Otel-agent, prints out the read-only runtime configs of otel-agent if otel-agent is present and converter is enabled",
@@ -133,6 +160,27 @@ func showRuntimeConfiguration(_ log.Component, config config.Component, cliParam
 	return nil
 }
 
+func showRuntimeConfigurationBySource(_ log.Component, config config.Component, cliParams *cliParams) error {
+	err := util.SetAuthToken(config)
+	if err != nil {
+		return err
+	}
+
+	c, err := cliParams.GlobalParams.SettingsClient()
+	if err != nil {
+		return err
+	}
+
+	runtimeConfig, err := c.FullConfigBySource()
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(runtimeConfig)
+
+	return nil
+}
+
 func listRuntimeConfigurableValue(_ log.Component, config config.Component, cliParams *cliParams) error {
 	err := util.SetAuthToken(config)
 	if err != nil {
@@ -229,6 +277,62 @@ func getConfigValue(_ log.Component, config config.Component, cliParams *cliPara
 	return nil
 }
 
+func explainConfigValue(_ log.Component, config config.Component, cliParams *cliParams) error {
+	if cliParams.diff {
+		return diffConfigValues(cliParams)
+	}
+
+	if len(cliParams.args) != 1 {
+		return fmt.Errorf("a single setting name must be specified")
+	}
+
+	err := util.SetAuthToken(config)
+	if err != nil {
+		return err
+	}
+
+	c, err := cliParams.GlobalParams.SettingsClient()
+	if err != nil {
+		return err
+	}
+
+	explanation, err := c.Explain(cliParams.args[0])
+	if err != nil {
+		return err
+	}
+
+	printExplanation(os.Stdout, explanation)
+
+	return nil
+}
+
+func diffConfigValues(cliParams *cliParams) error {
+	if len(cliParams.args) != 2 && len(cliParams.args) != 3 {
+		return fmt.Errorf("two configuration dumps, and optionally a setting name, must be specified")
+	}
+
+	before, err := loadConfigDump(cliParams.args[0])
+	if err != nil {
+		return err
+	}
+	after, err := loadConfigDump(cliParams.args[1])
+	if err != nil {
+		return err
+	}
+
+	prefix := ""
+	if len(cliParams.args) == 3 {
+		prefix = strings.ToLower(cliParams.args[2])
+	}
+
+	fmt.Printf("--- %s\n+++ %s\n", cliParams.args[0], cliParams.args[1])
+	if diffConfigDumps(os.Stdout, before, after, prefix) == 0 {
+		fmt.Println("No difference found")
+	}
+
+	return nil
+}
+
 func otelAgentCfg(_ log.Component, config config.Component, cliParams *cliParams) error {
 	if !config.GetBool("otelcollector.enabled") {
 		return errors.New("otel-agent is not enabled")
@@ -83,3 +83,55 @@ func TestConfigGetCommand(t *testing.T) {
 			require.Equal(t, false, secretParams.Enabled)
 		})
 }
+
+func TestConfigBySourceCommand(t *testing.T) {
+	commands := []*cobra.Command{
+		MakeCommand(func() GlobalParams {
+			return GlobalParams{}
+		}),
+	}
+
+	fxutil.TestOneShotSubcommand(t,
+		commands,
+		[]string{"config", "by-source"},
+		showRuntimeConfigurationBySource,
+		func(cliParams *cliParams, _ core.BundleParams, secretParams secrets.Params) {
+			require.Equal(t, []string{}, cliParams.args)
+			require.Equal(t, false, secretParams.Enabled)
+		})
+}
+
+func TestConfigExplainCommand(t *testing.T) {
+	commands := []*cobra.Command{
+		MakeCommand(func() GlobalParams {
+			return GlobalParams{}
+		}),
+	}
+
+	fxutil.TestOneShotSubcommand(t,
+		commands,
+		[]string{"config", "explain", "foo"},
+		explainConfigValue,
+		func(cliParams *cliParams, _ core.BundleParams, secretParams secrets.Params) {
+			require.Equal(t, []string{"foo"}, cliParams.args)
+			require.Equal(t, false, cliParams.diff)
+			require.Equal(t, false, secretParams.Enabled)
+		})
+}
+
+func TestConfigExplainDiffCommand(t *testing.T) {
+	commands := []*cobra.Command{
+		MakeCommand(func() GlobalParams {
+			return GlobalParams{}
+		}),
+	}
+
+	fxutil.TestOneShotSubcommand(t,
+		commands,
+		[]string{"config", "explain", "--diff", "agent1.json", "agent2.json"},
+		explainConfigValue,
+		func(cliParams *cliParams, _ core.BundleParams, _ secrets.Params) {
+			require.Equal(t, []string{"agent1.json", "agent2.json"}, cliParams.args)
+			require.Equal(t, true, cliParams.diff)
+		})
+}
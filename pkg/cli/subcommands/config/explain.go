@@ -0,0 +1,154 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/DataDog/datadog-agent/comp/core/settings"
+	"github.com/DataDog/datadog-agent/pkg/config/model"
+)
+
+// printExplanation prints the value of a setting in every source, flagging the one in use.
+func printExplanation(w io.Writer, explanation settings.SettingExplanation) {
+	fmt.Fprintf(w, "%s is set to: %s (from %s)\n", explanation.Setting, formatValue(explanation.Value), explanation.Source)
+	fmt.Fprintf(w, "sources and their value, from the lowest priority to the highest:\n")
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, layer := range explanation.Layers {
+		marker := " "
+		if layer.Source == explanation.Source {
+			marker = "*"
+		}
+		details := []string{}
+		if layer.Origin != "" {
+			details = append(details, layer.Origin)
+		}
+		if layer.Secret != "" {
+			details = append(details, fmt.Sprintf("resolved from secret '%s'", layer.Secret))
+		}
+		fmt.Fprintf(tw, "%s %s:\t%s", marker, layer.Source, formatValue(layer.Value))
+		if len(details) > 0 {
+			fmt.Fprintf(tw, "\t(%s)", strings.Join(details, ", "))
+		}
+		fmt.Fprintln(tw)
+	}
+	_ = tw.Flush()
+}
+
+// formatValue formats a setting value, making empty strings visible.
+func formatValue(value interface{}) string {
+	if value == "" {
+		return `""`
+	}
+	return fmt.Sprintf("%v", value)
+}
+
+// configDump is the configuration of an agent, as printed by 'config by-source', with the settings of each
+// source flattened to their full name.
+type configDump map[model.Source]map[string]interface{}
+
+func loadConfigDump(path string) (configDump, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	bySource := map[string]interface{}{}
+	if err := json.Unmarshal(data, &bySource); err != nil {
+		return nil, fmt.Errorf("could not parse configuration dump %s: %v", path, err)
+	}
+
+	dump := configDump{}
+	for _, source := range model.Sources {
+		dump[source] = map[string]interface{}{}
+		if tree, ok := bySource[string(source)].(map[string]interface{}); ok {
+			flattenSettings("", tree, dump[source])
+		}
+	}
+	return dump, nil
+}
+
+func flattenSettings(prefix string, tree map[string]interface{}, out map[string]interface{}) {
+	for key, value := range tree {
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		if subtree, ok := value.(map[string]interface{}); ok && len(subtree) > 0 {
+			flattenSettings(key, subtree, out)
+			continue
+		}
+		if value != nil {
+			out[key] = value
+		}
+	}
+}
+
+// effectiveValue returns the value in use for the setting, and the source it comes from.
+func (d configDump) effectiveValue(setting string) string {
+	for i := len(model.Sources) - 1; i >= 0; i-- {
+		if value, found := d[model.Sources[i]][setting]; found {
+			return fmt.Sprintf("%s (from %s)", formatValue(value), model.Sources[i])
+		}
+	}
+	return "<not set>"
+}
+
+func formatDumpValue(value interface{}, found bool) string {
+	if !found {
+		return "<not set>"
+	}
+	return formatValue(value)
+}
+
+// diffConfigDumps prints the settings whose value differs in any source between two configuration dumps. If
+// prefix isn't empty, only the settings under it are compared.
+func diffConfigDumps(w io.Writer, before, after configDump, prefix string) int {
+	keys := map[string]struct{}{}
+	for _, dump := range []configDump{before, after} {
+		for _, settings := range dump {
+			for key := range settings {
+				if prefix == "" || key == prefix || strings.HasPrefix(key, prefix+".") {
+					keys[key] = struct{}{}
+				}
+			}
+		}
+	}
+	sortedKeys := make([]string, 0, len(keys))
+	for key := range keys {
+		sortedKeys = append(sortedKeys, key)
+	}
+	sort.Strings(sortedKeys)
+
+	differences := 0
+	for _, key := range sortedKeys {
+		lines := []string{}
+		for _, source := range model.Sources {
+			valueBefore, foundBefore := before[source][key]
+			valueAfter, foundAfter := after[source][key]
+			if foundBefore == foundAfter && reflect.DeepEqual(valueBefore, valueAfter) {
+				continue
+			}
+			lines = append(lines, fmt.Sprintf("  %s: %s -> %s", source, formatDumpValue(valueBefore, foundBefore), formatDumpValue(valueAfter, foundAfter)))
+		}
+		if len(lines) == 0 {
+			continue
+		}
+		differences++
+
+		fmt.Fprintf(w, "%s: %s -> %s\n", key, before.effectiveValue(key), after.effectiveValue(key))
+		for _, line := range lines {
+			fmt.Fprintln(w, line)
+		}
+	}
+	return differences
+}
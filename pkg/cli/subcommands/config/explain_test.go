@@ -0,0 +1,87 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package config
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/comp/core/settings"
+	"github.com/DataDog/datadog-agent/pkg/config/model"
+)
+
+func TestPrintExplanation(t *testing.T) {
+	var buffer bytes.Buffer
+	printExplanation(&buffer, settings.SettingExplanation{
+		Setting: "hostname",
+		Value:   "********",
+		Source:  model.SourceAgentRuntime,
+		Layers: []settings.SettingLayer{
+			{Source: model.SourceDefault, Value: ""},
+			{Source: model.SourceFile, Value: "ENC[hostname]", Origin: "/etc/datadog-agent/datadog.yaml:3"},
+			{Source: model.SourceEnvVar, Value: "ENC[hostname]", Origin: "DD_HOSTNAME"},
+			{Source: model.SourceAgentRuntime, Value: "********", Secret: "hostname"},
+		},
+	})
+
+	assert.Equal(t, `hostname is set to: ******** (from agent-runtime)
+sources and their value, from the lowest priority to the highest:
+  default:               ""
+  file:                  ENC[hostname]  (/etc/datadog-agent/datadog.yaml:3)
+  environment-variable:  ENC[hostname]  (DD_HOSTNAME)
+* agent-runtime:         ********       (resolved from secret 'hostname')
+`, buffer.String())
+}
+
+func TestDiffConfigDumps(t *testing.T) {
+	dir := t.TempDir()
+	writeDump := func(name string, content string) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+		return path
+	}
+
+	before, err := loadConfigDump(writeDump("before.json", `{
+		"default": {"log_level": "info", "logs_config": {"batch_wait": 5, "use_compression": true}, "tags": []},
+		"file": {"logs_config": {"batch_wait": 10}, "tags": ["env:prod"]},
+		"environment-variable": {},
+		"provided": {"log_level": "info"}
+	}`))
+	require.NoError(t, err)
+	after, err := loadConfigDump(writeDump("after.json", `{
+		"default": {"log_level": "info", "logs_config": {"batch_wait": 5, "use_compression": true}, "tags": []},
+		"file": {"logs_config": {"batch_wait": 10}, "tags": ["env:prod"]},
+		"environment-variable": {"logs_config": {"batch_wait": 15}},
+		"remote-config": {"log_level": "debug"}
+	}`))
+	require.NoError(t, err)
+
+	var buffer bytes.Buffer
+	assert.Equal(t, 2, diffConfigDumps(&buffer, before, after, ""))
+	assert.Equal(t, `log_level: info (from default) -> debug (from remote-config)
+  remote-config: <not set> -> debug
+logs_config.batch_wait: 10 (from file) -> 15 (from environment-variable)
+  environment-variable: <not set> -> 15
+`, buffer.String())
+
+	buffer.Reset()
+	assert.Equal(t, 1, diffConfigDumps(&buffer, before, after, "logs_config"))
+	assert.Equal(t, `logs_config.batch_wait: 10 (from file) -> 15 (from environment-variable)
+  environment-variable: <not set> -> 15
+`, buffer.String())
+
+	buffer.Reset()
+	assert.Equal(t, 0, diffConfigDumps(&buffer, before, before, ""))
+	assert.Empty(t, buffer.String())
+
+	_, err = loadConfigDump(writeDump("invalid.json", "log_level: info"))
+	assert.ErrorContains(t, err, "could not parse configuration dump")
+}
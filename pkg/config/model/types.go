@@ -182,6 +182,8 @@ type Reader interface {
 	// GetEnvVars returns a list of the env vars that the config supports.
 	// These have had the EnvPrefix applied, as well as the EnvKeyReplacer.
 	GetEnvVars() []string
+	// GetEnvVarsForKey returns the env vars that are bound to the given key.
+	GetEnvVarsForKey(key string) []string
 
 	// Warnings returns pointer to a list of warnings (completes config.Component interface)
 	Warnings() *Warnings
@@ -9,4 +9,7 @@ package model
 type Warnings struct {
 	TraceMallocEnabledWithPy2 bool
 	Err                       error
+	// ValidationErrors are the errors found by the validation of the configuration when it was loaded, the
+	// blocking ones prevent the agent from starting when 'strict_config_validation' is enabled.
+	ValidationErrors []error
 }
@@ -386,6 +386,19 @@ func TestEnvVarMultipleSettings(t *testing.T) {
 	assert.Equal(t, 0, cfg.GetInt("c"))
 }
 
+func TestGetEnvVarsForKey(t *testing.T) {
+	cfg := NewConfig("test", "TEST", strings.NewReplacer(".", "_")) // nolint: forbidigo
+	cfg.BindEnv("a", "TEST_MY_ENVVAR", "TEST_OTHER_ENVVAR")
+	cfg.BindEnv("b", "TEST_MY_ENVVAR")
+	cfg.BindEnv("c.d")
+	cfg.BuildSchema()
+
+	assert.Equal(t, []string{"TEST_MY_ENVVAR", "TEST_OTHER_ENVVAR"}, cfg.GetEnvVarsForKey("a"))
+	assert.Equal(t, []string{"TEST_MY_ENVVAR"}, cfg.GetEnvVarsForKey("b"))
+	assert.Equal(t, []string{"TEST_C_D"}, cfg.GetEnvVarsForKey("c.d"))
+	assert.Equal(t, []string{}, cfg.GetEnvVarsForKey("unknown"))
+}
+
 func TestAllKeysLowercased(t *testing.T) {
 	cfg := NewConfig("test", "TEST", nil)
 	cfg.SetDefault("a", 0)
@@ -8,6 +8,7 @@ package nodetreemodel
 import (
 	"maps"
 	"slices"
+	"strings"
 	"time"
 
 	"github.com/DataDog/datadog-agent/pkg/config/model"
@@ -55,6 +56,21 @@ func (c *ntmConfig) GetEnvVars() []string {
 	return vars
 }
 
+// GetEnvVarsForKey returns the environment variables bound to the given key
+func (c *ntmConfig) GetEnvVarsForKey(key string) []string {
+	c.RLock()
+	defer c.RUnlock()
+	key = strings.ToLower(key)
+	vars := []string{}
+	for v, keys := range c.configEnvVars {
+		if slices.Contains(keys, key) {
+			vars = append(vars, v)
+		}
+	}
+	slices.Sort(vars)
+	return vars
+}
+
 // GetProxies returns the proxy settings from the configuration
 func (c *ntmConfig) GetProxies() *model.Proxy {
 	c.Lock()
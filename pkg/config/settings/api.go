@@ -16,6 +16,7 @@ import (
 type Client interface {
 	Get(key string) (interface{}, error)
 	GetWithSources(key string) (map[string]interface{}, error)
+	Explain(key string) (settings.SettingExplanation, error)
 	Set(key string, value string) (bool, error)
 	List() (map[string]settings.RuntimeSettingResponse, error)
 	FullConfig() (string, error)
@@ -118,6 +118,17 @@ func (rc *runtimeSettingsHTTPClient) GetWithSources(key string) (map[string]inte
 	return setting, nil
 }
 
+func (rc *runtimeSettingsHTTPClient) Explain(key string) (settingsComponent.SettingExplanation, error) {
+	var explanation settingsComponent.SettingExplanation
+	r, err := rc.doGet(fmt.Sprintf("%s/explain/%s", rc.baseURL, key), false)
+	if err != nil {
+		return explanation, err
+	}
+
+	err = json.Unmarshal([]byte(r), &explanation)
+	return explanation, err
+}
+
 func (rc *runtimeSettingsHTTPClient) Set(key string, value string) (bool, error) {
 	settingsList, err := rc.List()
 	if err != nil {
@@ -45,6 +45,8 @@ func setupAPM(config pkgconfigmodel.Setup) {
 	config.BindEnvAndSetDefault("apm_config.obfuscation.valkey.remove_all_args", false, "DD_APM_OBFUSCATION_VALKEY_REMOVE_ALL_ARGS")
 	config.BindEnvAndSetDefault("apm_config.obfuscation.memcached.enabled", true, "DD_APM_OBFUSCATION_MEMCACHED_ENABLED")
 	config.BindEnvAndSetDefault("apm_config.obfuscation.memcached.keep_command", false, "DD_APM_OBFUSCATION_MEMCACHED_KEEP_COMMAND")
+	config.BindEnvAndSetDefault("apm_config.obfuscation.graphql.enabled", true, "DD_APM_OBFUSCATION_GRAPHQL_ENABLED")
+	config.BindEnvAndSetDefault("apm_config.obfuscation.cql.enabled", true, "DD_APM_OBFUSCATION_CQL_ENABLED")
 	config.BindEnvAndSetDefault("apm_config.obfuscation.cache.enabled", true, "DD_APM_OBFUSCATION_CACHE_ENABLED")
 	config.BindEnvAndSetDefault("apm_config.obfuscation.cache.max_size", 5000000, "DD_APM_OBFUSCATION_CACHE_MAX_SIZE")
 	config.SetKnown("apm_config.filter_tags.require")
@@ -137,6 +139,45 @@ func setupAPM(config pkgconfigmodel.Setup) {
 	config.BindEnv("apm_config.probabilistic_sampler.sampling_percentage", "DD_APM_PROBABILISTIC_SAMPLER_SAMPLING_PERCENTAGE")
 	config.BindEnv("apm_config.probabilistic_sampler.hash_seed", "DD_APM_PROBABILISTIC_SAMPLER_HASH_SEED")
 	config.BindEnvAndSetDefault("apm_config.error_tracking_standalone.enabled", false, "DD_APM_ERROR_TRACKING_STANDALONE_ENABLED")
+	config.BindEnv("apm_config.tail_sampling.enabled", "DD_APM_TAIL_SAMPLING_ENABLED")
+	config.BindEnv("apm_config.tail_sampling.decision_wait", "DD_APM_TAIL_SAMPLING_DECISION_WAIT")
+	config.BindEnv("apm_config.tail_sampling.max_traces", "DD_APM_TAIL_SAMPLING_MAX_TRACES")
+	config.BindEnv("apm_config.tail_sampling.max_spans", "DD_APM_TAIL_SAMPLING_MAX_SPANS")
+	config.BindEnv("apm_config.tail_sampling.policies", "DD_APM_TAIL_SAMPLING_POLICIES")
+	config.ParseEnvAsSlice("apm_config.tail_sampling.policies", func(in string) []interface{} {
+		var policies []interface{}
+		if err := json.Unmarshal([]byte(in), &policies); err != nil {
+			log.Errorf(`"apm_config.tail_sampling.policies" can not be parsed: %v`, err)
+		}
+		return policies
+	})
+	config.BindEnv("apm_config.route_templating.enabled", "DD_APM_ROUTE_TEMPLATING_ENABLED")
+	config.BindEnv("apm_config.route_templating.cardinality_threshold", "DD_APM_ROUTE_TEMPLATING_CARDINALITY_THRESHOLD")
+	config.BindEnv("apm_config.route_templating.max_nodes", "DD_APM_ROUTE_TEMPLATING_MAX_NODES")
+	config.BindEnv("apm_config.span_metrics", "DD_APM_SPAN_METRICS")
+	config.ParseEnvAsSlice("apm_config.span_metrics", func(in string) []interface{} {
+		var metrics []interface{}
+		if err := json.Unmarshal([]byte(in), &metrics); err != nil {
+			log.Errorf(`"apm_config.span_metrics" can not be parsed: %v`, err)
+		}
+		return metrics
+	})
+	config.BindEnv("apm_config.span_filters", "DD_APM_SPAN_FILTERS")
+	config.ParseEnvAsSlice("apm_config.span_filters", func(in string) []interface{} {
+		var rules []interface{}
+		if err := json.Unmarshal([]byte(in), &rules); err != nil {
+			log.Errorf(`"apm_config.span_filters" can not be parsed: %v`, err)
+		}
+		return rules
+	})
+	config.BindEnv("apm_config.normalization.max_service_len", "DD_APM_NORMALIZATION_MAX_SERVICE_LEN")
+	config.BindEnv("apm_config.normalization.max_name_len", "DD_APM_NORMALIZATION_MAX_NAME_LEN")
+	config.BindEnv("apm_config.normalization.max_resource_len", "DD_APM_NORMALIZATION_MAX_RESOURCE_LEN")
+	config.BindEnv("apm_config.normalization.max_type_len", "DD_APM_NORMALIZATION_MAX_TYPE_LEN")
+	config.BindEnv("apm_config.normalization.max_meta_key_len", "DD_APM_NORMALIZATION_MAX_META_KEY_LEN")
+	config.BindEnv("apm_config.normalization.max_meta_value_len", "DD_APM_NORMALIZATION_MAX_META_VALUE_LEN")
+	config.BindEnv("apm_config.normalization.max_metrics_key_len", "DD_APM_NORMALIZATION_MAX_METRICS_KEY_LEN")
+	config.BindEnv("apm_config.normalization.keep_truncated_overflow", "DD_APM_NORMALIZATION_KEEP_TRUNCATED_OVERFLOW")
 
 	config.BindEnv("apm_config.max_memory", "DD_APM_MAX_MEMORY")
 	config.BindEnv("apm_config.max_cpu_percent", "DD_APM_MAX_CPU_PERCENT")
@@ -146,6 +187,9 @@ func setupAPM(config pkgconfigmodel.Setup) {
 	config.BindEnv("apm_config.connection_limit", "DD_APM_CONNECTION_LIMIT", "DD_CONNECTION_LIMIT")
 	config.BindEnv("apm_config.connection_reset_interval", "DD_APM_CONNECTION_RESET_INTERVAL")
 	config.BindEnv("apm_config.max_sender_retries", "DD_APM_MAX_SENDER_RETRIES")
+	config.BindEnvAndSetDefault("apm_config.disk_retry.enabled", false, "DD_APM_DISK_RETRY_ENABLED")
+	config.BindEnvAndSetDefault("apm_config.disk_retry.path", "", "DD_APM_DISK_RETRY_PATH")
+	config.BindEnvAndSetDefault("apm_config.disk_retry.max_size_bytes", 512*1024*1024, "DD_APM_DISK_RETRY_MAX_SIZE_BYTES")
 	config.BindEnv("apm_config.profiling_dd_url", "DD_APM_PROFILING_DD_URL")
 	config.BindEnv("apm_config.profiling_additional_endpoints", "DD_APM_PROFILING_ADDITIONAL_ENDPOINTS")
 	config.BindEnv("apm_config.additional_endpoints", "DD_APM_ADDITIONAL_ENDPOINTS")
@@ -162,6 +206,10 @@ func setupAPM(config pkgconfigmodel.Setup) {
 	})
 	config.BindEnv("apm_config.receiver_socket", "DD_APM_RECEIVER_SOCKET")
 	config.BindEnv("apm_config.windows_pipe_name", "DD_APM_WINDOWS_PIPE_NAME")
+	config.BindEnvAndSetDefault("apm_config.zipkin.enabled", false, "DD_APM_ZIPKIN_ENABLED")
+	config.BindEnvAndSetDefault("apm_config.zipkin.parse_string_tags", false, "DD_APM_ZIPKIN_PARSE_STRING_TAGS")
+	config.BindEnvAndSetDefault("apm_config.jaeger.enabled", false, "DD_APM_JAEGER_ENABLED")
+	config.BindEnvAndSetDefault("apm_config.jaeger.grpc_port", 0, "DD_APM_JAEGER_GRPC_PORT")
 	config.BindEnv("apm_config.sync_flushing", "DD_APM_SYNC_FLUSHING")
 	config.BindEnv("apm_config.filter_tags.require", "DD_APM_FILTER_TAGS_REQUIRE")
 	config.BindEnv("apm_config.filter_tags.reject", "DD_APM_FILTER_TAGS_REJECT")
@@ -235,6 +283,14 @@ func setupAPM(config pkgconfigmodel.Setup) {
 		return out
 	})
 
+	config.BindEnv("apm_config.stats_dimensions", "DD_APM_STATS_DIMENSIONS")
+	config.ParseEnvAsSlice("apm_config.stats_dimensions", func(in string) []interface{} {
+		var dims []interface{}
+		if err := json.Unmarshal([]byte(in), &dims); err != nil {
+			log.Errorf(`"apm_config.stats_dimensions" can not be parsed: %v`, err)
+		}
+		return dims
+	})
 	config.BindEnv("apm_config.peer_tags", "DD_APM_PEER_TAGS")
 	config.ParseEnvAsStringSlice("apm_config.peer_tags", func(in string) []string {
 		var out []string
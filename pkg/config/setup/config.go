@@ -355,6 +355,16 @@ func InitConfig(config pkgconfigmodel.Setup) {
 	config.BindEnvAndSetDefault("secret_backend_command_allow_group_exec_perm", false)
 	config.BindEnvAndSetDefault("secret_backend_skip_checks", false)
 	config.BindEnvAndSetDefault("secret_backend_remove_trailing_line_break", false)
+	config.BindEnvAndSetDefault("secret_backend_type", "")
+	declareEnum("secret_backend_type", append([]string{""}, secrets.BackendTypes...)...)
+	config.BindEnvAndSetDefault("secret_backend_config", map[string]interface{}{})
+	config.ParseEnvAsMapStringInterface("secret_backend_config", func(in string) map[string]interface{} {
+		var conf map[string]interface{}
+		if err := json.Unmarshal([]byte(in), &conf); err != nil {
+			log.Errorf(`"secret_backend_config" can not be parsed: %v`, err)
+		}
+		return conf
+	})
 	config.BindEnvAndSetDefault("secret_refresh_interval", 0)
 	config.SetDefault("secret_audit_file_max_size", 0)
 
@@ -893,8 +903,10 @@ func InitConfig(config pkgconfigmodel.Setup) {
 	// Orchestrator Explorer - process agent
 	// DEPRECATED in favor of `orchestrator_explorer.orchestrator_dd_url` setting. If both are set `orchestrator_explorer.orchestrator_dd_url` will take precedence.
 	config.BindEnv("process_config.orchestrator_dd_url", "DD_PROCESS_CONFIG_ORCHESTRATOR_DD_URL", "DD_PROCESS_AGENT_ORCHESTRATOR_DD_URL")
+	declareDeprecated("process_config.orchestrator_dd_url", "orchestrator_explorer.orchestrator_dd_url")
 	// DEPRECATED in favor of `orchestrator_explorer.orchestrator_additional_endpoints` setting. If both are set `orchestrator_explorer.orchestrator_additional_endpoints` will take precedence.
 	config.SetKnown("process_config.orchestrator_additional_endpoints")
+	declareDeprecated("process_config.orchestrator_additional_endpoints", "orchestrator_explorer.orchestrator_additional_endpoints")
 	config.BindEnvAndSetDefault("orchestrator_explorer.extra_tags", []string{})
 
 	// Network
@@ -945,7 +957,8 @@ func InitConfig(config pkgconfigmodel.Setup) {
 
 	// Datadog security agent (compliance)
 	config.BindEnvAndSetDefault("compliance_config.enabled", false)
-	config.BindEnvAndSetDefault("compliance_config.xccdf.enabled", false) // deprecated, use host_benchmarks instead
+	config.BindEnvAndSetDefault("compliance_config.xccdf.enabled", false)
+	declareDeprecated("compliance_config.xccdf.enabled", "compliance_config.host_benchmarks.enabled")
 	config.BindEnvAndSetDefault("compliance_config.host_benchmarks.enabled", true)
 	config.BindEnvAndSetDefault("compliance_config.database_benchmarks.enabled", false)
 	config.BindEnvAndSetDefault("compliance_config.check_interval", 20*time.Minute)
@@ -1086,6 +1099,8 @@ func agent(config pkgconfigmodel.Setup) {
 	config.BindEnv("env")
 	config.BindEnvAndSetDefault("tag_value_split_separator", map[string]string{})
 	config.BindEnvAndSetDefault("conf_path", ".")
+	// Refuse to start when the configuration has unknown keys or invalid values, instead of only logging them
+	config.BindEnvAndSetDefault("strict_config_validation", false)
 	config.BindEnvAndSetDefault("confd_path", defaultConfdPath)
 	config.BindEnvAndSetDefault("additional_checksd", defaultAdditionalChecksPath)
 	config.BindEnvAndSetDefault("jmx_log_file", "")
@@ -1095,6 +1110,7 @@ func agent(config pkgconfigmodel.Setup) {
 	config.BindEnvAndSetDefault("log_file_max_size", "10Mb")
 	config.BindEnvAndSetDefault("log_file_max_rolls", 1)
 	config.BindEnvAndSetDefault("log_level", "info")
+	declareEnum("log_level", "trace", "debug", "info", "warn", "warning", "error", "critical", "off")
 	config.BindEnvAndSetDefault("log_to_syslog", false)
 	config.BindEnvAndSetDefault("log_to_console", true)
 	config.BindEnvAndSetDefault("log_format_rfc3339", false)
@@ -1106,7 +1122,8 @@ func agent(config pkgconfigmodel.Setup) {
 	config.BindEnvAndSetDefault("syslog_pem", "")
 	config.BindEnvAndSetDefault("syslog_key", "")
 	config.BindEnvAndSetDefault("syslog_tls_verify", true)
-	config.BindEnv("ipc_address") // deprecated: use `cmd_host` instead
+	config.BindEnv("ipc_address")
+	declareDeprecated("ipc_address", "cmd_host")
 	config.BindEnvAndSetDefault("cmd_host", "localhost")
 	config.BindEnvAndSetDefault("cmd_port", 5001)
 	config.BindEnvAndSetDefault("agent_ipc.host", "localhost")
@@ -1144,6 +1161,7 @@ func agent(config pkgconfigmodel.Setup) {
 
 	// Yaml keys which values are stripped from flare
 	config.BindEnvAndSetDefault("flare_stripped_keys", []string{})
+	declareDeprecated("flare_stripped_keys", "scrubber.additional_keys")
 	config.BindEnvAndSetDefault("scrubber.additional_keys", []string{})
 
 	// Duration during which the host tags will be submitted with metrics.
@@ -1292,8 +1310,10 @@ func debugging(config pkgconfigmodel.Setup) {
 	config.BindEnvAndSetDefault("tracemalloc_debug", false)
 	config.BindEnvAndSetDefault("tracemalloc_include", "")
 	config.BindEnvAndSetDefault("tracemalloc_exclude", "")
-	config.BindEnvAndSetDefault("tracemalloc_whitelist", "") // deprecated
-	config.BindEnvAndSetDefault("tracemalloc_blacklist", "") // deprecated
+	config.BindEnvAndSetDefault("tracemalloc_whitelist", "")
+	declareDeprecated("tracemalloc_whitelist", "tracemalloc_include")
+	config.BindEnvAndSetDefault("tracemalloc_blacklist", "")
+	declareDeprecated("tracemalloc_blacklist", "tracemalloc_exclude")
 	config.BindEnvAndSetDefault("run_path", defaultRunPath)
 	config.BindEnv("no_proxy_nonexact_match")
 }
@@ -1339,7 +1359,18 @@ func serializer(config pkgconfigmodel.Setup) {
 	config.BindEnvAndSetDefault("serializer_max_series_payload_size", 512000)
 	config.BindEnvAndSetDefault("serializer_max_series_uncompressed_payload_size", 5242880)
 	config.BindEnvAndSetDefault("serializer_compressor_kind", DefaultCompressorKind)
+	declareEnum("serializer_compressor_kind", "zlib", "zstd", "gzip", "none")
 	config.BindEnvAndSetDefault("serializer_zstd_compressor_level", DefaultZstdCompressionLevel)
+	// Compression of the payloads sent to the series and sketches endpoints, overriding the settings above when set
+	for _, payloadKind := range []string{"series", "sketches"} {
+		config.BindEnv("serializer_compression." + payloadKind + ".compressor_kind")
+		declareEnum("serializer_compression."+payloadKind+".compressor_kind", "zlib", "zstd", "gzip", "none")
+		config.BindEnv("serializer_compression." + payloadKind + ".zstd_compressor_level")
+		config.BindEnv("serializer_compression." + payloadKind + ".zstd_dictionary_path")
+		config.BindEnvAndSetDefault("serializer_compression."+payloadKind+".zstd_dictionary_training", false)
+	}
+	// Compression of the series and sketches payloads sent to specific endpoints, overriding the settings above
+	config.BindEnv("serializer_compression.endpoints")
 
 	config.BindEnvAndSetDefault("use_v2_api.series", true)
 	// Serializer: allow user to blacklist any kind of payload to be sent
@@ -1375,7 +1406,8 @@ func forwarder(config pkgconfigmodel.Setup) {
 	// Forwarder
 	config.BindEnvAndSetDefault("additional_endpoints", map[string][]string{})
 	config.BindEnvAndSetDefault("forwarder_timeout", 20)
-	config.BindEnv("forwarder_retry_queue_max_size")                                                     // Deprecated in favor of `forwarder_retry_queue_payloads_max_size`
+	config.BindEnv("forwarder_retry_queue_max_size")
+	declareDeprecated("forwarder_retry_queue_max_size", "forwarder_retry_queue_payloads_max_size")
 	config.BindEnv("forwarder_retry_queue_payloads_max_size")                                            // Default value is defined inside `NewOptions` in pkg/forwarder/forwarder.go
 	config.BindEnvAndSetDefault("forwarder_connection_reset_interval", 0)                                // in seconds, 0 means disabled
 	config.BindEnvAndSetDefault("forwarder_apikey_validation_interval", DefaultAPIKeyValidationInterval) // in minutes
@@ -1485,6 +1517,24 @@ func dogstatsd(config pkgconfigmodel.Setup) {
 		return mappings
 	})
 
+	// Cap the number of unique tag sets of each metric name (or namespace) per window, 0 means no limit.
+	config.BindEnvAndSetDefault("dogstatsd_cardinality_limit", 0)
+	config.BindEnvAndSetDefault("dogstatsd_cardinality_limit_by_namespace", false)
+	// Either `collapse` the values of the offending tags or `drop` them
+	config.BindEnvAndSetDefault("dogstatsd_cardinality_limit_action", "collapse")
+	config.BindEnvAndSetDefault("dogstatsd_cardinality_limit_window", 15*time.Second)
+	config.BindEnv("dogstatsd_cardinality_limit_overrides")
+	config.ParseEnvAsSlice("dogstatsd_cardinality_limit_overrides", func(in string) []interface{} {
+		var overrides []interface{}
+		if err := json.Unmarshal([]byte(in), &overrides); err != nil {
+			log.Errorf(`"dogstatsd_cardinality_limit_overrides" can not be parsed: %v`, err)
+		}
+		return overrides
+	})
+	// Prometheus remote write receiver
+	config.BindEnvAndSetDefault("dogstatsd_remote_write.enabled", false)
+	config.BindEnvAndSetDefault("dogstatsd_remote_write.port", 8129)
+
 	config.BindEnvAndSetDefault("statsd_forward_host", "")
 	config.BindEnvAndSetDefault("statsd_forward_port", 0)
 	config.BindEnvAndSetDefault("statsd_metric_namespace", "")
@@ -1504,7 +1554,8 @@ func logsagent(config pkgconfigmodel.Setup) {
 	// External Use: modify those parameters to configure the logs-agent.
 	// enable the logs-agent:
 	config.BindEnvAndSetDefault("logs_enabled", false)
-	config.BindEnvAndSetDefault("log_enabled", false) // deprecated, use logs_enabled instead
+	config.BindEnvAndSetDefault("log_enabled", false)
+	declareDeprecated("log_enabled", "logs_enabled")
 	// collect all logs from all containers:
 	config.BindEnvAndSetDefault("logs_config.container_collect_all", false)
 	// add a socks5 proxy:
@@ -1555,11 +1606,11 @@ func logsagent(config pkgconfigmodel.Setup) {
 	config.BindEnvAndSetDefault("logs_config.docker_client_read_timeout", 30)
 	// Internal Use Only: avoid modifying those configuration parameters, this could lead to unexpected results.
 	config.BindEnvAndSetDefault("logs_config.run_path", defaultRunPath)
-	// DEPRECATED in favor of `logs_config.force_use_http`.
 	config.BindEnvAndSetDefault("logs_config.use_http", false)
+	declareDeprecated("logs_config.use_http", "logs_config.force_use_http")
 	config.BindEnvAndSetDefault("logs_config.force_use_http", false)
-	// DEPRECATED in favor of `logs_config.force_use_tcp`.
 	config.BindEnvAndSetDefault("logs_config.use_tcp", false)
+	declareDeprecated("logs_config.use_tcp", "logs_config.force_use_tcp")
 	config.BindEnvAndSetDefault("logs_config.force_use_tcp", false)
 
 	// Transport protocol for log payloads
@@ -1582,6 +1633,13 @@ func logsagent(config pkgconfigmodel.Setup) {
 	config.BindEnvAndSetDefault("logs_config.message_channel_size", 100)
 	config.BindEnvAndSetDefault("logs_config.payload_channel_size", 10)
 
+	// Spool payloads to disk while the reliable logs endpoints are unreachable, instead of
+	// blocking the pipelines. The size limit is shared by all pipelines.
+	config.BindEnvAndSetDefault("logs_config.disk_buffer.enabled", false)
+	config.BindEnvAndSetDefault("logs_config.disk_buffer.path", "")
+	config.BindEnvAndSetDefault("logs_config.disk_buffer.max_size_bytes", 1024*1024*1024)
+	config.BindEnvAndSetDefault("logs_config.disk_buffer.max_age", 24*time.Hour)
+
 	// maximum time that the unix tailer will hold a log file open after it has been rotated
 	config.BindEnvAndSetDefault("logs_config.close_timeout", 60)
 	// maximum time that the windows tailer will hold a log file open, while waiting for
@@ -1860,7 +1918,7 @@ func Merge(configPaths []string, config pkgconfigmodel.Config) error {
 	return nil
 }
 
-func findUnknownKeys(config pkgconfigmodel.Config) []string {
+func findUnknownKeys(config pkgconfigmodel.Reader) []string {
 	var unknownKeys []string
 	knownKeys := config.GetKnownKeysLowercased()
 	loadedKeys := config.AllKeysLowercased()
@@ -2036,7 +2094,8 @@ func LoadDatadogCustom(config pkgconfigmodel.Config, origin string, secretResolv
 	}()
 
 	warnings := &pkgconfigmodel.Warnings{}
-	err := LoadCustom(config, additionalKnownEnvVars)
+	var err error
+	warnings.ValidationErrors, err = loadCustom(config, additionalKnownEnvVars)
 	if err != nil {
 		if errors.Is(err, os.ErrPermission) {
 			return warnings, log.Warnf("Error loading config: %v (check config file permissions for dd-agent user)", err)
@@ -2097,18 +2156,33 @@ func LoadDatadogCustom(config pkgconfigmodel.Config, origin string, secretResolv
 
 // LoadCustom reads config into the provided config object
 func LoadCustom(config pkgconfigmodel.Config, additionalKnownEnvVars []string) error {
+	_, err := loadCustom(config, additionalKnownEnvVars)
+	return err
+}
+
+// loadCustom reads config into the provided config object, and returns the errors found by its validation.
+func loadCustom(config pkgconfigmodel.Config, additionalKnownEnvVars []string) ([]error, error) {
 	log.Info("Starting to load the configuration")
 	if err := config.ReadInConfig(); err != nil {
 		if pkgconfigenv.IsServerless() {
 			log.Debug("No config file detected, using environment variable based configuration only")
 			// The remaining code in LoadCustom is not run to keep a low cold start time
-			return nil
+			return nil, nil
 		}
-		return err
+		return nil, err
 	}
 
-	for _, key := range findUnknownKeys(config) {
-		log.Warnf("Unknown key in config file: %v", key)
+	var strictErrors []string
+	strict := config.IsKnown("strict_config_validation") && config.GetBool("strict_config_validation")
+	validationErrors := ValidateConfig(config)
+	for _, err := range validationErrors {
+		log.Warnf("Invalid configuration: %s", err)
+		if strict && IsBlockingValidationError(err) {
+			strictErrors = append(strictErrors, err.Error())
+		}
+	}
+	if len(strictErrors) > 0 {
+		return validationErrors, fmt.Errorf("invalid configuration, fix the following errors or disable 'strict_config_validation':\n%s", strings.Join(strictErrors, "\n"))
 	}
 
 	for _, v := range findUnknownEnvVars(config, os.Environ(), additionalKnownEnvVars) {
@@ -2119,7 +2193,7 @@ func LoadCustom(config pkgconfigmodel.Config, additionalKnownEnvVars []string) e
 		log.Warnf("%s", warningMsg)
 	}
 
-	return nil
+	return validationErrors, nil
 }
 
 // setupFipsEndpoints overwrites the Agent endpoint for outgoing data to be sent to the local FIPS proxy. The local FIPS
@@ -2281,9 +2355,11 @@ func ResolveSecrets(config pkgconfigmodel.Config, secretResolver secrets.Compone
 		RemoveLinebreak:  config.GetBool("secret_backend_remove_trailing_line_break"),
 		RunPath:          config.GetString("run_path"),
 		AuditFileMaxSize: config.GetInt("secret_audit_file_max_size"),
+		Type:             config.GetString("secret_backend_type"),
+		BackendConfig:    config.GetStringMap("secret_backend_config"),
 	})
 
-	if config.GetString("secret_backend_command") != "" {
+	if config.GetString("secret_backend_command") != "" || config.GetString("secret_backend_type") != "" {
 		// Viper doesn't expose the final location of the file it
 		// loads. Since we are searching for 'datadog.yaml' in multiple
 		// locations we let viper determine the one to use before
@@ -2475,8 +2551,10 @@ func bindEnvAndSetLogsConfigKeys(config pkgconfigmodel.Setup, prefix string) {
 	config.BindEnv(prefix + "logs_dd_url") // Send the logs to a proxy. Must respect format '<HOST>:<PORT>' and '<PORT>' to be an integer
 	config.BindEnv(prefix + "dd_url")
 	config.BindEnv(prefix + "additional_endpoints")
+	config.BindEnv(prefix + "otlp_endpoints")
 	config.BindEnvAndSetDefault(prefix+"use_compression", true)
 	config.BindEnvAndSetDefault(prefix+"compression_kind", DefaultLogCompressionKind)
+	declareEnum(prefix+"compression_kind", "gzip", "zstd")
 	config.BindEnvAndSetDefault(prefix+"zstd_compression_level", DefaultZstdCompressionLevel) // Default level for the zstd algorithm
 	config.BindEnvAndSetDefault(prefix+"compression_level", DefaultGzipCompressionLevel)      // Default level for the gzip algorithm
 	config.BindEnvAndSetDefault(prefix+"batch_wait", DefaultBatchWait)
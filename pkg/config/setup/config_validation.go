@@ -0,0 +1,295 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package setup
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/cast"
+
+	pkgconfigmodel "github.com/DataDog/datadog-agent/pkg/config/model"
+)
+
+var (
+	// settingsSchemaMutex protects deprecatedSettings and enumSettings, which are filled along with the
+	// declaration of the settings
+	settingsSchemaMutex sync.RWMutex
+	// deprecatedSettings are the settings that are still supported but have been replaced by another one.
+	deprecatedSettings = map[string]string{}
+	// enumSettings are the settings that only accept a fixed set of values. Values are compared case-insensitively.
+	enumSettings = map[string][]string{}
+)
+
+// declareDeprecated records that the setting is still supported but has been replaced by another one.
+func declareDeprecated(key string, replacement string) {
+	settingsSchemaMutex.Lock()
+	defer settingsSchemaMutex.Unlock()
+	deprecatedSettings[key] = replacement
+}
+
+// declareEnum records that the setting only accepts the allowed values.
+func declareEnum(key string, allowed ...string) {
+	settingsSchemaMutex.Lock()
+	defer settingsSchemaMutex.Unlock()
+	enumSettings[key] = allowed
+}
+
+// validatedSources are the sources whose values are set by the user, and therefore validated.
+var validatedSources = []pkgconfigmodel.Source{pkgconfigmodel.SourceFile, pkgconfigmodel.SourceEnvVar}
+
+// UnknownKeyError is returned for a setting that isn't part of the configuration schema.
+type UnknownKeyError struct {
+	Key string
+	// Suggestion is the closest known setting, if any
+	Suggestion string
+}
+
+func (e *UnknownKeyError) Error() string {
+	if e.Suggestion != "" {
+		return fmt.Sprintf("unknown key '%s', did you mean '%s'?", e.Key, e.Suggestion)
+	}
+	return fmt.Sprintf("unknown key '%s'", e.Key)
+}
+
+// TypeMismatchError is returned for a setting whose value can't be converted to the type of its default value.
+type TypeMismatchError struct {
+	Key      string
+	Source   pkgconfigmodel.Source
+	Expected string
+	Value    interface{}
+}
+
+func (e *TypeMismatchError) Error() string {
+	return fmt.Sprintf("invalid value for '%s' from %s: expected type %s, got %#v", e.Key, e.Source, e.Expected, e.Value)
+}
+
+// DeprecatedKeyError is returned for a deprecated setting that is still set.
+type DeprecatedKeyError struct {
+	Key         string
+	Source      pkgconfigmodel.Source
+	Replacement string
+}
+
+func (e *DeprecatedKeyError) Error() string {
+	return fmt.Sprintf("'%s' from %s is deprecated, use '%s' instead", e.Key, e.Source, e.Replacement)
+}
+
+// InvalidValueError is returned for a setting whose value isn't one of the values it accepts.
+type InvalidValueError struct {
+	Key     string
+	Source  pkgconfigmodel.Source
+	Value   interface{}
+	Allowed []string
+}
+
+func (e *InvalidValueError) Error() string {
+	return fmt.Sprintf("invalid value for '%s' from %s: %#v is not one of %s", e.Key, e.Source, e.Value, strings.Join(e.Allowed, ", "))
+}
+
+// IsBlockingValidationError returns whether the validation error prevents the agent from starting when
+// 'strict_config_validation' is enabled. Deprecated settings are still supported, so they're only reported.
+func IsBlockingValidationError(err error) bool {
+	_, deprecated := err.(*DeprecatedKeyError)
+	return !deprecated
+}
+
+// ValidateConfig checks the settings set by the user in the configuration files and in the environment against the
+// schema of the configuration: unknown keys, values that don't match the type of their default value, deprecated
+// settings and values not accepted by the setting. Errors are sorted by key.
+func ValidateConfig(config pkgconfigmodel.Reader) []error {
+	var errs []error
+	knownKeys := config.GetKnownKeysLowercased()
+
+	for _, key := range findUnknownKeys(config) {
+		errs = append(errs, &UnknownKeyError{Key: key, Suggestion: suggestKey(key, knownKeys)})
+	}
+
+	layers := config.AllSettingsBySource()
+	keys := make([]string, 0, len(knownKeys))
+	for key := range knownKeys {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	settingsSchemaMutex.RLock()
+	defer settingsSchemaMutex.RUnlock()
+	for _, key := range keys {
+		defaultValue, _ := settingAtPath(layers[pkgconfigmodel.SourceDefault], key)
+		for _, source := range validatedSources {
+			value, found := settingAtPath(layers[source], key)
+			if !found || value == nil {
+				continue
+			}
+			if replacement, ok := deprecatedSettings[key]; ok {
+				errs = append(errs, &DeprecatedKeyError{Key: key, Source: source, Replacement: replacement})
+			}
+			if isSecretHandle(value) {
+				// secrets are validated once they're resolved
+				continue
+			}
+			if expected, ok := matchesType(defaultValue, value); !ok {
+				errs = append(errs, &TypeMismatchError{Key: key, Source: source, Expected: expected, Value: value})
+				continue
+			}
+			if allowed, ok := enumSettings[key]; ok && !isAllowedValue(value, allowed) {
+				errs = append(errs, &InvalidValueError{Key: key, Source: source, Value: value, Allowed: allowed})
+			}
+		}
+	}
+
+	sort.SliceStable(errs, func(i, j int) bool { return validationErrorKey(errs[i]) < validationErrorKey(errs[j]) })
+	return errs
+}
+
+func validationErrorKey(err error) string {
+	switch e := err.(type) {
+	case *UnknownKeyError:
+		return e.Key
+	case *TypeMismatchError:
+		return e.Key
+	case *DeprecatedKeyError:
+		return e.Key
+	case *InvalidValueError:
+		return e.Key
+	}
+	return ""
+}
+
+// settingAtPath returns the value of a setting from a tree of settings, as returned by AllSettingsBySource. Some
+// layers hold nested settings under their full dotted name, like the environment variables one, so both are looked up.
+func settingAtPath(tree interface{}, key string) (interface{}, bool) {
+	parts := strings.Split(key, ".")
+	for i, part := range parts {
+		node, ok := tree.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if value, found := node[strings.Join(parts[i:], ".")]; found {
+			return value, true
+		}
+		value, found := node[part]
+		if !found {
+			return nil, false
+		}
+		tree = value
+	}
+	return tree, true
+}
+
+func isSecretHandle(value interface{}) bool {
+	str, ok := value.(string)
+	return ok && strings.HasPrefix(str, "ENC[") && strings.HasSuffix(str, "]")
+}
+
+// matchesType returns whether the value can be converted to the type of the default value of the setting, and
+// the name of that type. Settings without a default value accept anything.
+func matchesType(defaultValue interface{}, value interface{}) (string, bool) {
+	if defaultValue == nil {
+		return "", true
+	}
+	if _, ok := defaultValue.(time.Duration); ok {
+		_, err := cast.ToDurationE(value)
+		return "duration", err == nil
+	}
+
+	kind := reflect.ValueOf(value).Kind()
+	switch reflect.ValueOf(defaultValue).Kind() {
+	case reflect.Bool:
+		_, err := cast.ToBoolE(value)
+		return "boolean", err == nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		_, err := cast.ToInt64E(value)
+		return "integer", err == nil
+	case reflect.Float32, reflect.Float64:
+		_, err := cast.ToFloat64E(value)
+		return "number", err == nil
+	case reflect.String:
+		return "string", kind != reflect.Map && kind != reflect.Slice
+	case reflect.Slice, reflect.Array:
+		// lists can also be set from a space-separated or JSON string, like environment variables are
+		return "list", kind == reflect.Slice || kind == reflect.Array || kind == reflect.String
+	case reflect.Map:
+		// maps can also be set from a JSON string, like environment variables are
+		return "map", kind == reflect.Map || kind == reflect.String
+	}
+	return "", true
+}
+
+func isAllowedValue(value interface{}, allowed []string) bool {
+	str, err := cast.ToStringE(value)
+	if err != nil {
+		return false
+	}
+	for _, a := range allowed {
+		if strings.EqualFold(strings.TrimSpace(str), a) {
+			return true
+		}
+	}
+	return false
+}
+
+// suggestKey returns the known key, or the section of known keys, closest to the unknown one, if it's close enough
+// to be a typo.
+func suggestKey(key string, knownKeys map[string]interface{}) string {
+	candidates := map[string]struct{}{}
+	for knownKey := range knownKeys {
+		candidates[knownKey] = struct{}{}
+		// also suggest sections, for unknown keys nested under a misspelled section
+		parts := strings.Split(knownKey, ".")
+		for i := 1; i < len(parts); i++ {
+			candidates[strings.Join(parts[:i], ".")] = struct{}{}
+		}
+	}
+
+	maxDistance := min(3, len(key)/3)
+	suggestion := ""
+	bestDistance := maxDistance + 1
+	for candidate := range candidates {
+		d := levenshtein(key, candidate, bestDistance)
+		if d < bestDistance || (d == bestDistance && candidate < suggestion) {
+			suggestion, bestDistance = candidate, d
+		}
+	}
+	if bestDistance > maxDistance {
+		return ""
+	}
+	return suggestion
+}
+
+// levenshtein returns the edit distance between a and b, or limit+1 once it's known to be greater than limit.
+func levenshtein(a, b string, limit int) int {
+	if d := len(a) - len(b); d > limit || -d > limit {
+		return limit + 1
+	}
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		rowMin := curr[0]
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+			rowMin = min(rowMin, curr[j])
+		}
+		if rowMin > limit {
+			return limit + 1
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,136 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package setup
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	pkgconfigmodel "github.com/DataDog/datadog-agent/pkg/config/model"
+)
+
+func TestValidateConfig(t *testing.T) {
+	conf := confFromYAML(t, `
+api_key: fakeapikey
+log_level: verbose
+log_enabled: true
+hostname: ENC[hostname]
+log_config:
+  batch_wait: 5
+logs_config:
+  batch_wait: abc
+  compression_kind: ZSTD
+secret_backend_type: keepass
+`)
+
+	errs := ValidateConfig(conf)
+	require.Len(t, errs, 5)
+
+	assert.Equal(t, &UnknownKeyError{Key: "log_config.batch_wait", Suggestion: "logs_config.batch_wait"}, errs[0])
+	assert.Equal(t, &DeprecatedKeyError{Key: "log_enabled", Source: pkgconfigmodel.SourceFile, Replacement: "logs_enabled"}, errs[1])
+	assert.Equal(t, &InvalidValueError{
+		Key:     "log_level",
+		Source:  pkgconfigmodel.SourceFile,
+		Value:   "verbose",
+		Allowed: []string{"trace", "debug", "info", "warn", "warning", "error", "critical", "off"},
+	}, errs[2])
+	assert.Equal(t, &TypeMismatchError{Key: "logs_config.batch_wait", Source: pkgconfigmodel.SourceFile, Expected: "integer", Value: "abc"}, errs[3])
+	assert.Equal(t, &InvalidValueError{
+		Key:     "secret_backend_type",
+		Source:  pkgconfigmodel.SourceFile,
+		Value:   "keepass",
+		Allowed: []string{"", "aws.secrets", "aws.ssm", "hashicorp.vault", "http"},
+	}, errs[4])
+
+	assert.True(t, IsBlockingValidationError(errs[0]))
+	assert.False(t, IsBlockingValidationError(errs[1]))
+	assert.EqualError(t, errs[0], "unknown key 'log_config.batch_wait', did you mean 'logs_config.batch_wait'?")
+	assert.EqualError(t, errs[3], `invalid value for 'logs_config.batch_wait' from file: expected type integer, got "abc"`)
+}
+
+func TestValidateConfigEnvVars(t *testing.T) {
+	t.Setenv("DD_LOG_LEVEL", "loud")
+	t.Setenv("DD_LOGS_CONFIG_USE_HTTP", "true")
+
+	conf := newTestConf()
+	errs := ValidateConfig(conf)
+	require.Len(t, errs, 2)
+	assert.EqualError(t, errs[0], `invalid value for 'log_level' from environment-variable: "loud" is not one of trace, debug, info, warn, warning, error, critical, off`)
+	assert.EqualError(t, errs[1], "'logs_config.use_http' from environment-variable is deprecated, use 'logs_config.force_use_http' instead")
+}
+
+func TestValidateConfigValid(t *testing.T) {
+	conf := confFromYAML(t, `
+api_key: fakeapikey
+log_level: DEBUG
+tags: env:prod team:agent
+logs_config:
+  batch_wait: "10"
+  use_compression: "true"
+`)
+	assert.Empty(t, ValidateConfig(conf))
+}
+
+func TestSuggestKey(t *testing.T) {
+	knownKeys := map[string]interface{}{
+		"logs_enabled":           nil,
+		"logs_config.batch_wait": nil,
+		"api_key":                nil,
+	}
+
+	assert.Equal(t, "logs_enabled", suggestKey("log_enabled", knownKeys))
+	assert.Equal(t, "api_key", suggestKey("apikey", knownKeys))
+	assert.Equal(t, "logs_config", suggestKey("log_config", knownKeys))
+	assert.Equal(t, "", suggestKey("completely_unrelated", knownKeys))
+	// short keys don't get suggestions, any other short key would be close enough
+	assert.Equal(t, "", suggestKey("ab", knownKeys))
+}
+
+func TestStrictConfigValidation(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "datadog.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte(`
+strict_config_validation: true
+log_enabled: true
+api_kye: fakeapikey
+`), 0o600))
+
+	conf := newTestConf()
+	conf.SetConfigFile(configPath)
+	err := LoadCustom(conf, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown key 'api_kye', did you mean 'api_key'?")
+	assert.NotContains(t, err.Error(), "log_enabled")
+
+	// deprecated settings are still supported
+	require.NoError(t, os.WriteFile(configPath, []byte(`
+strict_config_validation: true
+log_enabled: true
+`), 0o600))
+	conf = newTestConf()
+	conf.SetConfigFile(configPath)
+	assert.NoError(t, LoadCustom(conf, nil))
+}
+
+func TestLoadValidationErrors(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "datadog.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte(`
+log_enabled: true
+api_kye: fakeapikey
+`), 0o600))
+
+	conf := newTestConf()
+	conf.SetConfigFile(configPath)
+	warnings, err := LoadWithoutSecret(conf, nil)
+	require.NoError(t, err)
+	// the validation errors are kept for the status page
+	require.Len(t, warnings.ValidationErrors, 2)
+	assert.EqualError(t, warnings.ValidationErrors[0], "unknown key 'api_kye', did you mean 'api_key'?")
+	assert.EqualError(t, warnings.ValidationErrors[1], "'log_enabled' from file is deprecated, use 'logs_enabled' instead")
+}
@@ -117,6 +117,8 @@ func InitSystemProbeConfig(cfg pkgconfigmodel.Config) {
 	cfg.BindEnvAndSetDefault("secret_backend_timeout", 0)
 	cfg.BindEnvAndSetDefault("secret_backend_command_allow_group_exec_perm", false)
 	cfg.BindEnvAndSetDefault("secret_backend_skip_checks", false)
+	cfg.BindEnvAndSetDefault("secret_backend_type", "")
+	cfg.BindEnvAndSetDefault("secret_backend_config", map[string]interface{}{})
 
 	// settings for system-probe in general
 	cfg.BindEnvAndSetDefault(join(spNS, "enabled"), false, "DD_SYSTEM_PROBE_ENABLED")
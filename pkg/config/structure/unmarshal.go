@@ -54,6 +54,22 @@ var ImplicitlyConvertArrayToMapSet UnmarshalKeyOption = func(fs *featureSet) {
 	fs.convertArrayToMap = true
 }
 
+// strictConfigValidation is the setting that makes UnmarshalKey error on unused keys, as if ErrorUnused was set,
+// like the configuration loading errors on unknown keys.
+const strictConfigValidation = "strict_config_validation"
+
+// newFeatureSet returns the features enabled by opts and by the config.
+func newFeatureSet(cfg model.Reader, opts []UnmarshalKeyOption) *featureSet {
+	fs := &featureSet{}
+	for _, o := range opts {
+		o(fs)
+	}
+	if cfg.IsKnown(strictConfigValidation) && cfg.GetBool(strictConfigValidation) {
+		fs.errorUnused = true
+	}
+	return fs
+}
+
 // errorUnused is a mapstructure.DecoderConfig that enables erroring on unused keys
 var errorUnused = func(cfg *mapstructure.DecoderConfig) {
 	cfg.ErrorUnused = true
@@ -83,16 +99,16 @@ var legacyConvertArrayToMap = func(c *mapstructure.DecoderConfig) {
 // the data model of the config. Target struct can use of struct tag of "yaml", "json", or "mapstructure" to rename fields
 //
 // Else the viper/legacy version is used.
+//
+// When strict_config_validation is enabled, keys that don't match a field of the target are errors, as with the
+// ErrorUnused option.
 func UnmarshalKey(cfg model.Reader, key string, target interface{}, opts ...UnmarshalKeyOption) error {
 	nodetreemodel := os.Getenv("DD_CONF_NODETREEMODEL")
 	if nodetreemodel == "enable" || nodetreemodel == "unmarshal" {
 		return unmarshalKeyReflection(cfg, key, target, opts...)
 	}
 
-	fs := &featureSet{}
-	for _, o := range opts {
-		o(fs)
-	}
+	fs := newFeatureSet(cfg, opts)
 
 	decodeHooks := []func(c *mapstructure.DecoderConfig){}
 	if fs.convertArrayToMap {
@@ -106,10 +122,7 @@ func UnmarshalKey(cfg model.Reader, key string, target interface{}, opts ...Unma
 }
 
 func unmarshalKeyReflection(cfg model.Reader, key string, target interface{}, opts ...UnmarshalKeyOption) error {
-	fs := &featureSet{}
-	for _, o := range opts {
-		o(fs)
-	}
+	fs := newFeatureSet(cfg, opts)
 	rawval := cfg.Get(key)
 	// Don't create a reflect.Value out of nil, just return immediately
 	if rawval == nil {
@@ -194,11 +207,19 @@ func copyStruct(target reflect.Value, source nodetreemodel.Node, fs *featureSet)
 			if !fs.allowSquash {
 				return fmt.Errorf("feature 'squash' not allowed for UnmarshalKey without EnableSquash option")
 			}
-			err := copyAny(target.FieldByName(f.Name), source, fs)
+			// the squashed struct only uses some of the keys, the unused ones are checked against all the fields
+			squashed := *fs
+			squashed.errorUnused = false
+			err := copyAny(target.FieldByName(f.Name), source, &squashed)
 			if err != nil {
 				return err
 			}
-			usedFields[fieldKey] = struct{}{}
+			if f.Type.Kind() == reflect.Struct {
+				for j := 0; j < f.Type.NumField(); j++ {
+					key, _ := fieldNameToKey(f.Type.Field(j))
+					usedFields[key] = struct{}{}
+				}
+			}
 			continue
 		}
 		child, err := source.GetChild(fieldKey)
@@ -1328,6 +1328,37 @@ service:
 	}
 }
 
+func TestUnmarshalKeyStrictConfigValidation(t *testing.T) {
+	confYaml := `
+strict_config_validation: true
+service:
+  host: datad0g.com
+  name: intake
+  apikey: abc1
+  foo: bar
+`
+	mockConfig := newConfigFromYaml(t, confYaml)
+	mockConfig.SetKnown("service")
+	mockConfig.SetKnown("strict_config_validation")
+
+	err := UnmarshalKey(mockConfig, "service", &serviceConfig{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "foo")
+
+	err = unmarshalKeyReflection(mockConfig, "service", &serviceConfig{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "found unused config keys: [apikey foo name]")
+
+	// the fields of squashed structs are used
+	err = unmarshalKeyReflection(mockConfig, "service", &squashConfig{}, EnableSquash)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "found unused config keys: [foo]")
+
+	mockConfig.Set("strict_config_validation", false, model.SourceAgentRuntime)
+	assert.NoError(t, UnmarshalKey(mockConfig, "service", &serviceConfig{}))
+	assert.NoError(t, unmarshalKeyReflection(mockConfig, "service", &serviceConfig{}))
+}
+
 func TestUnmarshalKeysToMapOfString(t *testing.T) {
 	confYaml := `
 service:
@@ -485,6 +485,14 @@ func (t *teeConfig) GetEnvVars() []string {
 
 }
 
+// GetEnvVarsForKey implements the Config interface
+func (t *teeConfig) GetEnvVarsForKey(key string) []string {
+	base := t.baseline.GetEnvVarsForKey(key)
+	compare := t.compare.GetEnvVarsForKey(key)
+	t.compareResult(key, "GetEnvVarsForKey", base, compare)
+	return base
+}
+
 // BindEnvAndSetDefault implements the Config interface
 func (t *teeConfig) BindEnvAndSetDefault(key string, val interface{}, env ...string) {
 	t.baseline.BindEnvAndSetDefault(key, val, env...)
@@ -46,8 +46,8 @@ type safeConfig struct {
 	proxies *model.Proxy
 
 	// configEnvVars is the set of env vars that are consulted for
-	// configuration values.
-	configEnvVars map[string]struct{}
+	// configuration values, along with the keys they are bound to.
+	configEnvVars map[string][]string
 
 	// keys that have been used but are unknown
 	// used to warn (a single time) on use
@@ -514,12 +514,12 @@ func (c *safeConfig) BindEnv(key string, envvars ...string) {
 		envKeys = envvars
 	}
 
-	for _, key := range envKeys {
+	for _, envKey := range envKeys {
 		// apply EnvKeyReplacer to each key
 		if c.envKeyReplacer != nil {
-			key = c.envKeyReplacer.Replace(key)
+			envKey = c.envKeyReplacer.Replace(envKey)
 		}
-		c.configEnvVars[key] = struct{}{}
+		c.configEnvVars[envKey] = append(c.configEnvVars[envKey], strings.ToLower(key))
 	}
 
 	newKeys := append([]string{key}, envvars...)
@@ -768,6 +768,21 @@ func (c *safeConfig) GetEnvVars() []string {
 	return vars
 }
 
+// GetEnvVarsForKey implements the Config interface
+func (c *safeConfig) GetEnvVarsForKey(key string) []string {
+	c.RLock()
+	defer c.RUnlock()
+	key = strings.ToLower(key)
+	vars := []string{}
+	for v, keys := range c.configEnvVars {
+		if slices.Contains(keys, key) {
+			vars = append(vars, v)
+		}
+	}
+	slices.Sort(vars)
+	return vars
+}
+
 // BindEnvAndSetDefault implements the Config interface
 func (c *safeConfig) BindEnvAndSetDefault(key string, val interface{}, envvars ...string) {
 	c.SetDefault(key, val)
@@ -787,7 +802,7 @@ func NewConfig(name string, envPrefix string, envKeyReplacer *strings.Replacer)
 	config := safeConfig{
 		Viper:         viper.New(),
 		configSources: map[model.Source]*viper.Viper{},
-		configEnvVars: map[string]struct{}{},
+		configEnvVars: map[string][]string{},
 		unknownKeys:   map[string]struct{}{},
 	}
 
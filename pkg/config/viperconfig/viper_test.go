@@ -76,6 +76,19 @@ func TestGetConfigEnvVars(t *testing.T) {
 	assert.Contains(t, config.GetEnvVars(), "DD_CONFIG_OPTION")
 }
 
+func TestGetConfigEnvVarsForKey(t *testing.T) {
+	config := NewConfig("test", "DD", strings.NewReplacer(".", "_")) // nolint: forbidigo
+
+	config.BindEnv("logs_config.run_path")
+	config.BindEnv("config_option_1", "DD_CONFIG_OPTION", "DD_OTHER_OPTION")
+	config.BindEnv("config_option_2", "DD_CONFIG_OPTION")
+
+	assert.Equal(t, []string{"DD_LOGS_CONFIG_RUN_PATH"}, config.GetEnvVarsForKey("logs_config.run_path"))
+	assert.Equal(t, []string{"DD_CONFIG_OPTION", "DD_OTHER_OPTION"}, config.GetEnvVarsForKey("config_option_1"))
+	assert.Equal(t, []string{"DD_CONFIG_OPTION"}, config.GetEnvVarsForKey("config_option_2"))
+	assert.Equal(t, []string{}, config.GetEnvVarsForKey("unknown"))
+}
+
 // check for de-duplication of environment variables by declaring two
 // config parameters using DD_CONFIG_OPTION, and asserting that
 // GetConfigVars only returns that env var once.
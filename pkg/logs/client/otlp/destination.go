@@ -0,0 +1,144 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// Package otlp implements a destination sending logs to an OpenTelemetry collector over OTLP/gRPC.
+package otlp
+
+import (
+	"context"
+	"crypto/tls"
+	"expvar"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/collector/pdata/plog/plogotlp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/DataDog/datadog-agent/comp/logs/agent/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/client"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+	"github.com/DataDog/datadog-agent/pkg/logs/metrics"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// sendTimeout bounds the duration of a single export request.
+const sendTimeout = 10 * time.Second
+
+// exporter exports OTLP logs, it is implemented by plogotlp.GRPCClient.
+type exporter interface {
+	Export(ctx context.Context, request plogotlp.ExportRequest, opts ...grpc.CallOption) (plogotlp.ExportResponse, error)
+}
+
+// Destination sends payloads to an OTLP/gRPC logs receiver. It expects the
+// messages of the payloads to have been encoded by the JSON encoder, as done
+// for HTTP endpoints. Payloads that can't be exported are dropped.
+type Destination struct {
+	endpoint            config.OTLPEndpoint
+	exporter            exporter
+	conn                *grpc.ClientConn
+	destinationsContext *client.DestinationsContext
+	destMeta            *client.DestinationMetadata
+}
+
+// NewDestination returns a new OTLP destination. The connection to the
+// receiver is established lazily by gRPC.
+func NewDestination(endpoint config.OTLPEndpoint, destinationsContext *client.DestinationsContext, destMeta *client.DestinationMetadata) (*Destination, error) {
+	creds := credentials.NewTLS(&tls.Config{MinVersion: tls.VersionTLS12})
+	if endpoint.Insecure {
+		creds = insecure.NewCredentials()
+	}
+	conn, err := grpc.NewClient(endpoint.Endpoint, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return nil, fmt.Errorf("could not create an OTLP/gRPC client for %s: %v", endpoint.Endpoint, err)
+	}
+	d := newDestination(endpoint, plogotlp.NewGRPCClient(conn), destinationsContext, destMeta)
+	d.conn = conn
+	return d, nil
+}
+
+func newDestination(endpoint config.OTLPEndpoint, exporter exporter, destinationsContext *client.DestinationsContext, destMeta *client.DestinationMetadata) *Destination {
+	metrics.DestinationLogsDropped.Set(endpoint.Endpoint, &expvar.Int{})
+	return &Destination{
+		endpoint:            endpoint,
+		exporter:            exporter,
+		destinationsContext: destinationsContext,
+		destMeta:            destMeta,
+	}
+}
+
+// IsMRF is always false for OTLP destinations
+func (d *Destination) IsMRF() bool {
+	return false
+}
+
+// Target is the address of the OTLP receiver.
+func (d *Destination) Target() string {
+	return d.endpoint.Endpoint
+}
+
+// Metadata returns the metadata of the destination
+func (d *Destination) Metadata() *client.DestinationMetadata {
+	return d.destMeta
+}
+
+// Start reads payloads from the input and exports them to the OTLP receiver.
+func (d *Destination) Start(input chan *message.Payload, output chan *message.Payload, _ chan bool) (stopChan <-chan struct{}) {
+	stop := make(chan struct{})
+	go func() {
+		for payload := range input {
+			dropped, err := d.send(payload)
+			if err != nil {
+				log.Debugf("Could not send payload to %s: %v", d.endpoint.Endpoint, err)
+				d.incrementErrors(dropped)
+			}
+			sent := len(payload.Messages) - dropped
+			metrics.LogsSent.Add(int64(sent))
+			metrics.TlmLogsSent.Add(float64(sent))
+			output <- payload
+		}
+		if d.conn != nil {
+			d.conn.Close()
+		}
+		stop <- struct{}{}
+	}()
+	return stop
+}
+
+// send exports a payload and returns the number of log records that were not accepted by the receiver.
+func (d *Destination) send(payload *message.Payload) (int, error) {
+	ctx := d.destinationsContext.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx, cancel := context.WithTimeout(ctx, sendTimeout)
+	defer cancel()
+	if len(d.endpoint.Headers) > 0 {
+		ctx = metadata.NewOutgoingContext(ctx, metadata.New(d.endpoint.Headers))
+	}
+
+	request := plogotlp.NewExportRequestFromLogs(toLogs(payload))
+	response, err := d.exporter.Export(ctx, request)
+	if err != nil {
+		return len(payload.Messages), err
+	}
+
+	metrics.BytesSent.Add(int64(payload.UnencodedSize))
+	metrics.TlmBytesSent.Add(float64(payload.UnencodedSize))
+
+	if rejected := response.PartialSuccess().RejectedLogRecords(); rejected > 0 {
+		return int(rejected), fmt.Errorf("%d log records rejected: %s", rejected, response.PartialSuccess().ErrorMessage())
+	}
+	return 0, nil
+}
+
+func (d *Destination) incrementErrors(dropped int) {
+	metrics.DestinationLogsDropped.Add(d.endpoint.Endpoint, int64(dropped))
+	metrics.TlmLogsDropped.Add(float64(dropped), d.endpoint.Endpoint)
+	metrics.DestinationErrors.Add(1)
+	metrics.TlmDestinationErrors.Inc()
+}
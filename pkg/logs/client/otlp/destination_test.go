@@ -0,0 +1,158 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package otlp
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/plog/plogotlp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/DataDog/datadog-agent/comp/logs/agent/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/client"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+	"github.com/DataDog/datadog-agent/pkg/logs/sources"
+)
+
+type fakeExporter struct {
+	requests []plogotlp.ExportRequest
+	headers  []metadata.MD
+	rejected int64
+	err      error
+}
+
+func (e *fakeExporter) Export(ctx context.Context, request plogotlp.ExportRequest, _ ...grpc.CallOption) (plogotlp.ExportResponse, error) {
+	e.requests = append(e.requests, request)
+	md, _ := metadata.FromOutgoingContext(ctx)
+	e.headers = append(e.headers, md)
+	response := plogotlp.NewExportResponse()
+	response.PartialSuccess().SetRejectedLogRecords(e.rejected)
+	return response, e.err
+}
+
+func newEncodedMessage(logsConfig *config.LogsConfig, encoded string, status string) *message.Message {
+	origin := message.NewOrigin(sources.NewLogSource("", logsConfig))
+	msg := message.NewMessage(nil, origin, status, 1700000000000000000)
+	msg.SetEncoded([]byte(encoded))
+	return msg
+}
+
+func startDestination(t *testing.T, endpoint config.OTLPEndpoint, exporter exporter) (chan *message.Payload, chan *message.Payload) {
+	destinationsContext := client.NewDestinationsContext()
+	destinationsContext.Start()
+	d := newDestination(endpoint, exporter, destinationsContext, client.NewNoopDestinationMetadata())
+
+	input := make(chan *message.Payload)
+	output := make(chan *message.Payload, 1)
+	stop := d.Start(input, output, nil)
+	t.Cleanup(func() {
+		close(input)
+		<-stop
+		destinationsContext.Stop()
+	})
+	return input, output
+}
+
+func TestToLogs(t *testing.T) {
+	fileOrigin := newEncodedMessage(&config.LogsConfig{Service: "web", Source: "nginx", Tags: []string{"env:prod"}},
+		`{"message":"GET /","status":"info","timestamp":1700000000123,"hostname":"host1","service":"web","ddsource":"nginx","ddtags":"env:prod"}`,
+		message.StatusInfo)
+	fileOrigin.Origin.Identifier = "file:/var/log/nginx/access.log"
+	sameResource := newEncodedMessage(&config.LogsConfig{Service: "web"},
+		`{"message":"boom","status":"error","timestamp":1700000000456,"hostname":"host1"}`,
+		message.StatusError)
+	otherResource := newEncodedMessage(&config.LogsConfig{Service: "db"},
+		`{"message":"slow query","status":"warn","timestamp":1700000000789,"hostname":"host1"}`,
+		message.StatusWarning)
+
+	logs := toLogs(&message.Payload{Messages: []*message.Message{fileOrigin, sameResource, otherResource}})
+
+	require.Equal(t, 2, logs.ResourceLogs().Len())
+	assert.Equal(t, 3, logs.LogRecordCount())
+
+	web := logs.ResourceLogs().At(0)
+	assert.Equal(t, map[string]any{"host.name": "host1", "service.name": "web"}, web.Resource().Attributes().AsRaw())
+	assert.Equal(t, scopeName, web.ScopeLogs().At(0).Scope().Name())
+
+	records := web.ScopeLogs().At(0).LogRecords()
+	require.Equal(t, 2, records.Len())
+	record := records.At(0)
+	assert.Equal(t, "GET /", record.Body().Str())
+	assert.Equal(t, int64(1700000000123), record.Timestamp().AsTime().UnixMilli())
+	assert.Equal(t, int64(1700000000000000000), int64(record.ObservedTimestamp()))
+	assert.Equal(t, "info", record.SeverityText())
+	assert.Equal(t, plog.SeverityNumberInfo, record.SeverityNumber())
+	assert.Equal(t, map[string]any{
+		"ddsource":      "nginx",
+		"ddtags":        "env:prod",
+		"log.file.path": "/var/log/nginx/access.log",
+	}, record.Attributes().AsRaw())
+
+	record = records.At(1)
+	assert.Equal(t, "boom", record.Body().Str())
+	assert.Equal(t, plog.SeverityNumberError, record.SeverityNumber())
+	assert.Equal(t, 0, record.Attributes().Len())
+
+	db := logs.ResourceLogs().At(1)
+	assert.Equal(t, map[string]any{"host.name": "host1", "service.name": "db"}, db.Resource().Attributes().AsRaw())
+	assert.Equal(t, plog.SeverityNumberWarn, db.ScopeLogs().At(0).LogRecords().At(0).SeverityNumber())
+}
+
+func TestToLogsUnencodedContent(t *testing.T) {
+	msg := message.NewMessage([]byte("raw line"), message.NewOrigin(sources.NewLogSource("", &config.LogsConfig{})), "", 0)
+
+	logs := toLogs(&message.Payload{Messages: []*message.Message{msg}})
+
+	require.Equal(t, 1, logs.LogRecordCount())
+	rl := logs.ResourceLogs().At(0)
+	assert.Equal(t, 0, rl.Resource().Attributes().Len())
+	record := rl.ScopeLogs().At(0).LogRecords().At(0)
+	assert.Equal(t, "raw line", record.Body().Str())
+	// like in the rest of the pipeline, a message without a status is an info one
+	assert.Equal(t, message.StatusInfo, record.SeverityText())
+	assert.Equal(t, plog.SeverityNumberInfo, record.SeverityNumber())
+}
+
+func TestDestinationSend(t *testing.T) {
+	exporter := &fakeExporter{}
+	endpoint := config.OTLPEndpoint{Endpoint: "localhost:4317", Headers: map[string]string{"X-Scope": "logs"}}
+	input, output := startDestination(t, endpoint, exporter)
+
+	payload := &message.Payload{Messages: []*message.Message{
+		newEncodedMessage(&config.LogsConfig{}, `{"message":"hello","hostname":"host1"}`, message.StatusInfo),
+	}}
+	input <- payload
+	assert.Same(t, payload, <-output)
+
+	require.Len(t, exporter.requests, 1)
+	assert.Equal(t, 1, exporter.requests[0].Logs().LogRecordCount())
+	assert.Equal(t, []string{"logs"}, exporter.headers[0].Get("x-scope"))
+}
+
+func TestDestinationDropsOnError(t *testing.T) {
+	exporter := &fakeExporter{err: errors.New("unavailable")}
+	input, output := startDestination(t, config.OTLPEndpoint{Endpoint: "localhost:4317"}, exporter)
+
+	payload := &message.Payload{Messages: []*message.Message{
+		newEncodedMessage(&config.LogsConfig{}, `{"message":"hello"}`, message.StatusInfo),
+	}}
+	input <- payload
+	// the payload is still acknowledged, the destination doesn't retry
+	assert.Same(t, payload, <-output)
+	assert.Len(t, exporter.requests, 1)
+}
+
+func TestDestinationTarget(t *testing.T) {
+	d := newDestination(config.OTLPEndpoint{Endpoint: "collector:4317"}, &fakeExporter{}, client.NewDestinationsContext(), client.NewNoopDestinationMetadata())
+	assert.Equal(t, "collector:4317", d.Target())
+	assert.False(t, d.IsMRF())
+}
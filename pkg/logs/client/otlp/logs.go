@@ -0,0 +1,121 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package otlp
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+	"github.com/DataDog/datadog-agent/pkg/version"
+)
+
+const (
+	scopeName = "datadog-agent"
+
+	attrHostName    = "host.name"
+	attrServiceName = "service.name"
+	attrFilePath    = "log.file.path"
+	attrSource      = "ddsource"
+	attrTags        = "ddtags"
+)
+
+// severities maps the status of a log to its OTLP severity.
+var severities = map[string]plog.SeverityNumber{
+	message.StatusEmergency: plog.SeverityNumberFatal4,
+	message.StatusAlert:     plog.SeverityNumberFatal3,
+	message.StatusCritical:  plog.SeverityNumberFatal,
+	message.StatusError:     plog.SeverityNumberError,
+	message.StatusWarning:   plog.SeverityNumberWarn,
+	message.StatusNotice:    plog.SeverityNumberInfo2,
+	message.StatusInfo:      plog.SeverityNumberInfo,
+	message.StatusDebug:     plog.SeverityNumberDebug,
+}
+
+// encodedMessage holds the fields of a message encoded by the JSON encoder of
+// the pipeline that are not available on message.Message anymore.
+type encodedMessage struct {
+	Message   string `json:"message"`
+	Timestamp int64  `json:"timestamp"`
+	Hostname  string `json:"hostname"`
+}
+
+// resource identifies the OTLP resource a log belongs to.
+type resource struct {
+	hostname string
+	service  string
+}
+
+// toLogs maps the messages of a payload to OTLP logs, grouping them by host and service.
+func toLogs(payload *message.Payload) plog.Logs {
+	logs := plog.NewLogs()
+	records := make(map[resource]plog.LogRecordSlice)
+
+	for _, msg := range payload.Messages {
+		encoded := decode(msg)
+		res := resource{hostname: encoded.Hostname, service: msg.Origin.Service()}
+
+		slice, ok := records[res]
+		if !ok {
+			rl := logs.ResourceLogs().AppendEmpty()
+			if res.hostname != "" {
+				rl.Resource().Attributes().PutStr(attrHostName, res.hostname)
+			}
+			if res.service != "" {
+				rl.Resource().Attributes().PutStr(attrServiceName, res.service)
+			}
+			sl := rl.ScopeLogs().AppendEmpty()
+			sl.Scope().SetName(scopeName)
+			sl.Scope().SetVersion(version.AgentVersion)
+			slice = sl.LogRecords()
+			records[res] = slice
+		}
+		fillRecord(slice.AppendEmpty(), msg, encoded)
+	}
+	return logs
+}
+
+func fillRecord(record plog.LogRecord, msg *message.Message, encoded encodedMessage) {
+	record.Body().SetStr(encoded.Message)
+
+	if encoded.Timestamp != 0 {
+		record.SetTimestamp(pcommon.NewTimestampFromTime(time.UnixMilli(encoded.Timestamp)))
+	}
+	if msg.IngestionTimestamp != 0 {
+		record.SetObservedTimestamp(pcommon.Timestamp(msg.IngestionTimestamp))
+	}
+
+	status := msg.GetStatus()
+	record.SetSeverityText(status)
+	if severity, ok := severities[status]; ok {
+		record.SetSeverityNumber(severity)
+	}
+
+	attributes := record.Attributes()
+	if source := msg.Origin.Source(); source != "" {
+		attributes.PutStr(attrSource, source)
+	}
+	if tags := msg.TagsToString(); tags != "" {
+		attributes.PutStr(attrTags, tags)
+	}
+	if path, ok := strings.CutPrefix(msg.Origin.Identifier, "file:"); ok {
+		attributes.PutStr(attrFilePath, path)
+	}
+}
+
+// decode reads back the content, timestamp and hostname of a message encoded
+// by the JSON encoder. The raw content is used as body when it can't be decoded.
+func decode(msg *message.Message) encodedMessage {
+	var encoded encodedMessage
+	if msg.State == message.StateEncoded && json.Unmarshal(msg.GetContent(), &encoded) == nil {
+		return encoded
+	}
+	return encodedMessage{Message: string(msg.GetContent())}
+}
@@ -0,0 +1,107 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package framer
+
+import (
+	"bytes"
+	"strconv"
+)
+
+// maxOctetCountDigits is the maximum number of digits of the MSG-LEN of an
+// octet-counted syslog frame.
+const maxOctetCountDigits = 10
+
+// syslogMatcher frames syslog messages using the octet counting framing of
+// RFC 6587 when a frame starts with `MSG-LEN SP`, and falls back to the
+// non-transparent framing (newline-terminated messages) otherwise.
+type syslogMatcher struct {
+	// contentLenLimit is the maximum content length that will be returned.
+	// Messages longer than this value will be split into multiple frames.
+	contentLenLimit int
+
+	// remaining is the number of bytes of an octet-counted message over
+	// contentLenLimit that are still to be framed.
+	remaining int
+}
+
+// FindFrame implements FrameMatcher#FindFrame.
+func (s *syslogMatcher) FindFrame(buf []byte, seen int) ([]byte, int) {
+	if s.remaining > 0 {
+		n := min(s.remaining, s.contentLenLimit)
+		if len(buf) < n {
+			return nil, 0
+		}
+		s.remaining -= n
+		return buf[:n], n
+	}
+
+	msgLen, prefixLen, ok := s.octetCount(buf)
+	if !ok {
+		return s.findNewline(buf, seen)
+	}
+	if prefixLen == 0 {
+		// the MSG-LEN is not complete yet
+		return nil, 0
+	}
+
+	// the raw frame, including its prefix, must fit in contentLenLimit, otherwise
+	// the framer would chop it before it is complete.
+	if maxContentLen := s.contentLenLimit - prefixLen; msgLen > maxContentLen {
+		if len(buf) < s.contentLenLimit {
+			return nil, 0
+		}
+		s.remaining = msgLen - maxContentLen
+		return buf[prefixLen:s.contentLenLimit], s.contentLenLimit
+	}
+
+	if len(buf) < prefixLen+msgLen {
+		return nil, 0
+	}
+	return buf[prefixLen : prefixLen+msgLen], prefixLen + msgLen
+}
+
+// octetCount parses the `MSG-LEN SP` prefix of an octet-counted frame. It returns
+// false if the frame doesn't use octet counting, and a prefixLen of 0 if more
+// data is needed to decide.
+func (s *syslogMatcher) octetCount(buf []byte) (msgLen int, prefixLen int, ok bool) {
+	if len(buf) == 0 || buf[0] < '1' || buf[0] > '9' {
+		return 0, 0, false
+	}
+	i := 1
+	for i < len(buf) && i <= maxOctetCountDigits && buf[i] >= '0' && buf[i] <= '9' {
+		i++
+	}
+	if i > maxOctetCountDigits {
+		return 0, 0, false
+	}
+	if i == len(buf) {
+		return 0, 0, true
+	}
+	if buf[i] != ' ' {
+		return 0, 0, false
+	}
+	msgLen, err := strconv.Atoi(string(buf[:i]))
+	if err != nil {
+		return 0, 0, false
+	}
+	return msgLen, i + 1, true
+}
+
+// findNewline finds a newline-terminated frame.
+func (s *syslogMatcher) findNewline(buf []byte, seen int) ([]byte, int) {
+	nl := bytes.IndexByte(buf[seen:], '\n')
+	if nl == -1 {
+		return nil, 0
+	}
+
+	eol := nl + seen
+	if eol > s.contentLenLimit {
+		return buf[:s.contentLenLimit], s.contentLenLimit
+	}
+	// some senders terminate the message with CRLF
+	content := bytes.TrimSuffix(buf[:eol], []byte{'\r'})
+	return content, eol + 1
+}
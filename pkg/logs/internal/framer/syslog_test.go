@@ -0,0 +1,63 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package framer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+)
+
+func frameSyslog(t *testing.T, limit int, chunks ...string) ([]string, []int) {
+	t.Helper()
+	var contents []string
+	var rawLens []int
+	outputFn := func(msg *message.Message, rawDataLen int) {
+		contents = append(contents, string(msg.GetContent()))
+		rawLens = append(rawLens, rawDataLen)
+	}
+	fr := NewFramer(outputFn, Syslog, limit)
+	for _, chunk := range chunks {
+		fr.Process(message.NewMessage([]byte(chunk), nil, "", 0))
+	}
+	return contents, rawLens
+}
+
+func TestSyslogOctetCounting(t *testing.T) {
+	contents, rawLens := frameSyslog(t, contentLenLimit, "11 <34>1 - a b11 <34>1 - c\nd")
+	assert.Equal(t, []string{"<34>1 - a b", "<34>1 - c\nd"}, contents)
+	assert.Equal(t, []int{14, 14}, rawLens)
+}
+
+func TestSyslogOctetCountingChunked(t *testing.T) {
+	contents, rawLens := frameSyslog(t, contentLenLimit, "1", "1 <34>1 -", " a b", "5 hello")
+	assert.Equal(t, []string{"<34>1 - a b", "hello"}, contents)
+	assert.Equal(t, []int{14, 7}, rawLens)
+}
+
+func TestSyslogNonTransparentFraming(t *testing.T) {
+	contents, rawLens := frameSyslog(t, contentLenLimit, "<34>Oct 11 22:14:15 host su: hello\r\n<13>1 - world\n")
+	assert.Equal(t, []string{"<34>Oct 11 22:14:15 host su: hello", "<13>1 - world"}, contents)
+	assert.Equal(t, []int{36, 14}, rawLens)
+}
+
+func TestSyslogMixedFraming(t *testing.T) {
+	contents, _ := frameSyslog(t, contentLenLimit, "5 hello<13>1 - world\n3 abc")
+	assert.Equal(t, []string{"hello", "<13>1 - world", "abc"}, contents)
+}
+
+func TestSyslogOctetCountingOverLimit(t *testing.T) {
+	contents, rawLens := frameSyslog(t, 10, "15 abcdefghijklmno", "3 xyz")
+	assert.Equal(t, []string{"abcdefg", "hijklmno", "xyz"}, contents)
+	assert.Equal(t, []int{10, 8, 5}, rawLens)
+}
+
+func TestSyslogDigitsWithoutSpace(t *testing.T) {
+	contents, _ := frameSyslog(t, contentLenLimit, "123abc\n<13>1 - hello\n")
+	assert.Equal(t, []string{"123abc", "<13>1 - hello"}, contents)
+}
@@ -0,0 +1,269 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// Package syslog implements a parser for RFC 5424 and RFC 3164 syslog messages.
+package syslog
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strconv"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/internal/parsers"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+)
+
+const nilValue = "-"
+
+// severities maps a syslog severity (the low three bits of PRI) to a log status.
+var severities = [8]string{
+	message.StatusEmergency,
+	message.StatusAlert,
+	message.StatusCritical,
+	message.StatusError,
+	message.StatusWarning,
+	message.StatusNotice,
+	message.StatusInfo,
+	message.StatusDebug,
+}
+
+var bom = []byte{0xEF, 0xBB, 0xBF}
+
+var (
+	errNoPriority      = errors.New("syslog: missing or invalid PRI")
+	errInvalidHeader   = errors.New("syslog: invalid header")
+	errInvalidSDString = errors.New("syslog: invalid structured data")
+)
+
+// New creates a parser that decodes syslog messages, sets the log status from
+// the syslog severity and replaces the content with a JSON object holding the
+// message body and the decoded header fields. Messages that are not valid
+// syslog are submitted as is.
+func New() parsers.Parser {
+	return &syslogFormat{}
+}
+
+type syslogFormat struct{}
+
+// header holds the decoded syslog header, rendered under the `syslog` attribute.
+type header struct {
+	Facility       int                          `json:"facility"`
+	Severity       int                          `json:"severity"`
+	Version        int                          `json:"version,omitempty"`
+	Timestamp      string                       `json:"timestamp,omitempty"`
+	Hostname       string                       `json:"hostname,omitempty"`
+	AppName        string                       `json:"appname,omitempty"`
+	ProcID         string                       `json:"procid,omitempty"`
+	MsgID          string                       `json:"msgid,omitempty"`
+	StructuredData map[string]map[string]string `json:"structured_data,omitempty"`
+}
+
+type structuredLog struct {
+	Message string `json:"message"`
+	Syslog  header `json:"syslog"`
+}
+
+// Parse implements Parser#Parse
+func (p *syslogFormat) Parse(msg *message.Message) (*message.Message, error) {
+	content := msg.GetContent()
+
+	pri, rest, err := parsePriority(content)
+	if err != nil {
+		return msg, err
+	}
+	h := header{Facility: pri / 8, Severity: pri % 8}
+
+	var body []byte
+	if version, after, ok := parseVersion(rest); ok {
+		h.Version = version
+		body, err = parseRFC5424(after, &h)
+	} else {
+		body = parseRFC3164(rest, &h)
+	}
+	if err != nil {
+		return msg, err
+	}
+
+	encoded, err := json.Marshal(structuredLog{Message: string(body), Syslog: h})
+	if err != nil {
+		return msg, err
+	}
+	msg.SetContent(encoded)
+	msg.Status = severities[h.Severity]
+	return msg, nil
+}
+
+// SupportsPartialLine implements Parser#SupportsPartialLine
+func (p *syslogFormat) SupportsPartialLine() bool {
+	return false
+}
+
+// parsePriority parses the leading `<PRI>` of a message, PRI being a number
+// between 0 and 191.
+func parsePriority(content []byte) (int, []byte, error) {
+	if len(content) < 3 || content[0] != '<' {
+		return 0, nil, errNoPriority
+	}
+	end := bytes.IndexByte(content[:min(len(content), 5)], '>')
+	if end < 2 {
+		return 0, nil, errNoPriority
+	}
+	pri, err := strconv.Atoi(string(content[1:end]))
+	if err != nil || pri < 0 || pri > 191 {
+		return 0, nil, errNoPriority
+	}
+	return pri, content[end+1:], nil
+}
+
+// parseVersion parses the RFC 5424 VERSION field following PRI. RFC 3164
+// messages have a timestamp or the message itself there instead.
+func parseVersion(content []byte) (int, []byte, bool) {
+	i := 0
+	for i < len(content) && i < 3 && content[i] >= '0' && content[i] <= '9' {
+		i++
+	}
+	if i == 0 || i >= len(content) || content[i] != ' ' || content[0] == '0' {
+		return 0, nil, false
+	}
+	version, _ := strconv.Atoi(string(content[:i]))
+	return version, content[i+1:], true
+}
+
+// parseRFC5424 parses `TIMESTAMP HOSTNAME APP-NAME PROCID MSGID SD [MSG]`.
+func parseRFC5424(content []byte, h *header) ([]byte, error) {
+	fields := make([]string, 5)
+	for i := range fields {
+		end := bytes.IndexByte(content, ' ')
+		if end <= 0 {
+			return nil, errInvalidHeader
+		}
+		if field := string(content[:end]); field != nilValue {
+			fields[i] = field
+		}
+		content = content[end+1:]
+	}
+	h.Timestamp, h.Hostname, h.AppName, h.ProcID, h.MsgID = fields[0], fields[1], fields[2], fields[3], fields[4]
+
+	sd, rest, err := parseStructuredData(content)
+	if err != nil {
+		return nil, err
+	}
+	h.StructuredData = sd
+
+	if len(rest) > 0 {
+		if rest[0] != ' ' {
+			return nil, errInvalidSDString
+		}
+		rest = rest[1:]
+	}
+	return bytes.TrimPrefix(rest, bom), nil
+}
+
+// parseStructuredData parses either the NILVALUE or a sequence of
+// `[SD-ID PARAM="VALUE" ...]` elements.
+func parseStructuredData(content []byte) (map[string]map[string]string, []byte, error) {
+	if len(content) == 0 {
+		return nil, nil, errInvalidSDString
+	}
+	if content[0] == '-' {
+		return nil, content[1:], nil
+	}
+
+	sd := make(map[string]map[string]string)
+	for len(content) > 0 && content[0] == '[' {
+		content = content[1:]
+		end := bytes.IndexAny(content, " ]")
+		if end <= 0 {
+			return nil, nil, errInvalidSDString
+		}
+		params := make(map[string]string)
+		sd[string(content[:end])] = params
+		content = content[end:]
+
+		for len(content) > 0 && content[0] == ' ' {
+			content = content[1:]
+			eq := bytes.IndexByte(content, '=')
+			if eq <= 0 || eq+1 >= len(content) || content[eq+1] != '"' {
+				return nil, nil, errInvalidSDString
+			}
+			name := string(content[:eq])
+			value, rest, ok := parseParamValue(content[eq+2:])
+			if !ok {
+				return nil, nil, errInvalidSDString
+			}
+			params[name] = value
+			content = rest
+		}
+		if len(content) == 0 || content[0] != ']' {
+			return nil, nil, errInvalidSDString
+		}
+		content = content[1:]
+	}
+	return sd, content, nil
+}
+
+// parseParamValue reads a PARAM-VALUE up to its closing quote, unescaping
+// `\"`, `\\` and `\]`.
+func parseParamValue(content []byte) (string, []byte, bool) {
+	var value []byte
+	for i := 0; i < len(content); i++ {
+		switch c := content[i]; c {
+		case '"':
+			return string(value), content[i+1:], true
+		case '\\':
+			if i+1 < len(content) && (content[i+1] == '"' || content[i+1] == '\\' || content[i+1] == ']') {
+				i++
+				c = content[i]
+			}
+			value = append(value, c)
+		default:
+			value = append(value, c)
+		}
+	}
+	return "", nil, false
+}
+
+// parseRFC3164 leniently parses `TIMESTAMP HOSTNAME TAG[PID]: MSG`. Fields that
+// cannot be found are left empty and the remaining content is the message.
+func parseRFC3164(content []byte, h *header) []byte {
+	// Mmm dd hh:mm:ss, with the day padded with a space
+	const timestampLen = len("Jan _2 15:04:05")
+	if len(content) <= timestampLen || content[3] != ' ' || content[6] != ' ' ||
+		content[9] != ':' || content[12] != ':' || content[timestampLen] != ' ' {
+		return content
+	}
+	h.Timestamp = string(content[:timestampLen])
+	content = content[timestampLen+1:]
+
+	end := bytes.IndexByte(content, ' ')
+	if end <= 0 {
+		return content
+	}
+	h.Hostname = string(content[:end])
+	content = content[end+1:]
+
+	// the tag is terminated by `[`, `:` or a space; it is only taken as a tag
+	// when followed by `: ` or `[PID]: `
+	end = bytes.IndexAny(content, "[: ")
+	if end <= 0 {
+		return content
+	}
+	tag, rest := content[:end], content[end:]
+	var procID []byte
+	if rest[0] == '[' {
+		closing := bytes.IndexByte(rest, ']')
+		if closing < 0 {
+			return content
+		}
+		procID, rest = rest[1:closing], rest[closing+1:]
+	}
+	if !bytes.HasPrefix(rest, []byte(":")) {
+		return content
+	}
+	h.AppName = string(tag)
+	h.ProcID = string(procID)
+	return bytes.TrimPrefix(rest[1:], []byte(" "))
+}
@@ -0,0 +1,104 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package syslog
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+)
+
+func parse(t *testing.T, content string) (*message.Message, structuredLog) {
+	t.Helper()
+	msg, err := New().Parse(message.NewMessage([]byte(content), nil, message.StatusInfo, 0))
+	require.NoError(t, err)
+	var decoded structuredLog
+	require.NoError(t, json.Unmarshal(msg.GetContent(), &decoded))
+	return msg, decoded
+}
+
+func TestParseRFC5424(t *testing.T) {
+	msg, decoded := parse(t, `<165>1 2003-10-11T22:14:15.003Z mymachine.example.com evntslog - ID47 [exampleSDID@32473 iut="3" eventSource="Appli\"cation"][examplePriority@32473 class="high"] `+"\xEF\xBB\xBF"+`An application event`)
+
+	assert.Equal(t, message.StatusNotice, msg.Status)
+	assert.Equal(t, "An application event", decoded.Message)
+	assert.Equal(t, header{
+		Facility:  20,
+		Severity:  5,
+		Version:   1,
+		Timestamp: "2003-10-11T22:14:15.003Z",
+		Hostname:  "mymachine.example.com",
+		AppName:   "evntslog",
+		MsgID:     "ID47",
+		StructuredData: map[string]map[string]string{
+			"exampleSDID@32473":     {"iut": "3", "eventSource": `Appli"cation`},
+			"examplePriority@32473": {"class": "high"},
+		},
+	}, decoded.Syslog)
+}
+
+func TestParseRFC5424NilValues(t *testing.T) {
+	msg, decoded := parse(t, "<11>1 - - - - - -")
+
+	assert.Equal(t, message.StatusError, msg.Status)
+	assert.Equal(t, "", decoded.Message)
+	assert.Equal(t, header{Facility: 1, Severity: 3, Version: 1}, decoded.Syslog)
+}
+
+func TestParseRFC3164(t *testing.T) {
+	msg, decoded := parse(t, "<34>Oct 11 22:14:15 mymachine su[1234]: 'su root' failed for lonvick on /dev/pts/8")
+
+	assert.Equal(t, message.StatusCritical, msg.Status)
+	assert.Equal(t, "'su root' failed for lonvick on /dev/pts/8", decoded.Message)
+	assert.Equal(t, header{
+		Facility:  4,
+		Severity:  2,
+		Timestamp: "Oct 11 22:14:15",
+		Hostname:  "mymachine",
+		AppName:   "su",
+		ProcID:    "1234",
+	}, decoded.Syslog)
+}
+
+func TestParseRFC3164WithoutTag(t *testing.T) {
+	_, decoded := parse(t, "<15>Oct  1 02:04:05 host just some text")
+
+	assert.Equal(t, "just some text", decoded.Message)
+	assert.Equal(t, "Oct  1 02:04:05", decoded.Syslog.Timestamp)
+	assert.Equal(t, "host", decoded.Syslog.Hostname)
+	assert.Empty(t, decoded.Syslog.AppName)
+}
+
+func TestParseRFC3164WithoutHeader(t *testing.T) {
+	msg, decoded := parse(t, "<7>hello world")
+
+	assert.Equal(t, message.StatusDebug, msg.Status)
+	assert.Equal(t, "hello world", decoded.Message)
+	assert.Equal(t, header{Facility: 0, Severity: 7}, decoded.Syslog)
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, content := range []string{
+		"no priority",
+		"<>1 - - - - - -",
+		"<192>1 - - - - - -",
+		"<13>1 - - - -",
+		"<13>1 - - - - - [id",
+		`<13>1 - - - - - [id a="b]`,
+		"<13>1 - - - - - [id]msg",
+	} {
+		t.Run(content, func(t *testing.T) {
+			msg, err := New().Parse(message.NewMessage([]byte(content), nil, message.StatusInfo, 0))
+			assert.Error(t, err)
+			assert.Equal(t, content, string(msg.GetContent()))
+			assert.Equal(t, message.StatusInfo, msg.Status)
+		})
+	}
+}
@@ -0,0 +1,96 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// Package kafka implements the launcher of the kafka tailers.
+package kafka
+
+import (
+	"github.com/DataDog/datadog-agent/comp/logs/agent/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/auditor"
+	"github.com/DataDog/datadog-agent/pkg/logs/launchers"
+	"github.com/DataDog/datadog-agent/pkg/logs/pipeline"
+	"github.com/DataDog/datadog-agent/pkg/logs/sources"
+	"github.com/DataDog/datadog-agent/pkg/logs/tailers"
+	tailer "github.com/DataDog/datadog-agent/pkg/logs/tailers/kafka"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+	"github.com/DataDog/datadog-agent/pkg/util/startstop"
+)
+
+// ConsumerFactory creates the kafka consumer of a source.
+type ConsumerFactory func(source *config.LogsConfig, registry auditor.Registry) (tailer.Consumer, error)
+
+// Launcher is in charge of starting and stopping the kafka tailers.
+type Launcher struct {
+	addedSources     chan *sources.LogSource
+	removedSources   chan *sources.LogSource
+	pipelineProvider pipeline.Provider
+	registry         auditor.Registry
+	tailers          map[*sources.LogSource]*tailer.Tailer
+	consumerFactory  ConsumerFactory
+	stop             chan struct{}
+}
+
+// NewLauncher returns a new Launcher.
+func NewLauncher() *Launcher {
+	return NewLauncherWithFactory(tailer.NewConsumer)
+}
+
+// NewLauncherWithFactory returns a new Launcher creating the consumers with the given factory.
+func NewLauncherWithFactory(consumerFactory ConsumerFactory) *Launcher {
+	return &Launcher{
+		tailers:         make(map[*sources.LogSource]*tailer.Tailer),
+		consumerFactory: consumerFactory,
+		stop:            make(chan struct{}),
+	}
+}
+
+// Start starts the launcher.
+func (l *Launcher) Start(sourceProvider launchers.SourceProvider, pipelineProvider pipeline.Provider, registry auditor.Registry, _ *tailers.TailerTracker) {
+	l.addedSources, l.removedSources = sourceProvider.SubscribeForType(config.KafkaType)
+	l.pipelineProvider = pipelineProvider
+	l.registry = registry
+	go l.run()
+}
+
+// run starts and stops the tailers as sources are added and removed.
+func (l *Launcher) run() {
+	for {
+		select {
+		case source := <-l.addedSources:
+			l.startTailer(source)
+		case source := <-l.removedSources:
+			if t, exists := l.tailers[source]; exists {
+				t.Stop()
+				delete(l.tailers, source)
+			}
+		case <-l.stop:
+			return
+		}
+	}
+}
+
+// Stop stops all active tailers, the offsets of the logs sent so far are
+// committed to their consumer group.
+func (l *Launcher) Stop() {
+	l.stop <- struct{}{}
+	stopper := startstop.NewParallelStopper()
+	for source, t := range l.tailers {
+		stopper.Add(t)
+		delete(l.tailers, source)
+	}
+	stopper.Stop()
+}
+
+func (l *Launcher) startTailer(source *sources.LogSource) {
+	consumer, err := l.consumerFactory(source.Config, l.registry)
+	if err != nil {
+		log.Warnf("Could not create kafka consumer for topics %v: %v", source.Config.Topics, err)
+		source.Status.Error(err)
+		return
+	}
+	t := tailer.NewTailer(source, l.pipelineProvider.NextPipelineChan(), consumer, l.registry)
+	t.Start()
+	l.tailers[source] = t
+}
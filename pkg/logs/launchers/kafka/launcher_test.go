@@ -0,0 +1,101 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package kafka
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/twmb/franz-go/pkg/kgo"
+
+	"github.com/DataDog/datadog-agent/comp/logs/agent/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/auditor"
+	auditorMock "github.com/DataDog/datadog-agent/pkg/logs/auditor/mock"
+	pipelineMock "github.com/DataDog/datadog-agent/pkg/logs/pipeline/mock"
+	"github.com/DataDog/datadog-agent/pkg/logs/sources"
+	"github.com/DataDog/datadog-agent/pkg/logs/tailers"
+	tailer "github.com/DataDog/datadog-agent/pkg/logs/tailers/kafka"
+)
+
+type idleConsumer struct {
+	closed chan struct{}
+}
+
+func (c *idleConsumer) Poll(ctx context.Context) ([]*kgo.Record, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func (c *idleConsumer) Commit(context.Context, map[string]map[int32]int64) error {
+	return nil
+}
+
+func (c *idleConsumer) Close() {
+	close(c.closed)
+}
+
+func newKafkaSource(group string) *sources.LogSource {
+	return sources.NewLogSource(group, &config.LogsConfig{
+		Type:          config.KafkaType,
+		Brokers:       []string{"localhost:9092"},
+		Topics:        []string{"app-logs"},
+		ConsumerGroup: group,
+	})
+}
+
+func TestLauncherStartsAndStopsTailers(t *testing.T) {
+	consumers := make(chan *idleConsumer, 2)
+	launcher := NewLauncherWithFactory(func(*config.LogsConfig, auditor.Registry) (tailer.Consumer, error) {
+		c := &idleConsumer{closed: make(chan struct{})}
+		consumers <- c
+		return c, nil
+	})
+	logSources := sources.NewLogSources()
+	launcher.Start(logSources, pipelineMock.NewMockProvider(), auditorMock.NewRegistry(), tailers.NewTailerTracker())
+
+	first := newKafkaSource("group-a")
+	second := newKafkaSource("group-b")
+	logSources.AddSource(first)
+	logSources.AddSource(second)
+
+	firstConsumer := <-consumers
+	secondConsumer := <-consumers
+	assert.Eventually(t, func() bool { return len(first.GetInputs()) == 1 && len(second.GetInputs()) == 1 }, 5*time.Second, 10*time.Millisecond)
+
+	// removing a source stops its tailer
+	logSources.RemoveSource(first)
+	select {
+	case <-firstConsumer.closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the consumer of the removed source has not been closed")
+	}
+
+	launcher.Stop()
+	select {
+	case <-secondConsumer.closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the consumer has not been closed")
+	}
+	assert.Empty(t, launcher.tailers)
+}
+
+func TestLauncherConsumerError(t *testing.T) {
+	launcher := NewLauncherWithFactory(func(*config.LogsConfig, auditor.Registry) (tailer.Consumer, error) {
+		return nil, errors.New("no brokers")
+	})
+	logSources := sources.NewLogSources()
+	launcher.Start(logSources, pipelineMock.NewMockProvider(), auditorMock.NewRegistry(), tailers.NewTailerTracker())
+
+	source := newKafkaSource("group-a")
+	logSources.AddSource(source)
+
+	assert.Eventually(t, func() bool { return source.Status.IsError() }, 5*time.Second, 10*time.Millisecond)
+	launcher.Stop()
+	assert.Empty(t, launcher.tailers)
+}
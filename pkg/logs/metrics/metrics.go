@@ -25,6 +25,11 @@ var (
 	// TlmLogsProcessed is the total number of processed logs.
 	TlmLogsProcessed = telemetry.NewCounter("logs", "processed",
 		nil, "Total number of processed logs")
+	// LogsSampledOut is the total number of logs dropped by sampling processing rules, per rule.
+	LogsSampledOut = expvar.Map{}
+	// TlmLogsSampledOut is the total number of logs dropped by sampling processing rules, per rule.
+	TlmLogsSampledOut = telemetry.NewCounter("logs", "sampled_out",
+		[]string{"rule"}, "Total number of logs dropped by sampling processing rules")
 
 	// LogsSent is the total number of sent logs.
 	LogsSent = expvar.Int{}
@@ -63,6 +68,12 @@ var (
 	// TlmBytesMissed is the number of bytes lost before they could be consumed by the agent, such as after log rotation
 	TlmBytesMissed = telemetry.NewCounter("logs", "bytes_missed",
 		nil, "Total number of bytes lost before they could be consumed by the agent, such as after log rotation")
+	// DiskBufferPayloads is the number of payloads stored in the disk buffers of the senders
+	DiskBufferPayloads = expvar.Int{}
+	// DiskBufferBytes is the size of the payloads stored in the disk buffers of the senders
+	DiskBufferBytes = expvar.Int{}
+	// DiskBufferDropped is the total number of payloads dropped from the disk buffers of the senders
+	DiskBufferDropped = expvar.Int{}
 	// SenderLatency the last reported latency value from the http sender (ms)
 	SenderLatency = expvar.Int{}
 	// TlmSenderLatency a histogram of http sender latency (ms)
@@ -97,6 +108,7 @@ func init() {
 	LogsExpvars = expvar.NewMap("logs-agent")
 	LogsExpvars.Set("LogsDecoded", &LogsDecoded)
 	LogsExpvars.Set("LogsProcessed", &LogsProcessed)
+	LogsExpvars.Set("LogsSampledOut", &LogsSampledOut)
 	LogsExpvars.Set("LogsSent", &LogsSent)
 	LogsExpvars.Set("DestinationErrors", &DestinationErrors)
 	LogsExpvars.Set("DestinationLogsDropped", &DestinationLogsDropped)
@@ -106,5 +118,8 @@ func init() {
 	LogsExpvars.Set("EncodedBytesSent", &EncodedBytesSent)
 	LogsExpvars.Set("BytesMissed", &BytesMissed)
 	LogsExpvars.Set("SenderLatency", &SenderLatency)
+	LogsExpvars.Set("DiskBufferPayloads", &DiskBufferPayloads)
+	LogsExpvars.Set("DiskBufferBytes", &DiskBufferBytes)
+	LogsExpvars.Set("DiskBufferDropped", &DiskBufferDropped)
 	LogsExpvars.Set("HttpDestinationStats", &DestinationExpVars)
 }
@@ -8,6 +8,8 @@ package pipeline
 
 import (
 	"context"
+	"os"
+	"path/filepath"
 	"strconv"
 	"sync"
 
@@ -18,6 +20,7 @@ import (
 	pkgconfigsetup "github.com/DataDog/datadog-agent/pkg/config/setup"
 	"github.com/DataDog/datadog-agent/pkg/logs/client"
 	"github.com/DataDog/datadog-agent/pkg/logs/client/http"
+	"github.com/DataDog/datadog-agent/pkg/logs/client/otlp"
 	"github.com/DataDog/datadog-agent/pkg/logs/client/tcp"
 	"github.com/DataDog/datadog-agent/pkg/logs/diagnostic"
 	"github.com/DataDog/datadog-agent/pkg/logs/message"
@@ -26,6 +29,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/logs/sender"
 	"github.com/DataDog/datadog-agent/pkg/logs/status/statusinterface"
 	compressioncommon "github.com/DataDog/datadog-agent/pkg/util/compression"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
 
 // Pipeline processes and sends messages to the backend
@@ -82,7 +86,12 @@ func NewPipeline(outputChan chan *message.Payload,
 	}
 
 	strategy := getStrategy(strategyInput, senderInput, flushChan, endpoints, serverless, flushWg, pipelineMonitor, compression)
-	logsSender = sender.NewSender(cfg, senderInput, outputChan, mainDestinations, pkgconfigsetup.Datadog().GetInt("logs_config.payload_channel_size"), senderDoneChan, flushWg, pipelineMonitor)
+	payloadChanSize := pkgconfigsetup.Datadog().GetInt("logs_config.payload_channel_size")
+	if diskBuffer := getDiskBuffer(cfg, serverless, pipelineID); diskBuffer != nil {
+		logsSender = sender.NewSenderWithDiskBuffer(cfg, senderInput, outputChan, mainDestinations, payloadChanSize, diskBuffer, pipelineMonitor)
+	} else {
+		logsSender = sender.NewSender(cfg, senderInput, outputChan, mainDestinations, payloadChanSize, senderDoneChan, flushWg, pipelineMonitor)
+	}
 
 	inputChan := make(chan *message.Message, pkgconfigsetup.Datadog().GetInt("logs_config.message_channel_size"))
 
@@ -147,6 +156,17 @@ func getDestinations(endpoints *config.Endpoints, destinationsContext *client.De
 				additionals = append(additionals, http.NewDestination(endpoint, http.JSONContentType, destinationsContext, endpoints.BatchMaxConcurrentSend, false, destMeta, cfg, pipelineMonitor))
 			}
 		}
+		if !serverless {
+			for i, endpoint := range endpoints.OTLPEndpoints {
+				destMeta := client.NewDestinationMetadata("logs", pipelineMonitor.ID(), "otlp", strconv.Itoa(i))
+				destination, err := otlp.NewDestination(endpoint, destinationsContext, destMeta)
+				if err != nil {
+					log.Warnf("Ignoring OTLP logs endpoint: %v", err)
+					continue
+				}
+				additionals = append(additionals, destination)
+			}
+		}
 		return client.NewDestinations(reliable, additionals)
 	}
 	for _, endpoint := range endpoints.GetReliableEndpoints() {
@@ -159,6 +179,50 @@ func getDestinations(endpoints *config.Endpoints, destinationsContext *client.De
 	return client.NewDestinations(reliable, additionals)
 }
 
+// getDiskBuffer returns the disk buffer of a pipeline, or nil if disk buffering is disabled.
+func getDiskBuffer(cfg pkgconfigmodel.Reader, serverless bool, pipelineID int) *sender.DiskBuffer {
+	if serverless || !cfg.GetBool("logs_config.disk_buffer.enabled") {
+		return nil
+	}
+	path := cfg.GetString("logs_config.disk_buffer.path")
+	if path == "" {
+		path = filepath.Join(cfg.GetString("logs_config.run_path"), "logs-buffer")
+	}
+	pipelines := max(1, cfg.GetInt("logs_config.pipelines"))
+	root := path
+	// payloads are replayed by the pipeline that spooled them, so each one has its own directory
+	path = filepath.Join(root, strconv.Itoa(pipelineID))
+	adoptOrphanDiskBuffers(root, path, pipelineID, pipelines)
+	maxSize := cfg.GetInt64("logs_config.disk_buffer.max_size_bytes") / int64(pipelines)
+
+	diskBuffer, err := sender.NewDiskBuffer(path, maxSize, cfg.GetDuration("logs_config.disk_buffer.max_age"))
+	if err != nil {
+		log.Errorf("Could not create the logs disk buffer in %s, payloads will only be buffered in memory: %v", path, err)
+		return nil
+	}
+	return diskBuffer
+}
+
+// adoptOrphanDiskBuffers moves to the disk buffer of a pipeline the payloads
+// left by the pipelines which don't exist anymore since the number of pipelines
+// was reduced. Pipeline i adopts the buffers of pipelines i+n, i+2n, ...
+func adoptOrphanDiskBuffers(root string, path string, pipelineID int, pipelines int) {
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		id, err := strconv.Atoi(entry.Name())
+		if err != nil || !entry.IsDir() || id < pipelines || id%pipelines != pipelineID {
+			continue
+		}
+		orphan := filepath.Join(root, entry.Name())
+		if err := sender.MergeDiskBuffer(orphan, path); err != nil {
+			log.Warnf("Could not move the logs payloads of %s to %s: %v", orphan, path, err)
+		}
+	}
+}
+
 //nolint:revive // TODO(AML) Fix revive linter
 func getStrategy(
 	inputChan chan *message.Message,
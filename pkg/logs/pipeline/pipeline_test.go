@@ -0,0 +1,60 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package pipeline
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/comp/logs/agent/config"
+	configmock "github.com/DataDog/datadog-agent/pkg/config/mock"
+	"github.com/DataDog/datadog-agent/pkg/logs/client"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+	"github.com/DataDog/datadog-agent/pkg/logs/metrics"
+	"github.com/DataDog/datadog-agent/pkg/logs/status/statusinterface"
+)
+
+func TestGetDestinationsWithOTLPEndpoints(t *testing.T) {
+	cfg := configmock.New(t)
+	endpoints := config.NewEndpoints(config.NewEndpoint("", "", "localhost", 8080, false), nil, false, true)
+	endpoints.OTLPEndpoints = []config.OTLPEndpoint{{Endpoint: "localhost:4317", Insecure: true}}
+	monitor := metrics.NewNoopPipelineMonitor("0")
+
+	destinations := getDestinations(endpoints, client.NewDestinationsContext(), monitor, false, nil, statusinterface.NewNoopStatusProvider(), cfg)
+	require.Len(t, destinations.Reliable, 1)
+	require.Len(t, destinations.Unreliable, 1)
+	assert.Equal(t, "localhost:4317", destinations.Unreliable[0].Target())
+
+	// OTLP endpoints are not used by serverless pipelines
+	destinations = getDestinations(endpoints, client.NewDestinationsContext(), monitor, true, nil, statusinterface.NewNoopStatusProvider(), cfg)
+	assert.Empty(t, destinations.Unreliable)
+}
+
+func TestGetDiskBufferAdoptsOrphanBuffers(t *testing.T) {
+	root := t.TempDir()
+	cfg := configmock.New(t)
+	cfg.SetWithoutSource("logs_config.disk_buffer.enabled", true)
+	cfg.SetWithoutSource("logs_config.disk_buffer.path", root)
+	cfg.SetWithoutSource("logs_config.pipelines", 4)
+
+	for i := 0; i < 4; i++ {
+		require.NoError(t, getDiskBuffer(cfg, false, i).Store(&message.Payload{Encoded: []byte("payload")}))
+	}
+
+	// with 2 pipelines, the payloads of pipelines 2 and 3 are replayed by pipelines 0 and 1
+	cfg.SetWithoutSource("logs_config.pipelines", 2)
+	assert.Equal(t, 2, getDiskBuffer(cfg, false, 0).Len())
+	assert.Equal(t, 2, getDiskBuffer(cfg, false, 1).Len())
+	entries, err := os.ReadDir(root)
+	require.NoError(t, err)
+	assert.Len(t, entries, 2)
+	assert.NoDirExists(t, filepath.Join(root, "2"))
+	assert.NoDirExists(t, filepath.Join(root, "3"))
+}
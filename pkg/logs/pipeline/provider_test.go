@@ -14,6 +14,7 @@ import (
 
 	"github.com/DataDog/datadog-agent/comp/logs/agent/config"
 	compressionfx "github.com/DataDog/datadog-agent/comp/serializer/logscompression/fx-mock"
+	configmock "github.com/DataDog/datadog-agent/pkg/config/mock"
 	"github.com/DataDog/datadog-agent/pkg/logs/auditor"
 	"github.com/DataDog/datadog-agent/pkg/status/health"
 )
@@ -33,6 +34,7 @@ func (suite *ProviderTestSuite) SetupTest() {
 		endpoints:            config.NewEndpoints(config.Endpoint{}, nil, true, false),
 		currentPipelineIndex: atomic.NewUint32(0),
 		compression:          compressionfx.NewMockCompressor(),
+		cfg:                  configmock.New(suite.T()),
 	}
 }
 
@@ -0,0 +1,492 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package processor
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/DataDog/datadog-agent/comp/logs/agent/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+)
+
+// defaultAttributeMask replaces the value of an attribute masked by a
+// mask_attribute rule without any replace_placeholder.
+const defaultAttributeMask = "[REDACTED]"
+
+// attributeReference matches the `%{path}` references in the value of an
+// add_attribute rule.
+var attributeReference = regexp.MustCompile(`%\{([^}]+)\}`)
+
+type attributesFormat int
+
+const (
+	formatUnknown attributesFormat = iota
+	formatJSON
+	formatLogfmt
+)
+
+// attributes is the parsed representation of a JSON or logfmt log line on which
+// attribute processing rules are applied. A log line which is neither a JSON
+// object nor a logfmt line is left untouched by these rules.
+type attributes struct {
+	format   attributesFormat
+	modified bool
+
+	// JSON content
+	object *jsonObject
+
+	// logfmt content, keys are kept in order to render the line as it came in.
+	keys   []string
+	values map[string]string
+}
+
+// parseAttributes parses the content as a JSON object or as a logfmt line.
+func parseAttributes(content []byte) *attributes {
+	trimmed := bytes.TrimSpace(content)
+	if len(trimmed) > 0 && trimmed[0] == '{' {
+		decoder := json.NewDecoder(bytes.NewReader(trimmed))
+		decoder.UseNumber()
+		value, err := decodeJSONValue(decoder)
+		object, ok := value.(*jsonObject)
+		if err != nil || !ok || decoder.More() {
+			return &attributes{}
+		}
+		return &attributes{format: formatJSON, object: object}
+	}
+
+	if keys, values, ok := parseLogfmt(trimmed); ok {
+		return &attributes{format: formatLogfmt, keys: keys, values: values}
+	}
+	return &attributes{}
+}
+
+// render returns the content of the log line once the rules have been applied,
+// the original content is returned if nothing has changed.
+func (a *attributes) render(content []byte) []byte {
+	if !a.modified {
+		return content
+	}
+	switch a.format {
+	case formatJSON:
+		var buf bytes.Buffer
+		encoder := json.NewEncoder(&buf)
+		encoder.SetEscapeHTML(false)
+		if err := encoder.Encode(a.object); err != nil {
+			return content
+		}
+		return bytes.TrimSuffix(buf.Bytes(), []byte{'\n'})
+	case formatLogfmt:
+		return renderLogfmt(a.keys, a.values)
+	}
+	return content
+}
+
+// apply applies the given attribute rule.
+func (a *attributes) apply(rule *config.ProcessingRule, msg *message.Message) {
+	if a.format == formatUnknown {
+		return
+	}
+
+	switch rule.Type {
+	case config.DropAttribute:
+		if value, exists := a.getString(rule.AttributePath); exists && a.matches(rule, value) {
+			a.delete(rule.AttributePath)
+		}
+	case config.RenameAttribute:
+		// the attribute is left in place if it can't be moved to the target
+		if !a.canSet(rule.TargetPath) {
+			return
+		}
+		if value, exists := a.delete(rule.AttributePath); exists {
+			a.set(rule.TargetPath, value)
+		}
+	case config.HashAttribute:
+		if value, exists := a.getString(rule.AttributePath); exists && a.matches(rule, value) {
+			a.set(rule.AttributePath, hashValue(rule.HashKey, value))
+		}
+	case config.MaskAttribute:
+		value, exists := a.getString(rule.AttributePath)
+		if !exists {
+			return
+		}
+		placeholder := rule.ReplacePlaceholder
+		if rule.Regex != nil {
+			if rule.Regex.MatchString(value) {
+				a.set(rule.AttributePath, rule.Regex.ReplaceAllString(value, placeholder))
+			}
+			return
+		}
+		if placeholder == "" {
+			placeholder = defaultAttributeMask
+		}
+		a.set(rule.AttributePath, placeholder)
+	case config.AddAttribute:
+		value := attributeReference.ReplaceAllStringFunc(rule.Value, func(ref string) string {
+			path := strings.Split(ref[2:len(ref)-1], ".")
+			v, _ := a.getString(path)
+			return v
+		})
+		a.set(rule.AttributePath, value)
+	case config.RouteOnAttribute:
+		if value, exists := a.getString(rule.AttributePath); exists && a.matches(rule, value) {
+			msg.ProcessingTags = append(msg.ProcessingTags, rule.Tags...)
+		}
+	}
+}
+
+// hashValue returns the hex-encoded HMAC-SHA256 of the value with the given key,
+// or its plain SHA-256 if there is no key.
+func hashValue(key string, value string) string {
+	if key == "" {
+		sum := sha256.Sum256([]byte(value))
+		return hex.EncodeToString(sum[:])
+	}
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(value))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// matches returns true if the rule has no pattern or if its pattern matches the value.
+func (a *attributes) matches(rule *config.ProcessingRule, value string) bool {
+	return rule.Regex == nil || rule.Regex.MatchString(value)
+}
+
+// getString returns the value at the given path, non-string values are
+// returned in their JSON representation.
+func (a *attributes) getString(path []string) (string, bool) {
+	switch a.format {
+	case formatJSON:
+		value, exists := lookup(a.object, path)
+		if !exists {
+			return "", false
+		}
+		if s, ok := value.(string); ok {
+			return s, true
+		}
+		data, err := json.Marshal(value)
+		if err != nil {
+			return "", false
+		}
+		return string(data), true
+	case formatLogfmt:
+		value, exists := a.values[strings.Join(path, ".")]
+		return value, exists
+	}
+	return "", false
+}
+
+// canSet returns false if one of the parents of the path is a value that
+// isn't an object, it would otherwise be overwritten to set the path.
+func (a *attributes) canSet(path []string) bool {
+	if a.format != formatJSON {
+		return true
+	}
+	current := a.object
+	for _, segment := range path[:len(path)-1] {
+		value, exists := current.get(segment)
+		if !exists {
+			return true
+		}
+		next, ok := value.(*jsonObject)
+		if !ok {
+			return false
+		}
+		current = next
+	}
+	return true
+}
+
+// set stores the value at the given path, creating the intermediate objects
+// if necessary. Nothing is set if a parent of the path isn't an object.
+func (a *attributes) set(path []string, value interface{}) {
+	if !a.canSet(path) {
+		return
+	}
+	switch a.format {
+	case formatJSON:
+		current := a.object
+		for _, segment := range path[:len(path)-1] {
+			next, ok := current.get(segment)
+			if !ok {
+				next = newJSONObject()
+				current.set(segment, next)
+			}
+			current = next.(*jsonObject)
+		}
+		current.set(path[len(path)-1], value)
+	case formatLogfmt:
+		key := strings.Join(path, ".")
+		if _, exists := a.values[key]; !exists {
+			a.keys = append(a.keys, key)
+		}
+		switch v := value.(type) {
+		case string:
+			a.values[key] = v
+		default:
+			data, _ := json.Marshal(v)
+			a.values[key] = string(data)
+		}
+	}
+	a.modified = true
+}
+
+// delete removes the value at the given path and returns it.
+func (a *attributes) delete(path []string) (interface{}, bool) {
+	switch a.format {
+	case formatJSON:
+		parent, exists := lookup(a.object, path[:len(path)-1])
+		if !exists {
+			return nil, false
+		}
+		object, ok := parent.(*jsonObject)
+		if !ok {
+			return nil, false
+		}
+		value, exists := object.get(path[len(path)-1])
+		if !exists {
+			return nil, false
+		}
+		object.delete(path[len(path)-1])
+		a.modified = true
+		return value, true
+	case formatLogfmt:
+		key := strings.Join(path, ".")
+		value, exists := a.values[key]
+		if !exists {
+			return nil, false
+		}
+		delete(a.values, key)
+		for i, k := range a.keys {
+			if k == key {
+				a.keys = append(a.keys[:i], a.keys[i+1:]...)
+				break
+			}
+		}
+		a.modified = true
+		return value, true
+	}
+	return nil, false
+}
+
+// lookup returns the value at the given path in a JSON object.
+func lookup(object *jsonObject, path []string) (interface{}, bool) {
+	var current interface{} = object
+	for _, segment := range path {
+		o, ok := current.(*jsonObject)
+		if !ok {
+			return nil, false
+		}
+		if current, ok = o.get(segment); !ok {
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+// jsonObject is a JSON object which keeps the order of its keys, so that a log
+// line is rendered with its keys in the order they came in.
+type jsonObject struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+func newJSONObject() *jsonObject {
+	return &jsonObject{values: make(map[string]interface{})}
+}
+
+func (o *jsonObject) get(key string) (interface{}, bool) {
+	value, exists := o.values[key]
+	return value, exists
+}
+
+// set updates the value of an existing key in place, new keys are added last.
+func (o *jsonObject) set(key string, value interface{}) {
+	if _, exists := o.values[key]; !exists {
+		o.keys = append(o.keys, key)
+	}
+	o.values[key] = value
+}
+
+func (o *jsonObject) delete(key string) {
+	if _, exists := o.values[key]; !exists {
+		return
+	}
+	delete(o.values, key)
+	for i, k := range o.keys {
+		if k == key {
+			o.keys = append(o.keys[:i], o.keys[i+1:]...)
+			break
+		}
+	}
+}
+
+// MarshalJSON renders the object with its keys in order.
+func (o *jsonObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	buf.WriteByte('{')
+	for i, key := range o.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		if err := encoder.Encode(key); err != nil {
+			return nil, err
+		}
+		buf.Truncate(buf.Len() - 1) // the encoder adds a newline after each value
+		buf.WriteByte(':')
+		if err := encoder.Encode(o.values[key]); err != nil {
+			return nil, err
+		}
+		buf.Truncate(buf.Len() - 1)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// decodeJSONValue decodes the next JSON value of the decoder, objects are
+// decoded as *jsonObject to keep the order of their keys.
+func decodeJSONValue(decoder *json.Decoder) (interface{}, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+	delim, ok := token.(json.Delim)
+	if !ok {
+		return token, nil
+	}
+	switch delim {
+	case '{':
+		object := newJSONObject()
+		for decoder.More() {
+			token, err := decoder.Token()
+			if err != nil {
+				return nil, err
+			}
+			key, ok := token.(string)
+			if !ok {
+				return nil, errors.New("invalid object key")
+			}
+			value, err := decodeJSONValue(decoder)
+			if err != nil {
+				return nil, err
+			}
+			object.set(key, value)
+		}
+		_, err = decoder.Token()
+		return object, err
+	case '[':
+		array := []interface{}{}
+		for decoder.More() {
+			value, err := decodeJSONValue(decoder)
+			if err != nil {
+				return nil, err
+			}
+			array = append(array, value)
+		}
+		_, err = decoder.Token()
+		return array, err
+	}
+	return nil, errors.New("unexpected delimiter")
+}
+
+// parseLogfmt parses a logfmt line, e.g. `level=info msg="hello world" user.id=12`.
+// The line is only considered valid if every token is a key/value pair, so that
+// plain text lines which happen to contain a `k=v` token are left untouched.
+func parseLogfmt(line []byte) ([]string, map[string]string, bool) {
+	var keys []string
+	values := make(map[string]string)
+
+	i := 0
+	for i < len(line) {
+		// skip spaces
+		for i < len(line) && line[i] == ' ' {
+			i++
+		}
+		if i == len(line) {
+			break
+		}
+
+		// key
+		start := i
+		for i < len(line) && line[i] != '=' && line[i] != ' ' {
+			if line[i] == '"' {
+				return nil, nil, false
+			}
+			i++
+		}
+		key := string(line[start:i])
+		if key == "" {
+			return nil, nil, false
+		}
+
+		if i == len(line) || line[i] != '=' {
+			return nil, nil, false
+		}
+		i++
+
+		value := ""
+		if i < len(line) && line[i] == '"' {
+			// quoted value, look for the closing quote
+			end := i + 1
+			for end < len(line) && line[end] != '"' {
+				if line[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(line) {
+				return nil, nil, false
+			}
+			unquoted, err := strconv.Unquote(string(line[i : end+1]))
+			if err != nil {
+				return nil, nil, false
+			}
+			value = unquoted
+			i = end + 1
+		} else {
+			start = i
+			for i < len(line) && line[i] != ' ' {
+				i++
+			}
+			value = string(line[start:i])
+		}
+
+		if _, exists := values[key]; !exists {
+			keys = append(keys, key)
+		}
+		values[key] = value
+	}
+
+	return keys, values, len(keys) > 0
+}
+
+// renderLogfmt renders the key/value pairs as a logfmt line.
+func renderLogfmt(keys []string, values map[string]string) []byte {
+	var buf bytes.Buffer
+	for i, key := range keys {
+		if i > 0 {
+			buf.WriteByte(' ')
+		}
+		buf.WriteString(key)
+		buf.WriteByte('=')
+		value := values[key]
+		if strings.ContainsAny(value, " =\"\\") {
+			buf.WriteString(strconv.Quote(value))
+		} else {
+			buf.WriteString(value)
+		}
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,200 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package processor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/comp/logs/agent/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/sources"
+)
+
+func newAttributeRule(rule config.ProcessingRule) *config.ProcessingRule {
+	rule.Name = "test"
+	if err := config.CompileProcessingRules([]*config.ProcessingRule{&rule}); err != nil {
+		panic(err)
+	}
+	return &rule
+}
+
+func TestAttributeRules(t *testing.T) {
+	tests := []struct {
+		name   string
+		rule   config.ProcessingRule
+		input  string
+		output string
+	}{
+		{
+			name:   "drop nested attribute",
+			rule:   config.ProcessingRule{Type: config.DropAttribute, Attribute: "user.password"},
+			input:  `{"msg":"login","user":{"name":"bob","password":"hunter2"}}`,
+			output: `{"msg":"login","user":{"name":"bob"}}`,
+		},
+		{
+			name:   "drop attribute matching pattern",
+			rule:   config.ProcessingRule{Type: config.DropAttribute, Attribute: "level", Pattern: "^debug$"},
+			input:  `{"level":"info","msg":"hello"}`,
+			output: `{"level":"info","msg":"hello"}`,
+		},
+		{
+			name:   "drop missing attribute",
+			rule:   config.ProcessingRule{Type: config.DropAttribute, Attribute: "user.password"},
+			input:  `{"msg":"hello"}`,
+			output: `{"msg":"hello"}`,
+		},
+		{
+			name:   "rename attribute",
+			rule:   config.ProcessingRule{Type: config.RenameAttribute, Attribute: "lvl", TargetAttribute: "log.level"},
+			input:  `{"lvl":"warn","msg":"hello"}`,
+			output: `{"msg":"hello","log":{"level":"warn"}}`,
+		},
+		{
+			name:   "rename attribute under a value which isn't an object",
+			rule:   config.ProcessingRule{Type: config.RenameAttribute, Attribute: "lvl", TargetAttribute: "msg.level"},
+			input:  `{"lvl":"warn","msg":"hello"}`,
+			output: `{"lvl":"warn","msg":"hello"}`,
+		},
+		{
+			name:   "hash attribute",
+			rule:   config.ProcessingRule{Type: config.HashAttribute, Attribute: "user.email"},
+			input:  `{"user":{"email":"bob@example.com"}}`,
+			output: `{"user":{"email":"5ff860bf1190596c7188ab851db691f0f3169c453936e9e1eba2f9a47f7a0018"}}`,
+		},
+		{
+			name:   "hash attribute with a key",
+			rule:   config.ProcessingRule{Type: config.HashAttribute, Attribute: "user.email", HashKey: "secret"},
+			input:  `{"user":{"email":"bob@example.com"}}`,
+			output: `{"user":{"email":"19d2874a5656a44394f7a94c5fa00a19a04fd9114939a49ed875ff70385f0352"}}`,
+		},
+		{
+			name:   "mask attribute",
+			rule:   config.ProcessingRule{Type: config.MaskAttribute, Attribute: "card"},
+			input:  `{"card":"4242424242424242","amount":12.5}`,
+			output: `{"card":"[REDACTED]","amount":12.5}`,
+		},
+		{
+			name:   "mask sequences in attribute",
+			rule:   config.ProcessingRule{Type: config.MaskAttribute, Attribute: "card", Pattern: `\d{12}`, ReplacePlaceholder: "************"},
+			input:  `{"card":"4242424242424242"}`,
+			output: `{"card":"************4242"}`,
+		},
+		{
+			name:   "add computed attribute",
+			rule:   config.ProcessingRule{Type: config.AddAttribute, Attribute: "http.summary", Value: "%{http.method} %{http.url}"},
+			input:  `{"http":{"method":"GET","url":"/users"}}`,
+			output: `{"http":{"method":"GET","url":"/users","summary":"GET /users"}}`,
+		},
+		{
+			name:   "add attribute under a value which isn't an object",
+			rule:   config.ProcessingRule{Type: config.AddAttribute, Attribute: "http.method.name", Value: "GET"},
+			input:  `{"http":{"method":"GET"}}`,
+			output: `{"http":{"method":"GET"}}`,
+		},
+		{
+			name:   "key order and values are preserved",
+			rule:   config.ProcessingRule{Type: config.DropAttribute, Attribute: "b"},
+			input:  `{"z":1,"b":2,"a":{"y":[{"d":1,"c":"<x>"}],"x":1.50},"m":null}`,
+			output: `{"z":1,"a":{"y":[{"d":1,"c":"<x>"}],"x":1.50},"m":null}`,
+		},
+		{
+			name:   "logfmt",
+			rule:   config.ProcessingRule{Type: config.MaskAttribute, Attribute: "token"},
+			input:  `level=info msg="user logged in" token=abcdef`,
+			output: `level=info msg="user logged in" token=[REDACTED]`,
+		},
+		{
+			name:   "logfmt rename",
+			rule:   config.ProcessingRule{Type: config.RenameAttribute, Attribute: "lvl", TargetAttribute: "level"},
+			input:  `lvl=info msg=hello`,
+			output: `msg=hello level=info`,
+		},
+		{
+			name:   "unstructured content is left untouched",
+			rule:   config.ProcessingRule{Type: config.DropAttribute, Attribute: "password"},
+			input:  `the password is hunter2`,
+			output: `the password is hunter2`,
+		},
+		{
+			name:   "plain text with a key/value pair is left untouched",
+			rule:   config.ProcessingRule{Type: config.MaskAttribute, Attribute: "token"},
+			input:  `login failed for bob token=abcdef`,
+			output: `login failed for bob token=abcdef`,
+		},
+		{
+			name:   "invalid json is left untouched",
+			rule:   config.ProcessingRule{Type: config.DropAttribute, Attribute: "password"},
+			input:  `{"password":"hunter2"`,
+			output: `{"password":"hunter2"`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			p := &Processor{processingRules: []*config.ProcessingRule{newAttributeRule(test.rule)}}
+			source := sources.LogSource{Config: &config.LogsConfig{}}
+			msg := newMessage([]byte(test.input), &source, "")
+			assert.True(t, p.applyRedactingRules(msg))
+			assert.Equal(t, test.output, string(msg.GetContent()))
+		})
+	}
+}
+
+func TestRouteOnAttribute(t *testing.T) {
+	rule := newAttributeRule(config.ProcessingRule{
+		Type:      config.RouteOnAttribute,
+		Attribute: "tenant.id",
+		Pattern:   "^acme$",
+		Tags:      []string{"team:acme"},
+	})
+	p := &Processor{processingRules: []*config.ProcessingRule{rule}}
+	source := sources.LogSource{Config: &config.LogsConfig{}}
+
+	msg := newMessage([]byte(`{"tenant":{"id":"acme"}}`), &source, "")
+	assert.True(t, p.applyRedactingRules(msg))
+	assert.Equal(t, []string{"team:acme"}, msg.ProcessingTags)
+	assert.Equal(t, `{"tenant":{"id":"acme"}}`, string(msg.GetContent()))
+
+	msg = newMessage([]byte(`{"tenant":{"id":"other"}}`), &source, "")
+	assert.True(t, p.applyRedactingRules(msg))
+	assert.Empty(t, msg.ProcessingTags)
+}
+
+func TestAttributeRulesMixedWithRegexRules(t *testing.T) {
+	p := &Processor{processingRules: []*config.ProcessingRule{
+		newAttributeRule(config.ProcessingRule{Type: config.DropAttribute, Attribute: "debug"}),
+		newProcessingRule(config.ExcludeAtMatch, "", `"debug"`),
+		newAttributeRule(config.ProcessingRule{Type: config.MaskAttribute, Attribute: "token"}),
+	}}
+	source := sources.LogSource{Config: &config.LogsConfig{}}
+
+	// the exclusion rule is applied on the content rendered after the first rule
+	msg := newMessage([]byte(`{"debug":true,"token":"secret"}`), &source, "")
+	require.True(t, p.applyRedactingRules(msg))
+	assert.Equal(t, `{"token":"[REDACTED]"}`, string(msg.GetContent()))
+}
+
+func TestParseLogfmt(t *testing.T) {
+	keys, values, ok := parseLogfmt([]byte(`a=1 b="two words" d=`))
+	require.True(t, ok)
+	assert.Equal(t, []string{"a", "b", "d"}, keys)
+	assert.Equal(t, map[string]string{"a": "1", "b": "two words", "d": ""}, values)
+	assert.Equal(t, `a=1 b="two words" d=`, string(renderLogfmt(keys, values)))
+
+	_, _, ok = parseLogfmt([]byte(`just some words`))
+	assert.False(t, ok)
+
+	// plain text with a single key/value pair isn't logfmt
+	_, _, ok = parseLogfmt([]byte(`retrying the request status=503 in 5s`))
+	assert.False(t, ok)
+	_, _, ok = parseLogfmt([]byte(`a=1 b`))
+	assert.False(t, ok)
+
+	_, _, ok = parseLogfmt([]byte(`a="unterminated`))
+	assert.False(t, ok)
+}
@@ -266,7 +266,22 @@ func (p *Processor) applyRedactingRules(msg *message.Message) bool {
 	// ---------------------------
 
 	rules := append(p.processingRules, msg.Origin.LogSource.Config.ProcessingRules...)
+	// attrs is lazily parsed on the first attribute rule, it has to be rendered back
+	// into the content before any rule operating on the raw line.
+	var attrs *attributes
 	for _, rule := range rules {
+		if rule.IsAttributeRule() {
+			if attrs == nil {
+				attrs = parseAttributes(content)
+			}
+			attrs.apply(rule, msg)
+			continue
+		}
+		if attrs != nil {
+			content = attrs.render(content)
+			attrs = nil
+		}
+
 		switch rule.Type {
 		case config.ExcludeAtMatch:
 			// if this message matches, we ignore it
@@ -278,12 +293,22 @@ func (p *Processor) applyRedactingRules(msg *message.Message) bool {
 			if !rule.Regex.Match(content) {
 				return false
 			}
+		case config.SampleAtMatch:
+			// if this message matches but is out of the sampling budget, we ignore it
+			if !samplerOf(rule).keep(content) {
+				metrics.LogsSampledOut.Add(rule.Name, 1)
+				metrics.TlmLogsSampledOut.Inc(rule.Name)
+				return false
+			}
 		case config.MaskSequences:
 			if isMatchingLiteralPrefix(rule.Regex, content) {
 				content = rule.Regex.ReplaceAll(content, rule.Placeholder)
 			}
 		}
 	}
+	if attrs != nil {
+		content = attrs.render(content)
+	}
 
 	// Use the SDS implementation
 	// --------------------------
@@ -6,6 +6,7 @@
 package processor
 
 import (
+	"expvar"
 	"regexp"
 	"sync/atomic"
 	"testing"
@@ -534,3 +535,26 @@ func BenchmarkMaskSequences(b *testing.B) {
 	})
 
 }
+
+func TestSampling(t *testing.T) {
+	rules := []*config.ProcessingRule{{Type: config.SampleAtMatch, Name: "sample_debug", Pattern: "DEBUG", SampleRate: 0.5}}
+	assert.NoError(t, config.CompileProcessingRules(rules))
+	p := &Processor{processingRules: rules}
+	source := sources.LogSource{Config: &config.LogsConfig{}}
+
+	before := metrics.LogsSampledOut.Get("sample_debug")
+	kept := 0
+	for i := 0; i < 10; i++ {
+		if p.applyRedactingRules(newMessage([]byte("DEBUG hello"), &source, "")) {
+			kept++
+		}
+	}
+	assert.Equal(t, 5, kept)
+	assert.True(t, p.applyRedactingRules(newMessage([]byte("INFO hello"), &source, "")))
+
+	sampledOut := metrics.LogsSampledOut.Get("sample_debug").(*expvar.Int).Value()
+	if before != nil {
+		sampledOut -= before.(*expvar.Int).Value()
+	}
+	assert.Equal(t, int64(5), sampledOut)
+}
@@ -0,0 +1,145 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package processor
+
+import (
+	"container/list"
+	"math"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/comp/logs/agent/config"
+)
+
+// maxSamplingKeys bounds the number of budgets tracked by a sampler, the
+// least recently used budget is evicted to track a new key over this limit.
+const maxSamplingKeys = 1000
+
+// samplingPrecision is the precision at which the sample rate is applied,
+// credits are integers to avoid accumulating floating point errors.
+const samplingPrecision = int64(1 / config.MinSampleRate)
+
+// sampler keeps a fraction and/or a per-second budget of the lines matching
+// a sample_at_match processing rule. Sampling is deterministic: with a sample
+// rate of 0.1, exactly one line out of ten is kept.
+// A sampler is safe for concurrent use since the same rule can be applied
+// by several pipelines.
+type sampler struct {
+	regex      *regexp.Regexp
+	sampleRate int64
+	rateLimit  int
+	group      int
+
+	mu      sync.Mutex
+	budgets map[string]*list.Element
+	// lru orders the budgets from the most to the least recently used.
+	lru *list.List
+	now func() time.Time
+}
+
+type samplingBudget struct {
+	key string
+	// credit accumulates the sample rate for every line, a line is kept when
+	// a full credit is available.
+	credit int64
+	// window is the second in which count lines have been kept.
+	window int64
+	count  int
+}
+
+// newSampler returns a sampler applied on the lines matching the given regex.
+// A sampleRate of 0 or a rateLimit of 0 disables respectively the fraction or the
+// per-second budget. When group is positive, a budget is kept per value of
+// the capture group of the given index.
+func newSampler(regex *regexp.Regexp, sampleRate float64, rateLimit int, group int) *sampler {
+	rate := int64(math.Round(sampleRate * float64(samplingPrecision)))
+	if sampleRate > 0 && rate == 0 {
+		// rates below the precision are rejected by the validation of the rules,
+		// they must not end up disabling the sampling
+		rate = 1
+	}
+	return &sampler{
+		regex:      regex,
+		sampleRate: rate,
+		rateLimit:  rateLimit,
+		group:      group,
+		budgets:    make(map[string]*list.Element),
+		lru:        list.New(),
+		now:        time.Now,
+	}
+}
+
+// samplerOf returns the sampler of a compiled sample_at_match rule, created on
+// its first use and then shared by all the pipelines applying the rule.
+func samplerOf(rule *config.ProcessingRule) *sampler {
+	if s, ok := rule.Sampler.Load().(*sampler); ok {
+		return s
+	}
+	rule.Sampler.CompareAndSwap(nil, newSampler(rule.Regex, rule.SampleRate, rule.RateLimit, rule.GroupIndex))
+	return rule.Sampler.Load().(*sampler)
+}
+
+// keep returns false if the content matches the sampler pattern and has to be
+// dropped to respect the configured budget.
+func (s *sampler) keep(content []byte) bool {
+	var key string
+	if s.group > 0 {
+		match := s.regex.FindSubmatchIndex(content)
+		if match == nil {
+			return true
+		}
+		if start := match[2*s.group]; start >= 0 {
+			key = string(content[start:match[2*s.group+1]])
+		}
+	} else if !s.regex.Match(content) {
+		return true
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	budget := s.budget(key)
+
+	if s.sampleRate > 0 {
+		budget.credit += s.sampleRate
+		if budget.credit < samplingPrecision {
+			return false
+		}
+		budget.credit -= samplingPrecision
+	}
+
+	if s.rateLimit > 0 {
+		window := s.now().Unix()
+		if budget.window != window {
+			budget.window = window
+			budget.count = 0
+		}
+		if budget.count >= s.rateLimit {
+			return false
+		}
+		budget.count++
+	}
+
+	return true
+}
+
+// budget returns the budget of the given key, s.mu must be held.
+func (s *sampler) budget(key string) *samplingBudget {
+	if element, exists := s.budgets[key]; exists {
+		s.lru.MoveToFront(element)
+		return element.Value.(*samplingBudget)
+	}
+	if s.lru.Len() >= maxSamplingKeys {
+		oldest := s.lru.Back()
+		s.lru.Remove(oldest)
+		delete(s.budgets, oldest.Value.(*samplingBudget).key)
+	}
+	// the first matching line is always kept by the sample rate
+	budget := &samplingBudget{key: key, credit: samplingPrecision - s.sampleRate}
+	s.budgets[key] = s.lru.PushFront(budget)
+	return budget
+}
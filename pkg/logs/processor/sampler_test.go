@@ -0,0 +1,105 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package processor
+
+import (
+	"fmt"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/comp/logs/agent/config"
+)
+
+func countKept(s *sampler, content string, n int) int {
+	kept := 0
+	for i := 0; i < n; i++ {
+		if s.keep([]byte(content)) {
+			kept++
+		}
+	}
+	return kept
+}
+
+func TestSamplerSampleRate(t *testing.T) {
+	s := newSampler(regexp.MustCompile("DEBUG"), 0.1, 0, -1)
+
+	// the first matching line is kept, then one line out of ten
+	assert.True(t, s.keep([]byte("DEBUG first")))
+	assert.Equal(t, 9, countKept(s, "DEBUG line", 99))
+
+	// lines not matching the pattern are always kept
+	assert.Equal(t, 100, countKept(s, "INFO line", 100))
+}
+
+func TestSamplerSmallSampleRate(t *testing.T) {
+	s := newSampler(regexp.MustCompile("DEBUG"), config.MinSampleRate, 0, -1)
+	assert.True(t, s.keep([]byte("DEBUG first")))
+	assert.Equal(t, 0, countKept(s, "DEBUG line", 1000))
+
+	// a rate below the precision still drops lines instead of keeping them all
+	s = newSampler(regexp.MustCompile("DEBUG"), config.MinSampleRate/10, 0, -1)
+	assert.True(t, s.keep([]byte("DEBUG first")))
+	assert.Equal(t, 0, countKept(s, "DEBUG line", 1000))
+}
+
+func TestSamplerRateLimit(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	s := newSampler(regexp.MustCompile("DEBUG"), 0, 5, -1)
+	s.now = func() time.Time { return now }
+
+	assert.Equal(t, 5, countKept(s, "DEBUG line", 20))
+
+	now = now.Add(time.Second)
+	assert.Equal(t, 5, countKept(s, "DEBUG line", 20))
+}
+
+func TestSamplerRateLimitGroupBy(t *testing.T) {
+	re := regexp.MustCompile(`error=(?P<signature>\w+)`)
+	s := newSampler(re, 0, 2, re.SubexpIndex("signature"))
+	s.now = func() time.Time { return time.Unix(1700000000, 0) }
+
+	assert.Equal(t, 2, countKept(s, "error=timeout", 10))
+	assert.Equal(t, 2, countKept(s, "error=refused", 10))
+	assert.Equal(t, 10, countKept(s, "no error", 10))
+}
+
+func TestSamplerMaxKeys(t *testing.T) {
+	re := regexp.MustCompile(`id=(\d+)`)
+	s := newSampler(re, 0, 1, 1)
+	s.now = func() time.Time { return time.Unix(1700000000, 0) }
+
+	for i := 0; i < maxSamplingKeys; i++ {
+		assert.True(t, s.keep([]byte(fmt.Sprintf("id=%d", i))))
+	}
+	// the first key is used again, the second one is then the least recently used
+	assert.False(t, s.keep([]byte("id=0")))
+
+	// new keys over the limit get their own budget, and evict the least recently used ones
+	for i := maxSamplingKeys; i < maxSamplingKeys+10; i++ {
+		assert.True(t, s.keep([]byte(fmt.Sprintf("id=%d", i))))
+	}
+	assert.Len(t, s.budgets, maxSamplingKeys)
+	assert.Contains(t, s.budgets, "0")
+	assert.NotContains(t, s.budgets, "1")
+
+	// an evicted key starts over with a new budget
+	assert.True(t, s.keep([]byte("id=1")))
+}
+
+func TestSamplerOf(t *testing.T) {
+	rules := []*config.ProcessingRule{{Type: config.SampleAtMatch, Name: "sample", Pattern: `error=(?P<sig>\w+)`, RateLimit: 1, GroupBy: "sig"}}
+	require.NoError(t, config.CompileProcessingRules(rules))
+
+	s := samplerOf(rules[0])
+	assert.Equal(t, 1, s.group)
+	assert.Equal(t, 1, s.rateLimit)
+	// the pipelines applying the rule share its sampler
+	assert.Same(t, s, samplerOf(rules[0]))
+}
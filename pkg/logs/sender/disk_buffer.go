@@ -0,0 +1,274 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package sender
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+	"github.com/DataDog/datadog-agent/pkg/logs/metrics"
+	"github.com/DataDog/datadog-agent/pkg/telemetry"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	diskBufferExtension = ".payload"
+	diskBufferVersion   = 1
+	// version, unencoded size and encoding length
+	diskBufferHeaderSize = 1 + 8 + 2
+)
+
+var (
+	tlmDiskBufferSpooled  = telemetry.NewCounter("logs_sender", "disk_buffer_spooled", nil, "Payloads written to the disk buffer")
+	tlmDiskBufferReplayed = telemetry.NewCounter("logs_sender", "disk_buffer_replayed", nil, "Payloads replayed from the disk buffer")
+	tlmDiskBufferDropped  = telemetry.NewCounter("logs_sender", "disk_buffer_dropped", []string{"reason"}, "Payloads dropped from the disk buffer")
+	tlmDiskBufferBytes    = telemetry.NewGauge("logs_sender", "disk_buffer_bytes", nil, "Size of the payloads stored in the disk buffer")
+	tlmDiskBufferPayloads = telemetry.NewGauge("logs_sender", "disk_buffer_payloads", nil, "Number of payloads stored in the disk buffer")
+)
+
+var errCorruptedPayload = errors.New("corrupted payload file")
+
+// DiskBuffer stores payloads on disk while the reliable destinations are
+// unavailable, so they can be replayed in order once they recover. Each payload
+// is stored in its own file, named after its spooling time.
+//
+// Only the encoded content of the payloads is stored: replayed payloads have no
+// messages since their offsets were committed to the auditor when they were
+// spooled.
+//
+// DiskBuffer is not safe for concurrent use.
+type DiskBuffer struct {
+	path      string
+	maxSize   int64
+	maxAge    time.Duration
+	filenames []string
+	sizes     map[string]int64
+	size      int64
+	sequence  uint64
+	now       func() time.Time
+}
+
+// NewDiskBuffer returns a disk buffer storing at most maxSize bytes of payloads
+// younger than maxAge in path. Payloads left by a previous run are kept.
+func NewDiskBuffer(path string, maxSize int64, maxAge time.Duration) (*DiskBuffer, error) {
+	if err := os.MkdirAll(path, 0700); err != nil {
+		return nil, err
+	}
+	b := &DiskBuffer{
+		path:    path,
+		maxSize: maxSize,
+		maxAge:  maxAge,
+		sizes:   make(map[string]int64),
+		now:     time.Now,
+	}
+	if err := b.reload(); err != nil {
+		return nil, err
+	}
+	if len(b.filenames) > 0 {
+		log.Infof("Found %d logs payloads (%d bytes) to replay in %s", len(b.filenames), b.size, path)
+	}
+	return b, nil
+}
+
+// MergeDiskBuffer moves the payloads stored in the src directory to the dst
+// directory, and removes src once it's empty. File names start with the spooling
+// time, so the payloads of both directories are then replayed in order.
+func MergeDiskBuffer(src string, dst string) error {
+	entries, err := os.ReadDir(src)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dst, 0700); err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() || filepath.Ext(entry.Name()) != diskBufferExtension {
+			continue
+		}
+		target := filepath.Join(dst, entry.Name())
+		if _, err := os.Stat(target); err == nil {
+			return fmt.Errorf("logs payload %s already exists", target)
+		}
+		if err := os.Rename(filepath.Join(src, entry.Name()), target); err != nil {
+			return err
+		}
+	}
+	return os.RemoveAll(src)
+}
+
+// Len returns the number of payloads in the buffer.
+func (b *DiskBuffer) Len() int {
+	return len(b.filenames)
+}
+
+// Size returns the size in bytes of the payloads in the buffer.
+func (b *DiskBuffer) Size() int64 {
+	return b.size
+}
+
+// Store writes a payload to the buffer, removing the oldest payloads if the
+// buffer would exceed its maximum size.
+func (b *DiskBuffer) Store(payload *message.Payload) error {
+	data := encodePayload(payload)
+	size := int64(len(data))
+	if size > b.maxSize {
+		return fmt.Errorf("payload of %d bytes exceeds the disk buffer size of %d bytes", size, b.maxSize)
+	}
+	for len(b.filenames) > 0 && b.size+size > b.maxSize {
+		log.Warnf("Logs disk buffer is full, dropping the oldest payload %s", b.filenames[0])
+		b.remove("size")
+	}
+
+	now := b.now()
+	name := filepath.Join(b.path, fmt.Sprintf("%020d_%010d%s", now.UnixNano(), b.sequence, diskBufferExtension))
+	b.sequence++
+	// write to a temporary file first so that a crash can't leave a truncated payload behind
+	tmp := name + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, name); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+
+	b.add(name, size)
+	tlmDiskBufferSpooled.Inc()
+	return nil
+}
+
+// Peek returns the oldest payload of the buffer without removing it, or nil if
+// the buffer is empty. Expired and unreadable payloads are dropped.
+func (b *DiskBuffer) Peek() *message.Payload {
+	for len(b.filenames) > 0 {
+		name := b.filenames[0]
+		if b.maxAge > 0 && b.now().Sub(spoolingTime(name)) > b.maxAge {
+			log.Warnf("Dropping logs payload %s older than %s", name, b.maxAge)
+			b.remove("age")
+			continue
+		}
+		data, err := os.ReadFile(name)
+		if err == nil {
+			var payload *message.Payload
+			if payload, err = decodePayload(data); err == nil {
+				return payload
+			}
+		}
+		log.Warnf("Dropping unreadable logs payload %s: %v", name, err)
+		b.remove("error")
+	}
+	return nil
+}
+
+// Pop removes the oldest payload of the buffer, once it has been handed over to a destination.
+func (b *DiskBuffer) Pop() {
+	if len(b.filenames) > 0 {
+		b.remove("")
+		tlmDiskBufferReplayed.Inc()
+	}
+}
+
+func (b *DiskBuffer) add(name string, size int64) {
+	b.filenames = append(b.filenames, name)
+	b.sizes[name] = size
+	b.size += size
+	b.updateStats(1, size)
+}
+
+// remove deletes the oldest payload, dropReason being empty if it was replayed.
+func (b *DiskBuffer) remove(dropReason string) {
+	name := b.filenames[0]
+	size := b.sizes[name]
+	b.filenames = b.filenames[1:]
+	delete(b.sizes, name)
+	b.size -= size
+	b.updateStats(-1, -size)
+
+	if dropReason != "" {
+		tlmDiskBufferDropped.Inc(dropReason)
+		metrics.DiskBufferDropped.Add(1)
+	}
+	if err := os.Remove(name); err != nil && !os.IsNotExist(err) {
+		log.Warnf("Could not remove logs payload %s: %v", name, err)
+	}
+}
+
+func (b *DiskBuffer) updateStats(payloads int, size int64) {
+	metrics.DiskBufferPayloads.Add(int64(payloads))
+	metrics.DiskBufferBytes.Add(size)
+	tlmDiskBufferPayloads.Add(float64(payloads))
+	tlmDiskBufferBytes.Add(float64(size))
+}
+
+func (b *DiskBuffer) reload() error {
+	entries, err := os.ReadDir(b.path)
+	if err != nil {
+		return err
+	}
+	// names start with the zero-padded spooling time, so they sort chronologically
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+	for _, entry := range entries {
+		name := filepath.Join(b.path, entry.Name())
+		if strings.HasSuffix(entry.Name(), diskBufferExtension+".tmp") {
+			_ = os.Remove(name)
+			continue
+		}
+		if !entry.Type().IsRegular() || filepath.Ext(entry.Name()) != diskBufferExtension {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			log.Warnf("Could not read logs payload %s: %v", name, err)
+			continue
+		}
+		b.add(name, info.Size())
+	}
+	return nil
+}
+
+// spoolingTime returns the time a payload was stored from its file name.
+func spoolingTime(name string) time.Time {
+	base := filepath.Base(name)
+	if i := strings.IndexByte(base, '_'); i > 0 {
+		if nanos, err := strconv.ParseInt(base[:i], 10, 64); err == nil {
+			return time.Unix(0, nanos)
+		}
+	}
+	return time.Time{}
+}
+
+func encodePayload(payload *message.Payload) []byte {
+	data := make([]byte, diskBufferHeaderSize, diskBufferHeaderSize+len(payload.Encoding)+len(payload.Encoded))
+	data[0] = diskBufferVersion
+	binary.BigEndian.PutUint64(data[1:], uint64(payload.UnencodedSize))
+	binary.BigEndian.PutUint16(data[9:], uint16(len(payload.Encoding)))
+	data = append(data, payload.Encoding...)
+	return append(data, payload.Encoded...)
+}
+
+func decodePayload(data []byte) (*message.Payload, error) {
+	if len(data) < diskBufferHeaderSize || data[0] != diskBufferVersion {
+		return nil, errCorruptedPayload
+	}
+	encodingLen := int(binary.BigEndian.Uint16(data[9:]))
+	if len(data) < diskBufferHeaderSize+encodingLen {
+		return nil, errCorruptedPayload
+	}
+	return &message.Payload{
+		Encoding:      string(data[diskBufferHeaderSize : diskBufferHeaderSize+encodingLen]),
+		Encoded:       data[diskBufferHeaderSize+encodingLen:],
+		UnencodedSize: int(binary.BigEndian.Uint64(data[1:])),
+	}, nil
+}
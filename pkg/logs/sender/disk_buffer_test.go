@@ -0,0 +1,146 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package sender
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+)
+
+func newPayload(content string) *message.Payload {
+	return &message.Payload{
+		Encoded:       []byte(content),
+		Encoding:      "gzip",
+		UnencodedSize: len(content) * 2,
+	}
+}
+
+func TestDiskBufferStoreAndReplay(t *testing.T) {
+	b, err := NewDiskBuffer(t.TempDir(), 1024, 0)
+	require.NoError(t, err)
+	assert.Nil(t, b.Peek())
+
+	require.NoError(t, b.Store(newPayload("first")))
+	require.NoError(t, b.Store(newPayload("second")))
+	assert.Equal(t, 2, b.Len())
+
+	payload := b.Peek()
+	require.NotNil(t, payload)
+	assert.Equal(t, []byte("first"), payload.Encoded)
+	assert.Equal(t, "gzip", payload.Encoding)
+	assert.Equal(t, 10, payload.UnencodedSize)
+	assert.Empty(t, payload.Messages)
+
+	// peeking doesn't remove the payload
+	assert.Equal(t, []byte("first"), b.Peek().Encoded)
+
+	b.Pop()
+	assert.Equal(t, []byte("second"), b.Peek().Encoded)
+	b.Pop()
+	assert.Nil(t, b.Peek())
+	assert.Equal(t, 0, b.Len())
+	assert.Equal(t, int64(0), b.Size())
+}
+
+func TestDiskBufferReload(t *testing.T) {
+	path := t.TempDir()
+	b, err := NewDiskBuffer(path, 1024, 0)
+	require.NoError(t, err)
+	require.NoError(t, b.Store(newPayload("first")))
+	require.NoError(t, b.Store(newPayload("second")))
+
+	// a payload interrupted while being written is discarded
+	tmp := filepath.Join(path, "00000000000000000001_0000000000.payload.tmp")
+	require.NoError(t, os.WriteFile(tmp, []byte("partial"), 0600))
+
+	b, err = NewDiskBuffer(path, 1024, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 2, b.Len())
+	assert.Equal(t, int64(2*diskBufferHeaderSize+2*len("gzip")+len("first")+len("second")), b.Size())
+	assert.Equal(t, []byte("first"), b.Peek().Encoded)
+	assert.NoFileExists(t, tmp)
+}
+
+func TestDiskBufferMaxSize(t *testing.T) {
+	size := int64(diskBufferHeaderSize + len("gzip") + len("payload-1"))
+	b, err := NewDiskBuffer(t.TempDir(), 2*size, 0)
+	require.NoError(t, err)
+
+	require.NoError(t, b.Store(newPayload("payload-1")))
+	require.NoError(t, b.Store(newPayload("payload-2")))
+	require.NoError(t, b.Store(newPayload("payload-3")))
+
+	// the oldest payload was dropped to make room for the last one
+	assert.Equal(t, 2, b.Len())
+	assert.Equal(t, 2*size, b.Size())
+	assert.Equal(t, []byte("payload-2"), b.Peek().Encoded)
+
+	assert.Error(t, b.Store(newPayload("a payload bigger than the whole buffer")))
+	assert.Equal(t, 2, b.Len())
+}
+
+func TestDiskBufferMaxAge(t *testing.T) {
+	b, err := NewDiskBuffer(t.TempDir(), 1024, time.Hour)
+	require.NoError(t, err)
+
+	now := time.Now()
+	b.now = func() time.Time { return now }
+	require.NoError(t, b.Store(newPayload("old")))
+	now = now.Add(30 * time.Minute)
+	require.NoError(t, b.Store(newPayload("recent")))
+
+	now = now.Add(45 * time.Minute)
+	assert.Equal(t, []byte("recent"), b.Peek().Encoded)
+	assert.Equal(t, 1, b.Len())
+
+	now = now.Add(time.Hour)
+	assert.Nil(t, b.Peek())
+	assert.Equal(t, 0, b.Len())
+}
+
+func TestDiskBufferCorruptedPayload(t *testing.T) {
+	path := t.TempDir()
+	b, err := NewDiskBuffer(path, 1024, 0)
+	require.NoError(t, err)
+	require.NoError(t, b.Store(newPayload("first")))
+	require.NoError(t, b.Store(newPayload("second")))
+
+	require.NoError(t, os.WriteFile(b.filenames[0], []byte{0xff, 0x01}, 0600))
+
+	assert.Equal(t, []byte("second"), b.Peek().Encoded)
+	assert.Equal(t, 1, b.Len())
+}
+
+func TestMergeDiskBuffer(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "1")
+	dst := filepath.Join(t.TempDir(), "0")
+
+	orphan, err := NewDiskBuffer(src, 1024, 0)
+	require.NoError(t, err)
+	b, err := NewDiskBuffer(dst, 1024, 0)
+	require.NoError(t, err)
+	require.NoError(t, orphan.Store(newPayload("first")))
+	require.NoError(t, b.Store(newPayload("second")))
+	require.NoError(t, orphan.Store(newPayload("third")))
+
+	require.NoError(t, MergeDiskBuffer(src, dst))
+	assert.NoDirExists(t, src)
+
+	// the payloads of both buffers are replayed in the order they were spooled
+	b, err = NewDiskBuffer(dst, 1024, 0)
+	require.NoError(t, err)
+	for _, expected := range []string{"first", "second", "third"} {
+		assert.Equal(t, []byte(expected), b.Peek().Encoded)
+		b.Pop()
+	}
+}
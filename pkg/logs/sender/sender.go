@@ -15,8 +15,12 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/logs/message"
 	"github.com/DataDog/datadog-agent/pkg/logs/metrics"
 	"github.com/DataDog/datadog-agent/pkg/telemetry"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
 
+// diskBufferReplayInterval is how often the disk buffer is replayed when no payload comes in.
+const diskBufferReplayInterval = time.Second
+
 var (
 	tlmPayloadsDropped = telemetry.NewCounterWithOpts("logs_sender", "payloads_dropped", []string{"reliable", "destination"}, "Payloads dropped", telemetry.Options{DefaultMetric: true})
 	tlmMessagesDropped = telemetry.NewCounterWithOpts("logs_sender", "messages_dropped", []string{"reliable", "destination"}, "Messages dropped", telemetry.Options{DefaultMetric: true})
@@ -39,6 +43,7 @@ type Sender struct {
 	bufferSize     int
 	senderDoneChan chan *sync.WaitGroup
 	flushWg        *sync.WaitGroup
+	diskBuffer     *DiskBuffer
 
 	pipelineMonitor metrics.PipelineMonitor
 	utilization     metrics.UtilizationMonitor
@@ -62,6 +67,16 @@ func NewSender(config pkgconfigmodel.Reader, inputChan chan *message.Payload, ou
 	}
 }
 
+// NewSenderWithDiskBuffer returns a new sender spooling payloads to diskBuffer
+// while its reliable destinations are retrying, instead of blocking the pipeline.
+// Spooled payloads are acknowledged to the output and replayed in order once a
+// reliable destination recovers.
+func NewSenderWithDiskBuffer(config pkgconfigmodel.Reader, inputChan chan *message.Payload, outputChan chan *message.Payload, destinations *client.Destinations, bufferSize int, diskBuffer *DiskBuffer, pipelineMonitor metrics.PipelineMonitor) *Sender {
+	s := NewSender(config, inputChan, outputChan, destinations, bufferSize, nil, nil, pipelineMonitor)
+	s.diskBuffer = diskBuffer
+	return s
+}
+
 // Start starts the sender.
 func (s *Sender) Start() {
 	go s.run()
@@ -80,26 +95,34 @@ func (s *Sender) run() {
 	sink := additionalDestinationsSink(s.bufferSize)
 	unreliableDestinations := buildDestinationSenders(s.config, s.destinations.Unreliable, sink, s.bufferSize)
 
-	for payload := range s.inputChan {
+	var replayTicker <-chan time.Time
+	if s.diskBuffer != nil {
+		ticker := time.NewTicker(diskBufferReplayInterval)
+		defer ticker.Stop()
+		replayTicker = ticker.C
+	}
+
+	for {
+		var payload *message.Payload
+		select {
+		case p, isOpen := <-s.inputChan:
+			if !isOpen {
+				s.stopDestinations(reliableDestinations, unreliableDestinations, sink)
+				return
+			}
+			payload = p
+		case <-replayTicker:
+			s.replay(reliableDestinations)
+			continue
+		}
+
 		s.utilization.Start()
 		var startInUse = time.Now()
 		senderDoneWg := &sync.WaitGroup{}
 
-		sent := false
+		sent, spooled := s.sendOrSpool(payload, reliableDestinations)
 		for !sent {
-			for _, destSender := range reliableDestinations {
-				if destSender.Send(payload) {
-					if destSender.destination.Metadata().ReportingEnabled {
-						s.pipelineMonitor.ReportComponentIngress(payload, destSender.destination.Metadata().MonitorTag())
-					}
-					sent = true
-					if s.senderDoneChan != nil {
-						senderDoneWg.Add(1)
-						s.senderDoneChan <- senderDoneWg
-					}
-				}
-			}
-
+			sent, _ = s.sendToReliable(payload, reliableDestinations, senderDoneWg)
 			if !sent {
 				// Throttle the poll loop while waiting for a send to succeed
 				// This will only happen when all reliable destinations
@@ -110,8 +133,8 @@ func (s *Sender) run() {
 
 		for i, destSender := range reliableDestinations {
 			// If an endpoint is stuck in the previous step, try to buffer the payloads if we have room to mitigate
-			// loss on intermittent failures.
-			if !destSender.lastSendSucceeded {
+			// loss on intermittent failures. Spooled payloads are replayed from the disk buffer instead.
+			if !destSender.lastSendSucceeded && !spooled {
 				if !destSender.NonBlockingSend(payload) {
 					tlmPayloadsDropped.Inc("true", strconv.Itoa(i))
 					tlmMessagesDropped.Add(float64(len(payload.Messages)), "true", strconv.Itoa(i))
@@ -143,8 +166,71 @@ func (s *Sender) run() {
 		}
 		s.pipelineMonitor.ReportComponentEgress(payload, "sender")
 	}
+}
+
+// sendToReliable sends a payload to the reliable destinations, sent is true if
+// at least one of them accepted it. A disabled MRF destination accepts payloads
+// without sending them not to block the pipeline, so delivered is only true if
+// an enabled destination accepted the payload.
+func (s *Sender) sendToReliable(payload *message.Payload, reliableDestinations []*DestinationSender, senderDoneWg *sync.WaitGroup) (sent bool, delivered bool) {
+	for _, destSender := range reliableDestinations {
+		if destSender.Send(payload) {
+			if destSender.destination.Metadata().ReportingEnabled {
+				s.pipelineMonitor.ReportComponentIngress(payload, destSender.destination.Metadata().MonitorTag())
+			}
+			sent = true
+			delivered = delivered || destSender.sendEnabled
+			if s.senderDoneChan != nil {
+				senderDoneWg.Add(1)
+				s.senderDoneChan <- senderDoneWg
+			}
+		}
+	}
+	return sent, delivered
+}
+
+// sendOrSpool sends the payload to the reliable destinations, or stores it in
+// the disk buffer when the buffer is not empty, to preserve ordering, or when no
+// reliable destination accepts it. sent is false if the payload still has to be
+// sent to the reliable destinations, which is always the case without disk buffer.
+func (s *Sender) sendOrSpool(payload *message.Payload, reliableDestinations []*DestinationSender) (sent bool, spooled bool) {
+	if s.diskBuffer == nil {
+		return false, false
+	}
+	if s.diskBuffer.Len() > 0 {
+		s.replay(reliableDestinations)
+	}
+	if s.diskBuffer.Len() == 0 {
+		if _, delivered := s.sendToReliable(payload, reliableDestinations, nil); delivered {
+			return true, false
+		}
+	}
+	if err := s.diskBuffer.Store(payload); err != nil {
+		log.Warnf("Could not store logs payload in the disk buffer, waiting for a destination to recover: %v", err)
+		return false, false
+	}
+	// the payload is now durably stored, its messages can be committed
+	s.outputChan <- payload
+	return true, true
+}
+
+// replay sends the payloads of the disk buffer to the reliable destinations, in
+// order, until the buffer is empty or no enabled destination accepts them. A
+// payload is only removed from the buffer once a destination is sending it.
+func (s *Sender) replay(reliableDestinations []*DestinationSender) {
+	for {
+		payload := s.diskBuffer.Peek()
+		if payload == nil {
+			return
+		}
+		if _, delivered := s.sendToReliable(payload, reliableDestinations, nil); !delivered {
+			return
+		}
+		s.diskBuffer.Pop()
+	}
+}
 
-	// Cleanup the destinations
+func (s *Sender) stopDestinations(reliableDestinations []*DestinationSender, unreliableDestinations []*DestinationSender, sink chan *message.Payload) {
 	for _, destSender := range reliableDestinations {
 		destSender.Stop()
 	}
@@ -317,3 +317,87 @@ func TestSenderReliableWhenOneFailsAndRecovers(t *testing.T) {
 	reliableServer2.Stop()
 	sender.Stop()
 }
+
+func TestSenderSpoolsToDiskBufferWhenReliableFails(t *testing.T) {
+	cfg := configmock.New(t)
+	input := make(chan *message.Payload, 1)
+	output := make(chan *message.Payload, 1)
+
+	reliableRespond := make(chan int)
+	reliableServer := http.NewTestServerWithOptions(200, 0, true, reliableRespond, cfg)
+
+	destinations := client.NewDestinations([]client.Destination{reliableServer.Destination}, nil)
+
+	diskBuffer, err := NewDiskBuffer(t.TempDir(), 1024, 0)
+	assert.NoError(t, err)
+
+	sender := NewSenderWithDiskBuffer(cfg, input, output, destinations, 10, diskBuffer, metrics.NewNoopPipelineMonitor(""))
+	sender.Start()
+
+	input <- &message.Payload{Encoded: []byte("first")}
+	<-reliableRespond
+	<-output
+
+	reliableServer.ChangeStatus(500)
+
+	input <- &message.Payload{Encoded: []byte("second")}
+	<-reliableRespond // let it respond 500 once
+	<-reliableRespond // its in a loop now, the destination is retrying
+
+	// the payload is spooled and acknowledged right away instead of blocking the pipeline
+	third := &message.Payload{Encoded: []byte("third")}
+	input <- third
+	assert.Equal(t, third, <-output)
+
+	// recover the server, the retried payload goes through and the spooled one is replayed
+	reliableServer.ChangeStatus(200)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-reliableRespond:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	assert.Equal(t, []byte("second"), (<-output).Encoded)
+	replayed := <-output
+	assert.Equal(t, []byte("third"), replayed.Encoded)
+	assert.Empty(t, replayed.Messages)
+
+	close(done)
+	reliableServer.Stop()
+	sender.Stop()
+	assert.Equal(t, 0, diskBuffer.Len())
+}
+
+func TestSenderSpoolsWhenOnlyADisabledMRFDestinationAccepts(t *testing.T) {
+	cfg := configmock.New(t)
+	output := make(chan *message.Payload, 1)
+
+	// a disabled MRF destination accepts payloads without sending them
+	dest := &mockDestination{isMRF: true}
+	reliableDestinations := []*DestinationSender{NewDestinationSender(cfg, dest, output, 1)}
+
+	diskBuffer, err := NewDiskBuffer(t.TempDir(), 1024, 0)
+	assert.NoError(t, err)
+	sender := NewSenderWithDiskBuffer(cfg, nil, output, client.NewDestinations(nil, nil), 1, diskBuffer, metrics.NewNoopPipelineMonitor(""))
+
+	payload := &message.Payload{Encoded: []byte("payload")}
+	sent, spooled := sender.sendOrSpool(payload, reliableDestinations)
+	assert.True(t, sent)
+	assert.True(t, spooled)
+	assert.Equal(t, payload, <-output)
+
+	// the payload stays in the buffer until a destination actually sends it
+	sender.replay(reliableDestinations)
+	assert.Equal(t, 1, diskBuffer.Len())
+
+	cfg.SetWithoutSource("multi_region_failover.enabled", true)
+	cfg.SetWithoutSource("multi_region_failover.failover_logs", true)
+	sender.replay(reliableDestinations)
+	assert.Equal(t, 0, diskBuffer.Len())
+	assert.Equal(t, []byte("payload"), (<-dest.input).Encoded)
+}
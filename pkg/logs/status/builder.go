@@ -209,6 +209,14 @@ func (b *Builder) getMetricsStatus() map[string]string {
 	metrics["RetryCount"] = fmt.Sprintf("%v", b.logsExpVars.Get("RetryCount").(*expvar.Int).Value())
 	metrics["RetryTimeSpent"] = time.Duration(b.logsExpVars.Get("RetryTimeSpent").(*expvar.Int).Value()).String()
 	metrics["EncodedBytesSent"] = fmt.Sprintf("%v", b.logsExpVars.Get("EncodedBytesSent").(*expvar.Int).Value())
+	// the disk buffer is only reported while it holds payloads or after it dropped some
+	diskBufferPayloads := b.logsExpVars.Get("DiskBufferPayloads").(*expvar.Int).Value()
+	diskBufferDropped := b.logsExpVars.Get("DiskBufferDropped").(*expvar.Int).Value()
+	if diskBufferPayloads > 0 || diskBufferDropped > 0 {
+		metrics["DiskBufferPayloads"] = fmt.Sprintf("%v", diskBufferPayloads)
+		metrics["DiskBufferBytes"] = fmt.Sprintf("%v", b.logsExpVars.Get("DiskBufferBytes").(*expvar.Int).Value())
+		metrics["DiskBufferDropped"] = fmt.Sprintf("%v", diskBufferDropped)
+	}
 	return metrics
 }
 
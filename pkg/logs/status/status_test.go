@@ -96,13 +96,13 @@ func TestStatusDeduplicateErrorsAndWarnings(t *testing.T) {
 func TestMetrics(t *testing.T) {
 	defer Clear()
 	Clear()
-	var expected = `{"BytesMissed": 0, "BytesSent": 0, "DestinationErrors": 0, "DestinationLogsDropped": {}, "EncodedBytesSent": 0, "Errors": "", "HttpDestinationStats": {}, "IsRunning": false, "LogsDecoded": 0, "LogsProcessed": 0, "LogsSent": 0, "RetryCount": 0, "RetryTimeSpent": 0, "SenderLatency": 0, "Warnings": ""}`
+	var expected = `{"BytesMissed": 0, "BytesSent": 0, "DestinationErrors": 0, "DestinationLogsDropped": {}, "DiskBufferBytes": 0, "DiskBufferDropped": 0, "DiskBufferPayloads": 0, "EncodedBytesSent": 0, "Errors": "", "HttpDestinationStats": {}, "IsRunning": false, "LogsDecoded": 0, "LogsProcessed": 0, "LogsSampledOut": {}, "LogsSent": 0, "RetryCount": 0, "RetryTimeSpent": 0, "SenderLatency": 0, "Warnings": ""}`
 	assert.Equal(t, expected, metrics.LogsExpvars.String())
 
 	initStatus()
 	AddGlobalWarning("bar", "Unique Warning")
 	AddGlobalError("bar", "I am an error")
-	expected = `{"BytesMissed": 0, "BytesSent": 0, "DestinationErrors": 0, "DestinationLogsDropped": {}, "EncodedBytesSent": 0, "Errors": "I am an error", "HttpDestinationStats": {}, "IsRunning": true, "LogsDecoded": 0, "LogsProcessed": 0, "LogsSent": 0, "RetryCount": 0, "RetryTimeSpent": 0, "SenderLatency": 0, "Warnings": "Unique Warning"}`
+	expected = `{"BytesMissed": 0, "BytesSent": 0, "DestinationErrors": 0, "DestinationLogsDropped": {}, "DiskBufferBytes": 0, "DiskBufferDropped": 0, "DiskBufferPayloads": 0, "EncodedBytesSent": 0, "Errors": "I am an error", "HttpDestinationStats": {}, "IsRunning": true, "LogsDecoded": 0, "LogsProcessed": 0, "LogsSampledOut": {}, "LogsSent": 0, "RetryCount": 0, "RetryTimeSpent": 0, "SenderLatency": 0, "Warnings": "Unique Warning"}`
 	assert.Equal(t, expected, metrics.LogsExpvars.String())
 }
 
@@ -132,6 +132,16 @@ func TestStatusMetrics(t *testing.T) {
 	assert.Equal(t, "21", status.StatusMetrics["EncodedBytesSent"])
 	assert.Equal(t, "42", status.StatusMetrics["RetryCount"])
 	assert.Equal(t, "2h0m0s", status.StatusMetrics["RetryTimeSpent"])
+	assert.NotContains(t, status.StatusMetrics, "DiskBufferPayloads")
+
+	metrics.DiskBufferPayloads.Set(2)
+	metrics.DiskBufferBytes.Set(1024)
+	defer metrics.DiskBufferPayloads.Set(0)
+	defer metrics.DiskBufferBytes.Set(0)
+	status = Get(false)
+	assert.Equal(t, "2", status.StatusMetrics["DiskBufferPayloads"])
+	assert.Equal(t, "1024", status.StatusMetrics["DiskBufferBytes"])
+	assert.Equal(t, "0", status.StatusMetrics["DiskBufferDropped"])
 
 	metrics.LogsProcessed.Set(math.MaxInt64)
 	metrics.LogsProcessed.Add(1)
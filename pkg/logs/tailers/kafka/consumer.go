@@ -0,0 +1,217 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package kafka
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+
+	"github.com/twmb/franz-go/pkg/kerr"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"github.com/twmb/franz-go/pkg/kmsg"
+
+	"github.com/DataDog/datadog-agent/comp/logs/agent/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/auditor"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// errClientClosed is returned by Poll once the consumer is closed.
+var errClientClosed = errors.New("kafka client closed")
+
+// Consumer consumes records from the topics of a consumer group.
+type Consumer interface {
+	// Poll blocks until records are available or the context is done.
+	Poll(ctx context.Context) ([]*kgo.Record, error)
+	// Commit commits the given next offsets to consume, per topic and partition, for the consumer group.
+	Commit(ctx context.Context, offsets map[string]map[int32]int64) error
+	// Close leaves the consumer group and closes the connections to the brokers.
+	Close()
+}
+
+// kgoConsumer is a Consumer backed by a franz-go client.
+type kgoConsumer struct {
+	client *kgo.Client
+
+	// assigned are the partitions currently assigned to this member of the group,
+	// only their offsets are committed.
+	mu       sync.Mutex
+	assigned map[string]map[int32]struct{}
+}
+
+// NewConsumer returns a Consumer joining the consumer group of the source.
+// Offsets are not auto-committed, the consumer resumes from the offsets stored
+// in the registry, which are only updated once the logs have been sent, then
+// from the offsets committed for the group, then from the start position of the source.
+func NewConsumer(source *config.LogsConfig, registry auditor.Registry) (Consumer, error) {
+	mode, _ := config.TailingModeFromString(source.TailingMode)
+	consumer := &kgoConsumer{assigned: make(map[string]map[int32]struct{})}
+
+	resetOffset := kgo.NewOffset().AtEnd()
+	if mode == config.Beginning || mode == config.ForceBeginning {
+		resetOffset = kgo.NewOffset().AtStart()
+	}
+
+	opts := []kgo.Opt{
+		kgo.SeedBrokers(source.Brokers...),
+		kgo.ConsumerGroup(source.ConsumerGroup),
+		kgo.ConsumeTopics(source.Topics...),
+		kgo.DisableAutoCommit(),
+		kgo.ConsumeResetOffset(resetOffset),
+		kgo.AdjustFetchOffsetsFn(func(_ context.Context, offsets map[string]map[int32]kgo.Offset) (map[string]map[int32]kgo.Offset, error) {
+			for topic, partitions := range offsets {
+				for partition := range partitions {
+					switch mode {
+					case config.ForceBeginning, config.ForceEnd:
+						partitions[partition] = resetOffset
+					default:
+						if offset, ok := registryOffset(registry, PartitionIdentifier(source, topic, partition)); ok {
+							partitions[partition] = kgo.NewOffset().At(offset)
+						}
+					}
+				}
+			}
+			return offsets, nil
+		}),
+		kgo.OnPartitionsAssigned(func(_ context.Context, _ *kgo.Client, assigned map[string][]int32) {
+			consumer.assign(assigned)
+		}),
+		kgo.OnPartitionsLost(func(_ context.Context, _ *kgo.Client, lost map[string][]int32) {
+			consumer.revoke(lost)
+		}),
+		kgo.OnPartitionsRevoked(func(ctx context.Context, client *kgo.Client, revoked map[string][]int32) {
+			defer consumer.revoke(revoked)
+			// commit what has been sent for the revoked partitions so that
+			// the next member of the group consuming them doesn't start over.
+			offsets := make(map[string]map[int32]int64)
+			for topic, partitions := range revoked {
+				for _, partition := range partitions {
+					if offset, ok := registryOffset(registry, PartitionIdentifier(source, topic, partition)); ok {
+						if offsets[topic] == nil {
+							offsets[topic] = make(map[int32]int64)
+						}
+						offsets[topic][partition] = offset
+					}
+				}
+			}
+			if err := commit(ctx, client, offsets); err != nil {
+				log.Warnf("Could not commit the offsets of the revoked partitions for consumer group %s: %v", source.ConsumerGroup, err)
+			}
+		}),
+	}
+
+	client, err := kgo.NewClient(opts...)
+	if err != nil {
+		return nil, err
+	}
+	consumer.client = client
+	return consumer, nil
+}
+
+func (c *kgoConsumer) assign(partitions map[string][]int32) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for topic, ps := range partitions {
+		if c.assigned[topic] == nil {
+			c.assigned[topic] = make(map[int32]struct{})
+		}
+		for _, partition := range ps {
+			c.assigned[topic][partition] = struct{}{}
+		}
+	}
+}
+
+func (c *kgoConsumer) revoke(partitions map[string][]int32) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for topic, ps := range partitions {
+		for _, partition := range ps {
+			delete(c.assigned[topic], partition)
+		}
+	}
+}
+
+// Poll implements Consumer.
+func (c *kgoConsumer) Poll(ctx context.Context) ([]*kgo.Record, error) {
+	fetches := c.client.PollFetches(ctx)
+	if fetches.IsClientClosed() {
+		return nil, errClientClosed
+	}
+	for _, fetchErr := range fetches.Errors() {
+		if errors.Is(fetchErr.Err, context.Canceled) {
+			return nil, fetchErr.Err
+		}
+		log.Warnf("Error while fetching kafka topic %s partition %d: %v", fetchErr.Topic, fetchErr.Partition, fetchErr.Err)
+	}
+	return fetches.Records(), nil
+}
+
+// Commit implements Consumer, the offsets of the partitions which are not
+// assigned anymore to this member of the group are ignored.
+func (c *kgoConsumer) Commit(ctx context.Context, offsets map[string]map[int32]int64) error {
+	c.mu.Lock()
+	for topic, partitions := range offsets {
+		for partition := range partitions {
+			if _, assigned := c.assigned[topic][partition]; !assigned {
+				delete(partitions, partition)
+			}
+		}
+		if len(partitions) == 0 {
+			delete(offsets, topic)
+		}
+	}
+	c.mu.Unlock()
+	return commit(ctx, c.client, offsets)
+}
+
+// Close implements Consumer.
+func (c *kgoConsumer) Close() {
+	c.client.Close()
+}
+
+func commit(ctx context.Context, client *kgo.Client, offsets map[string]map[int32]int64) error {
+	if len(offsets) == 0 {
+		return nil
+	}
+	epochOffsets := make(map[string]map[int32]kgo.EpochOffset, len(offsets))
+	for topic, partitions := range offsets {
+		epochOffsets[topic] = make(map[int32]kgo.EpochOffset, len(partitions))
+		for partition, offset := range partitions {
+			epochOffsets[topic][partition] = kgo.EpochOffset{Epoch: -1, Offset: offset}
+		}
+	}
+
+	var commitErr error
+	client.CommitOffsetsSync(ctx, epochOffsets, func(_ *kgo.Client, _ *kmsg.OffsetCommitRequest, resp *kmsg.OffsetCommitResponse, err error) {
+		if err != nil {
+			commitErr = err
+			return
+		}
+		for _, topic := range resp.Topics {
+			for _, partition := range topic.Partitions {
+				if err := kerr.ErrorForCode(partition.ErrorCode); err != nil {
+					commitErr = err
+				}
+			}
+		}
+	})
+	return commitErr
+}
+
+// registryOffset returns the next offset to consume stored in the registry for the given identifier.
+func registryOffset(registry auditor.Registry, identifier string) (int64, bool) {
+	value := registry.GetOffset(identifier)
+	if value == "" {
+		return 0, false
+	}
+	offset, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		log.Warnf("Invalid offset %q in the registry for %s: %v", value, identifier, err)
+		return 0, false
+	}
+	return offset, true
+}
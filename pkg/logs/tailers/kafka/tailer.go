@@ -0,0 +1,186 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// Package kafka implements a tailer consuming logs from kafka topics.
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+
+	"github.com/DataDog/datadog-agent/comp/logs/agent/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/auditor"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+	"github.com/DataDog/datadog-agent/pkg/logs/sources"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	// defaultCommitInterval is the interval at which the offsets acknowledged
+	// by the auditor are committed to the consumer group.
+	defaultCommitInterval = 5 * time.Second
+	// defaultCommitTimeout bounds the time spent committing offsets.
+	defaultCommitTimeout = 10 * time.Second
+	// retryPollDelay is the delay before polling again after an error.
+	retryPollDelay = 1 * time.Second
+)
+
+// Tailer consumes the records of the topics of a kafka source and forwards
+// them as log messages. Every record is a log message.
+//
+// The offset of each record is tracked in the auditor registry, per topic and partition,
+// and the offsets acknowledged by the auditor are periodically committed
+// to the consumer group: records are delivered at least once.
+type Tailer struct {
+	source     *sources.LogSource
+	outputChan chan *message.Message
+	consumer   Consumer
+	registry   auditor.Registry
+
+	// partitions are the partitions from which records have been consumed,
+	// it is only accessed by the run goroutine.
+	partitions     map[string]map[int32]struct{}
+	commitInterval time.Duration
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewTailer returns a new Tailer.
+func NewTailer(source *sources.LogSource, outputChan chan *message.Message, consumer Consumer, registry auditor.Registry) *Tailer {
+	return &Tailer{
+		source:         source,
+		outputChan:     outputChan,
+		consumer:       consumer,
+		registry:       registry,
+		partitions:     make(map[string]map[int32]struct{}),
+		commitInterval: defaultCommitInterval,
+		done:           make(chan struct{}),
+	}
+}
+
+// Identifier returns a string that identifies the source of the tailer.
+func (t *Tailer) Identifier() string {
+	return Identifier(t.source.Config)
+}
+
+// Identifier returns the identifier of a kafka source.
+func Identifier(source *config.LogsConfig) string {
+	return fmt.Sprintf("kafka:%s:%s", source.ConsumerGroup, strings.Join(source.Topics, ","))
+}
+
+// PartitionIdentifier returns the registry identifier of a partition consumed
+// by a kafka source.
+func PartitionIdentifier(source *config.LogsConfig, topic string, partition int32) string {
+	return fmt.Sprintf("kafka:%s:%s:%d", source.ConsumerGroup, topic, partition)
+}
+
+// Start starts consuming the records.
+func (t *Tailer) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.cancel = cancel
+	t.source.AddInput(t.Identifier())
+	log.Infof("Start consuming kafka topics %s with consumer group %s", strings.Join(t.source.Config.Topics, ","), t.source.Config.ConsumerGroup)
+	go t.run(ctx)
+}
+
+// Stop stops consuming the records, commits the offsets acknowledged so far and
+// leaves the consumer group.
+func (t *Tailer) Stop() {
+	log.Infof("Stop consuming kafka topics %s with consumer group %s", strings.Join(t.source.Config.Topics, ","), t.source.Config.ConsumerGroup)
+	t.cancel()
+	<-t.done
+	t.source.RemoveInput(t.Identifier())
+	t.consumer.Close()
+}
+
+func (t *Tailer) run(ctx context.Context) {
+	defer func() {
+		t.commit()
+		close(t.done)
+	}()
+
+	lastCommit := time.Now()
+	for {
+		records, err := t.consumer.Poll(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			t.source.Status.Error(err)
+			log.Warnf("Could not consume kafka topics %s: %v", strings.Join(t.source.Config.Topics, ","), err)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(retryPollDelay):
+			}
+			continue
+		}
+		t.source.Status.Success()
+
+		for _, record := range records {
+			select {
+			case <-ctx.Done():
+				return
+			case t.outputChan <- t.toMessage(record):
+			}
+		}
+
+		if time.Since(lastCommit) >= t.commitInterval {
+			t.commit()
+			lastCommit = time.Now()
+		}
+	}
+}
+
+// toMessage converts a record into a log message.
+func (t *Tailer) toMessage(record *kgo.Record) *message.Message {
+	partitions, exists := t.partitions[record.Topic]
+	if !exists {
+		partitions = make(map[int32]struct{})
+		t.partitions[record.Topic] = partitions
+	}
+	partitions[record.Partition] = struct{}{}
+
+	origin := message.NewOrigin(t.source)
+	origin.Identifier = PartitionIdentifier(t.source.Config, record.Topic, record.Partition)
+	// the offset stored in the registry is the next offset to consume
+	origin.Offset = strconv.FormatInt(record.Offset+1, 10)
+	origin.SetTags([]string{
+		"kafka_topic:" + record.Topic,
+		"kafka_partition:" + strconv.FormatInt(int64(record.Partition), 10),
+	})
+
+	t.source.RecordBytes(int64(len(record.Value)))
+	return message.NewMessage(record.Value, origin, message.StatusInfo, time.Now().UnixNano())
+}
+
+// commit commits to the consumer group the offsets of the records that have been sent.
+func (t *Tailer) commit() {
+	offsets := make(map[string]map[int32]int64)
+	for topic, partitions := range t.partitions {
+		for partition := range partitions {
+			offset, ok := registryOffset(t.registry, PartitionIdentifier(t.source.Config, topic, partition))
+			if !ok {
+				continue
+			}
+			if offsets[topic] == nil {
+				offsets[topic] = make(map[int32]int64)
+			}
+			offsets[topic][partition] = offset
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultCommitTimeout)
+	defer cancel()
+	if err := t.consumer.Commit(ctx, offsets); err != nil {
+		log.Warnf("Could not commit offsets for consumer group %s: %v", t.source.Config.ConsumerGroup, err)
+	}
+}
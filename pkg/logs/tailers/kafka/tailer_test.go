@@ -0,0 +1,184 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package kafka
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twmb/franz-go/pkg/kgo"
+
+	"github.com/DataDog/datadog-agent/comp/logs/agent/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+	"github.com/DataDog/datadog-agent/pkg/logs/sources"
+)
+
+type fakeConsumer struct {
+	records chan []*kgo.Record
+
+	mu        sync.Mutex
+	committed map[string]map[int32]int64
+	closed    bool
+}
+
+func newFakeConsumer() *fakeConsumer {
+	return &fakeConsumer{
+		records:   make(chan []*kgo.Record, 10),
+		committed: make(map[string]map[int32]int64),
+	}
+}
+
+func (c *fakeConsumer) Poll(ctx context.Context) ([]*kgo.Record, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case records := <-c.records:
+		return records, nil
+	}
+}
+
+func (c *fakeConsumer) Commit(_ context.Context, offsets map[string]map[int32]int64) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for topic, partitions := range offsets {
+		if c.committed[topic] == nil {
+			c.committed[topic] = make(map[int32]int64)
+		}
+		for partition, offset := range partitions {
+			c.committed[topic][partition] = offset
+		}
+	}
+	return nil
+}
+
+func (c *fakeConsumer) Close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closed = true
+}
+
+// fakeRegistry returns the offsets set per identifier.
+type fakeRegistry struct {
+	mu      sync.Mutex
+	offsets map[string]string
+}
+
+func (r *fakeRegistry) GetOffset(identifier string) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.offsets[identifier]
+}
+
+func (r *fakeRegistry) GetTailingMode(string) string {
+	return ""
+}
+
+func (r *fakeRegistry) setOffset(identifier, offset string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.offsets[identifier] = offset
+}
+
+func newTestSource() *sources.LogSource {
+	return sources.NewLogSource("kafka", &config.LogsConfig{
+		Type:          config.KafkaType,
+		Brokers:       []string{"localhost:9092"},
+		Topics:        []string{"app-logs"},
+		ConsumerGroup: "datadog-agent",
+	})
+}
+
+func TestTailerForwardsRecords(t *testing.T) {
+	source := newTestSource()
+	consumer := newFakeConsumer()
+	outputChan := make(chan *message.Message, 10)
+	tailer := NewTailer(source, outputChan, consumer, &fakeRegistry{offsets: map[string]string{}})
+	tailer.Start()
+
+	consumer.records <- []*kgo.Record{
+		{Topic: "app-logs", Partition: 2, Offset: 41, Value: []byte("hello")},
+		{Topic: "app-logs", Partition: 2, Offset: 42, Value: []byte("world")},
+	}
+
+	msg := <-outputChan
+	assert.Equal(t, "hello", string(msg.GetContent()))
+	assert.Equal(t, "kafka:datadog-agent:app-logs:2", msg.Origin.Identifier)
+	assert.Equal(t, "42", msg.Origin.Offset)
+	assert.Contains(t, msg.Tags(), "kafka_topic:app-logs")
+	assert.Contains(t, msg.Tags(), "kafka_partition:2")
+
+	msg = <-outputChan
+	assert.Equal(t, "world", string(msg.GetContent()))
+	assert.Equal(t, "43", msg.Origin.Offset)
+
+	tailer.Stop()
+	assert.True(t, consumer.closed)
+	assert.Equal(t, int64(10), source.BytesRead.Get())
+}
+
+func TestTailerCommitsAcknowledgedOffsets(t *testing.T) {
+	source := newTestSource()
+	consumer := newFakeConsumer()
+	registry := &fakeRegistry{offsets: map[string]string{}}
+	outputChan := make(chan *message.Message, 10)
+	tailer := NewTailer(source, outputChan, consumer, registry)
+	tailer.commitInterval = 0
+	tailer.Start()
+
+	consumer.records <- []*kgo.Record{
+		{Topic: "app-logs", Partition: 0, Offset: 10, Value: []byte("a")},
+		{Topic: "app-logs", Partition: 1, Offset: 20, Value: []byte("b")},
+	}
+	<-outputChan
+	<-outputChan
+
+	// only the first message has been sent and acknowledged by the auditor
+	registry.setOffset(PartitionIdentifier(source.Config, "app-logs", 0), "11")
+
+	tailer.Stop()
+	require.Contains(t, consumer.committed, "app-logs")
+	assert.Equal(t, map[int32]int64{0: 11}, consumer.committed["app-logs"])
+}
+
+func TestTailerStopWhileBlocked(t *testing.T) {
+	source := newTestSource()
+	consumer := newFakeConsumer()
+	// unbuffered and never read
+	outputChan := make(chan *message.Message)
+	tailer := NewTailer(source, outputChan, consumer, &fakeRegistry{offsets: map[string]string{}})
+	tailer.Start()
+
+	consumer.records <- []*kgo.Record{{Topic: "app-logs", Partition: 0, Offset: 0, Value: []byte("a")}}
+
+	stopped := make(chan struct{})
+	go func() {
+		tailer.Stop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the tailer did not stop")
+	}
+}
+
+func TestRegistryOffset(t *testing.T) {
+	registry := &fakeRegistry{offsets: map[string]string{"valid": "12", "invalid": "abc"}}
+
+	offset, ok := registryOffset(registry, "valid")
+	assert.True(t, ok)
+	assert.Equal(t, int64(12), offset)
+
+	_, ok = registryOffset(registry, "invalid")
+	assert.False(t, ok)
+
+	_, ok = registryOffset(registry, "missing")
+	assert.False(t, ok)
+}
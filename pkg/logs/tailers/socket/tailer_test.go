@@ -41,6 +41,26 @@ func TestReadAndForwardShouldSucceedWithSuccessfulRead(t *testing.T) {
 	tailer.Stop()
 }
 
+func TestReadAndForwardSyslog(t *testing.T) {
+	msgChan := make(chan *message.Message)
+	r, w := net.Pipe()
+	tailer := NewTailer(sources.NewLogSource("", &config.LogsConfig{Format: config.SyslogFormat}), r, msgChan, read)
+	tailer.Start()
+
+	// octet-counted and newline-delimited messages can be mixed on the same connection
+	w.Write([]byte("27 <11>1 - host app - - - boom<14>Oct 11 22:14:15 host app: hello\n"))
+
+	msg := <-msgChan
+	assert.Equal(t, `{"message":"boom","syslog":{"facility":1,"severity":3,"version":1,"hostname":"host","appname":"app"}}`, string(msg.GetContent()))
+	assert.Equal(t, message.StatusError, msg.GetStatus())
+
+	msg = <-msgChan
+	assert.Equal(t, `{"message":"hello","syslog":{"facility":1,"severity":6,"timestamp":"Oct 11 22:14:15","hostname":"host","appname":"app"}}`, string(msg.GetContent()))
+	assert.Equal(t, message.StatusInfo, msg.GetStatus())
+
+	tailer.Stop()
+}
+
 func TestReadShouldFailWithError(t *testing.T) {
 	msgChan := make(chan *message.Message)
 	r, w := net.Pipe()
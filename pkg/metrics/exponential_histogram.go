@@ -0,0 +1,179 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package metrics
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/DataDog/opentelemetry-mapping-go/pkg/quantile"
+	"github.com/DataDog/sketches-go/ddsketch"
+	"github.com/DataDog/sketches-go/ddsketch/mapping"
+	"github.com/DataDog/sketches-go/ddsketch/store"
+)
+
+const (
+	// MinExponentialHistogramScale is the lowest scale supported, as defined by OpenTelemetry
+	MinExponentialHistogramScale = -10
+	// MaxExponentialHistogramScale is the highest scale supported, as defined by OpenTelemetry
+	MaxExponentialHistogramScale = 20
+)
+
+// ExponentialHistogramBuckets are the contiguous buckets of one range (positive or
+// negative) of an exponential histogram.
+type ExponentialHistogramBuckets struct {
+	// Offset is the index of the first bucket
+	Offset int32
+	Counts []uint64
+}
+
+// count returns the count of the bucket of the given index.
+func (b ExponentialHistogramBuckets) count(index int32) uint64 {
+	i := int64(index) - int64(b.Offset)
+	if i < 0 || i >= int64(len(b.Counts)) {
+		return 0
+	}
+	return b.Counts[i]
+}
+
+// ExponentialHistogram is a histogram whose bucket boundaries grow exponentially, like
+// the OpenTelemetry exponential histograms and the Prometheus native histograms.
+//
+// With base = 2^(2^-Scale), the positive bucket of index i counts the values in
+// (base^i, base^(i+1)], and the negative bucket of index i the values in
+// [-base^(i+1), -base^i). ZeroCount counts the values too close to zero to be in any
+// bucket. Counts are deltas over the interval of the sample.
+type ExponentialHistogram struct {
+	Scale     int32
+	ZeroCount uint64
+	Positive  ExponentialHistogramBuckets
+	Negative  ExponentialHistogramBuckets
+	Sum       float64
+	// HasMinMax is true if Min and Max are the exact extrema of the values, otherwise
+	// they are estimated from the buckets.
+	HasMinMax bool
+	Min       float64
+	Max       float64
+}
+
+// Count returns the number of values counted by the histogram.
+func (h *ExponentialHistogram) Count() uint64 {
+	count := h.ZeroCount
+	for _, c := range h.Positive.Counts {
+		count += c
+	}
+	for _, c := range h.Negative.Counts {
+		count += c
+	}
+	return count
+}
+
+// RelativeAccuracy returns the maximum relative error of a value of the histogram
+// estimated from its bucket, ignoring the values counted by ZeroCount.
+func (h *ExponentialHistogram) RelativeAccuracy() float64 {
+	gamma := math.Pow(2, math.Pow(2, -float64(h.Scale)))
+	return 1 - 2/(1+gamma)
+}
+
+// Sketch converts the histogram into a sketch without going through individual values: the
+// buckets are mapped onto the bins of the sketch, splitting their counts when they overlap
+// several bins. The relative error of the quantiles of the sketch is of the order of the
+// relative accuracy of the histogram plus the one of the sketch. The sum, and the extrema
+// when they're known, are exact.
+//
+// It returns nil if the histogram is empty.
+func (h *ExponentialHistogram) Sketch() (*quantile.Sketch, error) {
+	if h.Scale < MinExponentialHistogramScale || h.Scale > MaxExponentialHistogramScale {
+		return nil, fmt.Errorf("unsupported exponential histogram scale %d", h.Scale)
+	}
+	if h.Count() == 0 {
+		return nil, nil
+	}
+
+	gamma := math.Pow(2, math.Pow(2, -float64(h.Scale)))
+	indexMapping, err := mapping.NewLogarithmicMappingWithGamma(gamma, 0)
+	if err != nil {
+		return nil, err
+	}
+	ddSketch := ddsketch.NewDDSketch(indexMapping, toDenseStore(h.Positive), toDenseStore(h.Negative))
+	if h.ZeroCount > 0 {
+		if err := ddSketch.AddWithCount(0, float64(h.ZeroCount)); err != nil {
+			return nil, err
+		}
+	}
+
+	sketch, err := quantile.ConvertDDSketchIntoSketch(ddSketch)
+	if err != nil {
+		return nil, err
+	}
+	// the sum estimated from the buckets is replaced by the exact one
+	sketch.Basic.Sum = h.Sum
+	sketch.Basic.Avg = h.Sum / float64(sketch.Basic.Cnt)
+	if h.HasMinMax {
+		sketch.Basic.Min = h.Min
+		sketch.Basic.Max = h.Max
+	}
+	return sketch, nil
+}
+
+// DeltaFrom returns the increase of h since previous, both being cumulative histograms of the
+// same series. It returns false if the histogram was reset, or if its scale changed.
+//
+// The extrema of the increase are kept when they can be told from the cumulative ones: when
+// previous was empty, or when both a lower minimum and a higher maximum were observed since.
+func (h *ExponentialHistogram) DeltaFrom(previous *ExponentialHistogram) (*ExponentialHistogram, bool) {
+	if h.Scale != previous.Scale || h.ZeroCount < previous.ZeroCount {
+		return nil, false
+	}
+	positive, ok := subtractBuckets(h.Positive, previous.Positive)
+	if !ok {
+		return nil, false
+	}
+	negative, ok := subtractBuckets(h.Negative, previous.Negative)
+	if !ok {
+		return nil, false
+	}
+	delta := &ExponentialHistogram{
+		Scale:     h.Scale,
+		ZeroCount: h.ZeroCount - previous.ZeroCount,
+		Positive:  positive,
+		Negative:  negative,
+		Sum:       h.Sum - previous.Sum,
+	}
+	if h.HasMinMax && (previous.Count() == 0 || previous.HasMinMax && h.Min < previous.Min && h.Max > previous.Max) {
+		delta.HasMinMax = true
+		delta.Min = h.Min
+		delta.Max = h.Max
+	}
+	return delta, true
+}
+
+func subtractBuckets(current, previous ExponentialHistogramBuckets) (ExponentialHistogramBuckets, bool) {
+	// a bucket whose count decreased, or which disappeared, means that the histogram was reset
+	for i, count := range previous.Counts {
+		if current.count(previous.Offset+int32(i)) < count {
+			return ExponentialHistogramBuckets{}, false
+		}
+	}
+	delta := ExponentialHistogramBuckets{
+		Offset: current.Offset,
+		Counts: make([]uint64, len(current.Counts)),
+	}
+	for i, count := range current.Counts {
+		delta.Counts[i] = count - previous.count(current.Offset+int32(i))
+	}
+	return delta, true
+}
+
+func toDenseStore(buckets ExponentialHistogramBuckets) store.Store {
+	s := store.NewDenseStore()
+	for i, count := range buckets.Counts {
+		if count > 0 {
+			s.AddWithCount(int(buckets.Offset)+i, float64(count))
+		}
+	}
+	return s
+}
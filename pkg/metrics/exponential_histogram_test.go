@@ -0,0 +1,201 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package metrics
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/DataDog/opentelemetry-mapping-go/pkg/quantile"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newExponentialHistogram returns the histogram of values with the given scale.
+func newExponentialHistogram(scale int32, values []float64) *ExponentialHistogram {
+	positive := map[int32]uint64{}
+	negative := map[int32]uint64{}
+	h := &ExponentialHistogram{Scale: scale, HasMinMax: true, Min: math.Inf(1), Max: math.Inf(-1)}
+	for _, v := range values {
+		h.Sum += v
+		h.Min = math.Min(h.Min, v)
+		h.Max = math.Max(h.Max, v)
+		if v == 0 {
+			h.ZeroCount++
+			continue
+		}
+		// the bucket of index i counts the values in (base^i, base^(i+1)]
+		index := int32(math.Ceil(math.Log2(math.Abs(v))*math.Exp2(float64(scale)))) - 1
+		if v > 0 {
+			positive[index]++
+		} else {
+			negative[index]++
+		}
+	}
+	h.Positive = toBuckets(positive)
+	h.Negative = toBuckets(negative)
+	return h
+}
+
+func toBuckets(counts map[int32]uint64) ExponentialHistogramBuckets {
+	var buckets ExponentialHistogramBuckets
+	if len(counts) == 0 {
+		return buckets
+	}
+	first, last := int32(math.MaxInt32), int32(math.MinInt32)
+	for index := range counts {
+		first = min(first, index)
+		last = max(last, index)
+	}
+	buckets.Offset = first
+	buckets.Counts = make([]uint64, last-first+1)
+	for index, count := range counts {
+		buckets.Counts[index-first] = count
+	}
+	return buckets
+}
+
+func TestExponentialHistogramSketchAccuracy(t *testing.T) {
+	r := rand.New(rand.NewSource(42))
+	values := make([]float64, 0, 10000)
+	for i := 0; i < cap(values); i++ {
+		v := math.Exp(r.NormFloat64()*2 + 3)
+		if i%4 == 0 {
+			v = -v
+		}
+		values = append(values, v)
+	}
+	sort.Float64s(values)
+
+	for _, scale := range []int32{0, 3, 8} {
+		h := newExponentialHistogram(scale, values)
+		sketch, err := h.Sketch()
+		require.NoError(t, err)
+
+		assert.EqualValues(t, len(values), sketch.Basic.Cnt)
+		assert.InDelta(t, h.Sum, sketch.Basic.Sum, 1e-6)
+		assert.Equal(t, values[0], sketch.Basic.Min)
+		assert.Equal(t, values[len(values)-1], sketch.Basic.Max)
+
+		// the counts of a bucket are spread over the bins of the sketch (1/128 accuracy) it
+		// overlaps, so the error is within twice their combined accuracy
+		maxError := 2 * (h.RelativeAccuracy() + 1.0/128)
+		for _, q := range []float64{0.01, 0.1, 0.3, 0.5, 0.75, 0.9, 0.99} {
+			expected := values[int(q*float64(len(values)-1))]
+			actual := sketch.Quantile(quantile.Default(), q)
+			assert.InEpsilon(t, expected, actual, maxError, "scale %d, quantile %v", scale, q)
+		}
+	}
+}
+
+func TestExponentialHistogramSketchZeroCount(t *testing.T) {
+	h := &ExponentialHistogram{
+		Scale:     2,
+		ZeroCount: 3,
+		Positive:  ExponentialHistogramBuckets{Offset: 4, Counts: []uint64{1}},
+		Sum:       2,
+	}
+	sketch, err := h.Sketch()
+	require.NoError(t, err)
+	assert.EqualValues(t, 4, sketch.Basic.Cnt)
+	assert.Equal(t, 2.0, sketch.Basic.Sum)
+	assert.Equal(t, 0.5, sketch.Basic.Avg)
+	assert.Equal(t, 0.0, sketch.Quantile(quantile.Default(), 0.5))
+}
+
+func TestExponentialHistogramSketchEmpty(t *testing.T) {
+	h := &ExponentialHistogram{Positive: ExponentialHistogramBuckets{Offset: 3, Counts: []uint64{0, 0}}}
+	sketch, err := h.Sketch()
+	assert.NoError(t, err)
+	assert.Nil(t, sketch)
+}
+
+func TestExponentialHistogramSketchInvalidScale(t *testing.T) {
+	h := &ExponentialHistogram{Scale: 21, ZeroCount: 1}
+	_, err := h.Sketch()
+	assert.Error(t, err)
+}
+
+func TestExponentialHistogramDeltaFrom(t *testing.T) {
+	previous := &ExponentialHistogram{
+		Scale:     1,
+		ZeroCount: 1,
+		Positive:  ExponentialHistogramBuckets{Offset: 2, Counts: []uint64{1, 2}},
+		Negative:  ExponentialHistogramBuckets{Offset: 0, Counts: []uint64{4}},
+		Sum:       10,
+	}
+	current := &ExponentialHistogram{
+		Scale:     1,
+		ZeroCount: 3,
+		Positive:  ExponentialHistogramBuckets{Offset: 1, Counts: []uint64{1, 1, 5, 0, 2}},
+		Negative:  ExponentialHistogramBuckets{Offset: 0, Counts: []uint64{4}},
+		Sum:       22.5,
+	}
+
+	delta, ok := current.DeltaFrom(previous)
+	require.True(t, ok)
+	assert.Equal(t, &ExponentialHistogram{
+		Scale:     1,
+		ZeroCount: 2,
+		Positive:  ExponentialHistogramBuckets{Offset: 1, Counts: []uint64{1, 0, 3, 0, 2}},
+		Negative:  ExponentialHistogramBuckets{Offset: 0, Counts: []uint64{0}},
+		Sum:       12.5,
+	}, delta)
+	assert.Equal(t, uint64(8), delta.Count())
+
+	// a bucket whose count decreased
+	reset := &ExponentialHistogram{Scale: 1, ZeroCount: 3, Positive: ExponentialHistogramBuckets{Offset: 2, Counts: []uint64{1}}}
+	_, ok = reset.DeltaFrom(previous)
+	assert.False(t, ok)
+
+	// a different scale
+	rescaled := *current
+	rescaled.Scale = 0
+	_, ok = rescaled.DeltaFrom(previous)
+	assert.False(t, ok)
+}
+
+func TestExponentialHistogramDeltaFromMinMax(t *testing.T) {
+	previous := &ExponentialHistogram{
+		Scale:     0,
+		Positive:  ExponentialHistogramBuckets{Offset: 0, Counts: []uint64{2}},
+		Sum:       3,
+		HasMinMax: true,
+		Min:       1.2,
+		Max:       1.8,
+	}
+
+	// both extrema moved: they were observed during the interval
+	current := &ExponentialHistogram{
+		Scale:     0,
+		Positive:  ExponentialHistogramBuckets{Offset: -1, Counts: []uint64{1, 2, 1}},
+		Sum:       7.5,
+		HasMinMax: true,
+		Min:       0.6,
+		Max:       2.9,
+	}
+	delta, ok := current.DeltaFrom(previous)
+	require.True(t, ok)
+	assert.True(t, delta.HasMinMax)
+	assert.Equal(t, 0.6, delta.Min)
+	assert.Equal(t, 2.9, delta.Max)
+
+	// the maximum didn't move: the one of the interval is unknown
+	current.Max = 1.8
+	delta, ok = current.DeltaFrom(previous)
+	require.True(t, ok)
+	assert.False(t, delta.HasMinMax)
+
+	// nothing was counted before
+	empty := &ExponentialHistogram{Scale: 0, HasMinMax: true, Min: 5, Max: 5}
+	delta, ok = current.DeltaFrom(empty)
+	require.True(t, ok)
+	assert.True(t, delta.HasMinMax)
+	assert.Equal(t, 0.6, delta.Min)
+	assert.Equal(t, 1.8, delta.Max)
+}
@@ -26,6 +26,7 @@ const (
 	DistributionType
 	GaugeWithTimestampType
 	CountWithTimestampType
+	ExponentialHistogramType
 
 	// NumMetricTypes is the number of metric types; must be the last item here
 	NumMetricTypes
@@ -34,7 +35,8 @@ const (
 // DistributionMetricTypes contains the MetricTypes that are used for percentiles
 var (
 	DistributionMetricTypes = map[MetricType]struct{}{
-		DistributionType: {},
+		DistributionType:         {},
+		ExponentialHistogramType: {},
 	}
 )
 
@@ -66,6 +68,8 @@ func (m MetricType) String() string {
 		return "GaugeWithTimestamp"
 	case CountWithTimestampType:
 		return "CountWithTimestamp"
+	case ExponentialHistogramType:
+		return "ExponentialHistogram"
 	default:
 		return ""
 	}
@@ -108,6 +112,8 @@ type MetricSample struct {
 	ListenerID      string
 	NoIndex         bool
 	Source          MetricSource
+	// ExponentialHistogram holds the buckets of the ExponentialHistogramType samples, Value is unused
+	ExponentialHistogram *ExponentialHistogram
 }
 
 // Implement the MetricSampleContext interface
@@ -0,0 +1,202 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package obfuscate
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ObfuscateCQL obfuscates the Cassandra CQL statement query, replacing its literals with "?":
+// strings, numbers, UUIDs, blobs, booleans and collection literals, such as the values of
+// "USING TTL 86400" or "SET tags = {'a', 'b'}". Consecutive literals, such as the values
+// of a tuple, are grouped. Comments are removed and whitespace is compacted.
+func (o *Obfuscator) ObfuscateCQL(query string) (string, error) {
+	cacheKey := "cql:" + query
+	if v, ok := o.queryCache.Get(cacheKey); ok {
+		return v.(string), nil
+	}
+	out, err := obfuscateCQL(query)
+	if err != nil {
+		return "", err
+	}
+	o.queryCache.Set(cacheKey, out, stringCost(out))
+	return out, nil
+}
+
+// cqlToken is the kind of the last token written by obfuscateCQL.
+type cqlToken int
+
+const (
+	cqlOther      cqlToken = iota
+	cqlIdentifier          // identifier, keyword or quoted identifier
+	cqlLiteral
+	cqlClosing // closing parenthesis or bracket
+)
+
+func obfuscateCQL(query string) (string, error) {
+	var w tokenWriter
+	last := cqlOther
+	for i := 0; i < len(query); {
+		c := query[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			w.writeSpace()
+			i++
+		case strings.HasPrefix(query[i:], "--") || strings.HasPrefix(query[i:], "//"):
+			for i < len(query) && query[i] != '\n' {
+				i++
+			}
+			w.writeSpace()
+		case strings.HasPrefix(query[i:], "/*"):
+			end := strings.Index(query[i+2:], "*/")
+			if end < 0 {
+				return "", errors.New("unterminated comment")
+			}
+			i += end + 4
+			w.writeSpace()
+		case c == '\'':
+			end := cqlQuotedEnd(query, i+1, '\'')
+			if end < 0 {
+				return "", errors.New("unterminated string")
+			}
+			w.writeLiteral()
+			last, i = cqlLiteral, end
+		case strings.HasPrefix(query[i:], "$$"):
+			end := strings.Index(query[i+2:], "$$")
+			if end < 0 {
+				return "", errors.New("unterminated string")
+			}
+			w.writeLiteral()
+			last, i = cqlLiteral, i+end+4
+		case c == '"':
+			end := cqlQuotedEnd(query, i+1, '"')
+			if end < 0 {
+				return "", errors.New("unterminated quoted identifier")
+			}
+			w.writeToken(query[i:end])
+			last, i = cqlIdentifier, end
+		case isCQLUUID(query[i:]):
+			w.writeLiteral()
+			last, i = cqlLiteral, i+36
+		case isDigit(rune(c)) || c == '-' && i+1 < len(query) && isDigit(rune(query[i+1])) && last != cqlIdentifier && last != cqlLiteral && last != cqlClosing:
+			// integers, floats, hexadecimal blobs and durations
+			i++
+			for i < len(query) && (isCQLIdentifierChar(query[i]) || query[i] == '.' ||
+				(query[i] == '+' || query[i] == '-') && (query[i-1] == 'e' || query[i-1] == 'E')) {
+				i++
+			}
+			w.writeLiteral()
+			last = cqlLiteral
+		case isCQLIdentifierChar(c):
+			start := i
+			for i < len(query) && isCQLIdentifierChar(query[i]) {
+				i++
+			}
+			switch word := query[start:i]; strings.ToLower(word) {
+			case "true", "false", "nan", "infinity":
+				w.writeLiteral()
+				last = cqlLiteral
+			default:
+				w.writeToken(word)
+				last = cqlIdentifier
+			}
+		case c == '{' || c == '[' && last != cqlIdentifier && last != cqlClosing:
+			// collection and user-defined type literals, unlike element accesses such as l[0]
+			end, err := cqlCollectionEnd(query, i)
+			if err != nil {
+				return "", err
+			}
+			w.writeLiteral()
+			last, i = cqlLiteral, end
+		case c == '?':
+			w.writeLiteral()
+			last = cqlLiteral
+			i++
+		case strings.IndexByte("()[],;.:=<>!+-*/%", c) >= 0:
+			w.writeToken(query[i : i+1])
+			last = cqlOther
+			if c == ')' || c == ']' {
+				last = cqlClosing
+			}
+			i++
+		default:
+			return "", fmt.Errorf("unexpected character %q at position %d", c, i)
+		}
+	}
+	return w.String(), nil
+}
+
+// cqlQuotedEnd returns the position following the end of the string or quoted identifier
+// starting at i in query and delimited by quote, or -1 if it isn't terminated. Quotes are
+// escaped by doubling them.
+func cqlQuotedEnd(query string, i int, quote byte) int {
+	for ; i < len(query); i++ {
+		if query[i] != quote {
+			continue
+		}
+		if i+1 < len(query) && query[i+1] == quote {
+			i++
+			continue
+		}
+		return i + 1
+	}
+	return -1
+}
+
+// cqlCollectionEnd returns the position following the end of the collection literal starting
+// at i in query.
+func cqlCollectionEnd(query string, i int) (int, error) {
+	var stack []byte
+	for i < len(query) {
+		switch c := query[i]; c {
+		case '{', '[', '(':
+			stack = append(stack, c)
+		case '}', ']', ')':
+			if len(stack) == 0 || stack[len(stack)-1] != "{[("[strings.IndexByte("}])", c)] {
+				return 0, fmt.Errorf("unexpected %q at position %d", c, i)
+			}
+			stack = stack[:len(stack)-1]
+			if len(stack) == 0 {
+				return i + 1, nil
+			}
+		case '\'', '"':
+			end := cqlQuotedEnd(query, i+1, c)
+			if end < 0 {
+				return 0, errors.New("unterminated string")
+			}
+			i = end
+			continue
+		}
+		i++
+	}
+	return 0, errors.New("unterminated collection literal")
+}
+
+// isCQLUUID reports whether s starts with a UUID such as 123e4567-e89b-12d3-a456-426614174000.
+func isCQLUUID(s string) bool {
+	if len(s) < 36 || len(s) > 36 && isCQLIdentifierChar(s[36]) {
+		return false
+	}
+	for i := 0; i < 36; i++ {
+		switch c := s[i]; i {
+		case 8, 13, 18, 23:
+			if c != '-' {
+				return false
+			}
+		default:
+			if !isDigit(rune(c)) && !(c >= 'a' && c <= 'f') && !(c >= 'A' && c <= 'F') {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func isCQLIdentifierChar(c byte) bool {
+	return c == '_' || isDigit(rune(c)) || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
@@ -0,0 +1,70 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package obfuscate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestObfuscateCQL(t *testing.T) {
+	for _, tt := range []struct {
+		in, out string
+	}{
+		{
+			"SELECT * FROM users WHERE id = 123e4567-e89b-12d3-a456-426614174000",
+			"SELECT * FROM users WHERE id = ?",
+		},
+		{
+			"SELECT name FROM users WHERE name = 'O''Brien' AND age > -3 LIMIT 10",
+			"SELECT name FROM users WHERE name = ? AND age > ? LIMIT ?",
+		},
+		{
+			"INSERT INTO users (id, name, tags, prefs) VALUES (1, 'jane', {'a', 'b'}, {'theme': 'dark', 'lang': 'en'}) USING TTL 86400 AND TIMESTAMP 1700000000",
+			"INSERT INTO users (id, name, tags, prefs) VALUES (?) USING TTL ? AND TIMESTAMP ?",
+		},
+		{
+			"UPDATE users USING TTL 3600 SET emails = emails + ['jane@example.com'], scores[2] = 4.5, active = true WHERE id IN (1, 2, 3)",
+			"UPDATE users USING TTL ? SET emails = emails + ?, scores[?] = ?, active = ? WHERE id IN (?)",
+		},
+		{
+			"SELECT * FROM \"Users\" WHERE token(id) > ? AND data = 0xcafe AND d = 1h30m -- comment\n/* block */ ALLOW FILTERING",
+			"SELECT * FROM \"Users\" WHERE token(id) > ? AND data = ? AND d = ? ALLOW FILTERING",
+		},
+		{
+			"INSERT INTO t (k, v) VALUES (:k, $$it's a {test}$$)",
+			"INSERT INTO t (k, v) VALUES (:k, ?)",
+		},
+		{
+			"UPDATE t SET addr = {street: '1 Main St', zip: [12345]} WHERE k = 1 IF EXISTS",
+			"UPDATE t SET addr = ? WHERE k = ? IF EXISTS",
+		},
+		{
+			"SELECT a-1 FROM t",
+			"SELECT a-? FROM t",
+		},
+	} {
+		t.Run("", func(t *testing.T) {
+			out, err := NewObfuscator(Config{}).ObfuscateCQL(tt.in)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.out, out)
+		})
+	}
+}
+
+func TestObfuscateCQLError(t *testing.T) {
+	for _, in := range []string{
+		"SELECT * FROM users WHERE name = 'jane",
+		"SELECT * FROM users /* comment",
+		"UPDATE t SET tags = {'a', 'b' WHERE k = 1",
+		"UPDATE t SET tags = {'a'] WHERE k = 1",
+		"SELECT * FROM users WHERE id = #1",
+	} {
+		_, err := NewObfuscator(Config{}).ObfuscateCQL(in)
+		assert.Error(t, err, in)
+	}
+}
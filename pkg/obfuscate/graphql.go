@@ -0,0 +1,134 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package obfuscate
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ObfuscateGraphQL obfuscates the GraphQL document query, replacing its string and numeric literals
+// (inline arguments, default values of variables, directive arguments) with "?". Comments are removed
+// and whitespace is compacted, the rest of the document, such as variable names, is kept.
+func (o *Obfuscator) ObfuscateGraphQL(query string) (string, error) {
+	cacheKey := "graphql:" + query
+	if v, ok := o.queryCache.Get(cacheKey); ok {
+		return v.(string), nil
+	}
+	out, err := obfuscateGraphQL(query)
+	if err != nil {
+		return "", err
+	}
+	o.queryCache.Set(cacheKey, out, stringCost(out))
+	return out, nil
+}
+
+func obfuscateGraphQL(query string) (string, error) {
+	var w tokenWriter
+	for i := 0; i < len(query); {
+		c := query[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			w.writeSpace()
+			i++
+		case c == '#':
+			// comments run until the end of the line
+			for i < len(query) && query[i] != '\n' && query[i] != '\r' {
+				i++
+			}
+			w.writeSpace()
+		case strings.HasPrefix(query[i:], `"""`):
+			end := graphQLBlockStringEnd(query, i+3)
+			if end < 0 {
+				return "", errors.New("unterminated block string")
+			}
+			w.writeLiteral()
+			i = end
+		case c == '"':
+			end := graphQLStringEnd(query, i+1)
+			if end < 0 {
+				return "", errors.New("unterminated string")
+			}
+			w.writeLiteral()
+			i = end
+		case isDigit(rune(c)) || c == '-' && i+1 < len(query) && isDigit(rune(query[i+1])):
+			i = graphQLNumberEnd(query, i+1)
+			w.writeLiteral()
+		case isGraphQLNameStart(c):
+			start := i
+			for i < len(query) && (isGraphQLNameStart(query[i]) || isDigit(rune(query[i]))) {
+				i++
+			}
+			w.writeToken(query[start:i])
+		case strings.HasPrefix(query[i:], "..."):
+			w.writeToken("...")
+			i += 3
+		case strings.IndexByte("!$&():=@[]{|},", c) >= 0:
+			w.writeToken(query[i : i+1])
+			i++
+		case strings.HasPrefix(query[i:], "\ufeff"):
+			// byte order mark
+			i += len("\ufeff")
+		default:
+			return "", fmt.Errorf("unexpected character %q at position %d", c, i)
+		}
+	}
+	return w.String(), nil
+}
+
+// graphQLStringEnd returns the position following the end of the string starting at i in query,
+// or -1 if it isn't terminated.
+func graphQLStringEnd(query string, i int) int {
+	for ; i < len(query); i++ {
+		switch query[i] {
+		case '\\':
+			i++
+		case '"':
+			return i + 1
+		case '\n', '\r':
+			return -1
+		}
+	}
+	return -1
+}
+
+// graphQLBlockStringEnd returns the position following the end of the block string starting at i
+// in query, or -1 if it isn't terminated.
+func graphQLBlockStringEnd(query string, i int) int {
+	for ; i < len(query); i++ {
+		switch {
+		case strings.HasPrefix(query[i:], `\"""`):
+			i += 3
+		case strings.HasPrefix(query[i:], `"""`):
+			return i + 3
+		}
+	}
+	return -1
+}
+
+// graphQLNumberEnd returns the position following the end of the integer or float value starting
+// before i in query.
+func graphQLNumberEnd(query string, i int) int {
+	for i < len(query) {
+		c := query[i]
+		switch {
+		case isDigit(rune(c)), c == '.':
+		case c == 'e' || c == 'E':
+			if i+1 < len(query) && (query[i+1] == '+' || query[i+1] == '-') {
+				i++
+			}
+		default:
+			return i
+		}
+		i++
+	}
+	return i
+}
+
+func isGraphQLNameStart(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
@@ -0,0 +1,83 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package obfuscate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestObfuscateGraphQL(t *testing.T) {
+	for _, tt := range []struct {
+		in, out string
+	}{
+		{
+			`query { user(id: 123) { name } }`,
+			`query { user(id: ?) { name } }`,
+		},
+		{
+			`query GetUser($id: ID!, $limit: Int = 10) { user(id: $id) { friends(first: $limit) { name } } }`,
+			`query GetUser($id: ID!, $limit: Int = ?) { user(id: $id) { friends(first: $limit) { name } } }`,
+		},
+		{
+			`mutation { login(email: "jane@example.com", password: "s3cr\"et") { token } }`,
+			`mutation { login(email: ?, password: ?) { token } }`,
+		},
+		{
+			`{ search(ids: [1, 2, 3], where: {price: {gt: -1.5e3}, status: ACTIVE, deleted: false}) { id } }`,
+			`{ search(ids: [?], where: {price: {gt: ?}, status: ACTIVE, deleted: false}) { id } }`,
+		},
+		{
+			"query {\n  # fetch the user 42\n  user(id: 42)   @include(if: true) {\n    ...UserFields\n    ... on Admin { level }\n  }\n}",
+			`query { user(id: ?) @include(if: true) { ...UserFields ... on Admin { level } } }`,
+		},
+		{
+			`{ post(body: """multi "line"
+text \""" end""") { id } }`,
+			`{ post(body: ?) { id } }`,
+		},
+		{
+			`{ matrix(values: [[1, 2], [3, 4]]) }`,
+			`{ matrix(values: [[?], [?]]) }`,
+		},
+		{
+			`GetUser`,
+			`GetUser`,
+		},
+	} {
+		t.Run("", func(t *testing.T) {
+			out, err := NewObfuscator(Config{}).ObfuscateGraphQL(tt.in)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.out, out)
+		})
+	}
+}
+
+func TestObfuscateGraphQLError(t *testing.T) {
+	for _, in := range []string{
+		`{ user(name: "jane) { id } }`,
+		`{ user(name: "ja
+ne") { id } }`,
+		`{ post(body: """text) }`,
+		`{ user(id: 1) } %`,
+	} {
+		_, err := NewObfuscator(Config{}).ObfuscateGraphQL(in)
+		assert.Error(t, err, in)
+	}
+}
+
+func TestObfuscateGraphQLCache(t *testing.T) {
+	o := NewObfuscator(Config{Cache: CacheConfig{Enabled: true, MaxSize: 1000}})
+	defer o.Stop()
+	in := `{ user(id: 1) { name } }`
+	out, err := o.ObfuscateGraphQL(in)
+	assert.NoError(t, err)
+	o.queryCache.Wait()
+	v, ok := o.queryCache.Get("graphql:" + in)
+	assert.True(t, ok)
+	assert.Equal(t, out, v)
+}
@@ -101,6 +101,12 @@ type Config struct {
 	// Memcached holds the obfuscation settings for Memcached commands.
 	Memcached MemcachedConfig `mapstructure:"memcached"`
 
+	// GraphQL holds the obfuscation settings for GraphQL documents.
+	GraphQL GraphQLConfig `mapstructure:"graphql"`
+
+	// CQL holds the obfuscation settings for Cassandra CQL statements.
+	CQL CQLConfig `mapstructure:"cql"`
+
 	// Memcached holds the obfuscation settings for obfuscation of CC numbers in meta.
 	CreditCard CreditCardsConfig `mapstructure:"credit_cards"`
 
@@ -259,6 +265,19 @@ type MemcachedConfig struct {
 	KeepCommand bool `mapstructure:"keep_command"`
 }
 
+// GraphQLConfig holds the configuration settings for GraphQL obfuscation
+type GraphQLConfig struct {
+	// Enabled specifies whether this feature should be enabled.
+	Enabled bool `mapstructure:"enabled"`
+}
+
+// CQLConfig holds the configuration settings for Cassandra CQL obfuscation
+type CQLConfig struct {
+	// Enabled specifies whether this feature should be enabled. When disabled,
+	// CQL statements are obfuscated as SQL queries.
+	Enabled bool `mapstructure:"enabled"`
+}
+
 // JSONConfig holds the obfuscation configuration for sensitive
 // data found in JSON objects.
 type JSONConfig struct {
@@ -392,3 +411,61 @@ func replaceDigits(buffer []byte) []byte {
 	}
 	return filtered
 }
+
+// tokenWriter builds an obfuscated query token by token. Tokens are separated by a single space
+// where the original query had whitespace or comments, and literals only separated by a comma or
+// whitespace, such as the values of a list, are grouped into a single "?".
+type tokenWriter struct {
+	out    []byte
+	space  bool // whitespace precedes the next token
+	litEnd int  // position in out following the last literal written
+	commas int  // commas written since the last literal, -1 if another token was written since
+}
+
+// writeSpace records that whitespace separates the previous and the next tokens.
+func (w *tokenWriter) writeSpace() {
+	w.space = true
+}
+
+// writeToken writes the token tok.
+func (w *tokenWriter) writeToken(tok string) {
+	if tok == "," && w.commas == 0 {
+		w.commas = 1
+	} else {
+		w.commas = -1
+	}
+	w.write(tok)
+}
+
+// writeLiteral writes an obfuscated literal, unless it follows another one.
+func (w *tokenWriter) writeLiteral() {
+	if w.litEnd > 0 && w.commas >= 0 {
+		// group it with the previous literal, dropping the comma between them
+		w.out = w.out[:w.litEnd]
+		w.space = false
+		w.commas = 0
+		return
+	}
+	w.write("?")
+	w.litEnd = len(w.out)
+	w.commas = 0
+}
+
+func (w *tokenWriter) write(tok string) {
+	if w.space && len(w.out) > 0 {
+		w.out = append(w.out, ' ')
+	}
+	w.space = false
+	w.out = append(w.out, tok...)
+}
+
+// String returns the obfuscated query.
+func (w *tokenWriter) String() string {
+	return string(w.out)
+}
+
+// stringCost returns the cost of caching the string s.
+func stringCost(s string) int64 {
+	// 16 bytes for the string header
+	return int64(len(s)) + 16
+}
@@ -0,0 +1,258 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package serializer
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/comp/core/config"
+	log "github.com/DataDog/datadog-agent/comp/core/log/def"
+	"github.com/DataDog/datadog-agent/pkg/config/structure"
+	"github.com/DataDog/datadog-agent/pkg/config/utils"
+	"github.com/DataDog/datadog-agent/pkg/util/compression"
+	"github.com/DataDog/datadog-agent/pkg/util/compression/dictionary"
+	"github.com/DataDog/datadog-agent/pkg/util/compression/selector"
+)
+
+const (
+	// zstdDictionaryHTTPHeader is the header of the ID of the zstd dictionary a payload is compressed with
+	zstdDictionaryHTTPHeader = "DD-Zstd-Dictionary-Id"
+
+	// negotiationTTL is how long the content encodings negotiated with a domain are used, before trying the
+	// configured ones again, like when the intake got the dictionary in the meantime
+	negotiationTTL = time.Hour
+)
+
+// endpointCompression is an entry of `serializer_compression.endpoints`, overriding the compression of the series and
+// sketches payloads sent to an endpoint
+type endpointCompression struct {
+	URL                 string   `mapstructure:"url"`
+	Payloads            []string `mapstructure:"payloads"`
+	CompressorKind      string   `mapstructure:"compressor_kind"`
+	ZstdCompressorLevel *int     `mapstructure:"zstd_compressor_level"`
+	ZstdDictionary      *bool    `mapstructure:"zstd_dictionary"`
+}
+
+// negotiation is what a domain accepts, learned from the 415 Unsupported Media Type responses it sent
+type negotiation struct {
+	noDictionary bool
+	// accepted are the content encodings listed in the Accept-Encoding header of the responses, if any
+	accepted []string
+	until    time.Time
+}
+
+// payloadEncoder re-encodes the payloads of a kind, like series, for the domains configured with another compression
+// in `serializer_compression.endpoints`, and for the domains which reject their content encoding, like intakes which
+// don't have the zstd dictionary they're compressed with.
+type payloadEncoder struct {
+	payloadKind string
+	logger      log.Component
+	now         func() time.Time
+
+	// withoutDictionary compresses like the payloads, without dictionary
+	withoutDictionary compression.Compressor
+	// fallbacks are the compressors used with the domains which don't accept the content encoding of the payloads,
+	// by order of preference
+	fallbacks []compression.Compressor
+
+	// compressors are the compressors of the domains configured with another compression
+	compressors map[string]compression.Compressor
+	// noDictionary are the domains configured to only be sent payloads compressed without dictionary
+	noDictionary map[string]bool
+
+	mu           sync.Mutex
+	negotiations map[string]negotiation
+}
+
+func newPayloadEncoder(cfg config.Component, payloadKind string, logger log.Component) *payloadEncoder {
+	kind, level := selector.KindAndLevelForPayload(cfg, payloadKind)
+	zstdLevel := cfg.GetInt("serializer_zstd_compressor_level")
+	if kind == compression.ZstdKind {
+		zstdLevel = level
+	}
+
+	e := &payloadEncoder{
+		payloadKind:       payloadKind,
+		logger:            logger,
+		now:               time.Now,
+		withoutDictionary: selector.NewCompressor(kind, level),
+		fallbacks: []compression.Compressor{
+			selector.NewCompressor(compression.ZstdKind, zstdLevel),
+			selector.NewCompressor(compression.GzipKind, 6),
+			selector.NewCompressor(compression.ZlibKind, 0),
+			selector.NewCompressor(compression.NoneKind, 0),
+		},
+		compressors:  map[string]compression.Compressor{},
+		noDictionary: map[string]bool{},
+		negotiations: map[string]negotiation{},
+	}
+
+	var endpoints []endpointCompression
+	if err := structure.UnmarshalKey(cfg, "serializer_compression.endpoints", &endpoints); err != nil {
+		logger.Errorf("Could not parse serializer_compression.endpoints, using the same compression for every endpoint: %v", err)
+		return e
+	}
+	for _, endpoint := range endpoints {
+		if len(endpoint.Payloads) > 0 && !slices.Contains(endpoint.Payloads, payloadKind) {
+			continue
+		}
+		// the transactions are sent to the domains with the agent version, like the forwarder does
+		domain, err := utils.AddAgentVersionToDomain(strings.TrimSuffix(endpoint.URL, "/"), "app")
+		if err != nil || endpoint.URL == "" {
+			logger.Errorf("Invalid URL %q in serializer_compression.endpoints, ignoring it: %v", endpoint.URL, err)
+			continue
+		}
+
+		if endpoint.ZstdDictionary != nil && !*endpoint.ZstdDictionary {
+			e.noDictionary[domain] = true
+		}
+		if endpoint.CompressorKind == "" && endpoint.ZstdCompressorLevel == nil {
+			continue
+		}
+		endpointKind, endpointLevel := kind, level
+		if endpoint.CompressorKind != "" {
+			endpointKind = strings.ToLower(endpoint.CompressorKind)
+		}
+		switch endpointKind {
+		case compression.ZstdKind:
+			endpointLevel = zstdLevel
+			if endpoint.ZstdCompressorLevel != nil {
+				endpointLevel = *endpoint.ZstdCompressorLevel
+			}
+		case compression.GzipKind:
+			endpointLevel = 6
+		case compression.ZlibKind, compression.NoneKind:
+			endpointLevel = 0
+		default:
+			logger.Errorf("Invalid compressor_kind %q for %s in serializer_compression.endpoints, ignoring it", endpoint.CompressorKind, endpoint.URL)
+			continue
+		}
+		if endpointKind != kind || endpointLevel != level {
+			logger.Infof("Compressing the %s payloads sent to %s with %s (level %d)", payloadKind, endpoint.URL, endpointKind, endpointLevel)
+			e.compressors[domain] = selector.NewCompressor(endpointKind, endpointLevel)
+		}
+	}
+	return e
+}
+
+// negotiation returns what the domain is known to accept, if it's been negotiated recently
+func (e *payloadEncoder) negotiation(domain string) (negotiation, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	n, ok := e.negotiations[domain]
+	if ok && e.now().After(n.until) {
+		delete(e.negotiations, domain)
+		return negotiation{}, false
+	}
+	return n, ok
+}
+
+// target returns the compressor to re-encode a payload with for the domain, or nil to send it as is
+func (e *payloadEncoder) target(domain string, content []byte, contentEncoding string) compression.Compressor {
+	compressor := e.compressors[domain]
+	n, negotiated := e.negotiation(domain)
+
+	if negotiated && len(n.accepted) > 0 {
+		encoding := contentEncoding
+		if compressor != nil {
+			encoding = compressor.ContentEncoding()
+		}
+		if !slices.Contains(n.accepted, encoding) {
+			for _, fallback := range e.fallbacks {
+				if slices.Contains(n.accepted, fallback.ContentEncoding()) {
+					compressor = fallback
+					break
+				}
+			}
+		}
+	}
+
+	withDictionary := compressor == nil && contentEncoding == compression.ZstdEncoding && dictionary.FrameID(content) != 0
+	if withDictionary && (e.noDictionary[domain] || (negotiated && n.noDictionary)) {
+		compressor = e.withoutDictionary
+	}
+	return compressor
+}
+
+// Encode implements transaction.PayloadEncoder
+func (e *payloadEncoder) Encode(domain string, content []byte, headers http.Header) ([]byte, error) {
+	contentEncoding := headers.Get("Content-Encoding")
+	if compressor := e.target(domain, content, contentEncoding); compressor != nil {
+		encoded, err := selector.Transcode(content, contentEncoding, compressor)
+		if err != nil {
+			return nil, err
+		}
+		content = encoded
+		headers.Set("Content-Encoding", compressor.ContentEncoding())
+	}
+
+	// the intake needs the dictionary to decompress the payload, and rejects it if it doesn't have it
+	headers.Del(zstdDictionaryHTTPHeader)
+	if headers.Get("Content-Encoding") == compression.ZstdEncoding {
+		if id := dictionary.FrameID(content); id != 0 {
+			headers.Set(zstdDictionaryHTTPHeader, strconv.FormatUint(uint64(id), 10))
+		}
+	}
+	return content, nil
+}
+
+// Reject implements transaction.PayloadEncoder
+func (e *payloadEncoder) Reject(domain string, _ []byte, requestHeaders http.Header, responseHeaders http.Header) bool {
+	accepted := parseAcceptEncoding(responseHeaders.Get("Accept-Encoding"))
+	contentEncoding := requestHeaders.Get("Content-Encoding")
+	withDictionary := requestHeaders.Get(zstdDictionaryHTTPHeader) != ""
+
+	n, _ := e.negotiation(domain)
+	changed := false
+	// without Accept-Encoding, or when it lists zstd, the dictionary is what isn't accepted
+	if withDictionary && !n.noDictionary && (accepted == nil || slices.Contains(accepted, compression.ZstdEncoding)) {
+		n.noDictionary = true
+		changed = true
+	}
+	if accepted != nil && !slices.Contains(accepted, contentEncoding) && !slices.Equal(n.accepted, accepted) {
+		n.accepted = accepted
+		changed = true
+	}
+	if !changed {
+		return false
+	}
+
+	e.logger.Warnf("%s doesn't accept the %s payloads encoded with %s (dictionary: %t, accepted encodings: %q), they're encoded differently for the next %s",
+		domain, e.payloadKind, contentEncoding, withDictionary, accepted, negotiationTTL)
+	n.until = e.now().Add(negotiationTTL)
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.negotiations[domain] = n
+	return true
+}
+
+// parseAcceptEncoding returns the content encodings listed in an Accept-Encoding header, without the ones with a null
+// weight, or nil if the header is empty
+func parseAcceptEncoding(header string) []string {
+	if strings.TrimSpace(header) == "" {
+		return nil
+	}
+	accepted := []string{}
+	for _, coding := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(coding, ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if weight, err := strconv.ParseFloat(q, 64); err == nil && weight == 0 {
+				continue
+			}
+		}
+		accepted = append(accepted, name)
+	}
+	return accepted
+}
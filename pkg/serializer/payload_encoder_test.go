@@ -0,0 +1,144 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build test && zlib && zstd
+
+package serializer
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	logmock "github.com/DataDog/datadog-agent/comp/core/log/mock"
+	configmock "github.com/DataDog/datadog-agent/pkg/config/mock"
+	"github.com/DataDog/datadog-agent/pkg/util/compression"
+	"github.com/DataDog/datadog-agent/pkg/util/compression/dictionary"
+	"github.com/DataDog/datadog-agent/pkg/util/compression/selector"
+)
+
+const testDomain = "https://intake.example.com"
+
+func encoderTestPayload(i int) []byte {
+	return []byte(fmt.Sprintf("system.cpu.user,host:fakehost-%d,env:prod,service:web-%d,version:%d", i, i%3, i*7))
+}
+
+func encode(t *testing.T, e *payloadEncoder, domain string, content []byte, contentEncoding string) ([]byte, http.Header) {
+	headers := http.Header{}
+	headers.Set("Content-Encoding", contentEncoding)
+	encoded, err := e.Encode(domain, content, headers)
+	require.NoError(t, err)
+	return encoded, headers
+}
+
+func decompress(t *testing.T, content []byte, contentEncoding string) []byte {
+	kind, ok := selector.KindOfEncoding(contentEncoding)
+	require.True(t, ok)
+	decompressed, err := selector.NewCompressor(kind, 0).Decompress(content)
+	require.NoError(t, err)
+	return decompressed
+}
+
+func TestPayloadEncoderEndpoints(t *testing.T) {
+	mockConfig := configmock.New(t)
+	mockConfig.SetWithoutSource("serializer_compressor_kind", compression.ZstdKind)
+	mockConfig.SetWithoutSource("serializer_compression.endpoints", []interface{}{
+		map[string]interface{}{"url": testDomain + "/", "compressor_kind": "gzip"},
+		map[string]interface{}{"url": "https://other.example.com", "payloads": []interface{}{"sketches"}, "compressor_kind": "zlib"},
+		map[string]interface{}{"url": "https://same.example.com", "zstd_compressor_level": 1},
+	})
+
+	e := newPayloadEncoder(mockConfig, "series", logmock.New(t))
+	compressed, err := selector.NewCompressor(compression.ZstdKind, 1).Compress(encoderTestPayload(1))
+	require.NoError(t, err)
+
+	encoded, headers := encode(t, e, testDomain, compressed, compression.ZstdEncoding)
+	assert.Equal(t, compression.GzipEncoding, headers.Get("Content-Encoding"))
+	assert.Equal(t, encoderTestPayload(1), decompress(t, encoded, compression.GzipEncoding))
+
+	// the other endpoints get the payloads as they are
+	for _, domain := range []string{"https://other.example.com", "https://same.example.com", "https://unknown.example.com"} {
+		encoded, headers = encode(t, e, domain, compressed, compression.ZstdEncoding)
+		assert.Equal(t, compression.ZstdEncoding, headers.Get("Content-Encoding"), domain)
+		assert.Equal(t, compressed, encoded, domain)
+	}
+
+	e = newPayloadEncoder(mockConfig, "sketches", logmock.New(t))
+	_, headers = encode(t, e, "https://other.example.com", compressed, compression.ZstdEncoding)
+	assert.Equal(t, compression.ZlibEncoding, headers.Get("Content-Encoding"))
+}
+
+func TestPayloadEncoderDictionaryNegotiation(t *testing.T) {
+	samples := [][]byte{}
+	for i := 0; i < 16; i++ {
+		samples = append(samples, encoderTestPayload(i))
+	}
+	dict, err := dictionary.Train(samples, 3, 4096)
+	require.NoError(t, err)
+	dictionaryPath := filepath.Join(t.TempDir(), "series.dict")
+	require.NoError(t, os.WriteFile(dictionaryPath, dict, 0o600))
+
+	mockConfig := configmock.New(t)
+	mockConfig.SetWithoutSource("serializer_compressor_kind", compression.ZstdKind)
+	mockConfig.SetWithoutSource("serializer_compression.series.zstd_dictionary_path", dictionaryPath)
+	mockConfig.SetWithoutSource("serializer_compression.endpoints", []interface{}{
+		map[string]interface{}{"url": "https://nodictionary.example.com", "zstd_dictionary": false},
+	})
+	compressed, err := selector.FromConfigForPayload(mockConfig, "series", nil).Compress(encoderTestPayload(42))
+	require.NoError(t, err)
+	require.Equal(t, dictionary.ID(dict), dictionary.FrameID(compressed))
+
+	now := time.Now()
+	e := newPayloadEncoder(mockConfig, "series", logmock.New(t))
+	e.now = func() time.Time { return now }
+
+	// the ID of the dictionary is sent along with the payload
+	encoded, headers := encode(t, e, testDomain, compressed, compression.ZstdEncoding)
+	assert.Equal(t, compressed, encoded)
+	assert.Equal(t, strconv.FormatUint(uint64(dictionary.ID(dict)), 10), headers.Get(zstdDictionaryHTTPHeader))
+
+	// the intake rejects it since it doesn't have the dictionary, so the payloads are compressed without it
+	assert.True(t, e.Reject(testDomain, encoded, headers, http.Header{}))
+	encoded, headers = encode(t, e, testDomain, compressed, compression.ZstdEncoding)
+	assert.Empty(t, headers.Get(zstdDictionaryHTTPHeader))
+	assert.Zero(t, dictionary.FrameID(encoded))
+	assert.Equal(t, encoderTestPayload(42), decompress(t, encoded, compression.ZstdEncoding))
+
+	// nothing else can be negotiated without Accept-Encoding
+	assert.False(t, e.Reject(testDomain, encoded, headers, http.Header{}))
+
+	// the intake only accepts gzip
+	assert.True(t, e.Reject(testDomain, encoded, headers, http.Header{"Accept-Encoding": []string{"gzip, zstd;q=0"}}))
+	encoded, headers = encode(t, e, testDomain, compressed, compression.ZstdEncoding)
+	assert.Equal(t, compression.GzipEncoding, headers.Get("Content-Encoding"))
+	assert.Equal(t, encoderTestPayload(42), decompress(t, encoded, compression.GzipEncoding))
+
+	// the endpoint configured without dictionary, and the others, aren't affected
+	encoded, headers = encode(t, e, "https://nodictionary.example.com", compressed, compression.ZstdEncoding)
+	assert.Empty(t, headers.Get(zstdDictionaryHTTPHeader))
+	assert.Equal(t, encoderTestPayload(42), decompress(t, encoded, compression.ZstdEncoding))
+	encoded, _ = encode(t, e, "https://other.example.com", compressed, compression.ZstdEncoding)
+	assert.Equal(t, compressed, encoded)
+
+	// the dictionary is tried again once the negotiation expires
+	now = now.Add(negotiationTTL + time.Second)
+	encoded, headers = encode(t, e, testDomain, compressed, compression.ZstdEncoding)
+	assert.Equal(t, compressed, encoded)
+	assert.NotEmpty(t, headers.Get(zstdDictionaryHTTPHeader))
+}
+
+func TestParseAcceptEncoding(t *testing.T) {
+	assert.Nil(t, parseAcceptEncoding(""))
+	assert.Equal(t, []string{"zstd", "gzip"}, parseAcceptEncoding("zstd, GZIP;q=0.5"))
+	assert.Equal(t, []string{"identity"}, parseAcceptEncoding("deflate;q=0, identity"))
+	assert.Equal(t, []string{}, parseAcceptEncoding("br;q=0"))
+}
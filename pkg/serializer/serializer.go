@@ -31,6 +31,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/serializer/split"
 	"github.com/DataDog/datadog-agent/pkg/serializer/types"
 	"github.com/DataDog/datadog-agent/pkg/util/compression"
+	"github.com/DataDog/datadog-agent/pkg/util/compression/selector"
 
 	log "github.com/DataDog/datadog-agent/comp/core/log/def"
 	"github.com/DataDog/datadog-agent/pkg/version"
@@ -67,16 +68,33 @@ func initExtraHeaders(s *Serializer) {
 	s.protobufExtraHeaders.Set("Content-Type", protobufContentType)
 	s.protobufExtraHeaders.Set(payloadVersionHTTPHeader, version.AgentPayloadVersion)
 
-	s.protobufExtraHeadersWithCompression = make(http.Header)
-	for k := range s.protobufExtraHeaders {
-		s.protobufExtraHeadersWithCompression.Set(k, s.protobufExtraHeaders.Get(k))
-	}
+	s.protobufExtraHeadersWithCompression = protobufExtraHeadersWithCompression(s.protobufExtraHeaders, s.Strategy)
+	s.seriesExtraHeadersWithCompression = protobufExtraHeadersWithCompression(s.protobufExtraHeaders, s.seriesStrategy)
+	s.sketchesExtraHeadersWithCompression = protobufExtraHeadersWithCompression(s.protobufExtraHeaders, s.sketchesStrategy)
 
 	encoding := s.Strategy.ContentEncoding()
 
 	if encoding != "" {
 		s.jsonExtraHeadersWithCompression.Set("Content-Encoding", encoding)
-		s.protobufExtraHeadersWithCompression.Set("Content-Encoding", encoding)
+	}
+}
+
+// protobufExtraHeadersWithCompression returns the headers of the protobuf payloads compressed with the strategy
+func protobufExtraHeadersWithCompression(protobufExtraHeaders http.Header, strategy compression.Compressor) http.Header {
+	headers := make(http.Header)
+	for k := range protobufExtraHeaders {
+		headers.Set(k, protobufExtraHeaders.Get(k))
+	}
+	if encoding := strategy.ContentEncoding(); encoding != "" {
+		headers.Set("Content-Encoding", encoding)
+	}
+	return headers
+}
+
+// setEncoder sets the encoder re-encoding the payloads for the endpoints which need it
+func setEncoder(payloads transaction.BytesPayloads, encoder *payloadEncoder) {
+	for _, payload := range payloads {
+		payload.Encoder = encoder
 	}
 }
 
@@ -110,6 +128,15 @@ type Serializer struct {
 	jsonExtraHeadersWithCompression     http.Header
 	protobufExtraHeadersWithCompression http.Header
 
+	// series and sketches are sent to their own endpoint, and can be compressed differently from the other payloads
+	seriesStrategy                      compression.Compressor
+	sketchesStrategy                    compression.Compressor
+	seriesExtraHeadersWithCompression   http.Header
+	sketchesExtraHeadersWithCompression http.Header
+	// they're re-encoded for the endpoints configured with another compression, or which don't accept theirs
+	seriesEncoder   *payloadEncoder
+	sketchesEncoder *payloadEncoder
+
 	// Those variables allow users to blacklist any kind of payload
 	// from being sent by the agent. This was introduced for
 	// environment where, for example, events or serviceChecks
@@ -149,6 +176,10 @@ func NewSerializer(forwarder forwarder.Forwarder, orchestratorForwarder orchestr
 		enableSketchProtobufStream:          streamAvailable && config.GetBool("enable_sketch_stream_payload_serialization"),
 		hostname:                            hostName,
 		Strategy:                            compressor,
+		seriesStrategy:                      selector.FromConfigForPayload(config, "series", compressor),
+		sketchesStrategy:                    selector.FromConfigForPayload(config, "sketches", compressor),
+		seriesEncoder:                       newPayloadEncoder(config, "series", logger),
+		sketchesEncoder:                     newPayloadEncoder(config, "sketches", logger),
 		jsonExtraHeaders:                    make(http.Header),
 		protobufExtraHeaders:                make(http.Header),
 		jsonExtraHeadersWithCompression:     make(http.Header),
@@ -353,7 +384,7 @@ func (s *Serializer) SendIterableSeries(serieSource metrics.SerieSource) error {
 		if failoverActive {
 			var filtered transaction.BytesPayloads
 			var localAutoscalingFaioverPayloads transaction.BytesPayloads
-			seriesBytesPayloads, filtered, localAutoscalingFaioverPayloads, err = seriesSerializer.MarshalSplitCompressMultiple(s.config, s.Strategy,
+			seriesBytesPayloads, filtered, localAutoscalingFaioverPayloads, err = seriesSerializer.MarshalSplitCompressMultiple(s.config, s.seriesStrategy,
 				func(s *metrics.Serie) bool { // Filter for MRF
 					_, allowed := allowlistForMRF[s.Name]
 					return allowed
@@ -375,12 +406,13 @@ func (s *Serializer) SendIterableSeries(serieSource metrics.SerieSource) error {
 			seriesBytesPayloads = append(seriesBytesPayloads, filtered...)
 			seriesBytesPayloads = append(seriesBytesPayloads, localAutoscalingFaioverPayloads...)
 		} else {
-			seriesBytesPayloads, err = seriesSerializer.MarshalSplitCompress(marshaler.NewBufferContext(), s.config, s.Strategy)
+			seriesBytesPayloads, err = seriesSerializer.MarshalSplitCompress(marshaler.NewBufferContext(), s.config, s.seriesStrategy)
 			for _, seriesBytesPayload := range seriesBytesPayloads {
 				seriesBytesPayload.Destination = transaction.AllRegions
 			}
 		}
-		extraHeaders = s.protobufExtraHeadersWithCompression
+		extraHeaders = s.seriesExtraHeadersWithCompression
+		setEncoder(seriesBytesPayloads, s.seriesEncoder)
 	}
 
 	if err != nil {
@@ -434,7 +466,7 @@ func (s *Serializer) SendSketch(sketches metrics.SketchesSource) error {
 	if s.enableSketchProtobufStream {
 		failoverActive, allowlist := s.getFailoverAllowlist()
 		if failoverActive && len(allowlist) > 0 {
-			payloads, filteredPayloads, err := sketchesSerializer.MarshalSplitCompressMultiple(s.config, s.Strategy, func(ss *metrics.SketchSeries) bool {
+			payloads, filteredPayloads, err := sketchesSerializer.MarshalSplitCompressMultiple(s.config, s.sketchesStrategy, func(ss *metrics.SketchSeries) bool {
 				_, allowed := allowlist[ss.Name]
 				return allowed
 			}, s.logger)
@@ -448,17 +480,20 @@ func (s *Serializer) SendSketch(sketches metrics.SketchesSource) error {
 				payload.Destination = transaction.SecondaryOnly
 			}
 			payloads = append(payloads, filteredPayloads...)
+			setEncoder(payloads, s.sketchesEncoder)
 
-			return s.Forwarder.SubmitSketchSeries(payloads, s.protobufExtraHeadersWithCompression)
+			return s.Forwarder.SubmitSketchSeries(payloads, s.sketchesExtraHeadersWithCompression)
 		} else {
-			payloads, err := sketchesSerializer.MarshalSplitCompress(marshaler.NewBufferContext(), s.config, s.Strategy, s.logger)
+			payloads, err := sketchesSerializer.MarshalSplitCompress(marshaler.NewBufferContext(), s.config, s.sketchesStrategy, s.logger)
 			if err != nil {
 				return fmt.Errorf("dropping sketch payload: %v", err)
 			}
+			setEncoder(payloads, s.sketchesEncoder)
 
-			return s.Forwarder.SubmitSketchSeries(payloads, s.protobufExtraHeadersWithCompression)
+			return s.Forwarder.SubmitSketchSeries(payloads, s.sketchesExtraHeadersWithCompression)
 		}
 	} else {
+		// the deprecated non-streamed serialization always uses the default compression
 		//nolint:revive // TODO(AML) Fix revive linter
 		compress := true
 		splitSketches, extraHeaders, err := s.serializePayloadProto(sketchesSerializer, compress)
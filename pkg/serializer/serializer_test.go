@@ -10,6 +10,8 @@ package serializer
 import (
 	"fmt"
 	"net/http"
+	"os"
+	"path/filepath"
 	"reflect"
 	"strings"
 	"testing"
@@ -32,6 +34,8 @@ import (
 	metricsserializer "github.com/DataDog/datadog-agent/pkg/serializer/internal/metrics"
 	"github.com/DataDog/datadog-agent/pkg/serializer/marshaler"
 	"github.com/DataDog/datadog-agent/pkg/util/compression"
+	"github.com/DataDog/datadog-agent/pkg/util/compression/dictionary"
+	"github.com/DataDog/datadog-agent/pkg/util/compression/selector"
 	"github.com/DataDog/datadog-agent/pkg/version"
 )
 
@@ -407,6 +411,58 @@ func TestSendSeries(t *testing.T) {
 	}
 }
 
+func TestSendSeriesWithPayloadCompression(t *testing.T) {
+	samples := [][]byte{}
+	for i := 0; i < 16; i++ {
+		samples = append(samples, []byte(fmt.Sprintf("host:fakehost-%d,env:prod,service:web-%d,version:%d", i, i%3, i*7)))
+	}
+	dict, err := dictionary.Train(samples, 3, 4096)
+	require.NoError(t, err)
+	dictionaryPath := filepath.Join(t.TempDir(), "series.dict")
+	require.NoError(t, os.WriteFile(dictionaryPath, dict, 0o600))
+
+	f := &forwarder.MockedForwarder{}
+	mockConfig := configmock.New(t)
+	mockConfig.SetWithoutSource("use_v2_api.series", true) // default value, but just to be sure
+	mockConfig.SetWithoutSource("serializer_compressor_kind", compression.ZlibKind)
+	mockConfig.SetWithoutSource("serializer_compression.series.compressor_kind", compression.ZstdKind)
+	mockConfig.SetWithoutSource("serializer_compression.series.zstd_dictionary_path", dictionaryPath)
+
+	compressor := metricscompressionimpl.NewCompressorReq(metricscompressionimpl.Requires{Cfg: mockConfig}).Comp
+	s := NewSerializer(f, nil, compressor, mockConfig, logmock.New(t), "testhost")
+
+	// only the series are compressed differently
+	assert.Equal(t, compression.ZlibEncoding, s.protobufExtraHeadersWithCompression.Get("Content-Encoding"))
+	assert.Equal(t, compression.ZstdEncoding, s.seriesExtraHeadersWithCompression.Get("Content-Encoding"))
+	assert.Equal(t, s.protobufExtraHeadersWithCompression, s.sketchesExtraHeadersWithCompression)
+
+	var submitted transaction.BytesPayloads
+	f.On("SubmitSeries", mock.Anything, s.seriesExtraHeadersWithCompression).Return(nil).Times(1).Run(func(args mock.Arguments) {
+		submitted = args.Get(0).(transaction.BytesPayloads)
+	})
+
+	err = s.SendIterableSeries(metricsserializer.CreateSerieSource(metrics.Series{&metrics.Serie{}}))
+	require.Nil(t, err)
+	f.AssertExpectations(t)
+
+	require.Len(t, submitted, 1)
+	// the payloads are re-encoded for the endpoints which don't have the dictionary
+	assert.Equal(t, s.seriesEncoder, submitted[0].Encoder)
+	expected, err := protoscope.NewScanner(`1: {
+		1: { 1: {"host"} }
+		5: 3
+		9: { 1: { 4: 10 }}
+	  }`).Exec()
+	require.NoError(t, err)
+	payload, err := selector.NewCompressorWithDictionary(compression.ZstdKind, 1, dict).Decompress(submitted[0].GetContent())
+	require.NoError(t, err)
+	assert.Equal(t, expected, payload)
+
+	// the payload can't be decompressed without the dictionary
+	_, err = selector.NewCompressor(compression.ZstdKind, 1).Decompress(submitted[0].GetContent())
+	assert.Error(t, err)
+}
+
 func TestSendSketch(t *testing.T) {
 	tests := map[string]struct {
 		kind string
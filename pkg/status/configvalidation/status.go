@@ -0,0 +1,91 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// Package configvalidation fetch information needed to render the 'Configuration Validation' section of the status page.
+package configvalidation
+
+import (
+	"embed"
+	"io"
+
+	"github.com/DataDog/datadog-agent/comp/core/config"
+	"github.com/DataDog/datadog-agent/comp/core/status"
+	pkgconfigsetup "github.com/DataDog/datadog-agent/pkg/config/setup"
+)
+
+//go:embed status_templates
+var templatesFS embed.FS
+
+// Provider provides the functionality to populate the status output
+type Provider struct {
+	config config.Component
+	// validationErrors are the errors found when the configuration was loaded
+	validationErrors []error
+}
+
+// GetProvider returns the status provider of the configuration validation
+func GetProvider(conf config.Component) status.Provider {
+	var validationErrors []error
+	if warnings := conf.Warnings(); warnings != nil {
+		validationErrors = warnings.ValidationErrors
+	} else {
+		// the configuration wasn't loaded from its files, validate it as it is
+		validationErrors = pkgconfigsetup.ValidateConfig(conf)
+	}
+	return Provider{config: conf, validationErrors: validationErrors}
+}
+
+func (p Provider) getStatusInfo() map[string]interface{} {
+	stats := make(map[string]interface{})
+
+	p.populateStatus(stats)
+
+	return stats
+}
+
+func (p Provider) populateStatus(stats map[string]interface{}) {
+	errs := []string{}
+	warnings := []string{}
+	for _, err := range p.validationErrors {
+		if pkgconfigsetup.IsBlockingValidationError(err) {
+			errs = append(errs, err.Error())
+		} else {
+			warnings = append(warnings, err.Error())
+		}
+	}
+
+	stats["configValidation"] = map[string]interface{}{
+		"errors":   errs,
+		"warnings": warnings,
+		"strict":   p.config.GetBool("strict_config_validation"),
+	}
+}
+
+// Name returns the name
+func (p Provider) Name() string {
+	return "Configuration Validation"
+}
+
+// Section return the section
+func (p Provider) Section() string {
+	return "Configuration Validation"
+}
+
+// JSON populates the status map
+func (p Provider) JSON(_ bool, stats map[string]interface{}) error {
+	p.populateStatus(stats)
+
+	return nil
+}
+
+// Text renders the text output
+func (p Provider) Text(_ bool, buffer io.Writer) error {
+	return status.RenderText(templatesFS, "configvalidation.tmpl", buffer, p.getStatusInfo())
+}
+
+// HTML renders the html output
+func (p Provider) HTML(_ bool, buffer io.Writer) error {
+	return status.RenderHTML(templatesFS, "configvalidationHTML.tmpl", buffer, p.getStatusInfo())
+}
@@ -0,0 +1,102 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package configvalidation
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/DataDog/datadog-agent/comp/core/config"
+	pkgconfigmodel "github.com/DataDog/datadog-agent/pkg/config/model"
+	pkgconfigsetup "github.com/DataDog/datadog-agent/pkg/config/setup"
+)
+
+func TestStatus(t *testing.T) {
+	provider := GetProvider(config.NewMockFromYAML(t, `
+log_enabled: true
+api_kye: fakeapikey
+`))
+
+	tests := []struct {
+		name       string
+		assertFunc func(t *testing.T)
+	}{
+		{"JSON", func(t *testing.T) {
+			stats := make(map[string]interface{})
+			provider.JSON(false, stats)
+
+			assert.Equal(t, map[string]interface{}{
+				"errors":   []string{"unknown key 'api_kye', did you mean 'api_key'?"},
+				"warnings": []string{"'log_enabled' from file is deprecated, use 'logs_enabled' instead"},
+				"strict":   false,
+			}, stats["configValidation"])
+		}},
+		{"Text", func(t *testing.T) {
+			b := new(bytes.Buffer)
+			err := provider.Text(false, b)
+
+			assert.NoError(t, err)
+
+			expected := `
+  Strict validation: disabled
+
+  Errors:
+    - unknown key 'api_kye', did you mean 'api_key'?
+
+  Warnings:
+    - 'log_enabled' from file is deprecated, use 'logs_enabled' instead
+`
+			assert.Equal(t, expected, b.String())
+		}},
+		{"HTML", func(t *testing.T) {
+			b := new(bytes.Buffer)
+			err := provider.HTML(false, b)
+
+			assert.NoError(t, err)
+
+			assert.Contains(t, b.String(), "unknown key &#39;api_kye&#39;, did you mean &#39;api_key&#39;?")
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.assertFunc(t)
+		})
+	}
+}
+
+// loadedConfig is a configuration loaded from its files, with the warnings of its loading
+type loadedConfig struct {
+	config.Component
+	warnings *pkgconfigmodel.Warnings
+}
+
+func (c loadedConfig) Warnings() *pkgconfigmodel.Warnings {
+	return c.warnings
+}
+
+func TestStatusLoadedConfig(t *testing.T) {
+	conf := config.NewMockFromYAML(t, `
+api_kye: fakeapikey
+`)
+	provider := GetProvider(loadedConfig{
+		Component: conf,
+		warnings: &pkgconfigmodel.Warnings{ValidationErrors: []error{
+			&pkgconfigsetup.DeprecatedKeyError{Key: "log_enabled", Source: pkgconfigmodel.SourceFile, Replacement: "logs_enabled"},
+		}},
+	})
+
+	// the errors found when the configuration was loaded are reported, it isn't validated again
+	stats := make(map[string]interface{})
+	provider.JSON(false, stats)
+	assert.Equal(t, map[string]interface{}{
+		"errors":   []string{},
+		"warnings": []string{"'log_enabled' from file is deprecated, use 'logs_enabled' instead"},
+		"strict":   false,
+	}, stats["configValidation"])
+}
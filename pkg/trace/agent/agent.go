@@ -8,6 +8,7 @@ package agent
 
 import (
 	"context"
+	"maps"
 	"reflect"
 	"runtime"
 	"strconv"
@@ -23,8 +24,10 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/trace/filters"
 	"github.com/DataDog/datadog-agent/pkg/trace/info"
 	"github.com/DataDog/datadog-agent/pkg/trace/log"
+	"github.com/DataDog/datadog-agent/pkg/trace/pathtemplate"
 	"github.com/DataDog/datadog-agent/pkg/trace/remoteconfighandler"
 	"github.com/DataDog/datadog-agent/pkg/trace/sampler"
+	"github.com/DataDog/datadog-agent/pkg/trace/spanmetrics"
 	"github.com/DataDog/datadog-agent/pkg/trace/stats"
 	"github.com/DataDog/datadog-agent/pkg/trace/telemetry"
 	"github.com/DataDog/datadog-agent/pkg/trace/timing"
@@ -89,12 +92,16 @@ type Agent struct {
 	ClientStatsAggregator *stats.ClientStatsAggregator
 	Blacklister           *filters.Blacklister
 	Replacer              *filters.Replacer
+	SpanFilter            *filters.SpanFilter
 	PrioritySampler       *sampler.PrioritySampler
 	ErrorsSampler         *sampler.ErrorsSampler
 	RareSampler           *sampler.RareSampler
 	NoPrioritySampler     *sampler.NoPrioritySampler
 	ProbabilisticSampler  *sampler.ProbabilisticSampler
 	SamplerMetrics        *sampler.Metrics
+	TailSampler           *sampler.TailSampler
+	SpanMetrics           *spanmetrics.Generator
+	PathTemplater         *pathtemplate.Templater
 	EventProcessor        *event.Processor
 	TraceWriter           TraceWriter
 	StatsWriter           *writer.DatadogStatsWriter
@@ -169,6 +176,20 @@ func NewAgent(ctx context.Context, conf *config.AgentConfig, telemetryCollector
 		Timing:                timing,
 	}
 	agnt.SamplerMetrics.Add(agnt.PrioritySampler, agnt.ErrorsSampler, agnt.NoPrioritySampler, agnt.RareSampler)
+	if conf.TailSampling.Enabled {
+		agnt.TailSampler = sampler.NewTailSampler(conf, agnt.SamplerMetrics, statsd, agnt.writeTailSampled)
+		agnt.SamplerMetrics.Add(agnt.TailSampler)
+		info.SetTailSampler(agnt.TailSampler)
+	}
+	if conf.RouteTemplating.Enabled {
+		agnt.PathTemplater = pathtemplate.NewTemplater(conf)
+	}
+	if len(conf.SpanFilters) > 0 {
+		agnt.SpanFilter = filters.NewSpanFilter(conf.SpanFilters)
+	}
+	if len(conf.SpanMetrics) > 0 {
+		agnt.SpanMetrics = spanmetrics.NewGenerator(conf, statsd)
+	}
 	agnt.Receiver = api.NewHTTPReceiver(conf, dynConf, in, agnt, telemetryCollector, statsd, timing)
 	agnt.OTLPReceiver = api.NewOTLPReceiver(in, conf, statsd, timing)
 	agnt.RemoteConfigHandler = remoteconfighandler.New(conf, agnt.PrioritySampler, agnt.RareSampler, agnt.ErrorsSampler)
@@ -192,6 +213,9 @@ func (a *Agent) Run() {
 	} {
 		starter.Start()
 	}
+	if a.TailSampler != nil {
+		a.TailSampler.Start()
+	}
 
 	go a.StatsWriter.Run()
 
@@ -264,6 +288,7 @@ func (a *Agent) loop() {
 	for _, stopper := range []interface{ Stop() }{
 		a.Concentrator,
 		a.ClientStatsAggregator,
+		a.TailSampler,
 		a.TraceWriter,
 		a.StatsWriter,
 		a.SamplerMetrics,
@@ -316,6 +341,8 @@ func (a *Agent) Process(p *api.Payload) {
 	defer a.Timing.Since("datadog.trace_agent.internal.process_payload_ms", now)
 	ts := p.Source
 	sampledChunks := new(writer.SampledChunks)
+	// tailPayload holds the attributes of the payload for the chunks buffered by the tail sampler.
+	var tailPayload *pb.TracerPayload
 	statsInput := stats.NewStatsInput(len(p.TracerPayload.Chunks), p.TracerPayload.ContainerID, p.ClientComputedStats, a.conf)
 
 	p.TracerPayload.Env = traceutil.NormalizeTagValue(p.TracerPayload.Env)
@@ -359,6 +386,18 @@ func (a *Agent) Process(p *api.Payload) {
 			continue
 		}
 
+		if a.SpanFilter != nil {
+			dropTrace, dropped := a.SpanFilter.Filter(chunk, root)
+			if dropTrace {
+				log.Debugf("Trace rejected by span filtering rules. root: %v", root)
+				ts.TracesFiltered.Inc()
+				ts.SpansFiltered.Add(tracen)
+				p.RemoveChunk(i)
+				continue
+			}
+			ts.SpansFiltered.Add(int64(dropped))
+		}
+
 		// Extra sanitization steps of the trace.
 		for _, span := range chunk.Spans {
 			for k, v := range a.conf.GlobalTags {
@@ -372,7 +411,10 @@ func (a *Agent) Process(p *api.Payload) {
 				a.SpanModifier.ModifySpan(chunk, span)
 			}
 			a.obfuscateSpan(span)
-			a.Truncate(span)
+			if a.PathTemplater != nil {
+				a.PathTemplater.TemplateResource(span)
+			}
+			a.Truncate(ts, span)
 			if p.ClientComputedTopLevel {
 				traceutil.UpdateTracerTopLevel(span)
 			}
@@ -392,8 +434,26 @@ func (a *Agent) Process(p *api.Payload) {
 		if !p.ClientComputedStats {
 			statsInput.Traces = append(statsInput.Traces, *pt.Clone())
 		}
+		if a.SpanMetrics != nil {
+			a.SpanMetrics.Process(pt)
+		}
 
+		spans := chunk.Spans
 		keep, numEvents := a.sample(now, ts, pt)
+		if a.TailSampler != nil {
+			if keep {
+				// The other chunks of the trace are kept too, even if they're handed to the tail sampler.
+				a.TailSampler.KeepTrace(now, root.TraceID)
+			} else if tailChunk := unsentChunk(pt.TraceChunk, spans); tailChunk != nil {
+				// The chunks dropped by the other samplers get another chance in the tail sampler,
+				// which takes its decision once all the chunks of the trace are received.
+				if tailPayload == nil {
+					tailPayload = p.TracerPayload.Cut(0)
+					tailPayload.Chunks = nil
+				}
+				a.TailSampler.Add(now, tailPayload, tailChunk)
+			}
+		}
 		if !keep && len(pt.TraceChunk.Spans) == 0 {
 			// The entire trace was dropped and no spans were kept.
 			p.RemoveChunk(i)
@@ -432,6 +492,53 @@ func (a *Agent) Process(p *api.Payload) {
 	}
 }
 
+// unsentChunk returns a copy of the chunk dropped by the samplers, holding the spans it had before
+// sampling, except the ones it still holds, which are sent as analytics events or single spans.
+// It returns nil if all the spans are sent.
+func unsentChunk(chunk *pb.TraceChunk, spans []*pb.Span) *pb.TraceChunk {
+	sent := make(map[*pb.Span]struct{}, len(chunk.Spans))
+	for _, span := range chunk.Spans {
+		sent[span] = struct{}{}
+	}
+	var unsent []*pb.Span
+	for _, span := range spans {
+		if _, ok := sent[span]; !ok {
+			unsent = append(unsent, span)
+		}
+	}
+	if len(unsent) == 0 {
+		return nil
+	}
+	c := chunk.ShallowCopy()
+	c.Spans = unsent
+	// the tags of the chunk kept by the tail sampler are modified, while the chunk is sent
+	c.Tags = maps.Clone(chunk.Tags)
+	return c
+}
+
+// writeTailSampled writes the chunks kept by the tail sampler, grouped by the payload they were received in.
+func (a *Agent) writeTailSampled(chunks []sampler.TailSampledChunk) {
+	payloads := make(map[*pb.TracerPayload]*writer.SampledChunks)
+	for _, c := range chunks {
+		sampledChunks, ok := payloads[c.Payload]
+		if !ok {
+			sampledChunks = &writer.SampledChunks{TracerPayload: c.Payload.Cut(0)}
+			payloads[c.Payload] = sampledChunks
+		}
+		a.setFirstTraceTags(traceutil.GetRoot(c.Chunk.Spans))
+		sampledChunks.TracerPayload.Chunks = append(sampledChunks.TracerPayload.Chunks, c.Chunk)
+		sampledChunks.SpanCount += int64(len(c.Chunk.Spans))
+		sampledChunks.Size += c.Chunk.Msgsize()
+		if sampledChunks.Size > writer.MaxPayloadSize {
+			a.TraceWriter.WriteChunks(sampledChunks)
+			delete(payloads, c.Payload)
+		}
+	}
+	for _, sampledChunks := range payloads {
+		a.TraceWriter.WriteChunks(sampledChunks)
+	}
+}
+
 func (a *Agent) setPayloadAttributes(p *api.Payload, root *pb.Span, chunk *pb.TraceChunk) {
 	if p.TracerPayload.Hostname == "" {
 		// Older tracers set tracer hostname in the root span.
@@ -299,6 +299,59 @@ func TestProcess(t *testing.T) {
 		assert.EqualValues(3, want.SpansFiltered.Load())
 	})
 
+	t.Run("SpanFilter", func(t *testing.T) {
+		cfg := config.New()
+		cfg.Endpoints[0].APIKey = "test"
+		cfg.SpanFilters = []*config.SpanFilterRule{
+			{Name: "drop-health", Action: config.SpanFilterDropTrace, Expression: `resource == "GET /health"`},
+			{Name: "drop-cache", Action: config.SpanFilterDropSpan, Expression: `type == "redis" && duration < 1ms`},
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		agnt := NewTestAgent(ctx, cfg, telemetry.NewNoopCollector())
+		defer cancel()
+
+		now := time.Now()
+		newSpan := func(spanID, parentID uint64, typ, resource string, duration time.Duration) *pb.Span {
+			return &pb.Span{
+				TraceID:  1,
+				SpanID:   spanID,
+				ParentID: parentID,
+				Service:  "web",
+				Name:     "span",
+				Resource: resource,
+				Type:     typ,
+				Start:    now.Add(-time.Second).UnixNano(),
+				Duration: duration.Nanoseconds(),
+			}
+		}
+
+		want := agnt.Receiver.Stats.GetTagStats(info.Tags{})
+		agnt.Process(&api.Payload{
+			TracerPayload: testutil.TracerPayloadWithChunks([]*pb.TraceChunk{
+				testutil.TraceChunkWithSpansAndPriority([]*pb.Span{
+					newSpan(1, 0, "web", "GET /health", 5*time.Millisecond),
+					newSpan(2, 1, "redis", "GET", time.Microsecond),
+				}, 2),
+				testutil.TraceChunkWithSpansAndPriority([]*pb.Span{
+					newSpan(1, 0, "web", "GET /checkout", 5*time.Millisecond),
+					newSpan(2, 1, "redis", "GET", time.Microsecond),
+					newSpan(3, 2, "sql", "SELECT", 2*time.Millisecond),
+				}, 2),
+			}),
+			Source: want,
+		})
+		assert.EqualValues(t, 1, want.TracesFiltered.Load())
+		assert.EqualValues(t, 3, want.SpansFiltered.Load())
+
+		payloads := agnt.TraceWriter.(*mockTraceWriter).payloads
+		require.Len(t, payloads, 1)
+		require.Len(t, payloads[0].TracerPayload.Chunks, 1)
+		spans := payloads[0].TracerPayload.Chunks[0].Spans
+		require.Len(t, spans, 2)
+		assert.Equal(t, uint64(3), spans[1].SpanID)
+		assert.Equal(t, uint64(1), spans[1].ParentID)
+	})
+
 	t.Run("BlacklistPayload", func(t *testing.T) {
 		// Regression test for DataDog/datadog-agent#6500
 		cfg := config.New()
@@ -828,6 +881,63 @@ func TestClientComputedTopLevel(t *testing.T) {
 	})
 }
 
+func TestProcessTailSampling(t *testing.T) {
+	cfg := config.New()
+	cfg.Endpoints[0].APIKey = "test"
+	cfg.TailSampling.Enabled = true
+	cfg.TailSampling.Policies = []*config.TailSamplingPolicy{
+		{Name: "slow", Type: config.TailSamplingPolicyLatency, LatencyThresholdMs: 1},
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	agnt := NewTestAgent(ctx, cfg, telemetry.NewNoopCollector())
+	agnt.TailSampler.Start()
+
+	root := &pb.Span{Service: "web", Name: "http.request", Resource: "GET /", TraceID: 1, SpanID: 1, Duration: 10}
+	child := &pb.Span{Service: "db", Name: "query", Resource: "SELECT", TraceID: 1, SpanID: 2, ParentID: 1, Duration: 2e6}
+	other := &pb.Span{Service: "web", Name: "http.request", Resource: "GET /", TraceID: 2, SpanID: 1, Duration: 10}
+	keptRoot := &pb.Span{Service: "web", Name: "http.request", Resource: "GET /", TraceID: 3, SpanID: 1, Duration: 10}
+	keptChild := &pb.Span{Service: "db", Name: "query", Resource: "SELECT", TraceID: 3, SpanID: 2, ParentID: 1, Duration: 5}
+	process := func(chunk *pb.TraceChunk, priority sampler.SamplingPriority) {
+		chunk.Priority = int32(priority)
+		tp := testutil.TracerPayloadWithChunk(chunk)
+		tp.Env = "prod"
+		agnt.Process(&api.Payload{
+			TracerPayload: tp,
+			Source:        agnt.Receiver.Stats.GetTagStats(info.Tags{}),
+		})
+	}
+	process(testutil.TraceChunkWithSpan(root), sampler.PriorityAutoDrop)
+	process(testutil.TraceChunkWithSpan(other), sampler.PriorityAutoDrop)
+	// the slow span is reported by another tracer, after the root span
+	process(testutil.TraceChunkWithSpan(child), sampler.PriorityAutoDrop)
+	// a trace kept by the priority sampler is written right away, and its chunks dropped by
+	// the other samplers are kept by the tail sampler
+	process(testutil.TraceChunkWithSpan(keptRoot), sampler.PriorityAutoKeep)
+	process(testutil.TraceChunkWithSpan(keptChild), sampler.PriorityAutoDrop)
+
+	tw := agnt.TraceWriter.(*mockTraceWriter)
+	require.Len(t, tw.payloads, 2)
+	assert.Equal(t, []*pb.Span{keptRoot}, tw.payloads[0].TracerPayload.Chunks[0].Spans)
+	assert.NotContains(t, tw.payloads[0].TracerPayload.Chunks[0].Tags, sampler.KeyTailSamplingPolicy)
+	assert.Equal(t, []*pb.Span{keptChild}, tw.payloads[1].TracerPayload.Chunks[0].Spans)
+	assert.Equal(t, "head", tw.payloads[1].TracerPayload.Chunks[0].Tags[sampler.KeyTailSamplingPolicy])
+	assert.Len(t, agnt.Concentrator.(*mockConcentrator).stats, 5, "stats must be computed on all the traces")
+
+	agnt.TailSampler.Stop()
+	require.Len(t, tw.payloads, 4)
+	var spans []*pb.Span
+	for _, p := range tw.payloads[2:] {
+		assert.Equal(t, "prod", p.TracerPayload.Env)
+		assert.Len(t, p.TracerPayload.Chunks, 1)
+		chunk := p.TracerPayload.Chunks[0]
+		assert.False(t, chunk.DroppedTrace)
+		assert.Equal(t, "slow", chunk.Tags[sampler.KeyTailSamplingPolicy])
+		spans = append(spans, chunk.Spans...)
+	}
+	assert.ElementsMatch(t, []*pb.Span{root, child}, spans)
+}
+
 func TestFilteredByTags(t *testing.T) {
 	for name, tt := range map[string]*struct {
 		require      []*config.Tag
@@ -2320,7 +2430,7 @@ func formatTrace(t pb.Trace) pb.Trace {
 	for _, span := range t {
 		a := &Agent{obfuscatorConf: &obfuscate.Config{}, conf: config.New()}
 		a.obfuscateSpan(span)
-		a.Truncate(span)
+		a.Truncate(newTagStats(), span)
 	}
 	return t
 }
@@ -20,8 +20,8 @@ import (
 )
 
 const (
-	// MaxTypeLen the maximum length a span type can have
-	MaxTypeLen = 100
+	// MaxTypeLen the default maximum length a span type can have
+	MaxTypeLen = traceutil.MaxTypeLen
 	// tagOrigin specifies the origin of the trace.
 	// DEPRECATED: Origin is now specified as a TraceChunk field.
 	tagOrigin = "_dd.origin"
@@ -50,14 +50,15 @@ func (a *Agent) normalize(ts *info.TagStats, s *pb.Span) error {
 		ts.TracesDropped.SpanIDZero.Inc()
 		return fmt.Errorf("SpanID is zero (reason:span_id_zero): %s", s)
 	}
-	svc, err := traceutil.NormalizeService(s.Service, ts.Lang)
+	limits := &a.conf.Normalization
+	svc, err := traceutil.NormalizeServiceMaxLen(s.Service, ts.Lang, limits.MaxServiceLen)
 	switch err {
 	case traceutil.ErrEmpty:
 		ts.SpansMalformed.ServiceEmpty.Inc()
 		log.Debugf("Fixing malformed trace. Service is empty (reason:service_empty), setting span.service=%s: %s", s.Service, s)
 	case traceutil.ErrTooLong:
 		ts.SpansMalformed.ServiceTruncate.Inc()
-		log.Debugf("Fixing malformed trace. Service is too long (reason:service_truncate), truncating span.service to length=%d: %s", traceutil.MaxServiceLen, s)
+		log.Debugf("Fixing malformed trace. Service is too long (reason:service_truncate), truncating span.service to length=%d: %s", limits.MaxServiceLen, s)
 	case traceutil.ErrInvalid:
 		ts.SpansMalformed.ServiceInvalid.Inc()
 		log.Debugf("Fixing malformed trace. Service is invalid (reason:service_invalid), replacing invalid span.service=%s with fallback span.service=%s: %s", s.Service, svc, s)
@@ -66,11 +67,11 @@ func (a *Agent) normalize(ts *info.TagStats, s *pb.Span) error {
 
 	pSvc, ok := s.Meta[peerServiceKey]
 	if ok {
-		ps, err := traceutil.NormalizePeerService(pSvc)
+		ps, err := traceutil.NormalizePeerServiceMaxLen(pSvc, limits.MaxServiceLen)
 		switch err {
 		case traceutil.ErrTooLong:
 			ts.SpansMalformed.PeerServiceTruncate.Inc()
-			log.Debugf("Fixing malformed trace. peer.service is too long (reason:peer_service_truncate), truncating peer.service to length=%d: %s", traceutil.MaxServiceLen, ps)
+			log.Debugf("Fixing malformed trace. peer.service is too long (reason:peer_service_truncate), truncating peer.service=%s to length=%d: %s", pSvc, limits.MaxServiceLen, ps)
 		case traceutil.ErrInvalid:
 			ts.SpansMalformed.PeerServiceInvalid.Inc()
 			log.Debugf("Fixing malformed trace. peer.service is invalid (reason:peer_service_invalid), replacing invalid peer.service=%s with empty string", pSvc)
@@ -84,11 +85,11 @@ func (a *Agent) normalize(ts *info.TagStats, s *pb.Span) error {
 
 	bSvc, ok := s.Meta[baseServiceKey]
 	if ok {
-		bs, err := traceutil.NormalizePeerService(bSvc)
+		bs, err := traceutil.NormalizePeerServiceMaxLen(bSvc, limits.MaxServiceLen)
 		switch err {
 		case traceutil.ErrTooLong:
 			ts.SpansMalformed.BaseServiceTruncate.Inc()
-			log.Debugf("Fixing malformed trace. _dd.base_service is too long (reason:base_service_truncate), truncating _dd.base_service to length=%d: %s", traceutil.MaxServiceLen, bs)
+			log.Debugf("Fixing malformed trace. _dd.base_service is too long (reason:base_service_truncate), truncating _dd.base_service=%s to length=%d: %s", bSvc, limits.MaxServiceLen, bs)
 		case traceutil.ErrInvalid:
 			ts.SpansMalformed.BaseServiceInvalid.Inc()
 			log.Debugf("Fixing malformed trace. _dd.base_service is invalid (reason:base_service_invalid), replacing invalid _dd.base_service=%s with empty string", bSvc)
@@ -111,17 +112,18 @@ func (a *Agent) normalize(ts *info.TagStats, s *pb.Span) error {
 			s.Name = v
 		}
 	}
-	s.Name, err = traceutil.NormalizeName(s.Name)
+	name := s.Name
+	s.Name, err = traceutil.NormalizeNameMaxLen(name, limits.MaxNameLen)
 	switch err {
 	case traceutil.ErrEmpty:
 		ts.SpansMalformed.SpanNameEmpty.Inc()
 		log.Debugf("Fixing malformed trace. Name is empty (reason:span_name_empty), setting span.name=%s: %s", s.Name, s)
 	case traceutil.ErrTooLong:
 		ts.SpansMalformed.SpanNameTruncate.Inc()
-		log.Debugf("Fixing malformed trace. Name is too long (reason:span_name_truncate), truncating span.name to length=%d: %s", traceutil.MaxServiceLen, s)
+		log.Debugf("Fixing malformed trace. Name is too long (reason:span_name_truncate), truncating span.name=%s to length=%d: %s", name, limits.MaxNameLen, s)
 	case traceutil.ErrInvalid:
 		ts.SpansMalformed.SpanNameInvalid.Inc()
-		log.Debugf("Fixing malformed trace. Name is invalid (reason:span_name_invalid), setting span.name=%s: %s", s.Name, s)
+		log.Debugf("Fixing malformed trace. Name is invalid (reason:span_name_invalid), replacing invalid span.name=%s with span.name=%s: %s", name, s.Name, s)
 	}
 
 	if s.Resource == "" {
@@ -163,10 +165,10 @@ func (a *Agent) normalize(ts *info.TagStats, s *pb.Span) error {
 		}
 	}
 
-	if len(s.Type) > MaxTypeLen {
+	if len(s.Type) > limits.MaxTypeLen {
 		ts.SpansMalformed.TypeTruncate.Inc()
-		log.Debugf("Fixing malformed trace. Type is too long (reason:type_truncate), truncating span.type to length=%d: %s", MaxTypeLen, s)
-		s.Type = traceutil.TruncateUTF8(s.Type, MaxTypeLen)
+		log.Debugf("Fixing malformed trace. Type is too long (reason:type_truncate), truncating span.type to length=%d: %s", limits.MaxTypeLen, s)
+		s.Type = traceutil.TruncateUTF8(s.Type, limits.MaxTypeLen)
 	}
 	if env, ok := s.Meta["env"]; ok {
 		s.Meta["env"] = traceutil.NormalizeTagValue(env)
@@ -182,7 +184,7 @@ func (a *Agent) normalize(ts *info.TagStats, s *pb.Span) error {
 	if len(s.SpanLinks) > 0 {
 		for _, link := range s.SpanLinks {
 			if val, ok := link.Attributes["link.name"]; ok {
-				link.Attributes["link.name"], err = traceutil.NormalizeName(val)
+				link.Attributes["link.name"], err = traceutil.NormalizeNameMaxLen(val, limits.MaxNameLen)
 				if err != nil {
 					log.Debugf("Fixing malformed trace. 'link.name' attribute in span link is invalid (reason=%q), setting link.Attributes[\"link.name\"]=%s", err, link.Attributes["link.name"])
 				}
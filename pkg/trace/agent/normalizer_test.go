@@ -644,3 +644,25 @@ func TestLexerNormalization(t *testing.T) {
 	agnt.obfuscateSpan(span)
 	assert.Equal(t, "SELECT * FROM u.users", span.Resource)
 }
+
+func TestNormalizeLimits(t *testing.T) {
+	conf := config.New()
+	conf.Normalization.MaxServiceLen = 5
+	conf.Normalization.MaxNameLen = 150
+	conf.Normalization.MaxTypeLen = 3
+	a := &Agent{conf: conf}
+	ts := newTagStats()
+	s := newTestSpan()
+	s.Service = "service"
+	s.Name = strings.Repeat("a", 200)
+	s.Type = "http"
+	assert.NoError(t, a.normalize(ts, s))
+	assert.Equal(t, "servi", s.Service)
+	assert.Equal(t, strings.Repeat("a", 150), s.Name)
+	assert.Equal(t, "htt", s.Type)
+	assert.Equal(t, tsMalformed(&info.SpansMalformed{
+		ServiceTruncate:  *atomic.NewInt64(1),
+		SpanNameTruncate: *atomic.NewInt64(1),
+		TypeTruncate:     *atomic.NewInt64(1),
+	}), ts)
+}
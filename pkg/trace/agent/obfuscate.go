@@ -24,10 +24,12 @@ const (
 	tagSQLQuery         = transform.TagSQLQuery
 	tagHTTPURL          = transform.TagHTTPURL
 	tagDBMS             = transform.TagDBMS
+	tagGraphQLSource    = transform.TagGraphQLSource
 )
 
 const (
-	textNonParsable = transform.TextNonParsable
+	textNonParsable        = transform.TextNonParsable
+	textNonParsableGraphQL = transform.TextNonParsableGraphQL
 )
 
 func (a *Agent) obfuscateSpan(span *pb.Span) {
@@ -52,6 +54,12 @@ func (a *Agent) obfuscateSpan(span *pb.Span) {
 		if span.Resource == "" {
 			return
 		}
+		if span.Type == "cassandra" && a.conf.Obfuscation.CQL.Enabled {
+			if err := transform.ObfuscateCQLSpan(o, span); err != nil {
+				log.Debugf("Error parsing CQL query: %v. Resource: %q", err, span.Resource)
+			}
+			return
+		}
 		oq, err := transform.ObfuscateSQLSpan(o, span)
 		if err != nil {
 			// we have an error, discard the SQL to avoid polluting user resources.
@@ -72,6 +80,13 @@ func (a *Agent) obfuscateSpan(span *pb.Span) {
 		if span.Type == "valkey" && a.conf.Obfuscation.Valkey.Enabled {
 			transform.ObfuscateValkeySpan(o, span, a.conf.Obfuscation.Valkey.RemoveAllArgs)
 		}
+	case "graphql":
+		if !a.conf.Obfuscation.GraphQL.Enabled {
+			return
+		}
+		if err := transform.ObfuscateGraphQLSpan(o, span); err != nil {
+			log.Debugf("Error parsing GraphQL query: %v. Resource: %q", err, span.Resource)
+		}
 	case "memcached":
 		if !a.conf.Obfuscation.Memcached.Enabled {
 			return
@@ -161,6 +176,15 @@ func (a *Agent) obfuscateStatsGroup(b *pb.ClientGroupedStats) {
 
 	switch b.Type {
 	case "sql", "cassandra":
+		if b.Type == "cassandra" && a.conf.Obfuscation.CQL.Enabled {
+			query, err := o.ObfuscateCQL(b.Resource)
+			if err != nil {
+				log.Errorf("Error obfuscating stats group resource %q: %v", b.Resource, err)
+				query = textNonParsable
+			}
+			b.Resource = query
+			return
+		}
 		oq, err := o.ObfuscateSQLStringForDBMS(b.Resource, b.DBType)
 		if err != nil {
 			log.Errorf("Error obfuscating stats group resource %q: %v", b.Resource, err)
@@ -170,6 +194,16 @@ func (a *Agent) obfuscateStatsGroup(b *pb.ClientGroupedStats) {
 		}
 	case "redis", "valkey":
 		b.Resource = o.QuantizeRedisString(b.Resource)
+	case "graphql":
+		if !a.conf.Obfuscation.GraphQL.Enabled {
+			return
+		}
+		query, err := o.ObfuscateGraphQL(b.Resource)
+		if err != nil {
+			log.Errorf("Error obfuscating stats group resource %q: %v", b.Resource, err)
+			query = textNonParsableGraphQL
+		}
+		b.Resource = query
 	}
 }
 
@@ -100,6 +100,31 @@ func TestObfuscateDefaults(t *testing.T) {
 	})
 }
 
+func TestObfuscateCQL(t *testing.T) {
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	defer cancelFunc()
+	cfg := config.New()
+	cfg.Endpoints[0].APIKey = "test"
+	cfg.Obfuscation.CQL.Enabled = true
+	agnt := NewAgent(ctx, cfg, telemetry.NewNoopCollector(), &statsd.NoOpClient{}, gzip.NewComponent())
+
+	span := &pb.Span{
+		Type:     "cassandra",
+		Resource: "UPDATE users USING TTL 3600 SET tags = {'a', 'b'} WHERE id IN (1, 2)",
+	}
+	agnt.obfuscateSpan(span)
+	assert.Equal(t, "UPDATE users USING TTL ? SET tags = ? WHERE id IN (?)", span.Resource)
+	assert.Equal(t, "UPDATE users USING TTL ? SET tags = ? WHERE id IN (?)", span.Meta[tagSQLQuery])
+
+	span = &pb.Span{Type: "cassandra", Resource: "SELECT * FROM users WHERE name = 'jane"}
+	agnt.obfuscateSpan(span)
+	assert.Equal(t, textNonParsable, span.Resource)
+
+	b := &pb.ClientGroupedStats{Type: "cassandra", Resource: "SELECT * FROM users WHERE id = 123e4567-e89b-12d3-a456-426614174000"}
+	agnt.obfuscateStatsGroup(b)
+	assert.Equal(t, "SELECT * FROM users WHERE id = ?", b.Resource)
+}
+
 func agentWithDefaults(features ...string) (agnt *Agent, stop func()) {
 	ctx, cancelFunc := context.WithCancel(context.Background())
 	cfg := config.New()
@@ -296,6 +321,22 @@ func TestObfuscateConfig(t *testing.T) {
 		&config.ObfuscationConfig{},
 	))
 
+	t.Run("graphql/enabled", testConfig(
+		"graphql",
+		tagGraphQLSource,
+		`query GetUser($id: ID!) { user(id: $id) { posts(first: 10, tag: "go") { title } } }`,
+		`query GetUser($id: ID!) { user(id: $id) { posts(first: ?, tag: ?) { title } } }`,
+		&config.ObfuscationConfig{GraphQL: obfuscate.GraphQLConfig{Enabled: true}},
+	))
+
+	t.Run("graphql/disabled", testConfig(
+		"graphql",
+		tagGraphQLSource,
+		`query { user(id: 123) { name } }`,
+		`query { user(id: 123) { name } }`,
+		&config.ObfuscationConfig{},
+	))
+
 	t.Run("creditcard", func(t *testing.T) {
 		for _, tt := range []struct {
 			k, v string
@@ -7,18 +7,46 @@ package agent
 
 import (
 	"strings"
+	"time"
 
 	pb "github.com/DataDog/datadog-agent/pkg/proto/pbgo/trace"
+	"github.com/DataDog/datadog-agent/pkg/trace/info"
 	"github.com/DataDog/datadog-agent/pkg/trace/log"
 	"github.com/DataDog/datadog-agent/pkg/trace/traceutil"
 )
 
+// truncateLogger logs the original values of truncated fields, which can be large.
+var truncateLogger = log.NewThrottled(10, 10*time.Second)
+
+// overflowTagPrefix prefixes the meta tags holding the part cut from truncated fields, when
+// the overflow of truncated fields is kept. These tags are themselves truncated to the
+// maximum meta value length, so fields longer than twice the limit still lose their end.
+const overflowTagPrefix = "_dd.overflow."
+
 // Truncate checks that the span resource, meta and metrics are within the max length
 // and modifies them if they are not
-func (a *Agent) Truncate(s *pb.Span) {
+func (a *Agent) Truncate(ts *info.TagStats, s *pb.Span) {
+	limits := &a.conf.Normalization
+	var overflows map[string]string
+	keepOverflow := func(field, orig, truncated string) {
+		if !limits.KeepTruncatedOverflow {
+			return
+		}
+		if overflows == nil {
+			overflows = make(map[string]string)
+		}
+		overflow := orig[len(truncated):]
+		if len(overflow) > limits.MaxMetaValueLen {
+			overflow = traceutil.TruncateUTF8(overflow, limits.MaxMetaValueLen) + "..."
+		}
+		overflows[overflowTagPrefix+field] = overflow
+	}
+
 	r, ok := a.TruncateResource(s.Resource)
 	if !ok {
-		log.Debugf("span.truncate: truncated `Resource` (max %d chars): %s", a.conf.MaxResourceLen, s.Resource)
+		ts.SpansMalformed.ResourceTruncate.Inc()
+		truncateLogger.Debug("span.truncate: truncated `Resource` (reason:resource_truncate) (max %d chars): %s", a.conf.MaxResourceLen, s.Resource)
+		keepOverflow("resource", s.Resource, r)
 	}
 	s.Resource = r
 
@@ -33,15 +61,20 @@ func (a *Agent) Truncate(s *pb.Span) {
 			continue
 		}
 
-		if len(k) > MaxMetaKeyLen {
-			log.Debugf("span.truncate: truncating `Meta` key (max %d chars): %s", MaxMetaKeyLen, k)
+		if len(k) > limits.MaxMetaKeyLen && !isReservedKey(k) {
+			ts.SpansMalformed.MetaKeyTruncate.Inc()
+			truncateLogger.Debug("span.truncate: truncating `Meta` key (reason:meta_key_truncate) (max %d chars): %s", limits.MaxMetaKeyLen, k)
 			delete(s.Meta, k)
-			k = traceutil.TruncateUTF8(k, MaxMetaKeyLen) + "..."
+			k = traceutil.TruncateUTF8(k, limits.MaxMetaKeyLen) + "..."
 			modified = true
 		}
 
-		if len(v) > MaxMetaValLen {
-			v = traceutil.TruncateUTF8(v, MaxMetaValLen) + "..."
+		if len(v) > limits.MaxMetaValueLen {
+			ts.SpansMalformed.MetaValueTruncate.Inc()
+			truncateLogger.Debug("span.truncate: truncating `Meta` value of %s (reason:meta_value_truncate) (max %d chars): %s", k, limits.MaxMetaValueLen, v)
+			truncated := traceutil.TruncateUTF8(v, limits.MaxMetaValueLen)
+			keepOverflow(k, v, truncated)
+			v = truncated + "..."
 			modified = true
 		}
 
@@ -49,11 +82,16 @@ func (a *Agent) Truncate(s *pb.Span) {
 			s.Meta[k] = v
 		}
 	}
+	for k, v := range overflows {
+		// added once the meta are truncated, so that they aren't truncated themselves
+		traceutil.SetMeta(s, k, v)
+	}
 	for k, v := range s.Metrics {
-		if len(k) > MaxMetricsKeyLen {
-			log.Debugf("span.truncate: truncating `Metrics` key (max %d chars): %s", MaxMetricsKeyLen, k)
+		if len(k) > limits.MaxMetricsKeyLen && !isReservedKey(k) {
+			ts.SpansMalformed.MetricsKeyTruncate.Inc()
+			truncateLogger.Debug("span.truncate: truncating `Metrics` key (reason:metrics_key_truncate) (max %d chars): %s", limits.MaxMetricsKeyLen, k)
 			delete(s.Metrics, k)
-			k = traceutil.TruncateUTF8(k, MaxMetricsKeyLen) + "..."
+			k = traceutil.TruncateUTF8(k, limits.MaxMetricsKeyLen) + "..."
 
 			s.Metrics[k] = v
 		}
@@ -61,12 +99,12 @@ func (a *Agent) Truncate(s *pb.Span) {
 }
 
 const (
-	// MaxMetaKeyLen the maximum length of metadata key
-	MaxMetaKeyLen = 200
-	// MaxMetaValLen the maximum length of metadata value
-	MaxMetaValLen = 25000
-	// MaxMetricsKeyLen the maximum length of a metric name key
-	MaxMetricsKeyLen = MaxMetaKeyLen
+	// MaxMetaKeyLen the default maximum length of metadata key
+	MaxMetaKeyLen = traceutil.MaxMetaKeyLen
+	// MaxMetaValLen the default maximum length of metadata value
+	MaxMetaValLen = traceutil.MaxMetaValueLen
+	// MaxMetricsKeyLen the default maximum length of a metric name key
+	MaxMetricsKeyLen = traceutil.MaxMetricsKeyLen
 )
 
 // isStructuredMetaKey returns true when the given key is a structured meta tag.
@@ -80,6 +118,17 @@ func isStructuredMetaKey(key string) bool {
 			strings.HasSuffix(key, ".protobuf"))
 }
 
+// isReservedKey returns true when the given meta or metrics key is reserved to the tracers
+// and the agent, such as _sampling_priority_v1 or _dd.measured. These keys are never
+// truncated, whatever the configured limits, since the agent relies on them.
+func isReservedKey(key string) bool {
+	switch key {
+	case tagSamplingPriority, "_top_level":
+		return true
+	}
+	return strings.HasPrefix(key, "_dd.") || strings.HasPrefix(key, "_dd1.")
+}
+
 // TruncateResource truncates a span's resource to the maximum allowed length.
 // It returns true if the input was below the max size.
 func (a *Agent) TruncateResource(r string) (string, bool) {
@@ -43,7 +43,7 @@ func TestTruncateResourcePassThru(t *testing.T) {
 	a := &Agent{conf: config.New()}
 	s := testSpan()
 	before := s.Resource
-	a.Truncate(s)
+	a.Truncate(newTagStats(), s)
 	assert.Equal(t, before, s.Resource)
 }
 
@@ -51,7 +51,7 @@ func TestTruncateLongResource(t *testing.T) {
 	a := &Agent{conf: config.New()}
 	s := testSpan()
 	s.Resource = strings.Repeat("TOOLONG", 5000)
-	a.Truncate(s)
+	a.Truncate(newTagStats(), s)
 	assert.Equal(t, 5000, len(s.Resource))
 }
 
@@ -59,7 +59,7 @@ func TestTruncateMetricsPassThru(t *testing.T) {
 	a := &Agent{conf: config.New()}
 	s := testSpan()
 	before := s.Metrics
-	a.Truncate(s)
+	a.Truncate(newTagStats(), s)
 	assert.Equal(t, before, s.Metrics)
 }
 
@@ -68,7 +68,7 @@ func TestTruncateMetricsKeyTooLong(t *testing.T) {
 	s := testSpan()
 	key := strings.Repeat("TOOLONG", 1000)
 	s.Metrics[key] = 42
-	a.Truncate(s)
+	a.Truncate(newTagStats(), s)
 	for k := range s.Metrics {
 		assert.True(t, len(k) < MaxMetricsKeyLen+4)
 	}
@@ -78,7 +78,7 @@ func TestTruncateMetaPassThru(t *testing.T) {
 	a := &Agent{conf: config.New()}
 	s := testSpan()
 	before := s.Meta
-	a.Truncate(s)
+	a.Truncate(newTagStats(), s)
 	assert.Equal(t, before, s.Meta)
 }
 
@@ -87,7 +87,7 @@ func TestTruncateMetaKeyTooLong(t *testing.T) {
 	s := testSpan()
 	key := strings.Repeat("TOOLONG", 1000)
 	s.Meta[key] = "foo"
-	a.Truncate(s)
+	a.Truncate(newTagStats(), s)
 	for k := range s.Meta {
 		assert.True(t, len(k) < MaxMetaKeyLen+4)
 	}
@@ -98,7 +98,7 @@ func TestTruncateMetaValueTooLong(t *testing.T) {
 	s := testSpan()
 	val := strings.Repeat("TOOLONG", 25000)
 	s.Meta["foo"] = val
-	a.Truncate(s)
+	a.Truncate(newTagStats(), s)
 	for _, v := range s.Meta {
 		assert.True(t, len(v) < MaxMetaValLen+4)
 	}
@@ -121,7 +121,7 @@ func TestTruncateStructuredMetaTag(t *testing.T) {
 			notStructuredTagName := fmt.Sprintf("key.%s", suffix)
 			s.Meta[structuredTagName] = val
 			s.Meta[notStructuredTagName] = val
-			a.Truncate(s)
+			a.Truncate(newTagStats(), s)
 			// The structured value must not be truncated.
 			require.Equal(t, val, s.Meta[structuredTagName])
 			// The non structured value must be truncated.
@@ -146,3 +146,60 @@ func TestTruncateResource(t *testing.T) {
 		assert.Equal(t, s, r)
 	})
 }
+
+func TestTruncateLimits(t *testing.T) {
+	conf := config.New()
+	conf.MaxResourceLen = 10
+	conf.Normalization.MaxMetaKeyLen = 5
+	conf.Normalization.MaxMetaValueLen = 8
+	conf.Normalization.MaxMetricsKeyLen = 6
+	a := &Agent{conf: conf}
+
+	t.Run("drop", func(t *testing.T) {
+		s := testSpan()
+		s.Resource = "SELECT * FROM users"
+		s.Meta["error.stack"] = "panic: oops"
+		s.Meta["_dd.p.dm"] = "-4"
+		s.Metrics["_sampling_priority_v1"] = 1
+		s.Metrics["_dd.measured"] = 1
+		s.Metrics["custom.metric"] = 2
+		ts := newTagStats()
+		a.Truncate(ts, s)
+
+		assert.Equal(t, "SELECT * F", s.Resource)
+		assert.Equal(t, "panic: o...", s.Meta["error..."])
+		assert.Equal(t, "fondue", s.Meta["pool"])
+		// reserved keys are never truncated
+		assert.Equal(t, "-4", s.Meta["_dd.p.dm"])
+		assert.Equal(t, 1.0, s.Metrics["_sampling_priority_v1"])
+		assert.Equal(t, 1.0, s.Metrics["_dd.measured"])
+		assert.Equal(t, 2.0, s.Metrics["custom..."])
+		assert.Len(t, s.Meta, 4)
+		assert.EqualValues(t, 1, ts.SpansMalformed.ResourceTruncate.Load())
+		assert.EqualValues(t, 1, ts.SpansMalformed.MetaKeyTruncate.Load())
+		assert.EqualValues(t, 1, ts.SpansMalformed.MetaValueTruncate.Load())
+		assert.EqualValues(t, 2, ts.SpansMalformed.MetricsKeyTruncate.Load())
+	})
+
+	t.Run("overflow", func(t *testing.T) {
+		conf.Normalization.KeepTruncatedOverflow = true
+		defer func() { conf.Normalization.KeepTruncatedOverflow = false }()
+		s := testSpan()
+		s.Resource = "SELECT * FROM users"
+		s.Meta = map[string]string{"stack": "goroutine 1 [running]:"}
+		a.Truncate(newTagStats(), s)
+
+		assert.Equal(t, map[string]string{
+			"stack":                 "goroutin...",
+			"_dd.overflow.stack":    "e 1 [run...",
+			"_dd.overflow.resource": "ROM user...",
+		}, s.Meta)
+
+		// the overflow is itself truncated to the maximum meta value length
+		s = testSpan()
+		s.Meta = map[string]string{"stack": "goroutine 1 [running]: main.main()"}
+		a.Truncate(newTagStats(), s)
+		assert.Equal(t, "goroutin...", s.Meta["stack"])
+		assert.Equal(t, "e 1 [run...", s.Meta["_dd.overflow.stack"])
+	})
+}
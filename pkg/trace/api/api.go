@@ -27,6 +27,7 @@ import (
 
 	"github.com/tinylib/msgp/msgp"
 	"go.uber.org/atomic"
+	"google.golang.org/grpc"
 
 	pb "github.com/DataDog/datadog-agent/pkg/proto/pbgo/trace"
 	"github.com/DataDog/datadog-agent/pkg/trace/api/apiutil"
@@ -115,6 +116,15 @@ type HTTPReceiver struct {
 	// outOfCPUCounter is counter to throttle the out of cpu warning log
 	outOfCPUCounter *atomic.Uint32
 
+	// capture is the capture of the received payloads in progress, if any.
+	capture atomic.Pointer[capture]
+
+	// otelConverter converts the Zipkin and Jaeger spans, once translated to OpenTelemetry spans.
+	// It is only set if one of these endpoints is enabled.
+	otelConverter *OTLPReceiver
+	// jaegerGRPC is the running Jaeger gRPC collector service, if enabled.
+	jaegerGRPC *grpc.Server
+
 	statsd   statsd.ClientInterface
 	timing   timing.Reporter
 	info     *watchdog.CurrentInfo
@@ -144,6 +154,10 @@ func NewHTTPReceiver(
 	log.Infof("Receiver configured with %d decoders and a timeout of %dms", semcount, conf.DecoderTimeout)
 	containerIDProvider := NewIDProvider(conf.ContainerProcRoot, conf.ContainerIDFromOriginInfo)
 	telemetryForwarder := NewTelemetryForwarder(conf, containerIDProvider, statsd)
+	var otelConverter *OTLPReceiver
+	if conf.ZipkinReceiver.Enabled || conf.JaegerReceiver.Enabled {
+		otelConverter = NewOTLPReceiver(out, conf, statsd, timing)
+	}
 	return &HTTPReceiver{
 		Stats: info.NewReceiverStats(),
 
@@ -170,6 +184,8 @@ func NewHTTPReceiver(
 
 		outOfCPUCounter: atomic.NewUint32(0),
 
+		otelConverter: otelConverter,
+
 		statsd:   statsd,
 		timing:   timing,
 		info:     watchdog.NewCurrentInfo(),
@@ -322,6 +338,10 @@ func (r *HTTPReceiver) Start() {
 		log.Infof("Listening for traces on Windows pipe %q. Security descriptor is %q", pipepath, secdec)
 	}
 
+	if r.conf.JaegerReceiver.Enabled && r.conf.JaegerReceiver.GRPCPort > 0 {
+		r.startJaegerGRPC()
+	}
+
 	go func() {
 		defer watchdog.LogOnPanic(r.statsd)
 		r.loop()
@@ -374,7 +394,11 @@ func (r *HTTPReceiver) Stop() error {
 	}
 	r.exit <- struct{}{}
 	<-r.exit
+	r.stopCapture(r.capture.Load())
 
+	if r.jaegerGRPC != nil {
+		r.jaegerGRPC.GracefulStop()
+	}
 	expiry := time.Now().Add(5 * time.Second) // give it 5 seconds
 	ctx, cancel := context.WithDeadline(context.Background(), expiry)
 	defer cancel()
@@ -415,8 +439,11 @@ func (r *HTTPReceiver) handleWithVersion(v Version, f func(Version, http.Respons
 			return
 		}
 
+		body, record := r.captureBody(req)
+		defer record()
+
 		// TODO(x): replace with http.MaxBytesReader?
-		req.Body = apiutil.NewLimitedReader(req.Body, r.conf.MaxRequestBytes)
+		req.Body = apiutil.NewLimitedReader(body, r.conf.MaxRequestBytes)
 
 		f(v, w, req)
 	}
@@ -0,0 +1,316 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/api/internal/header"
+	"github.com/DataDog/datadog-agent/pkg/trace/log"
+)
+
+// captureMagic starts every capture file, followed by the version of the format.
+const (
+	captureMagic   = "DDTRACECAPTURE"
+	captureVersion = 1
+)
+
+// maxCaptureDuration is the maximum duration of a capture.
+const maxCaptureDuration = time.Hour
+
+// capturedHeaders are the headers of the requests kept in captures, the ones the receiver
+// relies on to decode and tag payloads.
+var capturedHeaders = []string{
+	"Content-Type",
+	header.TraceCount,
+	header.ContainerID,
+	header.LocalData,
+	header.ExternalData,
+	header.Lang,
+	header.LangVersion,
+	header.LangInterpreter,
+	header.LangInterpreterVendor,
+	header.TracerVersion,
+	header.ComputedTopLevel,
+	header.ComputedStats,
+	header.DroppedP0Traces,
+	header.DroppedP0Spans,
+	header.TracerObfuscationVersion,
+}
+
+// CaptureRecord is a payload received by the trace endpoints, as recorded in a capture file.
+type CaptureRecord struct {
+	// Time is the time the payload was received.
+	Time time.Time `json:"time"`
+	// Path is the path of the endpoint the payload was sent to, such as /v0.4/traces.
+	Path string `json:"path"`
+	// Header holds the headers of the request relevant to the receiver.
+	Header http.Header `json:"header"`
+	// Body is the raw body of the request.
+	Body []byte `json:"-"`
+}
+
+// CaptureWriter writes capture records to a gzip compressed stream.
+type CaptureWriter struct {
+	gz  *gzip.Writer
+	buf [4]byte
+}
+
+// NewCaptureWriter returns a CaptureWriter writing to w. Close must be called once all
+// the records are written.
+func NewCaptureWriter(w io.Writer) (*CaptureWriter, error) {
+	gz := gzip.NewWriter(w)
+	if _, err := io.WriteString(gz, captureMagic); err != nil {
+		return nil, err
+	}
+	if _, err := gz.Write([]byte{captureVersion}); err != nil {
+		return nil, err
+	}
+	return &CaptureWriter{gz: gz}, nil
+}
+
+// Write writes rec. Every record is made of its length prefixed JSON encoded metadata,
+// followed by its length prefixed body.
+func (cw *CaptureWriter) Write(rec *CaptureRecord) error {
+	meta, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	if err := cw.writeChunk(meta); err != nil {
+		return err
+	}
+	return cw.writeChunk(rec.Body)
+}
+
+func (cw *CaptureWriter) writeChunk(b []byte) error {
+	binary.BigEndian.PutUint32(cw.buf[:], uint32(len(b)))
+	if _, err := cw.gz.Write(cw.buf[:]); err != nil {
+		return err
+	}
+	_, err := cw.gz.Write(b)
+	return err
+}
+
+// Close flushes the records written and closes the compressed stream. It doesn't close the
+// underlying writer.
+func (cw *CaptureWriter) Close() error {
+	return cw.gz.Close()
+}
+
+// CaptureReader reads the records of a capture file, as written by CaptureWriter.
+type CaptureReader struct {
+	r   *bufio.Reader
+	buf [4]byte
+}
+
+// NewCaptureReader returns a CaptureReader reading from r, or an error if r isn't a capture file.
+func NewCaptureReader(r io.Reader) (*CaptureReader, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("not a trace capture file: %v", err)
+	}
+	cr := &CaptureReader{r: bufio.NewReader(gz)}
+	magic := make([]byte, len(captureMagic)+1)
+	if _, err := io.ReadFull(cr.r, magic); err != nil || string(magic[:len(captureMagic)]) != captureMagic {
+		return nil, errors.New("not a trace capture file")
+	}
+	if v := magic[len(captureMagic)]; v != captureVersion {
+		return nil, fmt.Errorf("unsupported trace capture file version %d", v)
+	}
+	return cr, nil
+}
+
+// Next returns the next record, or io.EOF once all the records have been read.
+func (cr *CaptureReader) Next() (*CaptureRecord, error) {
+	meta, err := cr.readChunk()
+	if err != nil {
+		return nil, err
+	}
+	var rec CaptureRecord
+	if err := json.Unmarshal(meta, &rec); err != nil {
+		return nil, fmt.Errorf("invalid capture record: %v", err)
+	}
+	if rec.Body, err = cr.readChunk(); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	return &rec, nil
+}
+
+func (cr *CaptureReader) readChunk() ([]byte, error) {
+	if _, err := io.ReadFull(cr.r, cr.buf[:]); err != nil {
+		return nil, err
+	}
+	b := make([]byte, binary.BigEndian.Uint32(cr.buf[:]))
+	if _, err := io.ReadFull(cr.r, b); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	return b, nil
+}
+
+// capture is a capture of the payloads received by the trace endpoints, written to a file
+// for a set duration.
+type capture struct {
+	path string
+
+	mu     sync.Mutex
+	f      *os.File
+	w      *CaptureWriter
+	n      int
+	closed bool
+}
+
+// record writes the payload body received with req to the capture.
+func (c *capture) record(req *http.Request, body []byte, containerID string) {
+	rec := &CaptureRecord{
+		Time:   time.Now(),
+		Path:   req.URL.Path,
+		Header: make(http.Header),
+		Body:   body,
+	}
+	for _, k := range capturedHeaders {
+		for _, v := range req.Header.Values(k) {
+			rec.Header.Add(k, v)
+		}
+	}
+	if containerID != "" {
+		// the container ID may have been resolved from the origin of the connection,
+		// which is lost when replaying the capture.
+		rec.Header.Set(header.ContainerID, containerID)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return
+	}
+	if err := c.w.Write(rec); err != nil {
+		log.Errorf("Error writing to trace capture %s: %v", c.path, err)
+		return
+	}
+	c.n++
+}
+
+// close stops the capture, flushing the file.
+func (c *capture) close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return
+	}
+	c.closed = true
+	err := c.w.Close()
+	if cerr := c.f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		log.Errorf("Error closing trace capture %s: %v", c.path, err)
+		return
+	}
+	log.Infof("Trace capture %s done, %d payloads captured.", c.path, c.n)
+}
+
+// StartCapture starts capturing the payloads received by the trace endpoints, along with
+// their headers, to a new file in dir for the given duration. It returns the path of the file.
+func (r *HTTPReceiver) StartCapture(dir string, d time.Duration) (string, error) {
+	if d <= 0 || d > maxCaptureDuration {
+		return "", fmt.Errorf("capture duration must be positive and at most %s", maxCaptureDuration)
+	}
+	if dir == "" {
+		dir = os.TempDir()
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, fmt.Sprintf("trace-capture-%d.gz", time.Now().UnixNano()))
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return "", err
+	}
+	w, err := NewCaptureWriter(f)
+	if err != nil {
+		f.Close()
+		return "", err
+	}
+	c := &capture{path: path, f: f, w: w}
+	if !r.capture.CompareAndSwap(nil, c) {
+		w.Close()
+		f.Close()
+		os.Remove(path)
+		return "", errors.New("a trace capture is already in progress")
+	}
+	log.Infof("Starting trace capture to %s for %s.", path, d)
+	time.AfterFunc(d, func() { r.stopCapture(c) })
+	return path, nil
+}
+
+// stopCapture stops c if it's still the capture in progress.
+func (r *HTTPReceiver) stopCapture(c *capture) {
+	if c == nil || !r.capture.CompareAndSwap(c, nil) {
+		return
+	}
+	c.close()
+}
+
+// captureBody returns body, teeing what is read from it to a buffer if a capture is in
+// progress. The function returned records the buffer once the request is handled.
+func (r *HTTPReceiver) captureBody(req *http.Request) (io.ReadCloser, func()) {
+	c := r.capture.Load()
+	if c == nil {
+		return req.Body, func() {}
+	}
+	var buf bytes.Buffer
+	body := struct {
+		io.Reader
+		io.Closer
+	}{io.TeeReader(req.Body, &buf), req.Body}
+	return body, func() {
+		containerID := req.Header.Get(header.ContainerID)
+		if containerID == "" && r.containerIDProvider != nil {
+			containerID = r.containerIDProvider.GetContainerID(req.Context(), req.Header)
+		}
+		c.record(req, buf.Bytes(), containerID)
+	}
+}
+
+// CaptureHandler returns the handler starting trace captures. It accepts a "duration"
+// and a "path" query parameters, the directory the capture file is written to.
+func (r *HTTPReceiver) CaptureHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		d, err := time.ParseDuration(req.URL.Query().Get("duration"))
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid duration: %v", err), http.StatusBadRequest)
+			return
+		}
+		path, err := r.StartCapture(req.URL.Query().Get("path"), d)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"path": path}) //nolint:errcheck
+	})
+}
@@ -0,0 +1,127 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package api
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/api/internal/header"
+	"github.com/DataDog/datadog-agent/pkg/trace/testutil"
+)
+
+func TestCaptureReadWrite(t *testing.T) {
+	records := []*CaptureRecord{
+		{
+			Time:   time.Unix(1700000000, 0).UTC(),
+			Path:   "/v0.4/traces",
+			Header: http.Header{"Content-Type": {"application/msgpack"}, header.Lang: {"go"}},
+			Body:   []byte("payload"),
+		},
+		{
+			Time:   time.Unix(1700000001, 0).UTC(),
+			Path:   "/v0.5/traces",
+			Header: http.Header{},
+			Body:   []byte{},
+		},
+	}
+	var buf bytes.Buffer
+	w, err := NewCaptureWriter(&buf)
+	require.NoError(t, err)
+	for _, rec := range records {
+		require.NoError(t, w.Write(rec))
+	}
+	require.NoError(t, w.Close())
+
+	r, err := NewCaptureReader(&buf)
+	require.NoError(t, err)
+	for _, want := range records {
+		got, err := r.Next()
+		require.NoError(t, err)
+		assert.Equal(t, want, got)
+	}
+	_, err = r.Next()
+	assert.Equal(t, io.EOF, err)
+
+	_, err = NewCaptureReader(strings.NewReader("not a capture"))
+	assert.Error(t, err)
+}
+
+func TestCapture(t *testing.T) {
+	bts, err := testutil.GetTestTraces(2, 2, true).MarshalMsg(nil)
+	require.NoError(t, err)
+	receiver := newTestReceiverFromConfig(newTestReceiverConfig())
+	handler := receiver.handleWithVersion(v04, receiver.handleTraces)
+	send := func() {
+		req, _ := http.NewRequest("POST", "/v0.4/traces", bytes.NewReader(bts))
+		req.Header.Set("Content-Type", "application/msgpack")
+		req.Header.Set(header.Lang, "python")
+		req.Header.Set(header.ContainerID, "abc123")
+		req.Header.Set("Authorization", "secret")
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+		<-receiver.out
+	}
+
+	send() // not captured
+	path, err := receiver.StartCapture(t.TempDir(), time.Minute)
+	require.NoError(t, err)
+	_, err = receiver.StartCapture(t.TempDir(), time.Minute)
+	assert.EqualError(t, err, "a trace capture is already in progress")
+	send()
+	send()
+	receiver.stopCapture(receiver.capture.Load())
+	send() // not captured
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+	r, err := NewCaptureReader(f)
+	require.NoError(t, err)
+	want := http.Header{}
+	want.Set("Content-Type", "application/msgpack")
+	want.Set(header.Lang, "python")
+	want.Set(header.ContainerID, "abc123")
+	for i := 0; i < 2; i++ {
+		rec, err := r.Next()
+		require.NoError(t, err)
+		assert.Equal(t, "/v0.4/traces", rec.Path)
+		assert.Equal(t, bts, rec.Body)
+		assert.Equal(t, want, rec.Header)
+	}
+	_, err = r.Next()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestCaptureHandler(t *testing.T) {
+	receiver := newTestReceiverFromConfig(newTestReceiverConfig())
+	handler := receiver.CaptureHandler()
+
+	for _, tt := range []struct {
+		method, query string
+		status        int
+	}{
+		{"GET", "duration=1m", http.StatusMethodNotAllowed},
+		{"POST", "", http.StatusBadRequest},
+		{"POST", "duration=2h", http.StatusInternalServerError},
+		{"POST", "duration=1m&path=" + t.TempDir(), http.StatusOK},
+	} {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest(tt.method, "/capture?"+tt.query, nil)
+		handler.ServeHTTP(rr, req)
+		assert.Equal(t, tt.status, rr.Code, tt.query)
+	}
+	assert.NotNil(t, receiver.capture.Load())
+	receiver.stopCapture(receiver.capture.Load())
+}
@@ -94,6 +94,16 @@ var endpoints = []Endpoint{
 		Pattern: "/v0.7/traces",
 		Handler: func(r *HTTPReceiver) http.Handler { return r.handleWithVersion(V07, r.handleTraces) },
 	},
+	{
+		Pattern:   "/api/v2/spans",
+		Handler:   func(r *HTTPReceiver) http.Handler { return r.zipkinHandler() },
+		IsEnabled: func(cfg *config.AgentConfig) bool { return cfg.ZipkinReceiver.Enabled },
+	},
+	{
+		Pattern:   "/api/traces",
+		Handler:   func(r *HTTPReceiver) http.Handler { return r.jaegerHandler() },
+		IsEnabled: func(cfg *config.AgentConfig) bool { return cfg.JaegerReceiver.Enabled },
+	},
 	{
 		Pattern: "/profiling/v1/input",
 		Handler: func(r *HTTPReceiver) http.Handler { return r.profileProxyHandler() },
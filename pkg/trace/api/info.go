@@ -78,6 +78,12 @@ func (r *HTTPReceiver) makeInfoHandler() (hash string, handler http.HandlerFunc)
 		}
 	}
 
+	// The tracers computing stats send the values of the stats dimensions along with the peer tags.
+	var statsDimensions []string
+	for _, d := range r.conf.StatsDimensions {
+		statsDimensions = append(statsDimensions, d.Tag)
+	}
+
 	txt, err := json.MarshalIndent(struct {
 		Version                string        `json:"version"`
 		GitCommit              string        `json:"git_commit"`
@@ -91,6 +97,7 @@ func (r *HTTPReceiver) makeInfoHandler() (hash string, handler http.HandlerFunc)
 		Config                 reducedConfig `json:"config"`
 		PeerTags               []string      `json:"peer_tags"`
 		SpanKindsStatsComputed []string      `json:"span_kinds_stats_computed"`
+		StatsDimensions        []string      `json:"stats_dimensions,omitempty"`
 		ObfuscationVersion     int           `json:"obfuscation_version"`
 	}{
 		Version:                r.conf.AgentVersion,
@@ -119,7 +126,8 @@ func (r *HTTPReceiver) makeInfoHandler() (hash string, handler http.HandlerFunc)
 			AnalyzedSpansByService: r.conf.AnalyzedSpansByService,
 			Obfuscation:            oconf,
 		},
-		PeerTags: r.conf.ConfiguredPeerTags(),
+		PeerTags:        r.conf.ConfiguredPeerTags(),
+		StatsDimensions: statsDimensions,
 	}, "", "\t")
 	if err != nil {
 		panic(fmt.Errorf("Error making /info handler: %v", err))
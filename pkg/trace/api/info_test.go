@@ -347,3 +347,18 @@ func TestInfoHandler(t *testing.T) {
 	}
 	assert.NoError(t, ensureKeys(expectedKeys, m, ""))
 }
+
+func TestInfoHandlerStatsDimensions(t *testing.T) {
+	conf := config.New()
+	conf.StatsDimensions = []*config.StatsDimension{{Tag: "tenant", MaxCardinality: 100}, {Tag: "region", MaxCardinality: 10}}
+
+	rcv := newTestReceiverFromConfig(conf)
+	_, h := rcv.makeInfoHandler()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/info", nil))
+	var m struct {
+		StatsDimensions []string `json:"stats_dimensions"`
+	}
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&m))
+	assert.Equal(t, []string{"tenant", "region"}, m.StatsDimensions)
+}
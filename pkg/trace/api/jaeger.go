@@ -0,0 +1,104 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package api
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/apache/thrift/lib/go/thrift"
+	model "github.com/jaegertracing/jaeger-idl/model/v1"
+	"github.com/jaegertracing/jaeger-idl/proto-gen/api_v2"
+	"github.com/jaegertracing/jaeger-idl/thrift-gen/jaeger"
+	jaegertranslator "github.com/open-telemetry/opentelemetry-collector-contrib/pkg/translator/jaeger"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/log"
+	"github.com/DataDog/datadog-agent/pkg/trace/watchdog"
+)
+
+// jaegerHandler returns the handler of the Jaeger endpoint, which accepts batches of spans encoded
+// with the Thrift binary protocol, as sent by the HTTP sender of the Jaeger clients.
+func (r *HTTPReceiver) jaegerHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch mediaType := getMediaType(req); mediaType {
+		case "application/x-thrift", "application/vnd.apache.thrift.binary":
+		default:
+			httpFormatError(w, "jaeger_thrift", fmt.Errorf("unsupported media type: %q", mediaType), r.statsd)
+			return
+		}
+		r.handleOTelTranslated(w, req, "jaeger_thrift", http.StatusAccepted, func(body []byte) (ptrace.Traces, error) {
+			batch := &jaeger.Batch{}
+			if err := thrift.NewTDeserializer().Read(req.Context(), batch, body); err != nil {
+				return ptrace.Traces{}, err
+			}
+			return jaegertranslator.ThriftToTraces(batch)
+		})
+	})
+}
+
+// jaegerCollector implements the Jaeger gRPC collector service.
+type jaegerCollector struct {
+	r *HTTPReceiver
+}
+
+var _ api_v2.CollectorServiceServer = (*jaegerCollector)(nil)
+
+// PostSpans implements api_v2.CollectorServiceServer.
+func (c *jaegerCollector) PostSpans(ctx context.Context, req *api_v2.PostSpansRequest) (*api_v2.PostSpansResponse, error) {
+	defer c.r.timing.Since("datadog.trace_agent.receiver.jaeger_grpc.process_ms", time.Now())
+	select {
+	// As for the HTTP endpoints, wait for the semaphore before converting the spans.
+	case c.r.recvsem <- struct{}{}:
+	case <-time.After(time.Duration(c.r.conf.DecoderTimeout) * time.Millisecond):
+		log.Debugf("trace-agent is overwhelmed, a jaeger_grpc payload has been rejected")
+		_ = c.r.statsd.Count("datadog.trace_agent.receiver.payload_refused", 1, []string{"endpoint_version:jaeger_grpc"}, 1)
+		return nil, status.Error(codes.ResourceExhausted, "trace-agent is overwhelmed")
+	case <-ctx.Done():
+		return nil, status.FromContextError(ctx.Err()).Err()
+	}
+	defer func() { <-c.r.recvsem }()
+
+	traces, err := jaegertranslator.ProtoToTraces([]*model.Batch{&req.Batch})
+	if err != nil {
+		_ = c.r.statsd.Count(receiverErrorKey, 1, []string{"handler:traces", "v:jaeger_grpc", "error:decoding-error"}, 1)
+		return nil, err
+	}
+	tags := []string{"endpoint_version:jaeger_grpc"}
+	_ = c.r.statsd.Count("datadog.trace_agent.receiver.payload", 1, tags, 1)
+	_ = c.r.statsd.Count("datadog.trace_agent.receiver.spans", int64(traces.SpanCount()), tags, 1)
+	md, _ := metadata.FromIncomingContext(ctx)
+	c.r.receiveOTelTraces(ctx, http.Header(md), traces)
+	return &api_v2.PostSpansResponse{}, nil
+}
+
+// startJaegerGRPC starts the Jaeger gRPC collector service on the configured port. It is
+// only called if the Jaeger receiver is enabled.
+func (r *HTTPReceiver) startJaegerGRPC() {
+	addr := net.JoinHostPort(r.conf.ReceiverHost, strconv.Itoa(r.conf.JaegerReceiver.GRPCPort))
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		log.Errorf("Error starting Jaeger gRPC server: %v", err)
+		return
+	}
+	r.jaegerGRPC = grpc.NewServer(grpc.MaxRecvMsgSize(int(r.conf.MaxRequestBytes)))
+	api_v2.RegisterCollectorServiceServer(r.jaegerGRPC, &jaegerCollector{r: r})
+	go func() {
+		defer watchdog.LogOnPanic(r.statsd)
+		if err := r.jaegerGRPC.Serve(ln); err != nil {
+			log.Errorf("Could not start Jaeger gRPC server: %v. Jaeger gRPC receiver disabled.", err)
+		}
+	}()
+	log.Infof("Listening for Jaeger traces at grpc://%s", addr)
+}
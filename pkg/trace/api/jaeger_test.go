@@ -0,0 +1,124 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package api
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/apache/thrift/lib/go/thrift"
+	model "github.com/jaegertracing/jaeger-idl/model/v1"
+	"github.com/jaegertracing/jaeger-idl/proto-gen/api_v2"
+	"github.com/jaegertracing/jaeger-idl/thrift-gen/jaeger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func newJaegerTestReceiver(t *testing.T) *HTTPReceiver {
+	conf := NewTestConfig(t)
+	conf.Endpoints[0].APIKey = "test"
+	conf.DecoderTimeout = 10000
+	conf.JaegerReceiver.Enabled = true
+	return newTestReceiverFromConfig(conf)
+}
+
+func TestJaegerThriftEndpoint(t *testing.T) {
+	r := newJaegerTestReceiver(t)
+	parentID := int64(0x352bff9a74ca9ad2)
+	batch := &jaeger.Batch{
+		Process: &jaeger.Process{ServiceName: "frontend"},
+		Spans: []*jaeger.Span{{
+			TraceIdLow:    0x5af7183fb1d4cf5f,
+			SpanId:        0x6b221d5bc9e6496c,
+			ParentSpanId:  parentID,
+			OperationName: "get /api",
+			StartTime:     1556604172355737,
+			Duration:      1431,
+			Tags: []*jaeger.Tag{
+				{Key: "span.kind", VType: jaeger.TagType_STRING, VStr: thrift.StringPtr("server")},
+				{Key: "error", VType: jaeger.TagType_BOOL, VBool: thrift.BoolPtr(true)},
+			},
+		}},
+	}
+	body, err := thrift.NewTSerializer().Write(context.Background(), batch)
+	require.NoError(t, err)
+
+	req := httptest.NewRequest("POST", "/api/traces", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/x-thrift")
+	rec := httptest.NewRecorder()
+	r.buildMux().ServeHTTP(rec, req)
+	require.Equal(t, http.StatusAccepted, rec.Code, rec.Body.String())
+
+	require.Len(t, r.out, 1)
+	p := <-r.out
+	require.Len(t, p.Chunks(), 1)
+	require.Len(t, p.Chunk(0).Spans, 1)
+	span := p.Chunk(0).Spans[0]
+	assert.Equal(t, "frontend", span.Service)
+	assert.Equal(t, uint64(0x5af7183fb1d4cf5f), span.TraceID)
+	assert.Equal(t, uint64(0x6b221d5bc9e6496c), span.SpanID)
+	assert.Equal(t, uint64(0x352bff9a74ca9ad2), span.ParentID)
+	assert.Equal(t, int64(1431000), span.Duration)
+	assert.Equal(t, int32(1), span.Error)
+
+	t.Run("unsupported-type", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/api/traces", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		r.buildMux().ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusUnsupportedMediaType, rec.Code)
+		assert.Len(t, r.out, 0)
+	})
+}
+
+func TestJaegerGRPC(t *testing.T) {
+	r := newJaegerTestReceiver(t)
+	c := &jaegerCollector{r: r}
+	_, err := c.PostSpans(context.Background(), &api_v2.PostSpansRequest{
+		Batch: model.Batch{
+			Process: &model.Process{ServiceName: "frontend"},
+			Spans: []*model.Span{{
+				TraceID:       model.NewTraceID(0, 0x5af7183fb1d4cf5f),
+				SpanID:        model.NewSpanID(0x6b221d5bc9e6496c),
+				OperationName: "get /api",
+				StartTime:     time.Unix(1556604172, 0),
+				Duration:      1431 * time.Microsecond,
+			}},
+		},
+	})
+	require.NoError(t, err)
+
+	require.Len(t, r.out, 1)
+	p := <-r.out
+	require.Len(t, p.Chunks(), 1)
+	span := p.Chunk(0).Spans[0]
+	assert.Equal(t, "frontend", span.Service)
+	assert.Equal(t, uint64(0x5af7183fb1d4cf5f), span.TraceID)
+	assert.Equal(t, uint64(0x6b221d5bc9e6496c), span.SpanID)
+	assert.Equal(t, int64(1431000), span.Duration)
+}
+
+func TestJaegerGRPCOverwhelmed(t *testing.T) {
+	r := newJaegerTestReceiver(t)
+	r.conf.DecoderTimeout = 10
+	// the semaphore always blocks, as if all the decoders were busy
+	r.recvsem = make(chan struct{})
+	c := &jaegerCollector{r: r}
+	_, err := c.PostSpans(context.Background(), &api_v2.PostSpansRequest{
+		Batch: model.Batch{
+			Process: &model.Process{ServiceName: "frontend"},
+			Spans:   []*model.Span{{TraceID: model.NewTraceID(0, 1), SpanID: model.NewSpanID(1)}},
+		},
+	})
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	assert.Len(t, r.out, 0)
+}
@@ -0,0 +1,103 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package api
+
+import (
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/translator/zipkin/zipkinv2"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/api/apiutil"
+	"github.com/DataDog/datadog-agent/pkg/trace/log"
+)
+
+// zipkinHandler returns the handler of the Zipkin v2 endpoint, which accepts lists of spans
+// encoded as JSON or protobuf.
+func (r *HTTPReceiver) zipkinHandler() http.Handler {
+	jsonUnmarshaler := zipkinv2.NewJSONTracesUnmarshaler(r.conf.ZipkinReceiver.ParseStringTags)
+	protoUnmarshaler := zipkinv2.NewProtobufTracesUnmarshaler(false, r.conf.ZipkinReceiver.ParseStringTags)
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var unmarshaler ptrace.Unmarshaler
+		var codec string
+		switch mediaType := getMediaType(req); mediaType {
+		case "application/x-protobuf":
+			unmarshaler, codec = protoUnmarshaler, "protobuf"
+		case "application/json", "":
+			unmarshaler, codec = jsonUnmarshaler, "json"
+		default:
+			httpFormatError(w, "zipkin_v2", fmt.Errorf("unsupported media type: %q", mediaType), r.statsd)
+			return
+		}
+		r.handleOTelTranslated(w, req, "zipkin_v2_"+codec, http.StatusAccepted, unmarshaler.UnmarshalTraces)
+	})
+}
+
+// handleOTelTranslated handles the request of an endpoint accepting spans which are translated
+// to OpenTelemetry spans by unmarshal, then converted like OTLP spans. The endpoint replies with
+// status on success.
+func (r *HTTPReceiver) handleOTelTranslated(w http.ResponseWriter, req *http.Request, endpoint string, status int, unmarshal func([]byte) (ptrace.Traces, error)) {
+	defer r.timing.Since("datadog.trace_agent.receiver."+endpoint+".process_ms", time.Now())
+	defer req.Body.Close()
+	tags := []string{"endpoint_version:" + endpoint}
+	if req.Header.Get("Sec-Fetch-Site") == "cross-site" {
+		http.Error(w, "cross-site request rejected", http.StatusForbidden)
+		return
+	}
+
+	select {
+	// As for the Datadog endpoints, wait for the semaphore before decoding the payload.
+	case r.recvsem <- struct{}{}:
+	case <-time.After(time.Duration(r.conf.DecoderTimeout) * time.Millisecond):
+		log.Debugf("trace-agent is overwhelmed, a %s payload has been rejected", endpoint)
+		io.Copy(io.Discard, req.Body) //nolint:errcheck
+		_ = r.statsd.Count("datadog.trace_agent.receiver.payload_refused", 1, tags, 1)
+		w.WriteHeader(http.StatusTooManyRequests)
+		return
+	}
+	defer func() { <-r.recvsem }()
+
+	body, err := readTranslatedBody(req, r.conf.MaxRequestBytes)
+	if err == nil {
+		var traces ptrace.Traces
+		if traces, err = unmarshal(body); err == nil {
+			_ = r.statsd.Count("datadog.trace_agent.receiver.payload", 1, tags, 1)
+			_ = r.statsd.Count("datadog.trace_agent.receiver.spans", int64(traces.SpanCount()), tags, 1)
+			r.receiveOTelTraces(req.Context(), req.Header, traces)
+			w.WriteHeader(status)
+			return
+		}
+	}
+	log.Errorf("Cannot decode %s payload: %v", endpoint, err)
+	httpDecodingError(err, []string{"handler:traces", "v:" + endpoint}, w, r.statsd)
+}
+
+// readTranslatedBody reads the body of req, decompressing it if needed, up to limit bytes.
+func readTranslatedBody(req *http.Request, limit int64) ([]byte, error) {
+	var body io.Reader = apiutil.NewLimitedReader(req.Body, limit)
+	if req.Header.Get("Content-Encoding") == "gzip" {
+		gz, err := gzip.NewReader(body)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		body = apiutil.NewLimitedReader(gz, limit)
+	}
+	return io.ReadAll(body)
+}
+
+// receiveOTelTraces converts the translated traces like OTLP spans and sends them to the
+// processing stage.
+func (r *HTTPReceiver) receiveOTelTraces(ctx context.Context, header http.Header, traces ptrace.Traces) {
+	for i := 0; i < traces.ResourceSpans().Len(); i++ {
+		r.otelConverter.ReceiveResourceSpans(ctx, traces.ResourceSpans().At(i), header, nil)
+	}
+}
@@ -0,0 +1,108 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package api
+
+import (
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const zipkinTestPayload = `[{
+	"traceId": "5af7183fb1d4cf5f",
+	"id": "6b221d5bc9e6496c",
+	"parentId": "352bff9a74ca9ad2",
+	"kind": "SERVER",
+	"name": "get /api",
+	"timestamp": 1556604172355737,
+	"duration": 1431,
+	"localEndpoint": {"serviceName": "frontend"},
+	"tags": {"http.method": "GET", "error": "boom"}
+}]`
+
+func newZipkinTestReceiver(t *testing.T) *HTTPReceiver {
+	conf := NewTestConfig(t)
+	conf.Endpoints[0].APIKey = "test"
+	conf.DecoderTimeout = 10000
+	conf.ZipkinReceiver.Enabled = true
+	return newTestReceiverFromConfig(conf)
+}
+
+func TestZipkinEndpoint(t *testing.T) {
+	for name, tt := range map[string]struct {
+		header map[string]string
+		gzip   bool
+	}{
+		"json":         {header: map[string]string{"Content-Type": "application/json"}},
+		"default-type": {},
+		"gzip":         {header: map[string]string{"Content-Type": "application/json", "Content-Encoding": "gzip"}, gzip: true},
+	} {
+		t.Run(name, func(t *testing.T) {
+			r := newZipkinTestReceiver(t)
+			body := []byte(zipkinTestPayload)
+			if tt.gzip {
+				var buf bytes.Buffer
+				gz := gzip.NewWriter(&buf)
+				_, err := gz.Write(body)
+				require.NoError(t, err)
+				require.NoError(t, gz.Close())
+				body = buf.Bytes()
+			}
+			req := httptest.NewRequest("POST", "/api/v2/spans", bytes.NewReader(body))
+			for k, v := range tt.header {
+				req.Header.Set(k, v)
+			}
+			rec := httptest.NewRecorder()
+			r.buildMux().ServeHTTP(rec, req)
+			require.Equal(t, http.StatusAccepted, rec.Code, rec.Body.String())
+
+			require.Len(t, r.out, 1)
+			p := <-r.out
+			require.Len(t, p.Chunks(), 1)
+			require.Len(t, p.Chunk(0).Spans, 1)
+			span := p.Chunk(0).Spans[0]
+			assert.Equal(t, "frontend", span.Service)
+			assert.Equal(t, uint64(0x5af7183fb1d4cf5f), span.TraceID)
+			assert.Equal(t, uint64(0x6b221d5bc9e6496c), span.SpanID)
+			assert.Equal(t, uint64(0x352bff9a74ca9ad2), span.ParentID)
+			assert.Equal(t, int64(1431000), span.Duration)
+			assert.Equal(t, int32(1), span.Error)
+		})
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		r := newTestReceiverFromConfig(newTestReceiverConfig())
+		req := httptest.NewRequest("POST", "/api/v2/spans", bytes.NewReader([]byte(zipkinTestPayload)))
+		rec := httptest.NewRecorder()
+		r.buildMux().ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+
+	t.Run("unsupported-type", func(t *testing.T) {
+		r := newZipkinTestReceiver(t)
+		req := httptest.NewRequest("POST", "/api/v2/spans", bytes.NewReader([]byte(zipkinTestPayload)))
+		req.Header.Set("Content-Type", "application/msgpack")
+		rec := httptest.NewRecorder()
+		r.buildMux().ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusUnsupportedMediaType, rec.Code)
+		assert.Len(t, r.out, 0)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		r := newZipkinTestReceiver(t)
+		req := httptest.NewRequest("POST", "/api/v2/spans", bytes.NewReader([]byte(`[{"traceId": 1}`)))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		r.buildMux().ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Len(t, r.out, 0)
+	})
+}
@@ -90,6 +90,52 @@ type OTLP struct {
 	IgnoreMissingDatadogFields bool `mapstructure:"ignore_missing_datadog_fields"`
 }
 
+// ZipkinReceiver holds the configuration for the Zipkin v2 endpoint of the receiver, which accepts
+// spans encoded as JSON or protobuf and converts them like OpenTelemetry spans.
+type ZipkinReceiver struct {
+	// Enabled specifies whether the /api/v2/spans endpoint is served.
+	Enabled bool `mapstructure:"enabled"`
+
+	// ParseStringTags specifies whether string tags holding numbers or booleans are converted
+	// to typed attributes.
+	ParseStringTags bool `mapstructure:"parse_string_tags"`
+}
+
+// JaegerReceiver holds the configuration for the Jaeger endpoints of the receiver, which accept
+// batches of spans encoded with Thrift over HTTP, or protobuf over gRPC, and convert them like
+// OpenTelemetry spans.
+type JaegerReceiver struct {
+	// Enabled specifies whether the /api/traces Thrift endpoint and the gRPC collector service
+	// are served.
+	Enabled bool `mapstructure:"enabled"`
+
+	// GRPCPort specifies the port on which the Jaeger gRPC collector service listens.
+	// It is disabled if zero, or if the Jaeger receiver isn't enabled.
+	GRPCPort int `mapstructure:"grpc_port"`
+}
+
+// NormalizationConfig holds the maximum lengths of span fields, beyond which they are truncated
+// when normalizing spans. The maximum length of resources is AgentConfig.MaxResourceLen.
+type NormalizationConfig struct {
+	// MaxServiceLen is the maximum length of services, peer.service and _dd.base_service tags.
+	MaxServiceLen int
+	// MaxNameLen is the maximum length of span names.
+	MaxNameLen int
+	// MaxTypeLen is the maximum length of span types.
+	MaxTypeLen int
+	// MaxMetaKeyLen is the maximum length of meta keys, reserved keys such as "_dd.*" aside.
+	MaxMetaKeyLen int
+	// MaxMetaValueLen is the maximum length of meta values, structured meta tags aside.
+	MaxMetaValueLen int
+	// MaxMetricsKeyLen is the maximum length of metrics keys, reserved keys such as
+	// "_sampling_priority_v1" aside.
+	MaxMetricsKeyLen int
+	// KeepTruncatedOverflow reports whether the part cut from truncated resources and meta values
+	// is moved to a "_dd.overflow.<field>" meta tag, instead of being dropped. The tag is itself
+	// truncated to MaxMetaValueLen, so fields longer than twice the limit still lose their end.
+	KeepTruncatedOverflow bool
+}
+
 // ObfuscationConfig holds the configuration for obfuscating sensitive data
 // for various span types.
 type ObfuscationConfig struct {
@@ -128,6 +174,13 @@ type ObfuscationConfig struct {
 	// for spans of type "memcached".
 	Memcached obfuscate.MemcachedConfig `mapstructure:"memcached"`
 
+	// GraphQL holds the configuration for obfuscating the resource and the "graphql.source"
+	// tag of spans of type "graphql".
+	GraphQL obfuscate.GraphQLConfig `mapstructure:"graphql"`
+
+	// CQL holds the configuration for obfuscating the resource of spans of type "cassandra".
+	CQL obfuscate.CQLConfig `mapstructure:"cql"`
+
 	// CreditCards holds the configuration for obfuscating credit cards.
 	CreditCards obfuscate.CreditCardsConfig `mapstructure:"credit_cards"`
 
@@ -168,6 +221,8 @@ func (o *ObfuscationConfig) Export(conf *AgentConfig) obfuscate.Config {
 		Redis:                o.Redis,
 		Valkey:               o.Valkey,
 		Memcached:            o.Memcached,
+		GraphQL:              o.GraphQL,
+		CQL:                  o.CQL,
 		CreditCard:           o.CreditCards,
 		Logger:               new(debugLogger),
 		Cache:                o.Cache,
@@ -209,6 +264,149 @@ type ReplaceRule struct {
 	Repl string `mapstructure:"repl"`
 }
 
+// TailSamplingConfig holds the configuration of the tail sampler, which buffers the chunks of
+// each trace until a decision can be made on the trace as a whole.
+type TailSamplingConfig struct {
+	// Enabled reports whether the chunks dropped by the other samplers are sampled by the tail sampler.
+	Enabled bool
+	// DecisionWait is the time during which the chunks of a trace are buffered, starting when
+	// its first chunk is received.
+	DecisionWait time.Duration
+	// MaxTraces is the maximum number of traces buffered. Above it, the oldest traces are decided
+	// before the end of their decision window.
+	MaxTraces int
+	// MaxSpans is the maximum number of spans buffered, bounding the memory used by the tail sampler.
+	MaxSpans int
+	// Policies are the rules keeping traces. A trace is kept if any policy matches it.
+	Policies []*TailSamplingPolicy
+}
+
+// TailSamplingPolicyType is the type of a tail sampling policy.
+type TailSamplingPolicyType string
+
+const (
+	// TailSamplingPolicyLatency keeps the traces with a span lasting at least LatencyThresholdMs.
+	TailSamplingPolicyLatency TailSamplingPolicyType = "latency"
+	// TailSamplingPolicyError keeps the traces with a span in error.
+	TailSamplingPolicyError TailSamplingPolicyType = "error"
+	// TailSamplingPolicyAttribute keeps the traces with a span tagged with Key (and Value, if set).
+	TailSamplingPolicyAttribute TailSamplingPolicyType = "attribute"
+	// TailSamplingPolicyRate keeps a share of the traces of each service, either SampleRate
+	// or a share computed to keep TargetTPS traces per second.
+	TailSamplingPolicyRate TailSamplingPolicyType = "rate"
+)
+
+// TailSamplingPolicy is a rule of the tail sampler.
+type TailSamplingPolicy struct {
+	// Name identifies the policy in the metrics and in the tags of the traces it keeps.
+	Name string `mapstructure:"name"`
+	// Type specifies how traces are matched.
+	Type TailSamplingPolicyType `mapstructure:"type"`
+	// Service restricts the policy to the spans of a service. For rate policies, it restricts
+	// the policy to the traces whose root span is from this service.
+	Service string `mapstructure:"service"`
+	// LatencyThresholdMs is the duration, in milliseconds, of the spans matched by a latency policy.
+	LatencyThresholdMs float64 `mapstructure:"threshold_ms"`
+	// Key and Value specify the tag of the spans matched by an attribute policy. If Value is empty,
+	// any span with the tag matches.
+	Key   string `mapstructure:"key"`
+	Value string `mapstructure:"value"`
+	// SampleRate is the share of the traces kept by a rate policy.
+	SampleRate float64 `mapstructure:"sample_rate"`
+	// TargetTPS is the number of traces per second kept by a rate policy, spread across services
+	// like the priority sampler does. It is used if SampleRate is not set.
+	TargetTPS float64 `mapstructure:"target_tps"`
+}
+
+// SpanFilterAction is the action of a span filtering rule on the spans matching its expression.
+type SpanFilterAction string
+
+const (
+	// SpanFilterDropSpan drops the matching spans, except the root spans of the chunks.
+	SpanFilterDropSpan SpanFilterAction = "drop_span"
+	// SpanFilterKeepSpan keeps the matching spans, ignoring the drop_span rules following it.
+	SpanFilterKeepSpan SpanFilterAction = "keep_span"
+	// SpanFilterDropTrace drops the chunks having a matching span.
+	SpanFilterDropTrace SpanFilterAction = "drop_trace"
+	// SpanFilterKeepTrace keeps all the spans of the chunks having a matching span, ignoring
+	// the other rules.
+	SpanFilterKeepTrace SpanFilterAction = "keep_trace"
+)
+
+// SpanFilterRule is a rule dropping or keeping the spans, or their whole trace, matching an expression.
+type SpanFilterRule struct {
+	// Name identifies the rule in the logs.
+	Name string `mapstructure:"name"`
+	// Action specifies what is done with the matching spans.
+	Action SpanFilterAction `mapstructure:"action"`
+	// Expression is the condition on the properties and tags of the spans matched by the rule,
+	// such as `service == "web" && resource =~ "^GET /health"`.
+	Expression string `mapstructure:"expression"`
+}
+
+// DefaultStatsDimensionMaxCardinality is the default maximum number of distinct values of a
+// stats dimension.
+const DefaultStatsDimensionMaxCardinality = 100
+
+// StatsDimension is a span tag used as an additional dimension of the aggregation of trace stats.
+type StatsDimension struct {
+	// Tag is the key of the span tag.
+	Tag string `mapstructure:"tag"`
+	// MaxCardinality is the maximum number of distinct values of the tag kept per hour. The
+	// values seen beyond it are aggregated together under an overflow value.
+	MaxCardinality int `mapstructure:"max_cardinality"`
+}
+
+// SpanMetricType is the type of a span-derived metric.
+type SpanMetricType string
+
+const (
+	// SpanMetricCount counts the matching spans.
+	SpanMetricCount SpanMetricType = "count"
+	// SpanMetricDistribution sends the Value of each matching span as a distribution.
+	SpanMetricDistribution SpanMetricType = "distribution"
+)
+
+// SpanMetricDuration is the Value of span-derived metrics measuring the duration of the
+// spans, in seconds.
+const SpanMetricDuration = "duration"
+
+// SpanMetric is a rule generating a custom metric from the spans it matches, before they
+// are sampled.
+type SpanMetric struct {
+	// Name is the name of the metric.
+	Name string `mapstructure:"name"`
+	// Type specifies whether the metric is a count or a distribution.
+	Type SpanMetricType `mapstructure:"type"`
+	// Service, OperationName and Resource restrict the rule to the spans having these
+	// values, when set.
+	Service       string `mapstructure:"service"`
+	OperationName string `mapstructure:"operation_name"`
+	Resource      string `mapstructure:"resource"`
+	// Tags restricts the rule to the spans having all these tags. An empty value matches
+	// any value of the tag.
+	Tags map[string]string `mapstructure:"tags"`
+	// Value is SpanMetricDuration or the name of the numeric tag of the spans measured
+	// by a distribution. Spans without this tag are skipped.
+	Value string `mapstructure:"value"`
+	// GroupBy lists the span tags added as tags to the metric, in addition to env and
+	// service. "resource" and "operation_name" refer to the resource and name of the span.
+	GroupBy []string `mapstructure:"group_by"`
+}
+
+// RouteTemplatingConfig configures the templating of the URL paths in the resource of the HTTP
+// spans without an http.route tag.
+type RouteTemplatingConfig struct {
+	// Enabled reports whether URL paths are templated.
+	Enabled bool
+	// CardinalityThreshold is the number of distinct path segments following the same prefix
+	// above which all the segments following this prefix are replaced with a placeholder.
+	CardinalityThreshold int
+	// MaxNodes is the maximum number of distinct path prefixes learned for each service,
+	// bounding the memory used.
+	MaxNodes int
+}
+
 // WriterConfig specifies configuration for an API writer.
 type WriterConfig struct {
 	// ConnectionLimit specifies the maximum number of concurrent outgoing
@@ -224,6 +422,21 @@ type WriterConfig struct {
 	FlushPeriodSeconds float64 `mapstructure:"flush_period_seconds"`
 }
 
+// DiskRetryConfig configures the on-disk retry queue of the writers. When enabled, the
+// payloads which could not be sent are stored on disk instead of being dropped, and are
+// sent again once the intake accepts payloads, including after a restart.
+type DiskRetryConfig struct {
+	// Enabled reports whether failed payloads are stored on disk.
+	Enabled bool `mapstructure:"enabled"`
+
+	// Path is the directory where the payloads are stored.
+	Path string `mapstructure:"path"`
+
+	// MaxSizeBytes is the maximum size of the payloads stored for each writer and
+	// endpoint. The oldest payloads are removed to make room for new ones.
+	MaxSizeBytes int64 `mapstructure:"max_size_bytes"`
+}
+
 // FargateOrchestratorName is a Fargate orchestrator name.
 type FargateOrchestratorName string
 
@@ -337,6 +550,10 @@ type AgentConfig struct {
 	ComputeStatsBySpanKind bool          // enables/disables the computing of stats based on a span's `span.kind` field
 	PeerTags               []string      // additional tags to use for peer entity stats aggregation
 
+	// StatsDimensions are the span tags used as additional dimensions of the aggregation of
+	// trace stats, by the Concentrator and the ClientStatsAggregator, sent as additional peer tags.
+	StatsDimensions []*StatsDimension
+
 	// Sampler configuration
 	ExtraSampleRate float64
 	TargetTPS       float64
@@ -358,6 +575,19 @@ type AgentConfig struct {
 	// Error Tracking Standalone
 	ErrorTrackingStandalone bool
 
+	// TailSampling holds the configuration of the tail sampler.
+	TailSampling TailSamplingConfig
+
+	// SpanMetrics are the rules generating custom metrics from the received spans.
+	SpanMetrics []*SpanMetric
+
+	// SpanFilters are the rules dropping or keeping spans or whole traces, applied in order to
+	// each span: the first matching rule applies.
+	SpanFilters []*SpanFilterRule
+
+	// RouteTemplating configures the templating of the URL paths in the resource of HTTP spans.
+	RouteTemplating RouteTemplatingConfig
+
 	// Receiver
 	ReceiverEnabled bool // specifies whether Receiver listeners are enabled. Unless OTLPReceiver is used, this should always be true.
 	ReceiverHost    string
@@ -388,6 +618,9 @@ type AgentConfig struct {
 	// case, the sender will drop failed payloads when it is unable to enqueue
 	// them for another retry.
 	MaxSenderRetries int
+	// DiskRetry configures the storage on disk of the payloads which could not be
+	// sent after MaxSenderRetries retries.
+	DiskRetry DiskRetryConfig
 	// HTTP client used in writer connections. If nil, default client values will be used.
 	HTTPClientFunc func() *http.Client `json:"-"`
 	// HTTP Transport used in writer connections. If nil, default transport values will be used.
@@ -438,6 +671,9 @@ type AgentConfig struct {
 	// MaxResourceLen the maximum length the resource can have
 	MaxResourceLen int
 
+	// Normalization holds the maximum lengths of the other span fields.
+	Normalization NormalizationConfig
+
 	// RequireTags specifies a list of tags which must be present on the root span in order for a trace to be accepted.
 	RequireTags []*Tag
 
@@ -453,6 +689,12 @@ type AgentConfig struct {
 	// OTLPReceiver holds the configuration for OpenTelemetry receiver.
 	OTLPReceiver *OTLP
 
+	// ZipkinReceiver holds the configuration for the Zipkin v2 endpoint.
+	ZipkinReceiver ZipkinReceiver
+
+	// JaegerReceiver holds the configuration for the Jaeger endpoints.
+	JaegerReceiver JaegerReceiver
+
 	// ProfilingProxy specifies settings for the profiling proxy.
 	ProfilingProxy ProfilingProxyConfig
 
@@ -561,6 +803,17 @@ func New() *AgentConfig {
 
 		ErrorTrackingStandalone: false,
 
+		TailSampling: TailSamplingConfig{
+			DecisionWait: 10 * time.Second,
+			MaxTraces:    50000,
+			MaxSpans:     1000000,
+		},
+
+		RouteTemplating: RouteTemplatingConfig{
+			CardinalityThreshold: 50,
+			MaxNodes:             5000,
+		},
+
 		ReceiverEnabled:        true,
 		ReceiverHost:           "localhost",
 		ReceiverPort:           8126,
@@ -590,6 +843,14 @@ func New() *AgentConfig {
 		Obfuscation:                 &ObfuscationConfig{},
 		SQLObfuscationMode:          "",
 		MaxResourceLen:              5000,
+		Normalization: NormalizationConfig{
+			MaxServiceLen:    traceutil.MaxServiceLen,
+			MaxNameLen:       traceutil.MaxNameLen,
+			MaxTypeLen:       traceutil.MaxTypeLen,
+			MaxMetaKeyLen:    traceutil.MaxMetaKeyLen,
+			MaxMetaValueLen:  traceutil.MaxMetaValueLen,
+			MaxMetricsKeyLen: traceutil.MaxMetricsKeyLen,
+		},
 
 		GlobalTags: computeGlobalTags(),
 
@@ -0,0 +1,468 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package filters
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	pb "github.com/DataDog/datadog-agent/pkg/proto/pbgo/trace"
+)
+
+// Expression is a condition on the properties and tags of a span, such as:
+//
+//	service == "web" && (resource =~ "^GET /health" || meta.cache.hit == "true") && duration < 5ms
+//
+// The fields are service, name, resource, type, span.kind, duration (in nanoseconds), error,
+// meta.<key> and metrics.<key>. They are compared to strings, numbers, durations (10ms, 1.5s)
+// or booleans with ==, !=, <, <=, > and >=, or to regular expressions with =~ and !~.
+// Conditions are combined with &&, || and !. A field alone checks that a tag is set, or that
+// the span is in error. Comparisons on missing tags are false.
+type Expression struct {
+	root exprNode
+}
+
+// ParseExpression parses the expression s.
+func ParseExpression(s string) (*Expression, error) {
+	tokens, err := lexExpression(s)
+	if err != nil {
+		return nil, err
+	}
+	p := exprParser{tokens: tokens}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokenEOF {
+		return nil, fmt.Errorf("unexpected %q at position %d", tok.text, tok.pos)
+	}
+	return &Expression{root: root}, nil
+}
+
+// Match reports whether span matches the expression.
+func (e *Expression) Match(span *pb.Span) bool {
+	return e.root.eval(span)
+}
+
+// exprNode is a node of the tree of a parsed expression.
+type exprNode interface {
+	eval(span *pb.Span) bool
+}
+
+type andNode struct{ left, right exprNode }
+
+func (n andNode) eval(span *pb.Span) bool { return n.left.eval(span) && n.right.eval(span) }
+
+type orNode struct{ left, right exprNode }
+
+func (n orNode) eval(span *pb.Span) bool { return n.left.eval(span) || n.right.eval(span) }
+
+type notNode struct{ n exprNode }
+
+func (n notNode) eval(span *pb.Span) bool { return !n.n.eval(span) }
+
+// existsNode checks that a field is set.
+type existsNode struct{ f field }
+
+func (n existsNode) eval(span *pb.Span) bool {
+	v, ok := n.f.get(span)
+	if !ok {
+		return false
+	}
+	switch v.kind {
+	case valueBool:
+		return v.b
+	case valueString:
+		return n.f.kind == fieldMeta || v.s != ""
+	}
+	return true
+}
+
+// compareNode compares a field to a literal.
+type compareNode struct {
+	f   field
+	op  string
+	lit value
+	re  *regexp.Regexp // set for =~ and !~
+}
+
+func (n compareNode) eval(span *pb.Span) bool {
+	v, ok := n.f.get(span)
+	if !ok {
+		return false
+	}
+	if n.re != nil {
+		return n.re.MatchString(v.String()) == (n.op == "=~")
+	}
+	var cmp int
+	switch n.lit.kind {
+	case valueBool:
+		b, err := strconv.ParseBool(v.String())
+		if err != nil {
+			return false
+		}
+		if b == n.lit.b {
+			cmp = 0
+		} else {
+			cmp = 1
+		}
+	case valueNumber:
+		f := v.n
+		if v.kind != valueNumber {
+			var err error
+			if f, err = strconv.ParseFloat(v.String(), 64); err != nil {
+				return false
+			}
+		}
+		switch {
+		case f < n.lit.n:
+			cmp = -1
+		case f > n.lit.n:
+			cmp = 1
+		}
+	default:
+		cmp = strings.Compare(v.String(), n.lit.s)
+	}
+	switch n.op {
+	case "==":
+		return cmp == 0
+	case "!=":
+		return cmp != 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	default: // >=
+		return cmp >= 0
+	}
+}
+
+// fieldKind identifies the property of a span designated by a field.
+type fieldKind int
+
+const (
+	fieldService fieldKind = iota
+	fieldName
+	fieldResource
+	fieldType
+	fieldSpanKind
+	fieldDuration
+	fieldError
+	fieldMeta
+	fieldMetrics
+)
+
+var fieldKinds = map[string]fieldKind{
+	"service":   fieldService,
+	"name":      fieldName,
+	"resource":  fieldResource,
+	"type":      fieldType,
+	"span.kind": fieldSpanKind,
+	"duration":  fieldDuration,
+	"error":     fieldError,
+}
+
+// field is a property or a tag of a span.
+type field struct {
+	kind fieldKind
+	key  string // tag key of meta and metrics fields
+}
+
+// parseField parses the field s.
+func parseField(s string) (field, bool) {
+	if kind, ok := fieldKinds[s]; ok {
+		return field{kind: kind}, true
+	}
+	if key, ok := strings.CutPrefix(s, "meta."); ok && key != "" {
+		return field{kind: fieldMeta, key: key}, true
+	}
+	if key, ok := strings.CutPrefix(s, "metrics."); ok && key != "" {
+		return field{kind: fieldMetrics, key: key}, true
+	}
+	return field{}, false
+}
+
+// get returns the value of f for span, and whether it is set.
+func (f field) get(span *pb.Span) (value, bool) {
+	switch f.kind {
+	case fieldService:
+		return value{kind: valueString, s: span.Service}, true
+	case fieldName:
+		return value{kind: valueString, s: span.Name}, true
+	case fieldResource:
+		return value{kind: valueString, s: span.Resource}, true
+	case fieldType:
+		return value{kind: valueString, s: span.Type}, true
+	case fieldSpanKind:
+		v, ok := span.Meta["span.kind"]
+		return value{kind: valueString, s: v}, ok
+	case fieldDuration:
+		return value{kind: valueNumber, n: float64(span.Duration)}, true
+	case fieldError:
+		return value{kind: valueBool, b: span.Error != 0}, true
+	case fieldMeta:
+		v, ok := span.Meta[f.key]
+		return value{kind: valueString, s: v}, ok
+	default: // fieldMetrics
+		v, ok := span.Metrics[f.key]
+		return value{kind: valueNumber, n: v}, ok
+	}
+}
+
+// valueKind is the type of a value.
+type valueKind int
+
+const (
+	valueString valueKind = iota
+	valueNumber
+	valueBool
+)
+
+// value is the value of a field or a literal.
+type value struct {
+	kind valueKind
+	s    string
+	n    float64
+	b    bool
+}
+
+// String returns v formatted as a string.
+func (v value) String() string {
+	switch v.kind {
+	case valueNumber:
+		return strconv.FormatFloat(v.n, 'f', -1, 64)
+	case valueBool:
+		return strconv.FormatBool(v.b)
+	}
+	return v.s
+}
+
+// exprParser is a recursive descent parser of expressions.
+type exprParser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *exprParser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *exprParser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokenEOF {
+		p.pos++
+	}
+	return tok
+}
+
+// parseOr parses: and ("||" and)*
+func (p *exprParser) parseOr() (exprNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().text == "||" {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orNode{left, right}
+	}
+	return left, nil
+}
+
+// parseAnd parses: unary ("&&" unary)*
+func (p *exprParser) parseAnd() (exprNode, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().text == "&&" {
+		p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = andNode{left, right}
+	}
+	return left, nil
+}
+
+// parseUnary parses: "!" unary | "(" or ")" | field [operator literal]
+func (p *exprParser) parseUnary() (exprNode, error) {
+	tok := p.next()
+	switch {
+	case tok.text == "!":
+		n, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{n}, nil
+	case tok.text == "(":
+		n, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if tok := p.next(); tok.text != ")" {
+			return nil, fmt.Errorf("expected \")\" at position %d", tok.pos)
+		}
+		return n, nil
+	case tok.kind == tokenIdentifier:
+		f, ok := parseField(tok.text)
+		if !ok {
+			return nil, fmt.Errorf("unknown field %q at position %d", tok.text, tok.pos)
+		}
+		if p.peek().kind != tokenOperator {
+			return existsNode{f}, nil
+		}
+		op := p.next()
+		lit := p.next()
+		n := compareNode{f: f, op: op.text}
+		switch lit.kind {
+		case tokenString:
+			n.lit = value{kind: valueString, s: lit.value}
+		case tokenNumber:
+			n.lit = value{kind: valueNumber, n: lit.number}
+		case tokenIdentifier:
+			b, err := strconv.ParseBool(lit.text)
+			if err != nil || (op.text != "==" && op.text != "!=") {
+				return nil, fmt.Errorf("unexpected %q at position %d", lit.text, lit.pos)
+			}
+			n.lit = value{kind: valueBool, b: b}
+		default:
+			return nil, fmt.Errorf("expected a value at position %d", lit.pos)
+		}
+		if op.text == "=~" || op.text == "!~" {
+			if lit.kind != tokenString {
+				return nil, fmt.Errorf("expected a regular expression at position %d", lit.pos)
+			}
+			re, err := regexp.Compile(lit.value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid regular expression at position %d: %v", lit.pos, err)
+			}
+			n.re = re
+		}
+		return n, nil
+	case tok.kind == tokenEOF:
+		return nil, errors.New("unexpected end of expression")
+	}
+	return nil, fmt.Errorf("unexpected %q at position %d", tok.text, tok.pos)
+}
+
+// tokenKind is the kind of a token of an expression.
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdentifier
+	tokenString
+	tokenNumber
+	tokenOperator    // comparison operator
+	tokenPunctuation // "&&", "||", "!", "(" and ")"
+)
+
+// token is a token of an expression.
+type token struct {
+	kind   tokenKind
+	text   string
+	pos    int
+	value  string  // unquoted value of strings
+	number float64 // value of numbers and durations
+}
+
+// lexExpression splits the expression s into tokens, ending with a tokenEOF token.
+func lexExpression(s string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(s); {
+		c := s[i]
+		start := i
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+			continue
+		case c == '"':
+			for i++; i < len(s) && s[i] != '"'; i++ {
+				if s[i] == '\\' {
+					i++
+				}
+			}
+			if i >= len(s) {
+				return nil, fmt.Errorf("unterminated string at position %d", start)
+			}
+			i++
+			v, err := strconv.Unquote(s[start:i])
+			if err != nil {
+				return nil, fmt.Errorf("invalid string at position %d: %v", start, err)
+			}
+			tokens = append(tokens, token{kind: tokenString, text: s[start:i], pos: start, value: v})
+		case isDigit(c) || c == '-' && i+1 < len(s) && isDigit(s[i+1]):
+			for i++; i < len(s) && (isDigit(s[i]) || s[i] == '.'); i++ {
+			}
+			num := s[start:i]
+			for ; i < len(s) && isLetter(s[i]); i++ {
+			}
+			tok := token{kind: tokenNumber, text: s[start:i], pos: start}
+			if i > start+len(num) {
+				d, err := time.ParseDuration(tok.text)
+				if err != nil {
+					return nil, fmt.Errorf("invalid duration at position %d: %v", start, err)
+				}
+				tok.number = float64(d)
+			} else {
+				f, err := strconv.ParseFloat(num, 64)
+				if err != nil {
+					return nil, fmt.Errorf("invalid number at position %d: %v", start, err)
+				}
+				tok.number = f
+			}
+			tokens = append(tokens, tok)
+		case isLetter(c) || c == '_':
+			for i++; i < len(s) && (isLetter(s[i]) || isDigit(s[i]) || strings.IndexByte("_.-/:", s[i]) >= 0); i++ {
+			}
+			tokens = append(tokens, token{kind: tokenIdentifier, text: s[start:i], pos: start})
+		default:
+			var op string
+			for _, o := range []string{"==", "!=", "=~", "!~", "<=", ">=", "<", ">"} {
+				if strings.HasPrefix(s[i:], o) {
+					op = o
+					break
+				}
+			}
+			if op != "" {
+				tokens = append(tokens, token{kind: tokenOperator, text: op, pos: start})
+				i += len(op)
+				continue
+			}
+			for _, o := range []string{"&&", "||", "!", "(", ")"} {
+				if strings.HasPrefix(s[i:], o) {
+					op = o
+					break
+				}
+			}
+			if op == "" {
+				return nil, fmt.Errorf("unexpected %q at position %d", c, start)
+			}
+			tokens = append(tokens, token{kind: tokenPunctuation, text: op, pos: start})
+			i += len(op)
+		}
+	}
+	return append(tokens, token{kind: tokenEOF, pos: len(s)}), nil
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isLetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
@@ -0,0 +1,91 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package filters
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	pb "github.com/DataDog/datadog-agent/pkg/proto/pbgo/trace"
+)
+
+func TestExpressionMatch(t *testing.T) {
+	span := &pb.Span{
+		Service:  "web",
+		Name:     "http.request",
+		Resource: "GET /health",
+		Type:     "web",
+		Duration: int64(3 * time.Millisecond),
+		Meta:     map[string]string{"span.kind": "server", "http.status_code": "200", "cache.hit": "true", "empty": ""},
+		Metrics:  map[string]float64{"_sampling_priority_v1": 1, "db.row_count": 12.5},
+	}
+	for _, tt := range []struct {
+		expr  string
+		match bool
+	}{
+		{`service == "web"`, true},
+		{`service != "web"`, false},
+		{`name == "http.request" && resource == "GET /health"`, true},
+		{`resource =~ "^GET /(health|ping)$"`, true},
+		{`resource !~ "^GET /health"`, false},
+		{`type == "db" || span.kind == "server"`, true},
+		{`span.kind == "client"`, false},
+		{`duration < 5ms`, true},
+		{`duration >= 1.5s`, false},
+		{`duration > 2000000`, true},
+		{`error`, false},
+		{`!error`, true},
+		{`error == false`, true},
+		{`meta.cache.hit == true`, true},
+		{`meta.http.status_code >= 200 && meta.http.status_code < 300`, true},
+		{`meta.http.status_code == "200"`, true},
+		{`meta.http.status_code > 500`, false},
+		{`meta.empty`, true},
+		{`meta.missing`, false},
+		{`!meta.missing`, true},
+		{`meta.missing != "x"`, false},
+		{`!(meta.missing == "x")`, true},
+		{`metrics.db.row_count > 10`, true},
+		{`metrics.db.row_count == "12.5"`, true},
+		{`metrics.missing < 1`, false},
+		{`service == "api" || resource == "GET /health" && !error`, true},
+		{`(service == "api" || resource == "GET /health") && error`, false},
+		{`meta.span.kind =~ "serv"`, true},
+		{`service == "w\"eb"`, false},
+	} {
+		expr, err := ParseExpression(tt.expr)
+		if !assert.NoError(t, err, tt.expr) {
+			continue
+		}
+		assert.Equal(t, tt.match, expr.Match(span), tt.expr)
+	}
+}
+
+func TestParseExpressionError(t *testing.T) {
+	for _, tt := range []struct {
+		expr string
+		err  string
+	}{
+		{``, "unexpected end of expression"},
+		{`service ==`, "expected a value at position 10"},
+		{`service == "web`, "unterminated string at position 11"},
+		{`host == "web"`, `unknown field "host" at position 0`},
+		{`meta. == "x"`, `unknown field "meta." at position 0`},
+		{`service == "web" &&`, "unexpected end of expression"},
+		{`(service == "web"`, `expected ")" at position 17`},
+		{`service == "web")`, `unexpected ")" at position 16`},
+		{`resource =~ "[a-"`, "invalid regular expression at position 12: error parsing regexp: missing closing ]: `[a-`"},
+		{`resource =~ 12`, "expected a regular expression at position 12"},
+		{`error > true`, `unexpected "true" at position 8`},
+		{`duration < 5parsecs`, `invalid duration at position 11: time: unknown unit "parsecs" in duration "5parsecs"`},
+		{`service = "web"`, `unexpected '=' at position 8`},
+	} {
+		_, err := ParseExpression(tt.expr)
+		assert.EqualError(t, err, tt.err, tt.expr)
+	}
+}
@@ -0,0 +1,95 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package filters
+
+import (
+	pb "github.com/DataDog/datadog-agent/pkg/proto/pbgo/trace"
+	"github.com/DataDog/datadog-agent/pkg/trace/config"
+	"github.com/DataDog/datadog-agent/pkg/trace/log"
+)
+
+// SpanFilter drops spans, or whole traces, according to span filtering rules. The rules are
+// applied in order to each span, the first rule whose expression matches the span applies.
+type SpanFilter struct {
+	rules []spanFilterRule
+}
+
+type spanFilterRule struct {
+	name   string
+	action config.SpanFilterAction
+	expr   *Expression
+}
+
+// NewSpanFilter creates a new SpanFilter applying the given rules. Rules with an invalid
+// expression are ignored.
+func NewSpanFilter(rules []*config.SpanFilterRule) *SpanFilter {
+	f := &SpanFilter{rules: make([]spanFilterRule, 0, len(rules))}
+	for _, r := range rules {
+		expr, err := ParseExpression(r.Expression)
+		if err != nil {
+			log.Errorf("Invalid span filter %q: %s", r.Name, err)
+			continue
+		}
+		f.rules = append(f.rules, spanFilterRule{name: r.Name, action: r.Action, expr: expr})
+	}
+	return f
+}
+
+// action returns the action of the first rule matching span, or an empty action if none does.
+func (f *SpanFilter) action(span *pb.Span) config.SpanFilterAction {
+	for _, r := range f.rules {
+		if r.expr.Match(span) {
+			return r.action
+		}
+	}
+	return ""
+}
+
+// Filter applies the rules to the spans of chunk, whose root span is root. It reports whether
+// the whole chunk must be dropped. Otherwise, the spans dropped are removed from chunk, their
+// children being attached to their parent, and their number is returned. The root span is never
+// removed on its own.
+func (f *SpanFilter) Filter(chunk *pb.TraceChunk, root *pb.Span) (dropTrace bool, dropped int) {
+	// parents holds the parent of each dropped span
+	var parents map[uint64]uint64
+	for _, span := range chunk.Spans {
+		switch f.action(span) {
+		case config.SpanFilterKeepTrace:
+			return false, 0
+		case config.SpanFilterDropTrace:
+			dropTrace = true
+		case config.SpanFilterDropSpan:
+			if span == root {
+				continue
+			}
+			if parents == nil {
+				parents = make(map[uint64]uint64)
+			}
+			parents[span.SpanID] = span.ParentID
+		}
+	}
+	if dropTrace || len(parents) == 0 {
+		return dropTrace, 0
+	}
+	spans := chunk.Spans[:0]
+	for _, span := range chunk.Spans {
+		if _, ok := parents[span.SpanID]; ok {
+			continue
+		}
+		// the bound guards against cycles in malformed traces
+		for i := 0; i < len(parents); i++ {
+			parent, ok := parents[span.ParentID]
+			if !ok {
+				break
+			}
+			span.ParentID = parent
+		}
+		spans = append(spans, span)
+	}
+	dropped = len(chunk.Spans) - len(spans)
+	chunk.Spans = spans
+	return false, dropped
+}
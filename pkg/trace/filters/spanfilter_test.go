@@ -0,0 +1,90 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package filters
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	pb "github.com/DataDog/datadog-agent/pkg/proto/pbgo/trace"
+	"github.com/DataDog/datadog-agent/pkg/trace/config"
+)
+
+func testChunk() *pb.TraceChunk {
+	return &pb.TraceChunk{Spans: []*pb.Span{
+		{SpanID: 1, Service: "web", Resource: "GET /checkout"},
+		{SpanID: 2, ParentID: 1, Service: "cache", Name: "redis.command"},
+		{SpanID: 3, ParentID: 2, Service: "cache", Name: "redis.connect"},
+		{SpanID: 4, ParentID: 3, Service: "db", Name: "sql.query"},
+		{SpanID: 5, ParentID: 1, Service: "cache", Name: "redis.command", Error: 1},
+	}}
+}
+
+func spanIDs(chunk *pb.TraceChunk) map[uint64]uint64 {
+	ids := make(map[uint64]uint64, len(chunk.Spans))
+	for _, span := range chunk.Spans {
+		ids[span.SpanID] = span.ParentID
+	}
+	return ids
+}
+
+func TestSpanFilter(t *testing.T) {
+	for name, tt := range map[string]struct {
+		rules     []*config.SpanFilterRule
+		dropTrace bool
+		spans     map[uint64]uint64 // parent ID of the spans kept, by span ID
+	}{
+		"none": {
+			spans: map[uint64]uint64{1: 0, 2: 1, 3: 2, 4: 3, 5: 1},
+		},
+		"drop_span": {
+			rules: []*config.SpanFilterRule{
+				{Name: "keep-errors", Action: config.SpanFilterKeepSpan, Expression: `error`},
+				{Name: "drop-cache", Action: config.SpanFilterDropSpan, Expression: `service == "cache"`},
+			},
+			spans: map[uint64]uint64{1: 0, 4: 1, 5: 1},
+		},
+		"drop_span/root": {
+			rules: []*config.SpanFilterRule{
+				{Name: "drop-web", Action: config.SpanFilterDropSpan, Expression: `service == "web"`},
+			},
+			spans: map[uint64]uint64{1: 0, 2: 1, 3: 2, 4: 3, 5: 1},
+		},
+		"drop_trace": {
+			rules: []*config.SpanFilterRule{
+				{Name: "drop-health", Action: config.SpanFilterDropTrace, Expression: `service == "db"`},
+			},
+			dropTrace: true,
+		},
+		"keep_trace": {
+			rules: []*config.SpanFilterRule{
+				{Name: "keep-errors", Action: config.SpanFilterKeepTrace, Expression: `error`},
+				{Name: "drop-db", Action: config.SpanFilterDropTrace, Expression: `service == "db"`},
+				{Name: "drop-cache", Action: config.SpanFilterDropSpan, Expression: `service == "cache"`},
+			},
+			spans: map[uint64]uint64{1: 0, 2: 1, 3: 2, 4: 3, 5: 1},
+		},
+		"invalid": {
+			rules: []*config.SpanFilterRule{
+				{Name: "invalid", Action: config.SpanFilterDropTrace, Expression: `service ==`},
+			},
+			spans: map[uint64]uint64{1: 0, 2: 1, 3: 2, 4: 3, 5: 1},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			chunk := testChunk()
+			dropTrace, dropped := NewSpanFilter(tt.rules).Filter(chunk, chunk.Spans[0])
+			assert.Equal(t, tt.dropTrace, dropTrace)
+			if tt.dropTrace {
+				assert.Zero(t, dropped)
+				return
+			}
+			assert.Equal(t, 5-len(tt.spans), dropped)
+			assert.Equal(t, tt.spans, spanIDs(chunk))
+		})
+	}
+}
@@ -24,6 +24,7 @@ import (
 	"time"
 
 	"github.com/DataDog/datadog-agent/pkg/trace/config"
+	"github.com/DataDog/datadog-agent/pkg/trace/sampler"
 	"github.com/DataDog/datadog-agent/pkg/trace/watchdog"
 	"github.com/DataDog/datadog-agent/pkg/util/scrubber"
 )
@@ -37,6 +38,7 @@ var (
 
 	traceWriterInfo TraceWriterInfo
 	statsWriterInfo StatsWriterInfo
+	tailSampler     *sampler.TailSampler
 
 	watchdogInfo  watchdog.Info
 	rateByService map[string]float64
@@ -84,13 +86,20 @@ const (
   Priority sampling rate for '{{ $key }}': {{percent $value}} %
   {{ end }}
   {{ end }}
+  {{if .Status.Config.TailSampling.Enabled}}
+  Tail sampling: {{.Status.TailSampler.Traces}} traces decided, {{.Status.TailSampler.Kept}} kept, {{.Status.TailSampler.BufferedTraces}} traces ({{.Status.TailSampler.BufferedSpans}} spans) buffered
+  {{if gt .Status.TailSampler.Evicted 0}}WARNING: {{.Status.TailSampler.Evicted}} traces decided before the end of their decision window to stay within the memory bounds{{end}}
+  {{end}}
 
   --- Writer stats (1 min) ---
 
   Traces: {{.Status.TraceWriter.Payloads}} payloads, {{.Status.TraceWriter.Traces}} traces, {{if gt .Status.TraceWriter.Events.Load 0}}{{.Status.TraceWriter.Events.Load}} events, {{end}}{{.Status.TraceWriter.Bytes}} bytes
   {{if gt .Status.TraceWriter.Errors.Load 0}}WARNING: Traces API errors (1 min): {{.Status.TraceWriter.Errors.Load}}{{end}}
   Stats: {{.Status.StatsWriter.Payloads.Load}} payloads, {{.Status.StatsWriter.StatsBuckets.Load}} stats buckets, {{.Status.StatsWriter.Bytes.Load}} bytes
-  {{if gt .Status.StatsWriter.Errors.Load 0}}WARNING: Stats API errors (1 min): {{.Status.StatsWriter.Errors.Load}}{{end}}
+  {{if gt .Status.StatsWriter.Errors.Load 0}}WARNING: Stats API errors (1 min): {{.Status.StatsWriter.Errors.Load}}{{end}}{{if .Status.Config.DiskRetry.Enabled}}
+  Disk retry: traces {{.Status.TraceWriter.DiskStored.Load}} stored, {{.Status.TraceWriter.DiskReplayed.Load}} replayed, {{.Status.TraceWriter.DiskBytes.Load}} bytes; stats {{.Status.StatsWriter.DiskStored.Load}} stored, {{.Status.StatsWriter.DiskReplayed.Load}} replayed, {{.Status.StatsWriter.DiskBytes.Load}} bytes{{if gt .Status.TraceWriter.DiskEvicted.Load 0}}
+  WARNING: Traces dropped from the full disk retry queue: {{.Status.TraceWriter.DiskEvicted.Load}}{{end}}{{if gt .Status.StatsWriter.DiskEvicted.Load 0}}
+  WARNING: Stats dropped from the full disk retry queue: {{.Status.StatsWriter.DiskEvicted.Load}}{{end}}{{end}}
 `
 
 	notRunningTmplSrc = `{{.Banner}}
@@ -216,12 +225,13 @@ type StatusInfo struct {
 		Version   string
 		GitCommit string
 	} `json:"version"`
-	Receiver      []TagStats         `json:"receiver"`
-	RateByService map[string]float64 `json:"ratebyservice_filtered"`
-	TraceWriter   TraceWriterInfo    `json:"trace_writer"`
-	StatsWriter   StatsWriterInfo    `json:"stats_writer"`
-	Watchdog      watchdog.Info      `json:"watchdog"`
-	Config        config.AgentConfig `json:"config"`
+	Receiver      []TagStats               `json:"receiver"`
+	RateByService map[string]float64       `json:"ratebyservice_filtered"`
+	TraceWriter   TraceWriterInfo          `json:"trace_writer"`
+	StatsWriter   StatsWriterInfo          `json:"stats_writer"`
+	TailSampler   sampler.TailSamplerStats `json:"tail_sampler"`
+	Watchdog      watchdog.Info            `json:"watchdog"`
+	Config        config.AgentConfig       `json:"config"`
 }
 
 func getProgramBanner(version string) (string, string) {
@@ -336,6 +346,7 @@ func initInfo(conf *config.AgentConfig) error {
 	expvar.Publish("receiver", expvar.Func(publishReceiverStats))
 	expvar.Publish("trace_writer", expvar.Func(publishTraceWriterInfo))
 	expvar.Publish("stats_writer", expvar.Func(publishStatsWriterInfo))
+	expvar.Publish("tail_sampler", expvar.Func(publishTailSamplerInfo))
 	expvar.Publish("ratebyservice", expvar.Func(publishRateByService))
 	expvar.Publish("ratebyservice_filtered", expvar.Func(publishRateByServiceFiltered))
 	expvar.Publish("watchdog", expvar.Func(publishWatchdogInfo))
@@ -18,12 +18,16 @@ import (
 	"strconv"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/DataDog/datadog-go/v5/statsd"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/atomic"
 
+	pb "github.com/DataDog/datadog-agent/pkg/proto/pbgo/trace"
 	"github.com/DataDog/datadog-agent/pkg/trace/config"
+	"github.com/DataDog/datadog-agent/pkg/trace/sampler"
 	"github.com/DataDog/datadog-agent/pkg/trace/watchdog"
 )
 
@@ -486,6 +490,10 @@ func TestPublishReceiverStats(t *testing.T) {
 				atom(14),
 				atom(15),
 				atom(16),
+				atom(17),
+				atom(18),
+				atom(19),
+				atom(20),
 			},
 			TracesFiltered:     atom(4),
 			TracesPriorityNone: atom(5),
@@ -544,6 +552,10 @@ func TestPublishReceiverStats(t *testing.T) {
 				"InvalidStartDate":      14.0,
 				"InvalidDuration":       15.0,
 				"InvalidHTTPStatusCode": 16.0,
+				"ResourceTruncate":      17.0,
+				"MetaKeyTruncate":       18.0,
+				"MetaValueTruncate":     19.0,
+				"MetricsKeyTruncate":    20.0,
 			},
 			"SpansReceived": 10.0,
 			"TracerVersion": "",
@@ -579,6 +591,37 @@ func TestPublishWatchdogInfo(t *testing.T) {
 		})
 }
 
+func TestPublishTailSamplerInfo(t *testing.T) {
+	testExpvarPublish(t, publishTailSamplerInfo,
+		map[string]interface{}{
+			"Traces":         0.0,
+			"Kept":           0.0,
+			"Evicted":        0.0,
+			"LateSpans":      0.0,
+			"BufferedTraces": 0.0,
+			"BufferedSpans":  0.0,
+		})
+
+	conf := config.New()
+	conf.TailSampling.Enabled = true
+	ts := sampler.NewTailSampler(conf, sampler.NewMetrics(&statsd.NoOpClient{}), &statsd.NoOpClient{}, func([]sampler.TailSampledChunk) {})
+	now := time.Now()
+	ts.Add(now, &pb.TracerPayload{}, &pb.TraceChunk{Spans: []*pb.Span{{TraceID: 1, SpanID: 1}}})
+	ts.Add(now, &pb.TracerPayload{}, &pb.TraceChunk{Spans: []*pb.Span{{TraceID: 2, SpanID: 1}, {TraceID: 2, SpanID: 2, ParentID: 1}}})
+	SetTailSampler(ts)
+	defer SetTailSampler(nil)
+
+	testExpvarPublish(t, publishTailSamplerInfo,
+		map[string]interface{}{
+			"Traces":         0.0,
+			"Kept":           0.0,
+			"Evicted":        0.0,
+			"LateSpans":      0.0,
+			"BufferedTraces": 2.0,
+			"BufferedSpans":  3.0,
+		})
+}
+
 func TestScrubCreds(t *testing.T) {
 	assert := assert.New(t)
 	conf := testInit(t)
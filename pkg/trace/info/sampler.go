@@ -0,0 +1,26 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package info
+
+import (
+	"github.com/DataDog/datadog-agent/pkg/trace/sampler"
+)
+
+// SetTailSampler sets the tail sampler whose statistics are published.
+func SetTailSampler(ts *sampler.TailSampler) {
+	infoMu.Lock()
+	defer infoMu.Unlock()
+	tailSampler = ts
+}
+
+func publishTailSamplerInfo() interface{} {
+	infoMu.RLock()
+	defer infoMu.RUnlock()
+	if tailSampler == nil {
+		return sampler.TailSamplerStats{}
+	}
+	return tailSampler.Stats()
+}
@@ -283,6 +283,14 @@ type SpansMalformed struct {
 	InvalidDuration atomic.Int64
 	// InvalidHTTPStatusCode is when a span's metadata contains an invalid http status code
 	InvalidHTTPStatusCode atomic.Int64
+	// ResourceTruncate is when a span's Resource is truncated for exceeding the max length
+	ResourceTruncate atomic.Int64
+	// MetaKeyTruncate is when a span's meta key is truncated for exceeding the max length
+	MetaKeyTruncate atomic.Int64
+	// MetaValueTruncate is when a span's meta value is truncated for exceeding the max length
+	MetaValueTruncate atomic.Int64
+	// MetricsKeyTruncate is when a span's metrics key is truncated for exceeding the max length
+	MetricsKeyTruncate atomic.Int64
 }
 
 func (s *SpansMalformed) tagCounters() map[string]*atomic.Int64 {
@@ -303,6 +311,10 @@ func (s *SpansMalformed) tagCounters() map[string]*atomic.Int64 {
 		"invalid_start_date":       &s.InvalidStartDate,
 		"invalid_duration":         &s.InvalidDuration,
 		"invalid_http_status_code": &s.InvalidHTTPStatusCode,
+		"resource_truncate":        &s.ResourceTruncate,
+		"meta_key_truncate":        &s.MetaKeyTruncate,
+		"meta_value_truncate":      &s.MetaValueTruncate,
+		"metrics_key_truncate":     &s.MetricsKeyTruncate,
 	}
 }
 
@@ -448,6 +460,10 @@ func (s *Stats) update(recent *Stats) {
 	s.SpansMalformed.InvalidStartDate.Add(recent.SpansMalformed.InvalidStartDate.Load())
 	s.SpansMalformed.InvalidDuration.Add(recent.SpansMalformed.InvalidDuration.Load())
 	s.SpansMalformed.InvalidHTTPStatusCode.Add(recent.SpansMalformed.InvalidHTTPStatusCode.Load())
+	s.SpansMalformed.ResourceTruncate.Add(recent.SpansMalformed.ResourceTruncate.Load())
+	s.SpansMalformed.MetaKeyTruncate.Add(recent.SpansMalformed.MetaKeyTruncate.Load())
+	s.SpansMalformed.MetaValueTruncate.Add(recent.SpansMalformed.MetaValueTruncate.Load())
+	s.SpansMalformed.MetricsKeyTruncate.Add(recent.SpansMalformed.MetricsKeyTruncate.Load())
 	s.TracesFiltered.Add(recent.TracesFiltered.Load())
 	s.TracesPriorityNone.Add(recent.TracesPriorityNone.Load())
 	s.ClientDroppedP0Traces.Add(recent.ClientDroppedP0Traces.Load())
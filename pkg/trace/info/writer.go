@@ -26,6 +26,10 @@ type TraceWriterInfo struct {
 	Bytes             atomic.Int64
 	BytesUncompressed atomic.Int64
 	SingleMaxSize     atomic.Int64
+	DiskStored        atomic.Int64 // payloads stored in the disk retry queue
+	DiskReplayed      atomic.Int64 // payloads sent again from the disk retry queue
+	DiskEvicted       atomic.Int64 // payloads removed from the full disk retry queue
+	DiskBytes         atomic.Int64 // disk space used by the disk retry queue
 }
 
 // StatsWriterInfo represents statistics from the stats writer.
@@ -42,6 +46,10 @@ type StatsWriterInfo struct {
 	Retries        atomic.Int64
 	Splits         atomic.Int64
 	Bytes          atomic.Int64
+	DiskStored     atomic.Int64 // payloads stored in the disk retry queue
+	DiskReplayed   atomic.Int64 // payloads sent again from the disk retry queue
+	DiskEvicted    atomic.Int64 // payloads removed from the full disk retry queue
+	DiskBytes      atomic.Int64 // disk space used by the disk retry queue
 }
 
 // UpdateTraceWriterInfo updates internal trace writer stats
@@ -69,6 +77,10 @@ func (twi TraceWriterInfo) MarshalJSON() ([]byte, error) {
 		"Bytes":             float64(twi.Bytes.Load()),
 		"BytesUncompressed": float64(twi.BytesUncompressed.Load()),
 		"SingleMaxSize":     float64(twi.SingleMaxSize.Load()),
+		"DiskStored":        float64(twi.DiskStored.Load()),
+		"DiskReplayed":      float64(twi.DiskReplayed.Load()),
+		"DiskEvicted":       float64(twi.DiskEvicted.Load()),
+		"DiskBytes":         float64(twi.DiskBytes.Load()),
 	}
 	return json.Marshal(asMap)
 }
@@ -97,6 +109,10 @@ func (swi StatsWriterInfo) MarshalJSON() ([]byte, error) {
 		"Retries":        float64(swi.Retries.Load()),
 		"Splits":         float64(swi.Splits.Load()),
 		"Bytes":          float64(swi.Bytes.Load()),
+		"DiskStored":     float64(swi.DiskStored.Load()),
+		"DiskReplayed":   float64(swi.DiskReplayed.Load()),
+		"DiskEvicted":    float64(swi.DiskEvicted.Load()),
+		"DiskBytes":      float64(swi.DiskBytes.Load()),
 	}
 	return json.Marshal(asMap)
 }
@@ -21,6 +21,10 @@ func TestPublishTraceWriterInfo(t *testing.T) {
 		atom(7),
 		atom(8),
 		atom(9),
+		atom(10),
+		atom(11),
+		atom(12),
+		atom(13),
 	}
 
 	testExpvarPublish(t, publishTraceWriterInfo,
@@ -35,6 +39,10 @@ func TestPublishTraceWriterInfo(t *testing.T) {
 			"Bytes":             7.0,
 			"BytesUncompressed": 8.0,
 			"SingleMaxSize":     9.0,
+			"DiskStored":        10.0,
+			"DiskReplayed":      11.0,
+			"DiskEvicted":       12.0,
+			"DiskBytes":         13.0,
 		})
 }
 
@@ -49,6 +57,10 @@ func TestPublishStatsWriterInfo(t *testing.T) {
 		atom(6),
 		atom(7),
 		atom(8),
+		atom(9),
+		atom(10),
+		atom(11),
+		atom(12),
 	}
 
 	testExpvarPublish(t, publishStatsWriterInfo,
@@ -62,6 +74,10 @@ func TestPublishStatsWriterInfo(t *testing.T) {
 			"Retries":        6.0,
 			"Splits":         7.0,
 			"Bytes":          8.0,
+			"DiskStored":     9.0,
+			"DiskReplayed":   10.0,
+			"DiskEvicted":    11.0,
+			"DiskBytes":      12.0,
 		})
 }
 
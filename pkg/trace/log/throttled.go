@@ -51,6 +51,11 @@ func (tl *ThrottledLogger) Error(format string, params ...interface{}) {
 	tl.log(Errorf, format, params...)
 }
 
+// Debug logs the message at the debug level.
+func (tl *ThrottledLogger) Debug(format string, params ...interface{}) {
+	tl.log(Debugf, format, params...)
+}
+
 // Warn logs the message at the warning level.
 func (tl *ThrottledLogger) Warn(format string, params ...interface{}) {
 	tl.log(Warnf, format, params...)
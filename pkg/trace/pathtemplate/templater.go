@@ -0,0 +1,283 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// Package pathtemplate replaces the URL paths in the resource of HTTP spans with templates
+// learned from the paths observed for each service, such as /users/{id}/orders/{id}.
+package pathtemplate
+
+import (
+	"strings"
+	"sync"
+
+	pb "github.com/DataDog/datadog-agent/pkg/proto/pbgo/trace"
+	"github.com/DataDog/datadog-agent/pkg/trace/config"
+)
+
+const (
+	// placeholder replaces the path segments identified as variable.
+	placeholder = "{id}"
+	// maxServices is the maximum number of services for which templates are learned. The
+	// paths of the other services only have their identifiers replaced.
+	maxServices = 1000
+	// maxSegments is the maximum number of path segments templated, the others are kept.
+	maxSegments = 32
+)
+
+// Templater rewrites the resource of the HTTP spans without an http.route tag, replacing
+// their URL path with a template. Path segments are replaced with a placeholder when they
+// look like identifiers (numbers, UUIDs, hashes), or when the segments following the same
+// prefix take too many values. Templater is safe for concurrent use.
+type Templater struct {
+	threshold int // maximum number of literal values following a prefix
+	maxNodes  int // maximum number of nodes of the tree of each service
+
+	mu       sync.RWMutex // guards services
+	services map[string]*tree
+}
+
+// NewTemplater returns a Templater configured by conf.
+func NewTemplater(conf *config.AgentConfig) *Templater {
+	return &Templater{
+		threshold: conf.RouteTemplating.CardinalityThreshold,
+		maxNodes:  conf.RouteTemplating.MaxNodes,
+		services:  make(map[string]*tree),
+	}
+}
+
+// TemplateResource replaces the URL path of the resource of span with its template, if span
+// is an HTTP span of the form "METHOD /path" without an http.route tag.
+func (t *Templater) TemplateResource(span *pb.Span) {
+	if !isHTTP(span) {
+		return
+	}
+	if _, ok := span.Meta["http.route"]; ok {
+		return
+	}
+	method, path, ok := splitResource(span.Resource)
+	if !ok {
+		return
+	}
+	if template := t.template(span.Service, path); template != path {
+		span.Resource = method + template
+	}
+}
+
+// template returns the template of path for service.
+func (t *Templater) template(service, path string) string {
+	segments := strings.Split(path[1:], "/")
+	for i, s := range segments {
+		// segments made of a single "?" were already replaced by the tracer
+		if j := strings.IndexByte(s, '?'); j >= 0 && s != "?" {
+			// drop the query string
+			segments = append(segments[:i], s[:j])
+			break
+		}
+	}
+	if len(segments) > maxSegments {
+		segments = segments[:maxSegments]
+	}
+	if tr := t.tree(service); tr != nil {
+		tr.match(segments, t.threshold, t.maxNodes)
+	} else {
+		replaceIdentifiers(segments)
+	}
+	return "/" + strings.Join(segments, "/")
+}
+
+// tree returns the tree of service, or nil if the maximum number of services is reached.
+func (t *Templater) tree(service string) *tree {
+	t.mu.RLock()
+	tr, ok := t.services[service]
+	t.mu.RUnlock()
+	if ok {
+		return tr
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if tr, ok := t.services[service]; ok {
+		return tr
+	}
+	if len(t.services) >= maxServices {
+		return nil
+	}
+	tr = &tree{root: newNode(), nodes: 1}
+	t.services[service] = tr
+	return tr
+}
+
+// tree holds the path segments observed for a service, one node per distinct prefix.
+type tree struct {
+	mu    sync.Mutex
+	root  *node
+	nodes int
+}
+
+// node is a path prefix of a tree.
+type node struct {
+	children  map[string]*node // children by literal segment
+	variable  *node            // child of the segments replaced with the placeholder
+	collapsed bool             // all the segments following the prefix are replaced
+}
+
+func newNode() *node {
+	return &node{children: make(map[string]*node)}
+}
+
+// match walks the tree along segments, learning them, and replaces in segments the
+// variable ones with the placeholder.
+func (tr *tree) match(segments []string, threshold, maxNodes int) {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	n := tr.root
+	for i, s := range segments {
+		if !n.collapsed && !isIdentifier(s) {
+			if child, ok := n.children[s]; ok {
+				n = child
+				continue
+			}
+			switch {
+			case len(n.children) >= threshold:
+				// too many values follow this prefix: from now on they are all variable
+				n.collapsed = true
+				tr.nodes -= n.removeChildren()
+			case tr.nodes < maxNodes:
+				child := newNode()
+				n.children[s] = child
+				tr.nodes++
+				n = child
+				continue
+			default:
+				// the tree is full, the rest of the path is kept as is
+				replaceIdentifiers(segments[i:])
+				return
+			}
+		}
+		segments[i] = placeholder
+		if n.variable == nil {
+			if tr.nodes >= maxNodes {
+				// the tree is full, the rest of the path is kept as is
+				replaceIdentifiers(segments[i+1:])
+				return
+			}
+			n.variable = newNode()
+			tr.nodes++
+		}
+		n = n.variable
+	}
+}
+
+// replaceIdentifiers replaces in segments the identifiers with the placeholder.
+func replaceIdentifiers(segments []string) {
+	for i, s := range segments {
+		if isIdentifier(s) {
+			segments[i] = placeholder
+		}
+	}
+}
+
+// removeChildren removes the literal children of n and returns the number of nodes removed.
+func (n *node) removeChildren() int {
+	removed := 0
+	for s, child := range n.children {
+		removed += 1 + child.size()
+		delete(n.children, s)
+	}
+	return removed
+}
+
+// size returns the number of descendants of n.
+func (n *node) size() int {
+	size := 0
+	for _, child := range n.children {
+		size += 1 + child.size()
+	}
+	if n.variable != nil {
+		size += 1 + n.variable.size()
+	}
+	return size
+}
+
+// isHTTP reports whether span is an HTTP span.
+func isHTTP(span *pb.Span) bool {
+	switch span.Type {
+	case "web", "http":
+		return true
+	}
+	if _, ok := span.Meta["http.method"]; ok {
+		return true
+	}
+	_, ok := span.Meta["http.request.method"]
+	return ok
+}
+
+// splitResource splits a resource of the form "METHOD /path" or "/path" into the method,
+// including the trailing space, and the path.
+func splitResource(resource string) (method, path string, ok bool) {
+	i := strings.IndexByte(resource, '/')
+	switch {
+	case i == 0:
+		return "", resource, true
+	case i < 2 || resource[i-1] != ' ':
+		return "", "", false
+	}
+	for _, c := range resource[:i-1] {
+		if c < 'A' || c > 'Z' {
+			return "", "", false
+		}
+	}
+	return resource[:i], resource[i:], true
+}
+
+// isIdentifier reports whether the path segment s looks like an identifier: a number, a
+// UUID, a hexadecimal hash or a long token mixing letters and digits.
+func isIdentifier(s string) bool {
+	if s == "" {
+		return false
+	}
+	var digits, other int
+	for _, c := range s {
+		switch {
+		case c >= '0' && c <= '9':
+			digits++
+		case c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F':
+		case c == '-' || c == '_' || c >= 'g' && c <= 'z' || c >= 'G' && c <= 'Z':
+			other++
+		default:
+			return false
+		}
+	}
+	switch {
+	case digits == len(s):
+		return true
+	case digits == 0:
+		return false
+	case isUUID(s):
+		return true
+	case other == 0 && len(s) >= 8:
+		// hexadecimal hash
+		return true
+	}
+	return len(s) >= 20
+}
+
+// isUUID reports whether s is a UUID such as 123e4567-e89b-12d3-a456-426614174000.
+func isUUID(s string) bool {
+	if len(s) != 36 {
+		return false
+	}
+	for i, c := range s {
+		switch i {
+		case 8, 13, 18, 23:
+			if c != '-' {
+				return false
+			}
+		default:
+			if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F') {
+				return false
+			}
+		}
+	}
+	return true
+}
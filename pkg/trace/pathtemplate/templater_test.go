@@ -0,0 +1,118 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package pathtemplate
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	pb "github.com/DataDog/datadog-agent/pkg/proto/pbgo/trace"
+	"github.com/DataDog/datadog-agent/pkg/trace/config"
+)
+
+func newTestTemplater(threshold, maxNodes int) *Templater {
+	conf := config.New()
+	conf.RouteTemplating.Enabled = true
+	conf.RouteTemplating.CardinalityThreshold = threshold
+	conf.RouteTemplating.MaxNodes = maxNodes
+	return NewTemplater(conf)
+}
+
+func templateResource(t *Templater, service, resource string) string {
+	span := &pb.Span{Service: service, Type: "web", Resource: resource}
+	t.TemplateResource(span)
+	return span.Resource
+}
+
+func TestIsIdentifier(t *testing.T) {
+	for s, want := range map[string]bool{
+		"123":                                  true,
+		"123e4567-e89b-12d3-a456-426614174000": true,
+		"8f1c2b3ad4e5":                         true,
+		"01HZX3K4Q5RJ7Y8W9V0T1S2A3B":           true,
+		"users":                                false,
+		"v1":                                   false,
+		"8f1c":                                 false,
+		"deadbeef":                             false,
+		"order-12345":                          false,
+		"index.html":                           false,
+		"":                                     false,
+	} {
+		assert.Equal(t, want, isIdentifier(s), s)
+	}
+}
+
+func TestTemplateResource(t *testing.T) {
+	tt := newTestTemplater(50, 5000)
+	for resource, want := range map[string]string{
+		"GET /users/8f1c2b3a-1d2e-4f5a-8b9c-0d1e2f3a4b5c/orders/123": "GET /users/{id}/orders/{id}",
+		"GET /users/123/orders?page=2":                               "GET /users/{id}/orders",
+		"GET /users/?/orders":                                        "GET /users/?/orders",
+		"/files/abc123def4567890":                                    "/files/{id}",
+		"GET /health":                                                "GET /health",
+		"GET /":                                                      "GET /",
+		"GET":                                                        "GET",
+		"SELECT * FROM users":                                        "SELECT * FROM users",
+		"get /users/123":                                             "get /users/123",
+	} {
+		assert.Equal(t, want, templateResource(tt, "api", resource), resource)
+	}
+
+	t.Run("not-http", func(t *testing.T) {
+		span := &pb.Span{Service: "api", Type: "db", Resource: "GET /users/123"}
+		tt.TemplateResource(span)
+		assert.Equal(t, "GET /users/123", span.Resource)
+
+		span.Meta = map[string]string{"http.method": "GET"}
+		tt.TemplateResource(span)
+		assert.Equal(t, "GET /users/{id}", span.Resource)
+	})
+
+	t.Run("http.route", func(t *testing.T) {
+		span := &pb.Span{Service: "api", Type: "web", Resource: "GET /users/123", Meta: map[string]string{"http.route": "/users/:id"}}
+		tt.TemplateResource(span)
+		assert.Equal(t, "GET /users/123", span.Resource)
+	})
+}
+
+func TestTemplateResourceCardinality(t *testing.T) {
+	tt := newTestTemplater(3, 5000)
+	for _, name := range []string{"alice", "bob", "carol"} {
+		assert.Equal(t, "GET /users/"+name+"/profile", templateResource(tt, "api", "GET /users/"+name+"/profile"))
+	}
+	// the fourth distinct value makes the segment variable
+	assert.Equal(t, "GET /users/{id}/profile", templateResource(tt, "api", "GET /users/dave/profile"))
+	assert.Equal(t, "GET /users/{id}/profile", templateResource(tt, "api", "GET /users/alice/profile"))
+	assert.Equal(t, 4, tt.services["api"].nodes, "the subtrees of the collapsed segments must be removed")
+
+	// other prefixes and services are learned separately
+	assert.Equal(t, "GET /teams/alice", templateResource(tt, "api", "GET /teams/alice"))
+	assert.Equal(t, "GET /users/dave/profile", templateResource(tt, "web", "GET /users/dave/profile"))
+}
+
+func TestTemplateResourceBounds(t *testing.T) {
+	t.Run("nodes", func(t *testing.T) {
+		tt := newTestTemplater(1000, 4)
+		assert.Equal(t, "GET /a/b/c", templateResource(tt, "api", "GET /a/b/c"))
+		// the tree is full: unknown segments are kept, only identifiers are replaced
+		assert.Equal(t, "GET /a/b/d", templateResource(tt, "api", "GET /a/b/d"))
+		assert.Equal(t, "GET /x/b/{id}", templateResource(tt, "api", "GET /x/b/123"))
+		assert.Equal(t, "GET /a/{id}/c", templateResource(tt, "api", "GET /a/123/c"))
+		assert.Equal(t, 4, tt.services["api"].nodes)
+	})
+
+	t.Run("services", func(t *testing.T) {
+		tt := newTestTemplater(1, 5000)
+		for i := 0; i < maxServices; i++ {
+			templateResource(tt, fmt.Sprintf("service-%d", i), "GET /users")
+		}
+		// no more learning, only identifiers are replaced
+		assert.Equal(t, "GET /users/alice/{id}", templateResource(tt, "other", "GET /users/alice/123"))
+		assert.Len(t, tt.services, maxServices)
+	})
+}
@@ -0,0 +1,426 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package sampler
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	pb "github.com/DataDog/datadog-agent/pkg/proto/pbgo/trace"
+	"github.com/DataDog/datadog-agent/pkg/trace/config"
+	"github.com/DataDog/datadog-agent/pkg/trace/traceutil"
+	"github.com/DataDog/datadog-agent/pkg/trace/watchdog"
+	"github.com/DataDog/datadog-go/v5/statsd"
+	"go.uber.org/atomic"
+)
+
+const (
+	// KeyTailSamplingPolicy is the chunk tag holding the name of the policy which kept a trace in the tail sampler.
+	KeyTailSamplingPolicy = "_dd.tail_sampling.policy"
+
+	// MetricTailSamplerTraces is the metric name for the number of traces decided by the tail sampler.
+	MetricTailSamplerTraces = "datadog.trace_agent.tail_sampler.traces"
+	// MetricTailSamplerEvicted is the metric name for the number of traces decided before the end of
+	// their decision window to stay within the memory bounds of the tail sampler.
+	MetricTailSamplerEvicted = "datadog.trace_agent.tail_sampler.evicted"
+	// MetricTailSamplerLateSpans is the metric name for the number of spans received after the decision on their trace.
+	MetricTailSamplerLateSpans = "datadog.trace_agent.tail_sampler.late_spans"
+	// MetricTailSamplerBufferedTraces is the metric name for the number of traces waiting for a decision.
+	MetricTailSamplerBufferedTraces = "datadog.trace_agent.tail_sampler.buffered_traces"
+	// MetricTailSamplerBufferedSpans is the metric name for the number of spans waiting for a decision.
+	MetricTailSamplerBufferedSpans = "datadog.trace_agent.tail_sampler.buffered_spans"
+
+	// tailSamplingManualPolicy is the policy reported for the traces kept manually by a tracer.
+	tailSamplingManualPolicy = "manual"
+	// tailSamplingHeadPolicy is the policy reported for the chunks of the traces kept by the other samplers.
+	tailSamplingHeadPolicy = "head"
+	// tailSamplerTick is the frequency at which the traces are decided at the end of their decision window.
+	tailSamplerTick = time.Second
+)
+
+// TailSampledChunk is a chunk kept by the tail sampler, with the payload it was received in.
+type TailSampledChunk struct {
+	// Payload holds the attributes of the tracer payload, its chunks are not set.
+	Payload *pb.TracerPayload
+	Chunk   *pb.TraceChunk
+}
+
+// TailSamplerStats holds statistics from the tail sampler.
+type TailSamplerStats struct {
+	// Traces is the number of traces on which a decision was made.
+	Traces int64
+	// Kept is the number of traces kept.
+	Kept int64
+	// Evicted is the number of traces decided before the end of their decision
+	// window to stay within the memory bounds.
+	Evicted int64
+	// LateSpans is the number of spans received after the decision on their trace.
+	LateSpans int64
+	// BufferedTraces is the number of traces waiting for a decision.
+	BufferedTraces int64
+	// BufferedSpans is the number of spans waiting for a decision.
+	BufferedSpans int64
+}
+
+// TailSampler buffers the chunks of each trace during a decision window, then keeps or drops the
+// trace as a whole, so that the chunks of a trace sent by different tracers, in different payloads,
+// get the same decision. A trace is kept if one of the policies matches it, if a tracer kept it
+// manually, or if the other samplers kept one of its chunks (see KeepTrace).
+//
+// A decided trace is remembered for another decision window: its late chunks are kept or
+// dropped right away, like the rest of the trace.
+type TailSampler struct {
+	decisionWait time.Duration
+	maxTraces    int
+	maxSpans     int
+	policies     []*tailPolicy
+	metrics      *Metrics
+	statsd       statsd.ClientInterface
+	// write is called with the chunks of the traces kept.
+	write func([]TailSampledChunk)
+
+	mu     sync.Mutex
+	traces map[uint64]*tailTrace
+	// queue orders the traces by deadline. Pending traces are decided at their deadline,
+	// decided traces are forgotten.
+	queue *list.List
+	// pending and spans count the traces and spans waiting for a decision.
+	pending int
+	spans   int
+	// kept counts the traces kept by each policy since the last report.
+	kept    map[string]int64
+	dropped int64
+
+	// decided, keptTotal, evicted and lateSpans are counters since the start of the tail sampler.
+	decided   atomic.Int64
+	keptTotal atomic.Int64
+	evicted   atomic.Int64
+	lateSpans atomic.Int64
+	// reportedEvicted and reportedLateSpans are the values of the counters at the last report.
+	reportedEvicted   int64
+	reportedLateSpans int64
+
+	exit chan struct{}
+	done chan struct{}
+}
+
+type tailTrace struct {
+	id       uint64
+	deadline time.Time
+	elem     *list.Element
+	chunks   []TailSampledChunk
+	spans    int
+	decided  bool
+	// policy is the name of the policy which kept the trace, empty if it was dropped.
+	policy string
+}
+
+type tailPolicy struct {
+	*config.TailSamplingPolicy
+	// threshold is the latency threshold in nanoseconds.
+	threshold int64
+	// sampler accounts the traces of each service to keep TargetTPS traces per second.
+	sampler *Sampler
+}
+
+// NewTailSampler returns a TailSampler configured by conf. It calls write with the chunks of
+// the traces it keeps.
+func NewTailSampler(conf *config.AgentConfig, metrics *Metrics, statsd statsd.ClientInterface, write func([]TailSampledChunk)) *TailSampler {
+	s := &TailSampler{
+		decisionWait: conf.TailSampling.DecisionWait,
+		maxTraces:    conf.TailSampling.MaxTraces,
+		maxSpans:     conf.TailSampling.MaxSpans,
+		metrics:      metrics,
+		statsd:       statsd,
+		write:        write,
+		traces:       make(map[uint64]*tailTrace),
+		queue:        list.New(),
+		kept:         make(map[string]int64),
+		exit:         make(chan struct{}),
+		done:         make(chan struct{}),
+	}
+	for _, p := range conf.TailSampling.Policies {
+		policy := &tailPolicy{
+			TailSamplingPolicy: p,
+			threshold:          int64(p.LatencyThresholdMs * float64(time.Millisecond)),
+		}
+		if p.Type == config.TailSamplingPolicyRate && p.SampleRate == 0 {
+			policy.sampler = newSampler(conf.ExtraSampleRate, p.TargetTPS)
+		}
+		s.policies = append(s.policies, policy)
+	}
+	return s
+}
+
+// Start starts deciding the traces at the end of their decision window.
+func (s *TailSampler) Start() {
+	go func() {
+		defer watchdog.LogOnPanic(s.statsd)
+		defer close(s.done)
+		ticker := time.NewTicker(tailSamplerTick)
+		defer ticker.Stop()
+		for {
+			select {
+			case now := <-ticker.C:
+				s.flush(now, false)
+			case <-s.exit:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops the tail sampler, deciding the traces still buffered.
+func (s *TailSampler) Stop() {
+	close(s.exit)
+	<-s.done
+	s.flush(time.Now(), true)
+}
+
+// Add buffers a chunk until the decision on its trace. payload holds the attributes of the
+// tracer payload the chunk was received in. The chunk must not be modified afterwards.
+func (s *TailSampler) Add(now time.Time, payload *pb.TracerPayload, chunk *pb.TraceChunk) {
+	if len(chunk.Spans) == 0 {
+		return
+	}
+	c := TailSampledChunk{Payload: payload, Chunk: chunk}
+	id := chunk.Spans[0].TraceID
+
+	s.mu.Lock()
+	t, ok := s.traces[id]
+	if ok && t.decided {
+		policy := t.policy
+		s.mu.Unlock()
+		s.lateSpans.Add(int64(len(chunk.Spans)))
+		if policy != "" {
+			keepChunk(c.Chunk, policy)
+			s.write([]TailSampledChunk{c})
+		}
+		return
+	}
+	if !ok {
+		t = &tailTrace{id: id, deadline: now.Add(s.decisionWait)}
+		t.elem = s.queue.PushBack(t)
+		s.traces[id] = t
+		s.pending++
+	}
+	t.chunks = append(t.chunks, c)
+	t.spans += len(chunk.Spans)
+	s.spans += len(chunk.Spans)
+
+	// the oldest traces are decided early to stay within the memory bounds
+	var kept []TailSampledChunk
+	for (s.pending > s.maxTraces || s.spans > s.maxSpans) && s.queue.Len() > 0 {
+		t := s.queue.Front().Value.(*tailTrace)
+		if !t.decided {
+			kept = s.decide(now, t, kept)
+			s.evicted.Inc()
+		}
+		s.forget(t)
+	}
+	s.mu.Unlock()
+
+	if len(kept) > 0 {
+		s.write(kept)
+	}
+}
+
+// KeepTrace keeps the trace of the given ID, a chunk of which was kept by the other samplers, so that
+// all its chunks are kept: the ones buffered are written right away, and the ones received during the
+// next decision window are kept too.
+func (s *TailSampler) KeepTrace(now time.Time, id uint64) {
+	s.mu.Lock()
+	t, ok := s.traces[id]
+	switch {
+	case !ok:
+		t = &tailTrace{id: id, decided: true, policy: tailSamplingHeadPolicy, deadline: now.Add(s.decisionWait)}
+		t.elem = s.queue.PushBack(t)
+		s.traces[id] = t
+		s.mu.Unlock()
+		return
+	case t.decided:
+		// the chunks of a trace dropped earlier are lost, but the next ones are kept
+		if t.policy == "" {
+			t.policy = tailSamplingHeadPolicy
+		}
+		s.mu.Unlock()
+		return
+	}
+	s.decided.Inc()
+	s.keptTotal.Inc()
+	s.kept[tailSamplingHeadPolicy]++
+	kept := make([]TailSampledChunk, 0, len(t.chunks))
+	for _, c := range t.chunks {
+		keepChunk(c.Chunk, tailSamplingHeadPolicy)
+		kept = append(kept, c)
+	}
+	s.settle(now, t, tailSamplingHeadPolicy)
+	s.mu.Unlock()
+
+	s.write(kept)
+}
+
+// flush decides the traces at the end of their decision window, or all the pending traces if all is
+// true, and forgets the traces decided a decision window ago.
+func (s *TailSampler) flush(now time.Time, all bool) {
+	var kept []TailSampledChunk
+	s.mu.Lock()
+	for e := s.queue.Front(); e != nil; {
+		t := e.Value.(*tailTrace)
+		if !all && t.deadline.After(now) {
+			break
+		}
+		e = e.Next()
+		if t.decided {
+			s.forget(t)
+		} else {
+			kept = s.decide(now, t, kept)
+		}
+	}
+	s.mu.Unlock()
+
+	if len(kept) > 0 {
+		s.write(kept)
+	}
+}
+
+// decide keeps or drops the pending trace t, appending its chunks to kept if it's kept.
+// A caller of decide must hold a lock on s.mu.
+func (s *TailSampler) decide(now time.Time, t *tailTrace, kept []TailSampledChunk) []TailSampledChunk {
+	var spans []*pb.Span
+	policy := ""
+	for _, c := range t.chunks {
+		spans = append(spans, c.Chunk.Spans...)
+		if priority, ok := GetSamplingPriority(c.Chunk); ok && priority == PriorityUserKeep {
+			policy = tailSamplingManualPolicy
+		}
+	}
+	root := traceutil.GetRoot(spans)
+	env := t.chunks[0].Payload.Env
+	if policy == "" {
+		for _, p := range s.policies {
+			if p.match(now, spans, root, env) {
+				policy = p.Name
+				break
+			}
+		}
+	}
+
+	s.decided.Inc()
+	if policy != "" {
+		s.keptTotal.Inc()
+		s.kept[policy]++
+		for _, c := range t.chunks {
+			keepChunk(c.Chunk, policy)
+			kept = append(kept, c)
+		}
+	} else {
+		s.dropped++
+	}
+	s.metrics.RecordMetricsKey(policy != "", NewMetricsKey(root.Service, env, NameTail, PriorityNone))
+	s.settle(now, t, policy)
+	return kept
+}
+
+// settle marks the pending trace t as decided, remembering it for another decision window.
+// A caller of settle must hold a lock on s.mu.
+func (s *TailSampler) settle(now time.Time, t *tailTrace, policy string) {
+	s.pending--
+	s.spans -= t.spans
+	t.chunks, t.spans = nil, 0
+	t.decided, t.policy = true, policy
+	t.deadline = now.Add(s.decisionWait)
+	s.queue.MoveToBack(t.elem)
+}
+
+// forget removes the trace t. A caller of forget must hold a lock on s.mu.
+func (s *TailSampler) forget(t *tailTrace) {
+	s.queue.Remove(t.elem)
+	delete(s.traces, t.id)
+}
+
+func keepChunk(chunk *pb.TraceChunk, policy string) {
+	chunk.DroppedTrace = false
+	if chunk.Tags == nil {
+		chunk.Tags = make(map[string]string)
+	}
+	chunk.Tags[KeyTailSamplingPolicy] = policy
+}
+
+// match reports whether the policy keeps the trace made of spans.
+func (p *tailPolicy) match(now time.Time, spans []*pb.Span, root *pb.Span, env string) bool {
+	if p.Type == config.TailSamplingPolicyRate {
+		if p.Service != "" && root.Service != p.Service {
+			return false
+		}
+		rate := p.SampleRate
+		if p.sampler != nil {
+			signature := ServiceSignature{Name: root.Service, Env: env}.Hash()
+			p.sampler.countWeightedSig(now, signature, weightRoot(root))
+			rate = p.sampler.getSignatureSampleRate(signature)
+		}
+		return SampleByRate(root.TraceID, rate)
+	}
+	for _, span := range spans {
+		if p.Service != "" && span.Service != p.Service {
+			continue
+		}
+		switch p.Type {
+		case config.TailSamplingPolicyLatency:
+			if span.Duration >= p.threshold {
+				return true
+			}
+		case config.TailSamplingPolicyError:
+			if span.Error != 0 {
+				return true
+			}
+		case config.TailSamplingPolicyAttribute:
+			if v, ok := span.Meta[p.Key]; ok && (p.Value == "" || v == p.Value) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+var _ AdditionalMetricsReporter = (*TailSampler)(nil)
+
+func (s *TailSampler) report(statsd statsd.ClientInterface) {
+	s.mu.Lock()
+	kept, dropped := s.kept, s.dropped
+	s.kept, s.dropped = make(map[string]int64), 0
+	pending, spans := s.pending, s.spans
+	s.mu.Unlock()
+
+	for policy, count := range kept {
+		_ = statsd.Count(MetricTailSamplerTraces, count, []string{"decision:keep", "policy:" + policy}, 1)
+	}
+	if dropped > 0 {
+		_ = statsd.Count(MetricTailSamplerTraces, dropped, []string{"decision:drop"}, 1)
+	}
+	evicted, lateSpans := s.evicted.Load(), s.lateSpans.Load()
+	_ = statsd.Count(MetricTailSamplerEvicted, evicted-s.reportedEvicted, nil, 1)
+	_ = statsd.Count(MetricTailSamplerLateSpans, lateSpans-s.reportedLateSpans, nil, 1)
+	s.reportedEvicted, s.reportedLateSpans = evicted, lateSpans
+	_ = statsd.Gauge(MetricTailSamplerBufferedTraces, float64(pending), nil, 1)
+	_ = statsd.Gauge(MetricTailSamplerBufferedSpans, float64(spans), nil, 1)
+}
+
+// Stats returns statistics about the traces decided by the tail sampler since it was created.
+func (s *TailSampler) Stats() TailSamplerStats {
+	s.mu.Lock()
+	pending, spans := s.pending, s.spans
+	s.mu.Unlock()
+	return TailSamplerStats{
+		Traces:         s.decided.Load(),
+		Kept:           s.keptTotal.Load(),
+		Evicted:        s.evicted.Load(),
+		LateSpans:      s.lateSpans.Load(),
+		BufferedTraces: int64(pending),
+		BufferedSpans:  int64(spans),
+	}
+}
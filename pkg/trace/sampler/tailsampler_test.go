@@ -0,0 +1,225 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package sampler
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DataDog/datadog-go/v5/statsd"
+	"github.com/stretchr/testify/assert"
+
+	pb "github.com/DataDog/datadog-agent/pkg/proto/pbgo/trace"
+	"github.com/DataDog/datadog-agent/pkg/trace/config"
+)
+
+type tailSamplerWriter struct {
+	mu     sync.Mutex
+	chunks []TailSampledChunk
+}
+
+func (w *tailSamplerWriter) write(chunks []TailSampledChunk) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.chunks = append(w.chunks, chunks...)
+}
+
+// keptTraces returns the policy which kept each trace written.
+func (w *tailSamplerWriter) keptTraces() map[uint64]string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	kept := make(map[uint64]string)
+	for _, c := range w.chunks {
+		kept[c.Chunk.Spans[0].TraceID] = c.Chunk.Tags[KeyTailSamplingPolicy]
+	}
+	return kept
+}
+
+func newTestTailSampler(policies ...*config.TailSamplingPolicy) (*TailSampler, *tailSamplerWriter) {
+	conf := config.New()
+	conf.TailSampling.Enabled = true
+	conf.TailSampling.Policies = policies
+	w := &tailSamplerWriter{}
+	return NewTailSampler(conf, NewMetrics(&statsd.NoOpClient{}), &statsd.NoOpClient{}, w.write), w
+}
+
+func tailTestChunk(traceID uint64, spans ...*pb.Span) *pb.TraceChunk {
+	for _, span := range spans {
+		span.TraceID = traceID
+	}
+	return &pb.TraceChunk{Priority: int32(PriorityAutoDrop), DroppedTrace: true, Spans: spans}
+}
+
+func TestTailSamplerPolicies(t *testing.T) {
+	s, w := newTestTailSampler(
+		&config.TailSamplingPolicy{Name: "slow-api", Type: config.TailSamplingPolicyLatency, Service: "api", LatencyThresholdMs: 500},
+		&config.TailSamplingPolicy{Name: "errors", Type: config.TailSamplingPolicyError},
+		&config.TailSamplingPolicy{Name: "vip", Type: config.TailSamplingPolicyAttribute, Key: "customer.tier", Value: "gold"},
+		&config.TailSamplingPolicy{Name: "debug", Type: config.TailSamplingPolicyAttribute, Key: "debug"},
+	)
+	now := time.Now()
+	payload := &pb.TracerPayload{Env: "prod"}
+	second := int64(time.Second)
+
+	s.Add(now, payload, tailTestChunk(1, &pb.Span{Service: "api", SpanID: 1, Duration: second}))
+	s.Add(now, payload, tailTestChunk(2, &pb.Span{Service: "db", SpanID: 1, Duration: second}))
+	s.Add(now, payload, tailTestChunk(3, &pb.Span{Service: "db", SpanID: 2, ParentID: 1, Error: 1}))
+	s.Add(now, payload, tailTestChunk(4, &pb.Span{Service: "api", SpanID: 1, Meta: map[string]string{"customer.tier": "gold"}}))
+	s.Add(now, payload, tailTestChunk(5, &pb.Span{Service: "api", SpanID: 1, Meta: map[string]string{"customer.tier": "silver"}}))
+	s.Add(now, payload, tailTestChunk(6, &pb.Span{Service: "api", SpanID: 1, Meta: map[string]string{"debug": "1"}}))
+	manual := tailTestChunk(7, &pb.Span{Service: "api", SpanID: 1})
+	manual.Priority = int32(PriorityUserKeep)
+	s.Add(now, payload, manual)
+
+	s.flush(now.Add(time.Second), false)
+	assert.Empty(t, w.keptTraces(), "traces must be decided at the end of the decision window")
+
+	s.flush(now.Add(s.decisionWait), false)
+	assert.Equal(t, map[uint64]string{
+		1: "slow-api",
+		3: "errors",
+		4: "vip",
+		6: "debug",
+		7: tailSamplingManualPolicy,
+	}, w.keptTraces())
+	for _, c := range w.chunks {
+		assert.False(t, c.Chunk.DroppedTrace)
+		assert.Equal(t, payload, c.Payload)
+	}
+
+	stats := s.Stats()
+	assert.EqualValues(t, 7, stats.Traces)
+	assert.EqualValues(t, 5, stats.Kept)
+	assert.EqualValues(t, 0, stats.BufferedTraces)
+	assert.EqualValues(t, 0, stats.BufferedSpans)
+}
+
+func TestTailSamplerRatePolicy(t *testing.T) {
+	t.Run("sample-rate", func(t *testing.T) {
+		s, w := newTestTailSampler(&config.TailSamplingPolicy{Name: "all", Type: config.TailSamplingPolicyRate, Service: "api", SampleRate: 1})
+		now := time.Now()
+		for i := uint64(1); i <= 100; i++ {
+			service := "api"
+			if i%2 == 0 {
+				service = "web"
+			}
+			s.Add(now, &pb.TracerPayload{}, tailTestChunk(i, &pb.Span{Service: service, SpanID: 1}))
+		}
+		s.flush(now.Add(s.decisionWait), false)
+		kept := w.keptTraces()
+		assert.Len(t, kept, 50)
+		for id, policy := range kept {
+			assert.Equal(t, uint64(1), id%2)
+			assert.Equal(t, "all", policy)
+		}
+	})
+
+	t.Run("target-tps", func(t *testing.T) {
+		s, w := newTestTailSampler(
+			&config.TailSamplingPolicy{Name: "tps", Type: config.TailSamplingPolicyRate, TargetTPS: 1},
+		)
+		assert.NotNil(t, s.policies[0].sampler)
+		now := time.Now()
+		for i := uint64(1); i <= 1000; i++ {
+			s.Add(now, &pb.TracerPayload{}, tailTestChunk(i, &pb.Span{Service: "api", SpanID: 1}))
+		}
+		s.flush(now.Add(s.decisionWait), false)
+		kept := len(w.keptTraces())
+		assert.Greater(t, kept, 0)
+		assert.Less(t, kept, 100)
+	})
+}
+
+func TestTailSamplerLateChunks(t *testing.T) {
+	s, w := newTestTailSampler(&config.TailSamplingPolicy{Name: "errors", Type: config.TailSamplingPolicyError})
+	now := time.Now()
+	payload := &pb.TracerPayload{}
+
+	s.Add(now, payload, tailTestChunk(1, &pb.Span{SpanID: 1}))
+	s.Add(now, payload, tailTestChunk(2, &pb.Span{SpanID: 1}))
+	// chunks of the same trace are decided together
+	s.Add(now.Add(time.Second), payload, tailTestChunk(1, &pb.Span{SpanID: 2, ParentID: 1, Error: 1}))
+	s.flush(now.Add(s.decisionWait), false)
+	assert.Len(t, w.chunks, 2)
+	assert.Equal(t, map[uint64]string{1: "errors"}, w.keptTraces())
+
+	// late chunks get the decision of their trace
+	s.Add(now.Add(s.decisionWait), payload, tailTestChunk(1, &pb.Span{SpanID: 3, ParentID: 1}))
+	s.Add(now.Add(s.decisionWait), payload, tailTestChunk(2, &pb.Span{SpanID: 3, ParentID: 1, Error: 1}))
+	assert.Len(t, w.chunks, 3)
+	assert.Equal(t, map[uint64]string{1: "errors"}, w.keptTraces())
+	assert.EqualValues(t, 2, s.Stats().LateSpans)
+
+	// decided traces are forgotten after another decision window
+	s.flush(now.Add(2*s.decisionWait), false)
+	assert.Empty(t, s.traces)
+	assert.Equal(t, 0, s.queue.Len())
+}
+
+func TestTailSamplerKeepTrace(t *testing.T) {
+	s, w := newTestTailSampler()
+	now := time.Now()
+	payload := &pb.TracerPayload{}
+
+	// the buffered chunks of a trace kept by the other samplers are written right away
+	s.Add(now, payload, tailTestChunk(1, &pb.Span{SpanID: 1}))
+	s.KeepTrace(now, 1)
+	assert.Len(t, w.chunks, 1)
+	assert.Equal(t, map[uint64]string{1: "head"}, w.keptTraces())
+	assert.False(t, w.chunks[0].Chunk.DroppedTrace)
+
+	// so are its next chunks, and the ones of a trace kept before any chunk was buffered
+	s.KeepTrace(now, 2)
+	s.Add(now, payload, tailTestChunk(1, &pb.Span{SpanID: 2, ParentID: 1}))
+	s.Add(now, payload, tailTestChunk(2, &pb.Span{SpanID: 2, ParentID: 1}))
+	assert.Len(t, w.chunks, 3)
+	assert.Equal(t, map[uint64]string{1: "head", 2: "head"}, w.keptTraces())
+
+	stats := s.Stats()
+	assert.EqualValues(t, 1, stats.Traces)
+	assert.EqualValues(t, 1, stats.Kept)
+	assert.EqualValues(t, 0, stats.BufferedTraces)
+	assert.EqualValues(t, 0, stats.BufferedSpans)
+
+	s.flush(now.Add(s.decisionWait), false)
+	assert.Empty(t, s.traces)
+}
+
+func TestTailSamplerEviction(t *testing.T) {
+	s, w := newTestTailSampler(&config.TailSamplingPolicy{Name: "errors", Type: config.TailSamplingPolicyError})
+	s.maxTraces = 2
+	s.maxSpans = 4
+	now := time.Now()
+	payload := &pb.TracerPayload{}
+
+	s.Add(now, payload, tailTestChunk(1, &pb.Span{SpanID: 1, Error: 1}))
+	s.Add(now, payload, tailTestChunk(2, &pb.Span{SpanID: 1}))
+	assert.Empty(t, w.chunks)
+
+	// the oldest trace is decided early to stay within maxTraces
+	s.Add(now, payload, tailTestChunk(3, &pb.Span{SpanID: 1}))
+	assert.Equal(t, map[uint64]string{1: "errors"}, w.keptTraces())
+	assert.Equal(t, 2, s.pending)
+
+	// and to stay within maxSpans
+	s.Add(now, payload, tailTestChunk(3, &pb.Span{SpanID: 2, ParentID: 1}, &pb.Span{SpanID: 3, ParentID: 1}, &pb.Span{SpanID: 4, ParentID: 1}))
+	assert.Equal(t, 1, s.pending)
+	assert.Equal(t, 4, s.spans)
+
+	stats := s.Stats()
+	assert.EqualValues(t, 2, stats.Evicted)
+	assert.EqualValues(t, 1, stats.BufferedTraces)
+	assert.EqualValues(t, 4, stats.BufferedSpans)
+}
+
+func TestTailSamplerStop(t *testing.T) {
+	s, w := newTestTailSampler(&config.TailSamplingPolicy{Name: "errors", Type: config.TailSamplingPolicyError})
+	s.Start()
+	s.Add(time.Now(), &pb.TracerPayload{}, tailTestChunk(1, &pb.Span{SpanID: 1, Error: 1}))
+	s.Stop()
+	assert.Equal(t, map[uint64]string{1: "errors"}, w.keptTraces())
+}
@@ -0,0 +1,134 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// Package spanmetrics generates custom metrics from the spans received by the trace-agent.
+package spanmetrics
+
+import (
+	"strconv"
+
+	"github.com/DataDog/datadog-go/v5/statsd"
+
+	pb "github.com/DataDog/datadog-agent/pkg/proto/pbgo/trace"
+	"github.com/DataDog/datadog-agent/pkg/trace/config"
+	"github.com/DataDog/datadog-agent/pkg/trace/traceutil"
+)
+
+const (
+	// groupByResource and groupByOperationName are the group_by keys referring to the
+	// resource and the name of the spans.
+	groupByResource      = "resource"
+	groupByOperationName = "operation_name"
+)
+
+// Generator sends the span-derived metrics configured by the span metric rules.
+type Generator struct {
+	metrics  []*config.SpanMetric
+	agentEnv string
+	statsd   statsd.ClientInterface
+}
+
+// NewGenerator returns a Generator sending the span metrics of conf through statsd.
+func NewGenerator(conf *config.AgentConfig, statsd statsd.ClientInterface) *Generator {
+	return &Generator{
+		metrics:  conf.SpanMetrics,
+		agentEnv: conf.DefaultEnv,
+		statsd:   statsd,
+	}
+}
+
+// Process sends the metrics of the spans of pt matching the rules. It must be called before
+// pt is sampled, so that the metrics account for all the spans received.
+func (g *Generator) Process(pt *traceutil.ProcessedTrace) {
+	env := pt.TracerEnv
+	if env == "" {
+		env = g.agentEnv
+	}
+	for _, span := range pt.TraceChunk.Spans {
+		for _, m := range g.metrics {
+			if !matches(m, span) {
+				continue
+			}
+			switch m.Type {
+			case config.SpanMetricCount:
+				_ = g.statsd.Count(m.Name, 1, metricTags(m, env, span), 1)
+			case config.SpanMetricDistribution:
+				if v, ok := spanValue(span, m.Value); ok {
+					_ = g.statsd.Distribution(m.Name, v, metricTags(m, env, span), 1)
+				}
+			}
+		}
+	}
+}
+
+// matches reports whether span has the service, name, resource and tags required by m.
+func matches(m *config.SpanMetric, span *pb.Span) bool {
+	if m.Service != "" && m.Service != span.Service {
+		return false
+	}
+	if m.OperationName != "" && m.OperationName != span.Name {
+		return false
+	}
+	if m.Resource != "" && m.Resource != span.Resource {
+		return false
+	}
+	for k, want := range m.Tags {
+		v, ok := spanTag(span, k)
+		if !ok || (want != "" && want != v) {
+			return false
+		}
+	}
+	return true
+}
+
+// spanValue returns the value measured by a metric on span, which is its duration in seconds
+// or the numeric tag key.
+func spanValue(span *pb.Span, key string) (float64, bool) {
+	if key == config.SpanMetricDuration {
+		return float64(span.Duration) / 1e9, true
+	}
+	if v, ok := span.Metrics[key]; ok {
+		return v, true
+	}
+	if v, ok := span.Meta[key]; ok {
+		f, err := strconv.ParseFloat(v, 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// spanTag returns the value of the tag key of span, looking up both its string and numeric tags.
+func spanTag(span *pb.Span, key string) (string, bool) {
+	if v, ok := span.Meta[key]; ok {
+		return v, true
+	}
+	if v, ok := span.Metrics[key]; ok {
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	}
+	return "", false
+}
+
+// metricTags returns the tags of the metric m sent for span. The tags listed in the group_by
+// of m which are missing from span are omitted.
+func metricTags(m *config.SpanMetric, env string, span *pb.Span) []string {
+	tags := make([]string, 0, 2+len(m.GroupBy))
+	tags = append(tags, "env:"+env, "service:"+span.Service)
+	for _, k := range m.GroupBy {
+		var v string
+		switch k {
+		case groupByResource:
+			v = span.Resource
+		case groupByOperationName:
+			v = span.Name
+		default:
+			var ok bool
+			if v, ok = spanTag(span, k); !ok {
+				continue
+			}
+		}
+		tags = append(tags, traceutil.NormalizeTag(k+":"+v))
+	}
+	return tags
+}
@@ -0,0 +1,69 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package spanmetrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	pb "github.com/DataDog/datadog-agent/pkg/proto/pbgo/trace"
+	"github.com/DataDog/datadog-agent/pkg/trace/config"
+	"github.com/DataDog/datadog-agent/pkg/trace/teststatsd"
+	"github.com/DataDog/datadog-agent/pkg/trace/traceutil"
+)
+
+func newTestGenerator(metrics ...*config.SpanMetric) (*Generator, *teststatsd.Client) {
+	conf := config.New()
+	conf.DefaultEnv = "agent-env"
+	conf.SpanMetrics = metrics
+	statsd := &teststatsd.Client{}
+	return NewGenerator(conf, statsd), statsd
+}
+
+func testProcessedTrace(env string, spans ...*pb.Span) *traceutil.ProcessedTrace {
+	return &traceutil.ProcessedTrace{
+		TraceChunk: &pb.TraceChunk{Spans: spans},
+		Root:       spans[0],
+		TracerEnv:  env,
+	}
+}
+
+func TestGeneratorCount(t *testing.T) {
+	g, statsd := newTestGenerator(
+		&config.SpanMetric{Name: "checkout.failed", Type: config.SpanMetricCount, Service: "checkout", Tags: map[string]string{"error.type": ""}},
+		&config.SpanMetric{Name: "checkout.cards", Type: config.SpanMetricCount, OperationName: "checkout.process", Tags: map[string]string{"payment.method": "card"}, GroupBy: []string{"resource"}},
+	)
+	g.Process(testProcessedTrace("prod",
+		&pb.Span{Service: "checkout", Name: "checkout.process", Resource: "POST /checkout", Meta: map[string]string{"payment.method": "card", "error.type": "timeout"}},
+		&pb.Span{Service: "checkout", Name: "checkout.process", Resource: "POST /checkout", Meta: map[string]string{"payment.method": "paypal"}},
+		&pb.Span{Service: "cart", Name: "cart.update", Meta: map[string]string{"error.type": "timeout"}},
+	))
+
+	assert.Len(t, statsd.CountCalls, 2)
+	assert.Equal(t, teststatsd.MetricsArgs{Name: "checkout.failed", Value: 1, Tags: []string{"env:prod", "service:checkout"}, Rate: 1}, statsd.CountCalls[0])
+	assert.Equal(t, teststatsd.MetricsArgs{Name: "checkout.cards", Value: 1, Tags: []string{"env:prod", "service:checkout", "resource:post_/checkout"}, Rate: 1}, statsd.CountCalls[1])
+}
+
+func TestGeneratorDistribution(t *testing.T) {
+	g, statsd := newTestGenerator(
+		&config.SpanMetric{Name: "checkout.amount", Type: config.SpanMetricDistribution, Service: "checkout", Value: "cart.amount", GroupBy: []string{"payment.method", "operation_name", "missing"}},
+		&config.SpanMetric{Name: "checkout.duration", Type: config.SpanMetricDistribution, Resource: "POST /checkout", Value: config.SpanMetricDuration},
+	)
+	g.Process(testProcessedTrace("",
+		&pb.Span{Service: "checkout", Name: "checkout.process", Resource: "POST /checkout", Duration: int64(1500 * time.Millisecond), Metrics: map[string]float64{"cart.amount": 42.5}, Meta: map[string]string{"payment.method": "card"}},
+		&pb.Span{Service: "checkout", Name: "checkout.process", Meta: map[string]string{"cart.amount": "10"}},
+		&pb.Span{Service: "checkout", Name: "checkout.process", Meta: map[string]string{"cart.amount": "n/a"}},
+	))
+
+	assert.Equal(t, []teststatsd.MetricsArgs{
+		{Name: "checkout.amount", Value: 42.5, Tags: []string{"env:agent-env", "service:checkout", "payment.method:card", "operation_name:checkout.process"}, Rate: 1},
+		{Name: "checkout.duration", Value: 1.5, Tags: []string{"env:agent-env", "service:checkout"}, Rate: 1},
+		{Name: "checkout.amount", Value: 10, Tags: []string{"env:agent-env", "service:checkout", "operation_name:checkout.process"}, Rate: 1},
+	}, statsd.DistributionCalls)
+	assert.Empty(t, statsd.CountCalls)
+}
@@ -40,6 +40,7 @@ type BucketsAggregationKey struct {
 	StatusCode     uint32
 	Synthetics     bool
 	PeerTagsHash   uint64
+	DimensionsHash uint64
 	IsTraceRoot    pb.Trilean
 	GRPCStatusCode string
 }
@@ -94,6 +95,7 @@ func NewAggregationFromSpan(s *StatSpan, origin string, aggKey PayloadAggregatio
 			IsTraceRoot:    isTraceRoot,
 			GRPCStatusCode: s.grpcStatusCode,
 			PeerTagsHash:   peerTagsHash(s.matchingPeerTags),
+			DimensionsHash: peerTagsHash(s.dimensions),
 		},
 	}
 	return agg
@@ -58,6 +58,8 @@ type ClientStatsAggregator struct {
 	done chan struct{}
 
 	statsd statsd.ClientInterface
+
+	dimensions *statsDimensions
 }
 
 // NewClientStatsAggregator initializes a new aggregator ready to be started
@@ -75,6 +77,7 @@ func NewClientStatsAggregator(conf *config.AgentConfig, writer Writer, statsd st
 		exit:          make(chan struct{}),
 		done:          make(chan struct{}),
 		statsd:        statsd,
+		dimensions:    newStatsDimensions(conf.StatsDimensions, time.Now()),
 	}
 	return c
 }
@@ -154,6 +157,14 @@ func (a *ClientStatsAggregator) add(now time.Time, p *pb.ClientStatsPayload) {
 			}
 			a.buckets[ts.Unix()] = b
 		}
+		if a.dimensions != nil {
+			// the tracers send the extra dimensions along with the peer tags
+			for _, gs := range clientBucket.Stats {
+				if gs != nil {
+					gs.PeerTags = a.dimensions.capTags(now, gs.PeerTags)
+				}
+			}
+		}
 		b.aggregateStatsBucket(clientBucket, payloadAggKey)
 	}
 }
@@ -7,6 +7,7 @@ package stats
 
 import (
 	"math/rand"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -513,6 +514,33 @@ func TestCountAggregationPeerTags(t *testing.T) {
 	}
 }
 
+func TestCountAggregationStatsDimensions(t *testing.T) {
+	assert := assert.New(t)
+	a := newTestAggregator()
+	a.dimensions = newStatsDimensions([]*config.StatsDimension{{Tag: "tenant", MaxCardinality: 1}}, time.Now())
+	msw := &mockStatsWriter{}
+	a.writer = msw
+	testTime := time.Unix(time.Now().Unix(), 0)
+
+	k := BucketsAggregationKey{Service: "s", Name: "test.op"}
+	for i, tenant := range []string{"a", "b", "c", "a"} {
+		p := payloadWithCounts(testTime, k, "", "test-version", "", "", uint64(i+1), 0, 10)
+		p.Stats[0].Stats[0].PeerTags = []string{"peer.service:remote-service", "tenant:" + tenant}
+		a.add(testTime, p)
+	}
+	a.flushOnTime(testTime.Add(oldestBucketStart + time.Nanosecond))
+	require.Len(t, msw.payloads, 1)
+
+	hits := make(map[string]uint64)
+	for _, gs := range msw.payloads[0].Stats[0].Stats[0].Stats {
+		hits[strings.Join(gs.PeerTags, ",")] = gs.Hits
+	}
+	assert.Equal(map[string]uint64{
+		"peer.service:remote-service,tenant:a":         5,
+		"peer.service:remote-service,tenant:_overflow": 5,
+	}, hits)
+}
+
 func TestAggregationVersionData(t *testing.T) {
 	// Version data refers to all of: Version, GitCommitSha, and ImageTag.
 	t.Run("all version data provided in payload", func(t *testing.T) {
@@ -45,6 +45,7 @@ type Concentrator struct {
 	agentVersion  string
 	statsd        statsd.ClientInterface
 	peerTagKeys   []string
+	dimensions    *statsDimensions
 }
 
 // NewConcentrator initializes a new concentrator ready to be started
@@ -64,6 +65,7 @@ func NewConcentrator(conf *config.AgentConfig, writer Writer, now time.Time, sta
 		statsd:           statsd,
 		bsize:            bsize,
 		peerTagKeys:      conf.ConfiguredPeerTags(),
+		dimensions:       newStatsDimensions(conf.StatsDimensions, now),
 	}
 	return &c
 }
@@ -159,6 +161,7 @@ func (c *Concentrator) addNow(pt *traceutil.ProcessedTrace, containerID string,
 	for _, s := range pt.TraceChunk.Spans {
 		statSpan, ok := c.spanConcentrator.NewStatSpanFromPB(s, c.peerTagKeys)
 		if ok {
+			statSpan.dimensions = c.dimensions.fromMeta(time.Now(), s.Meta)
 			c.spanConcentrator.addSpan(statSpan, aggKey, containerID, containerTags, pt.TraceChunk.Origin, weight)
 		}
 	}
@@ -8,6 +8,7 @@ package stats
 import (
 	"fmt"
 	"math/rand"
+	"strings"
 	"testing"
 	"time"
 
@@ -757,6 +758,56 @@ func TestPeerTags(t *testing.T) {
 	})
 }
 
+func TestStatsDimensions(t *testing.T) {
+	assert := assert.New(t)
+	now := time.Now()
+	span := func(id uint64, meta map[string]string) *pb.Span {
+		return &pb.Span{
+			SpanID:   id,
+			Service:  "myservice",
+			Name:     "http.server.request",
+			Resource: "GET /users",
+			Duration: 100,
+			Meta:     meta,
+			Metrics:  map[string]float64{"_top_level": 1},
+		}
+	}
+	spans := []*pb.Span{
+		span(1, map[string]string{"tenant": "a", "region": "us1"}),
+		span(2, map[string]string{"tenant": "a", "region": "us1"}),
+		span(3, map[string]string{"tenant": "b", "region": "us1"}),
+		span(4, map[string]string{"tenant": "c", "region": "us1"}),
+		span(5, map[string]string{"tenant": "d"}),
+		span(6, nil),
+	}
+	cfg := config.AgentConfig{
+		BucketInterval: time.Duration(testBucketInterval),
+		AgentVersion:   "0.99.0",
+		DefaultEnv:     "env",
+		Hostname:       "hostname",
+		StatsDimensions: []*config.StatsDimension{
+			{Tag: "tenant", MaxCardinality: 2},
+			{Tag: "region"},
+		},
+	}
+	c := NewTestConcentratorWithCfg(now, &cfg)
+	for _, sp := range spans {
+		c.addNow(toProcessedTrace([]*pb.Span{sp}, "none", "", "", "", ""), "", nil)
+	}
+	stats := c.flushNow(now.UnixNano()+int64(c.spanConcentrator.bufferLen)*testBucketInterval, false)
+	hits := make(map[string]uint64)
+	for _, st := range stats.Stats[0].Stats[0].Stats {
+		hits[strings.Join(st.PeerTags, ",")] += st.Hits
+	}
+	assert.Equal(map[string]uint64{
+		"region:us1,tenant:a":         2,
+		"region:us1,tenant:b":         1,
+		"region:us1,tenant:_overflow": 1,
+		"tenant:_overflow":            1,
+		"":                            1,
+	}, hits)
+}
+
 // TestComputeStatsThroughSpanKindCheck ensures that we generate stats for spans that have an eligible span.kind.
 func TestComputeStatsThroughSpanKindCheck(t *testing.T) {
 	assert := assert.New(t)
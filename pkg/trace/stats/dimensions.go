@@ -0,0 +1,146 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package stats
+
+import (
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/config"
+	"github.com/DataDog/datadog-agent/pkg/trace/log"
+)
+
+// DimensionOverflowValue is the value given to a stats dimension once its maximum cardinality
+// is reached: the stats of all its new values are aggregated under it.
+const DimensionOverflowValue = "_overflow"
+
+// dimensionsResetInterval is the period after which the values seen for each dimension are
+// forgotten, letting new values in.
+const dimensionsResetInterval = time.Hour
+
+// statsDimensions holds the extra dimensions of the aggregation of stats, the span tags
+// configured in apm_config.stats_dimensions. It caps the number of distinct values of each
+// dimension. It is safe for concurrent use.
+type statsDimensions struct {
+	mu      sync.Mutex
+	dims    []*statsDimension
+	resetAt time.Time
+}
+
+type statsDimension struct {
+	tag            string
+	maxCardinality int
+	values         map[string]struct{}
+	// overflowed is set once a value is aggregated under DimensionOverflowValue, to log it once
+	// per reset interval.
+	overflowed bool
+}
+
+// newStatsDimensions returns the stats dimensions configured by conf, or nil if there are none.
+func newStatsDimensions(conf []*config.StatsDimension, now time.Time) *statsDimensions {
+	if len(conf) == 0 {
+		return nil
+	}
+	d := &statsDimensions{
+		dims:    make([]*statsDimension, 0, len(conf)),
+		resetAt: now.Add(dimensionsResetInterval),
+	}
+	for _, c := range conf {
+		maxCardinality := c.MaxCardinality
+		if maxCardinality <= 0 {
+			maxCardinality = config.DefaultStatsDimensionMaxCardinality
+		}
+		d.dims = append(d.dims, &statsDimension{
+			tag:            c.Tag,
+			maxCardinality: maxCardinality,
+			values:         make(map[string]struct{}),
+		})
+	}
+	return d
+}
+
+// fromMeta returns the dimensions of a span with the given tags, as "tag:value" strings.
+// The spans not tagged with a dimension have no value for it.
+func (d *statsDimensions) fromMeta(now time.Time, meta map[string]string) []string {
+	if d == nil {
+		return nil
+	}
+	var tags []string
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.maybeReset(now)
+	for _, dim := range d.dims {
+		if v, ok := meta[dim.tag]; ok && v != "" {
+			tags = append(tags, dim.tag+":"+dim.value(v))
+		}
+	}
+	return tags
+}
+
+// capTags returns the peer tags of client computed stats, the values of the dimensions among
+// them being replaced by DimensionOverflowValue if their maximum cardinality is reached. tags
+// is never modified.
+func (d *statsDimensions) capTags(now time.Time, tags []string) []string {
+	if d == nil || len(tags) == 0 {
+		return tags
+	}
+	var capped []string
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.maybeReset(now)
+	for i, t := range tags {
+		k, v, ok := strings.Cut(t, ":")
+		if !ok {
+			continue
+		}
+		for _, dim := range d.dims {
+			if dim.tag != k {
+				continue
+			}
+			if cv := dim.value(v); cv != v {
+				if capped == nil {
+					capped = slices.Clone(tags)
+				}
+				capped[i] = k + ":" + cv
+			}
+			break
+		}
+	}
+	if capped == nil {
+		return tags
+	}
+	return capped
+}
+
+// maybeReset forgets the values seen for each dimension once the reset interval has elapsed.
+func (d *statsDimensions) maybeReset(now time.Time) {
+	if now.Before(d.resetAt) {
+		return
+	}
+	for _, dim := range d.dims {
+		clear(dim.values)
+		dim.overflowed = false
+	}
+	d.resetAt = now.Add(dimensionsResetInterval)
+}
+
+// value returns v if the dimension has room for it, or DimensionOverflowValue.
+func (dim *statsDimension) value(v string) string {
+	if _, ok := dim.values[v]; ok {
+		return v
+	}
+	if len(dim.values) >= dim.maxCardinality {
+		if !dim.overflowed {
+			dim.overflowed = true
+			log.Warnf("Stats dimension %q reached its maximum cardinality of %d, its new values are aggregated under %q.", dim.tag, dim.maxCardinality, DimensionOverflowValue)
+		}
+		return DimensionOverflowValue
+	}
+	dim.values[v] = struct{}{}
+	return v
+}
@@ -0,0 +1,59 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package stats
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/config"
+)
+
+func TestStatsDimensionsFromMeta(t *testing.T) {
+	assert := assert.New(t)
+	now := time.Now()
+	d := newStatsDimensions([]*config.StatsDimension{
+		{Tag: "tenant", MaxCardinality: 2},
+		{Tag: "region"},
+	}, now)
+
+	assert.Equal([]string{"tenant:a", "region:us1"}, d.fromMeta(now, map[string]string{"tenant": "a", "region": "us1", "other": "x"}))
+	assert.Equal([]string{"tenant:b"}, d.fromMeta(now, map[string]string{"tenant": "b"}))
+	assert.Equal([]string{"tenant:_overflow"}, d.fromMeta(now, map[string]string{"tenant": "c"}))
+	assert.Equal([]string{"tenant:a"}, d.fromMeta(now, map[string]string{"tenant": "a"}))
+	assert.Nil(d.fromMeta(now, map[string]string{"tenant": ""}))
+	assert.Nil(d.fromMeta(now, nil))
+
+	// the values seen are forgotten after the reset interval
+	now = now.Add(dimensionsResetInterval)
+	assert.Equal([]string{"tenant:c"}, d.fromMeta(now, map[string]string{"tenant": "c"}))
+	assert.Equal([]string{"tenant:d"}, d.fromMeta(now, map[string]string{"tenant": "d"}))
+	assert.Equal([]string{"tenant:_overflow"}, d.fromMeta(now, map[string]string{"tenant": "a"}))
+
+	d = newStatsDimensions([]*config.StatsDimension{{Tag: "tenant"}}, now)
+	assert.Equal(config.DefaultStatsDimensionMaxCardinality, d.dims[0].maxCardinality)
+
+	// no dimensions configured
+	d = newStatsDimensions(nil, now)
+	assert.Nil(d)
+	assert.Nil(d.fromMeta(now, map[string]string{"tenant": "a"}))
+	assert.Equal([]string{"tenant:a"}, d.capTags(now, []string{"tenant:a"}))
+}
+
+func TestStatsDimensionsCapTags(t *testing.T) {
+	assert := assert.New(t)
+	now := time.Now()
+	d := newStatsDimensions([]*config.StatsDimension{{Tag: "tenant", MaxCardinality: 1}}, now)
+
+	tags := []string{"peer.service:db", "tenant:a"}
+	assert.Equal(tags, d.capTags(now, tags))
+	tags = []string{"peer.service:db", "tenant:b", "nocolon"}
+	assert.Equal([]string{"peer.service:db", "tenant:_overflow", "nocolon"}, d.capTags(now, tags))
+	assert.Equal([]string{"peer.service:db", "tenant:b", "nocolon"}, tags, "the tags must not be modified")
+	assert.Nil(d.capTags(now, nil))
+}
@@ -60,6 +60,12 @@ func OTLPTracesToConcentratorInputsWithObfuscation(
 	for _, resName := range conf.Ignore["resource"] {
 		ignoreResNames[resName] = struct{}{}
 	}
+	// the minimal spans hold the tags of the stats dimensions along with the peer tags, for
+	// the Concentrator to read them
+	tagKeys := peerTagKeys
+	for _, d := range conf.StatsDimensions {
+		tagKeys = append(slices.Clip(tagKeys), d.Tag)
+	}
 	chunks := make(map[chunkKey]*pb.TraceChunk)
 	containerTagsByID := make(map[string][]string)
 	for spanID, otelspan := range spanByID {
@@ -101,7 +107,7 @@ func OTLPTracesToConcentratorInputsWithObfuscation(
 			chunks[ckey] = chunk
 		}
 		_, isTop := topLevelSpans[spanID]
-		ddSpan := transform.OtelSpanToDDSpanMinimal(otelspan, otelres, scopeByID[spanID], isTop, topLevelByKind, conf, peerTagKeys)
+		ddSpan := transform.OtelSpanToDDSpanMinimal(otelspan, otelres, scopeByID[spanID], isTop, topLevelByKind, conf, tagKeys)
 		if obfuscator != nil {
 			obfuscateSpanForConcentrator(obfuscator, ddSpan, conf)
 		}
@@ -132,10 +138,22 @@ func obfuscateSpanForConcentrator(o *obfuscate.Obfuscator, span *pb.Span, conf *
 	}
 	switch span.Type {
 	case "sql", "cassandra":
+		if span.Type == "cassandra" && conf.Obfuscation.CQL.Enabled {
+			if err := transform.ObfuscateCQLSpan(o, span); err != nil {
+				log.Debugf("Error parsing CQL query: %v. Resource: %q", err, span.Resource)
+			}
+			return
+		}
 		_, err := transform.ObfuscateSQLSpan(o, span)
 		if err != nil {
 			log.Debugf("Error parsing SQL query: %v. Resource: %q", err, span.Resource)
 		}
+	case "graphql":
+		if conf.Obfuscation.GraphQL.Enabled {
+			if err := transform.ObfuscateGraphQLSpan(o, span); err != nil {
+				log.Debugf("Error parsing GraphQL query: %v. Resource: %q", err, span.Resource)
+			}
+		}
 	case "redis":
 		span.Resource = o.QuantizeRedisString(span.Resource)
 		if conf.Obfuscation.Redis.Enabled {
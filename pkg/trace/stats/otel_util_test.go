@@ -58,6 +58,7 @@ func TestProcessOTLPTraces(t *testing.T) {
 		spanNameRemappings               map[string]string
 		ignoreRes                        []string
 		peerTagsAggr                     bool
+		statsDimensions                  []*config.StatsDimension
 		legacyTopLevel                   bool
 		ctagKeys                         []string
 		expected                         *pb.StatsPayload
@@ -164,6 +165,16 @@ func TestProcessOTLPTraces(t *testing.T) {
 			sattrs:       map[string]any{"operation.name": "op", semconv.AttributeRPCMethod: "call", semconv.AttributeRPCService: "rpc_service"},
 			expected:     createStatsPayload(agentEnv, agentHost, "svc", "op", "http", "client", "call rpc_service", "dd-host", "tracer-env", "", nil, []string{"rpc.service:rpc_service"}, true, false),
 		},
+		{
+			name:            "with stats dimensions",
+			spanName:        "spanname10",
+			spanKind:        ptrace.SpanKindClient,
+			peerTagsAggr:    true,
+			statsDimensions: []*config.StatsDimension{{Tag: "tenant"}},
+			rattrs:          map[string]string{"service.name": "svc", semconv.AttributeDeploymentEnvironment: "tracer-env", "datadog.host.name": "dd-host"},
+			sattrs:          map[string]any{"operation.name": "op", semconv.AttributeRPCMethod: "call", semconv.AttributeRPCService: "rpc_service", "tenant": "acme"},
+			expected:        createStatsPayload(agentEnv, agentHost, "svc", "op", "http", "client", "call rpc_service", "dd-host", "tracer-env", "", nil, []string{"rpc.service:rpc_service", "tenant:acme"}, true, false),
+		},
 
 		{
 			name:      "ignore resource name",
@@ -240,6 +251,7 @@ func TestProcessOTLPTraces(t *testing.T) {
 				conf.Features["disable_receive_resource_spans_v2"] = struct{}{}
 			}
 			conf.PeerTagsAggregation = tt.peerTagsAggr
+			conf.StatsDimensions = tt.statsDimensions
 			conf.OTLPReceiver.AttributesTranslator = attributesTranslator
 			conf.OTLPReceiver.SpanNameAsResourceName = tt.spanNameAsResourceName
 			if conf.OTLPReceiver.SpanNameAsResourceName || tt.enableOperationAndResourceNameV2 {
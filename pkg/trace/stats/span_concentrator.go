@@ -42,6 +42,8 @@ type StatSpan struct {
 	isTopLevel       bool
 	matchingPeerTags []string
 	grpcStatusCode   string
+	// dimensions are the extra dimensions of the span, set by the Concentrator.
+	dimensions []string
 }
 
 func matchingPeerTags(meta map[string]string, peerTagKeys []string) []string {
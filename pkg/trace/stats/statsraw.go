@@ -8,6 +8,7 @@ package stats
 import (
 	"math"
 	"math/rand"
+	"slices"
 
 	pb "github.com/DataDog/datadog-agent/pkg/proto/pbgo/trace"
 	"github.com/DataDog/datadog-agent/pkg/trace/log"
@@ -39,6 +40,7 @@ type groupedStats struct {
 	okDistribution  *ddsketch.DDSketch
 	errDistribution *ddsketch.DDSketch
 	peerTags        []string
+	dimensions      []string
 }
 
 // round a float to an int, uniformly choosing
@@ -62,6 +64,12 @@ func (s *groupedStats) export(a Aggregation) (*pb.ClientGroupedStats, error) {
 	if err != nil {
 		return &pb.ClientGroupedStats{}, err
 	}
+	peerTags := s.peerTags
+	if len(s.dimensions) > 0 {
+		// ClientGroupedStats has no field for arbitrary tags: the extra dimensions are sent as
+		// additional "tag:value" peer tags.
+		peerTags = append(slices.Clip(peerTags), s.dimensions...)
+	}
 	return &pb.ClientGroupedStats{
 		Service:        a.Service,
 		Name:           a.Name,
@@ -76,7 +84,7 @@ func (s *groupedStats) export(a Aggregation) (*pb.ClientGroupedStats, error) {
 		ErrorSummary:   errSummary,
 		Synthetics:     a.Synthetics,
 		SpanKind:       a.SpanKind,
-		PeerTags:       s.peerTags,
+		PeerTags:       peerTags,
 		IsTraceRoot:    a.IsTraceRoot,
 		GRPCStatusCode: a.GRPCStatusCode,
 	}, nil
@@ -171,6 +179,7 @@ func (sb *RawBucket) add(s *StatSpan, weight float64, aggr Aggregation) {
 	if gs, ok = sb.data[aggr]; !ok {
 		gs = newGroupedStats()
 		gs.peerTags = s.matchingPeerTags
+		gs.dimensions = s.dimensions
 		sb.data[aggr] = gs
 	}
 	if s.isTopLevel {
@@ -52,6 +52,9 @@ type Client struct {
 	HistogramCalls []MetricsArgs
 	TimingErr      error
 	TimingCalls    []MetricsArgs
+
+	DistributionErr   error
+	DistributionCalls []MetricsArgs
 }
 
 // Reset resets client's internal records.
@@ -66,6 +69,8 @@ func (c *Client) Reset() {
 	c.HistogramCalls = c.HistogramCalls[:0]
 	c.TimingErr = nil
 	c.TimingCalls = c.TimingCalls[:0]
+	c.DistributionErr = nil
+	c.DistributionCalls = c.DistributionCalls[:0]
 }
 
 // Gauge records a call to a Gauge operation and replies with GaugeErr
@@ -108,6 +113,14 @@ func (c *Client) Histogram(name string, value float64, tags []string, rate float
 	return c.HistogramErr
 }
 
+// Distribution records a call to a Distribution operation and replies with DistributionErr
+func (c *Client) Distribution(name string, value float64, tags []string, rate float64) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.DistributionCalls = append(c.DistributionCalls, MetricsArgs{Name: name, Value: value, Tags: tags, Rate: rate})
+	return c.DistributionErr
+}
+
 // Timing records a call to a Timing operation.
 func (c *Client) Timing(name string, value time.Duration, tags []string, rate float64) error {
 	c.mu.Lock()
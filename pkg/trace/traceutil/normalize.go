@@ -27,6 +27,14 @@ const (
 	MaxServiceLen = 100
 	// MaxResourceLen the maximum length a resource can have
 	MaxResourceLen = 5000
+	// MaxTypeLen the maximum length a span type can have
+	MaxTypeLen = 100
+	// MaxMetaKeyLen the maximum length of metadata key
+	MaxMetaKeyLen = 200
+	// MaxMetaValueLen the maximum length of metadata value
+	MaxMetaValueLen = 25000
+	// MaxMetricsKeyLen the maximum length of a metric name key
+	MaxMetricsKeyLen = MaxMetaKeyLen
 )
 
 var (
@@ -41,12 +49,17 @@ var (
 // NormalizeName normalizes a span name and returns an error describing the reason
 // (if any) why the name was modified.
 func NormalizeName(name string) (string, error) {
+	return NormalizeNameMaxLen(name, MaxNameLen)
+}
+
+// NormalizeNameMaxLen is like NormalizeName, truncating the name to maxLen instead of MaxNameLen.
+func NormalizeNameMaxLen(name string, maxLen int) (string, error) {
 	if name == "" {
 		return DefaultSpanName, ErrEmpty
 	}
 	var err error
-	if len(name) > MaxNameLen {
-		name = TruncateUTF8(name, MaxNameLen)
+	if len(name) > maxLen {
+		name = TruncateUTF8(name, maxLen)
 		err = ErrTooLong
 	}
 	name, ok := normMetricNameParse(name)
@@ -59,12 +72,18 @@ func NormalizeName(name string) (string, error) {
 // NormalizeService normalizes a span service and returns an error describing the reason
 // (if any) why the name was modified.
 func NormalizeService(svc string, lang string) (string, error) {
+	return NormalizeServiceMaxLen(svc, lang, MaxServiceLen)
+}
+
+// NormalizeServiceMaxLen is like NormalizeService, truncating the service to maxLen instead of
+// MaxServiceLen. Services being tag values, they are never longer than 200 characters.
+func NormalizeServiceMaxLen(svc string, lang string, maxLen int) (string, error) {
 	if svc == "" {
 		return fallbackService(lang), ErrEmpty
 	}
 	var err error
-	if len(svc) > MaxServiceLen {
-		svc = TruncateUTF8(svc, MaxServiceLen)
+	if len(svc) > maxLen {
+		svc = TruncateUTF8(svc, maxLen)
 		err = ErrTooLong
 	}
 	// We are normalizing just the tag value.
@@ -78,12 +97,18 @@ func NormalizeService(svc string, lang string) (string, error) {
 // NormalizePeerService normalizes a span's peer.service and returns an error describing the reason
 // (if any) why the name was modified.
 func NormalizePeerService(svc string) (string, error) {
+	return NormalizePeerServiceMaxLen(svc, MaxServiceLen)
+}
+
+// NormalizePeerServiceMaxLen is like NormalizePeerService, truncating the service to maxLen
+// instead of MaxServiceLen.
+func NormalizePeerServiceMaxLen(svc string, maxLen int) (string, error) {
 	if svc == "" {
 		return "", nil
 	}
 	var err error
-	if len(svc) > MaxServiceLen {
-		svc = TruncateUTF8(svc, MaxServiceLen)
+	if len(svc) > maxLen {
+		svc = TruncateUTF8(svc, maxLen)
 		err = ErrTooLong
 	}
 	// We are normalizing just the tag value.
@@ -262,11 +287,12 @@ func isAlphaNum(b byte) bool {
 // normMetricNameParse normalizes metric names with a parser instead of using
 // garbage-creating string replacement routines.
 func normMetricNameParse(name string) (string, bool) {
-	if name == "" || len(name) > MaxNameLen {
+	if name == "" {
 		return name, false
 	}
 
 	var i, ptr int
+	// names longer than MaxNameLen, allowed by NormalizeNameMaxLen, are built on the heap
 	var resa [MaxNameLen]byte
 	res := resa[:0]
 
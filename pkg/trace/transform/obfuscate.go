@@ -30,11 +30,15 @@ const (
 	TagHTTPURL = "http.url"
 	// TagDBMS represents a DBMS tag
 	TagDBMS = "db.type"
+	// TagGraphQLSource represents a GraphQL document tag
+	TagGraphQLSource = "graphql.source"
 )
 
 const (
 	// TextNonParsable is the error text used when a query is non-parsable
 	TextNonParsable = "Non-parsable SQL query"
+	// TextNonParsableGraphQL is the error text used when a GraphQL document is non-parsable
+	TextNonParsableGraphQL = "Non-parsable GraphQL query"
 )
 
 // ObfuscateSQLSpan obfuscates a SQL span using pkg/obfuscate logic
@@ -57,6 +61,48 @@ func ObfuscateSQLSpan(o *obfuscate.Obfuscator, span *pb.Span) (*obfuscate.Obfusc
 	return oq, nil
 }
 
+// ObfuscateCQLSpan obfuscates a Cassandra CQL span using pkg/obfuscate logic
+func ObfuscateCQLSpan(o *obfuscate.Obfuscator, span *pb.Span) error {
+	if span.Resource == "" {
+		return nil
+	}
+	query, err := o.ObfuscateCQL(span.Resource)
+	if err != nil {
+		// we have an error, discard the CQL to avoid polluting user resources.
+		span.Resource = TextNonParsable
+		traceutil.SetMeta(span, TagSQLQuery, TextNonParsable)
+		return err
+	}
+	span.Resource = query
+	traceutil.SetMeta(span, TagSQLQuery, query)
+	return nil
+}
+
+// ObfuscateGraphQLSpan obfuscates a GraphQL span using pkg/obfuscate logic
+func ObfuscateGraphQLSpan(o *obfuscate.Obfuscator, span *pb.Span) error {
+	if span.Resource != "" {
+		query, err := o.ObfuscateGraphQL(span.Resource)
+		if err != nil {
+			// we have an error, discard the document to avoid polluting user resources.
+			span.Resource = TextNonParsableGraphQL
+			if span.Meta[TagGraphQLSource] != "" {
+				span.Meta[TagGraphQLSource] = TextNonParsableGraphQL
+			}
+			return err
+		}
+		span.Resource = query
+	}
+	if source := span.Meta[TagGraphQLSource]; source != "" {
+		query, err := o.ObfuscateGraphQL(source)
+		if err != nil {
+			span.Meta[TagGraphQLSource] = TextNonParsableGraphQL
+			return err
+		}
+		span.Meta[TagGraphQLSource] = query
+	}
+	return nil
+}
+
 // ObfuscateRedisSpan obfuscates a Redis span using pkg/obfuscate logic
 func ObfuscateRedisSpan(o *obfuscate.Obfuscator, span *pb.Span, removeAllArgs bool) {
 	if span.Meta == nil || span.Meta[TagRedisRawCommand] == "" {
@@ -0,0 +1,231 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package writer
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/log"
+)
+
+const (
+	diskQueueExtension = ".retry"
+	diskQueueVersion   = 1
+	// version and headers length
+	diskQueueHeaderSize = 1 + 4
+)
+
+var errCorruptedPayload = errors.New("corrupted payload file")
+
+// diskRetryQueue stores on disk the payloads which could not be sent, so that they can
+// be sent again once the intake is reachable. Each payload is stored in its own file,
+// named after the time it was stored, and the files left by a previous run are reloaded.
+// It is safe for concurrent use.
+type diskRetryQueue struct {
+	path    string
+	maxSize int64
+
+	mu        sync.Mutex // guards the fields below
+	filenames []string   // oldest first
+	sizes     map[string]int64
+	size      int64
+	sequence  uint64
+}
+
+// newDiskRetryQueue returns a queue storing at most maxSize bytes of payloads in path.
+func newDiskRetryQueue(path string, maxSize int64) (*diskRetryQueue, error) {
+	if maxSize <= 0 {
+		return nil, fmt.Errorf("invalid maximum size: %d", maxSize)
+	}
+	if err := os.MkdirAll(path, 0700); err != nil {
+		return nil, err
+	}
+	q := &diskRetryQueue{
+		path:    path,
+		maxSize: maxSize,
+		sizes:   make(map[string]int64),
+	}
+	if err := q.reload(); err != nil {
+		return nil, err
+	}
+	if len(q.filenames) > 0 {
+		log.Infof("Found %d payloads (%d bytes) to retry in %s", len(q.filenames), q.size, path)
+	}
+	return q, nil
+}
+
+// len returns the number of payloads in the queue.
+func (q *diskRetryQueue) len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.filenames)
+}
+
+// sizeInBytes returns the disk space used by the payloads in the queue.
+func (q *diskRetryQueue) sizeInBytes() int64 {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.size
+}
+
+// store writes p to the queue, removing the oldest payloads if the queue would exceed its
+// maximum size. It returns the number and size of the payloads removed.
+func (q *diskRetryQueue) store(p *payload) (evicted int, evictedBytes int64, err error) {
+	data, err := encodeRetryPayload(p)
+	if err != nil {
+		return 0, 0, err
+	}
+	size := int64(len(data))
+	if size > q.maxSize {
+		return 0, 0, fmt.Errorf("payload of %d bytes exceeds the maximum size of %d bytes", size, q.maxSize)
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for len(q.filenames) > 0 && q.size+size > q.maxSize {
+		log.Debugf("Maximum disk space for retried payloads is reached. Removing %s", q.filenames[0])
+		evicted++
+		evictedBytes += q.removeOldest()
+	}
+
+	name := filepath.Join(q.path, fmt.Sprintf("%020d_%010d%s", time.Now().UnixNano(), q.sequence, diskQueueExtension))
+	q.sequence++
+	// write to a temporary file first so that a crash can't leave a truncated payload behind
+	tmp := name + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		_ = os.Remove(tmp)
+		return evicted, evictedBytes, err
+	}
+	if err := os.Rename(tmp, name); err != nil {
+		_ = os.Remove(tmp)
+		return evicted, evictedBytes, err
+	}
+	q.add(name, size)
+	return evicted, evictedBytes, nil
+}
+
+// peekOldest returns the oldest payload of the queue along with the name of its file, which
+// stays in the queue until it is removed with remove. It returns an empty name if the queue is
+// empty, and the name along with an error if the file can't be read.
+func (q *diskRetryQueue) peekOldest() (string, *payload, error) {
+	q.mu.Lock()
+	if len(q.filenames) == 0 {
+		q.mu.Unlock()
+		return "", nil, nil
+	}
+	name := q.filenames[0]
+	data, err := os.ReadFile(name)
+	q.mu.Unlock()
+
+	if err != nil {
+		return name, nil, err
+	}
+	p, err := decodeRetryPayload(data)
+	return name, p, err
+}
+
+// remove deletes the payload stored in the file name, if it is still in the queue.
+func (q *diskRetryQueue) remove(name string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for i, n := range q.filenames {
+		if n == name {
+			q.removeAt(i)
+			return
+		}
+	}
+}
+
+// add registers the file name holding size bytes. q must be locked.
+func (q *diskRetryQueue) add(name string, size int64) {
+	q.filenames = append(q.filenames, name)
+	q.sizes[name] = size
+	q.size += size
+}
+
+// removeOldest deletes the oldest payload and returns its size. q must be locked.
+func (q *diskRetryQueue) removeOldest() int64 {
+	return q.removeAt(0)
+}
+
+// removeAt deletes the i-th oldest payload and returns its size. q must be locked.
+func (q *diskRetryQueue) removeAt(i int) int64 {
+	name := q.filenames[i]
+	size := q.sizes[name]
+	q.filenames = append(q.filenames[:i], q.filenames[i+1:]...)
+	delete(q.sizes, name)
+	q.size -= size
+	if err := os.Remove(name); err != nil && !os.IsNotExist(err) {
+		log.Warnf("Could not remove retried payload %s: %v", name, err)
+	}
+	return size
+}
+
+func (q *diskRetryQueue) reload() error {
+	entries, err := os.ReadDir(q.path)
+	if err != nil {
+		return err
+	}
+	// names start with the zero-padded storage time, so they sort chronologically
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+	for _, entry := range entries {
+		name := filepath.Join(q.path, entry.Name())
+		if strings.HasSuffix(entry.Name(), diskQueueExtension+".tmp") {
+			_ = os.Remove(name)
+			continue
+		}
+		if !entry.Type().IsRegular() || filepath.Ext(entry.Name()) != diskQueueExtension {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			log.Warnf("Could not read retried payload %s: %v", name, err)
+			continue
+		}
+		q.add(name, info.Size())
+	}
+	return nil
+}
+
+// encodeRetryPayload encodes the headers and the body of p.
+func encodeRetryPayload(p *payload) ([]byte, error) {
+	headers, err := json.Marshal(p.headers)
+	if err != nil {
+		return nil, err
+	}
+	data := make([]byte, diskQueueHeaderSize, diskQueueHeaderSize+len(headers)+p.body.Len())
+	data[0] = diskQueueVersion
+	binary.BigEndian.PutUint32(data[1:], uint32(len(headers)))
+	data = append(data, headers...)
+	return append(data, p.body.Bytes()...), nil
+}
+
+// decodeRetryPayload decodes a payload encoded by encodeRetryPayload.
+func decodeRetryPayload(data []byte) (*payload, error) {
+	if len(data) < diskQueueHeaderSize || data[0] != diskQueueVersion {
+		return nil, errCorruptedPayload
+	}
+	headersLen := int(binary.BigEndian.Uint32(data[1:]))
+	if len(data) < diskQueueHeaderSize+headersLen {
+		return nil, errCorruptedPayload
+	}
+	var headers map[string]string
+	if err := json.Unmarshal(data[diskQueueHeaderSize:diskQueueHeaderSize+headersLen], &headers); err != nil {
+		return nil, errCorruptedPayload
+	}
+	p := newPayload(headers)
+	p.body.Write(data[diskQueueHeaderSize+headersLen:])
+	return p, nil
+}
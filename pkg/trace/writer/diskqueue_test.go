@@ -0,0 +1,117 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package writer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRetryPayload(body string) *payload {
+	p := newPayload(map[string]string{"Content-Type": "application/x-protobuf"})
+	p.body.WriteString(body)
+	return p
+}
+
+func TestDiskRetryQueue(t *testing.T) {
+	t.Run("store", func(t *testing.T) {
+		q, err := newDiskRetryQueue(t.TempDir(), 1024)
+		require.NoError(t, err)
+		for _, body := range []string{"first", "second", "third"} {
+			_, _, err := q.store(newTestRetryPayload(body))
+			require.NoError(t, err)
+		}
+		assert.Equal(t, 3, q.len())
+
+		for _, body := range []string{"first", "second", "third"} {
+			name, p, err := q.peekOldest()
+			require.NoError(t, err)
+			assert.Equal(t, body, p.body.String())
+			assert.Equal(t, map[string]string{"Content-Type": "application/x-protobuf"}, p.headers)
+			assert.EqualValues(t, 0, p.retries.Load())
+			// the payload stays in the queue until it's removed
+			assert.FileExists(t, name)
+			_, again, err := q.peekOldest()
+			require.NoError(t, err)
+			assert.Equal(t, body, again.body.String())
+			q.remove(name)
+			assert.NoFileExists(t, name)
+		}
+		name, p, err := q.peekOldest()
+		assert.NoError(t, err)
+		assert.Empty(t, name)
+		assert.Nil(t, p)
+		assert.EqualValues(t, 0, q.sizeInBytes())
+
+		entries, err := os.ReadDir(q.path)
+		require.NoError(t, err)
+		assert.Empty(t, entries)
+	})
+
+	t.Run("evict", func(t *testing.T) {
+		data, err := encodeRetryPayload(newTestRetryPayload("payload-1"))
+		require.NoError(t, err)
+		size := int64(len(data))
+		q, err := newDiskRetryQueue(t.TempDir(), 2*size)
+		require.NoError(t, err)
+
+		for i, body := range []string{"payload-1", "payload-2", "payload-3"} {
+			evicted, evictedBytes, err := q.store(newTestRetryPayload(body))
+			require.NoError(t, err)
+			if i < 2 {
+				assert.Equal(t, 0, evicted)
+			} else {
+				assert.Equal(t, 1, evicted)
+				assert.Equal(t, size, evictedBytes)
+			}
+		}
+		assert.Equal(t, 2, q.len())
+		assert.Equal(t, 2*size, q.sizeInBytes())
+		_, p, err := q.peekOldest()
+		require.NoError(t, err)
+		assert.Equal(t, "payload-2", p.body.String())
+
+		_, _, err = q.store(newTestRetryPayload(string(make([]byte, 2*size))))
+		assert.Error(t, err)
+	})
+
+	t.Run("reload", func(t *testing.T) {
+		dir := t.TempDir()
+		q, err := newDiskRetryQueue(dir, 1024)
+		require.NoError(t, err)
+		for _, body := range []string{"first", "second"} {
+			_, _, err := q.store(newTestRetryPayload(body))
+			require.NoError(t, err)
+		}
+		// left by a crash during a write
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "00000000000000000000_0000000000.retry.tmp"), []byte("x"), 0600))
+
+		reloaded, err := newDiskRetryQueue(dir, 1024)
+		require.NoError(t, err)
+		assert.Equal(t, 2, reloaded.len())
+		assert.Equal(t, q.sizeInBytes(), reloaded.sizeInBytes())
+		_, p, err := reloaded.peekOldest()
+		require.NoError(t, err)
+		assert.Equal(t, "first", p.body.String())
+		assert.NoFileExists(t, filepath.Join(dir, "00000000000000000000_0000000000.retry.tmp"))
+	})
+
+	t.Run("corrupted", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "00000000000000000000_0000000000.retry"), []byte("garbage"), 0600))
+		q, err := newDiskRetryQueue(dir, 1024)
+		require.NoError(t, err)
+		assert.Equal(t, 1, q.len())
+		name, _, err := q.peekOldest()
+		assert.ErrorIs(t, err, errCorruptedPayload)
+		q.remove(name)
+		assert.Equal(t, 0, q.len())
+	})
+}
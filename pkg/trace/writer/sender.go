@@ -16,7 +16,9 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"path/filepath"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -44,6 +46,14 @@ func newSenders(cfg *config.AgentConfig, r eventRecorder, path string, climit, q
 			log.Criticalf("Invalid host endpoint: %q", endpoint.Host)
 			os.Exit(1)
 		}
+		var diskQueue *diskRetryQueue
+		if cfg.DiskRetry.Enabled {
+			dir := filepath.Join(cfg.DiskRetry.Path, filepath.Base(path), diskQueueDirname(url.Host))
+			if diskQueue, err = newDiskRetryQueue(dir, cfg.DiskRetry.MaxSizeBytes); err != nil {
+				log.Errorf("Could not create the disk retry queue in %s, failed payloads will be dropped: %v", dir, err)
+				diskQueue = nil
+			}
+		}
 		senders[i] = newSender(&senderConfig{
 			client:       cfg.NewHTTPClient(),
 			maxConns:     int(maxConns),
@@ -55,11 +65,22 @@ func newSenders(cfg *config.AgentConfig, r eventRecorder, path string, climit, q
 			userAgent:    fmt.Sprintf("Datadog Trace Agent/%s/%s", cfg.AgentVersion, cfg.GitCommit),
 			isMRF:        endpoint.IsMRF,
 			isMRFEnabled: cfg.IsMRFEnabled,
+			diskQueue:    diskQueue,
 		}, statsd)
 	}
 	return senders
 }
 
+// diskQueueDirname returns the name of the directory holding the retried payloads of host.
+func diskQueueDirname(host string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '.' {
+			return r
+		}
+		return '_'
+	}, host)
+}
+
 func maxConns(climit int, endpoints []*config.Endpoint) int {
 	// spread out the the maximum connection limit (climit) between senders.
 	// We exclude multi-region failover senders from this calculation, since they
@@ -99,6 +120,15 @@ const (
 	// eventTypeDropped specifies that a payload had to be dropped to make room
 	// in the queue.
 	eventTypeDropped
+	// eventTypeStored specifies that a payload was stored in the disk retry queue
+	// after failing all its retries.
+	eventTypeStored
+	// eventTypeReplayed specifies that a payload was taken from the disk retry
+	// queue to be sent again.
+	eventTypeReplayed
+	// eventTypeEvicted specifies that payloads were removed from the disk retry
+	// queue to make room for newer ones.
+	eventTypeEvicted
 )
 
 var eventTypeStrings = map[eventType]string{
@@ -106,6 +136,9 @@ var eventTypeStrings = map[eventType]string{
 	eventTypeSent:     "eventTypeSent",
 	eventTypeRejected: "eventTypeRejected",
 	eventTypeDropped:  "eventTypeDropped",
+	eventTypeStored:   "eventTypeStored",
+	eventTypeReplayed: "eventTypeReplayed",
+	eventTypeEvicted:  "eventTypeEvicted",
 }
 
 // String implements fmt.Stringer.
@@ -159,6 +192,8 @@ type senderConfig struct {
 	isMRF bool
 	// IsMRFEnabled determines whether Multi-Region Failover is enabled.
 	isMRFEnabled func() bool
+	// diskQueue, if set, stores the payloads which failed all their retries.
+	diskQueue *diskRetryQueue
 }
 
 // sender is responsible for sending payloads to a given URL. It uses a size-limited
@@ -174,6 +209,11 @@ type sender struct {
 	closed  bool         // closed reports if the loop is stopped
 	statsd  statsd.ClientInterface
 	enabled bool // false on inactive MRF senders. True otherwise
+
+	failing    *atomic.Bool  // reports whether the last payload sent failed with a retriable error
+	replayWake chan struct{} // notifies the replay loop that a payload was sent
+	replayStop chan struct{} // stops the replay loop
+	replayDone chan struct{} // closed when the replay loop returns
 }
 
 // newSender returns a new sender based on the given config cfg.
@@ -185,10 +225,21 @@ func newSender(cfg *senderConfig, statsd statsd.ClientInterface) *sender {
 		maxRetries: int32(cfg.maxRetries),
 		statsd:     statsd,
 		enabled:    true,
+		failing:    atomic.NewBool(false),
+		replayWake: make(chan struct{}, 1),
+		replayStop: make(chan struct{}),
+		replayDone: make(chan struct{}),
 	}
 	for i := 0; i < cfg.maxConns; i++ {
 		go s.loop()
 	}
+	if cfg.diskQueue != nil {
+		// replay the payloads left by a previous run
+		s.wakeReplay()
+		go s.replay()
+	} else {
+		close(s.replayDone)
+	}
 	return &s
 }
 
@@ -199,6 +250,59 @@ func (s *sender) loop() {
 	}
 }
 
+// replayProbeInterval specifies how often a payload of the disk retry queue is sent while
+// the destination is failing, to detect that it recovered.
+var replayProbeInterval = 10 * time.Second
+
+// replay sends again the payloads of the disk retry queue, oldest first, while the
+// destination accepts payloads and the sender has idle connections.
+func (s *sender) replay() {
+	defer close(s.replayDone)
+	tick := time.NewTicker(replayProbeInterval)
+	defer tick.Stop()
+	for {
+		probe := false
+		select {
+		case <-s.replayStop:
+			return
+		case <-s.replayWake:
+		case <-tick.C:
+			probe = true
+		}
+		if s.cfg.isMRF && s.cfg.isMRFEnabled != nil && !s.cfg.isMRFEnabled() {
+			// inactive failover destination
+			continue
+		}
+		for (probe || !s.failing.Load()) && int(s.inflight.Load()) < s.cfg.maxConns {
+			probe = false
+			name, p, err := s.cfg.diskQueue.peekOldest()
+			if name == "" {
+				break
+			}
+			if err != nil {
+				log.Warnf("Dropping unreadable payload from the disk retry queue: %v", err)
+				s.cfg.diskQueue.remove(name)
+				continue
+			}
+			if !s.push(p) {
+				// the sender is closed, keep the payload for the next run
+				return
+			}
+			// the payload is stored again if it fails once more
+			s.cfg.diskQueue.remove(name)
+			s.recordEvent(eventTypeReplayed, &eventData{bytes: p.body.Len(), count: 1})
+		}
+	}
+}
+
+// wakeReplay notifies the replay loop, if any, that the destination accepted a payload.
+func (s *sender) wakeReplay() {
+	select {
+	case s.replayWake <- struct{}{}:
+	default:
+	}
+}
+
 // backoff triggers a sleep period proportional to the retry attempt, if any.
 func (s *sender) backoff(attempt int) {
 	delay := backoffDuration(attempt)
@@ -211,6 +315,8 @@ func (s *sender) backoff(attempt int) {
 // Stop stops the sender. It attempts to wait for all inflight payloads to complete
 // with a timeout of 5 seconds.
 func (s *sender) Stop() {
+	close(s.replayStop)
+	<-s.replayDone
 	s.WaitForInflight()
 	s.mu.Lock()
 	s.closed = true
@@ -238,10 +344,16 @@ outer:
 
 // Push pushes p onto the sender's queue, to be written to the destination.
 func (s *sender) Push(p *payload) {
+	s.push(p)
+}
+
+// push pushes p onto the queue and reports whether it was enqueued, which is not the
+// case once the sender is closed.
+func (s *sender) push(p *payload) bool {
 	s.mu.RLock()
 	if s.closed {
 		s.mu.RUnlock()
-		return
+		return false
 	}
 	s.mu.RUnlock()
 	select {
@@ -251,6 +363,7 @@ func (s *sender) Push(p *payload) {
 		s.queue <- p
 	}
 	s.inflight.Inc()
+	return true
 }
 
 // sendPayload sends the payload p to the destination URL.
@@ -289,10 +402,11 @@ func (s *sender) sendOnce(p *payload) bool {
 		s.mu.RLock()
 		defer s.mu.RUnlock()
 		if s.closed {
-			s.releasePayload(p, eventTypeDropped, stats)
 			// sender is stopped
+			s.storeOrDrop(p, stats)
 			return true
 		}
+		s.failing.Store(true)
 
 		if r := p.retries.Inc(); (r&(r-1)) == 0 && r > 3 {
 			// Only log a warning if the retry attempt is a power of 2
@@ -301,15 +415,20 @@ func (s *sender) sendOnce(p *payload) bool {
 			log.Warnf("Retried payload %d times: %s", r, err.Error())
 		}
 		if p.retries.Load() >= s.maxRetries {
-			log.Warnf("Dropping Payload after %d retries, due to: %v.\n", p.retries.Load(), err)
-			// queue is full; since this is the oldest payload, we drop it
-			s.releasePayload(p, eventTypeDropped, stats)
+			if s.cfg.diskQueue == nil {
+				log.Warnf("Dropping Payload after %d retries, due to: %v.\n", p.retries.Load(), err)
+			}
+			s.storeOrDrop(p, stats)
 			return true
 		}
 		s.recordEvent(eventTypeRetry, stats)
 		return false
 	case nil:
+		s.failing.Store(false)
 		s.releasePayload(p, eventTypeSent, stats)
+		if s.cfg.diskQueue != nil {
+			s.wakeReplay()
+		}
 	default:
 		// this is a fatal error, we have to drop this payload
 		log.Warnf("Dropping Payload due to non-retryable error: %v.\n", err)
@@ -318,6 +437,36 @@ func (s *sender) sendOnce(p *payload) bool {
 	return true
 }
 
+// storeOrDrop stores the payload p in the disk retry queue if there is one, or drops it.
+func (s *sender) storeOrDrop(p *payload, data *eventData) {
+	if s.cfg.diskQueue == nil {
+		s.releasePayload(p, eventTypeDropped, data)
+		return
+	}
+	evicted, evictedBytes, err := s.cfg.diskQueue.store(p)
+	if evicted > 0 {
+		s.recordEvent(eventTypeEvicted, &eventData{count: evicted, bytes: int(evictedBytes)})
+	}
+	if err != nil {
+		log.Warnf("Dropping Payload, it could not be stored in the disk retry queue: %v", err)
+		s.releasePayload(p, eventTypeDropped, data)
+		return
+	}
+	s.releasePayload(p, eventTypeStored, data)
+}
+
+// diskQueueSize returns the disk space used by the disk retry queues of senders, and
+// whether any of them has one.
+func diskQueueSize(senders []*sender) (size int64, ok bool) {
+	for _, s := range senders {
+		if s.cfg.diskQueue != nil {
+			size += s.cfg.diskQueue.sizeInBytes()
+			ok = true
+		}
+	}
+	return size, ok
+}
+
 // waitForSenders blocks until all senders have sent their inflight payloads
 func waitForSenders(senders []*sender) {
 	var wg sync.WaitGroup
@@ -315,6 +315,113 @@ func TestSender(t *testing.T) {
 	})
 }
 
+func TestSenderDiskRetry(t *testing.T) {
+	testSenderConfig := func(serverURL string, q *diskRetryQueue, r eventRecorder) *senderConfig {
+		url, err := url.Parse(serverURL + "/")
+		if err != nil {
+			t.Fatal(err)
+		}
+		cfg := config.New()
+		cfg.ConnectionResetInterval = 0
+		return &senderConfig{
+			client:     cfg.NewHTTPClient(),
+			url:        url,
+			maxConns:   1,
+			maxQueued:  1,
+			maxRetries: 2,
+			apiKey:     testAPIKey,
+			userAgent:  "testUserAgent",
+			recorder:   r,
+			diskQueue:  q,
+		}
+	}
+	defer useBackoffDuration(0)()
+
+	t.Run("store", func(t *testing.T) {
+		assert := assert.New(t)
+		server := newTestServer()
+		defer server.Close()
+		q, err := newDiskRetryQueue(t.TempDir(), 1024)
+		assert.NoError(err)
+
+		var recorder mockRecorder
+		s := newSender(testSenderConfig(server.URL, q, &recorder), &statsd.NoOpClient{})
+		s.Push(expectResponses(503, 503, 200))
+		s.WaitForInflight()
+		assert.Equal(1, q.len())
+		assert.Len(recorder.data(eventTypeStored), 1)
+		assert.Empty(recorder.data(eventTypeDropped))
+
+		// the stored payload is sent again once the destination accepts payloads
+		s.Push(expectResponses(200))
+		assert.Eventually(func() bool { return server.Accepted() == 2 }, 5*time.Second, 10*time.Millisecond)
+		s.Stop()
+		assert.Equal(0, q.len())
+		assert.Equal(4, server.Total())
+		assert.Len(recorder.data(eventTypeReplayed), 1)
+	})
+
+	t.Run("restart", func(t *testing.T) {
+		assert := assert.New(t)
+		server := newTestServer()
+		defer server.Close()
+		dir := t.TempDir()
+		q, err := newDiskRetryQueue(dir, 1024)
+		assert.NoError(err)
+		for i := 0; i < 3; i++ {
+			_, _, err := q.store(expectResponses(200))
+			assert.NoError(err)
+		}
+
+		q, err = newDiskRetryQueue(dir, 1024)
+		assert.NoError(err)
+		s := newSender(testSenderConfig(server.URL, q, nil), &statsd.NoOpClient{})
+		assert.Eventually(func() bool { return server.Accepted() == 3 }, 5*time.Second, 10*time.Millisecond)
+		s.Stop()
+		assert.Equal(0, q.len())
+	})
+
+	t.Run("evict", func(t *testing.T) {
+		assert := assert.New(t)
+		server := newTestServer()
+		defer server.Close()
+		p := expectResponses(503)
+		data, err := encodeRetryPayload(p)
+		assert.NoError(err)
+		// room for two payloads
+		q, err := newDiskRetryQueue(t.TempDir(), int64(len(data)*5/2))
+		assert.NoError(err)
+
+		var recorder mockRecorder
+		s := newSender(testSenderConfig(server.URL, q, &recorder), &statsd.NoOpClient{})
+		s.Push(p)
+		s.Push(expectResponses(503))
+		s.Push(expectResponses(503))
+		s.WaitForInflight()
+		s.Stop()
+		assert.Equal(2, q.len())
+		assert.Len(recorder.data(eventTypeStored), 3)
+		assert.Len(recorder.data(eventTypeEvicted), 1)
+		assert.Equal(1, recorder.data(eventTypeEvicted)[0].count)
+	})
+
+	t.Run("closed", func(t *testing.T) {
+		assert := assert.New(t)
+		server := newTestServer()
+		defer server.Close()
+		q, err := newDiskRetryQueue(t.TempDir(), 1024)
+		assert.NoError(err)
+		_, _, err = q.store(expectResponses(200))
+		assert.NoError(err)
+
+		s := newSender(testSenderConfig(server.URL, q, nil), &statsd.NoOpClient{})
+		s.Stop()
+		assert.False(s.push(expectResponses(200)))
+		// the stored payload is either sent before the sender stops, or kept for the next run
+		assert.Equal(1, q.len()+server.Total())
+	})
+}
+
 func TestPayload(t *testing.T) {
 	expectBody := bytes.NewBufferString("body")
 	bodyLength := strconv.Itoa(expectBody.Len())
@@ -369,6 +476,7 @@ func useBackoffDuration(d time.Duration) func() {
 type mockRecorder struct {
 	mu                             sync.RWMutex
 	retry, sent, dropped, rejected []*eventData
+	stored, replayed, evicted      []*eventData
 }
 
 // data returns all call data for the given eventType.
@@ -384,6 +492,12 @@ func (r *mockRecorder) data(t eventType) []*eventData {
 		return r.dropped
 	case eventTypeRejected:
 		return r.rejected
+	case eventTypeStored:
+		return r.stored
+	case eventTypeReplayed:
+		return r.replayed
+	case eventTypeEvicted:
+		return r.evicted
 	default:
 		panic("unknown event")
 	}
@@ -402,5 +516,11 @@ func (r *mockRecorder) recordEvent(t eventType, data *eventData) {
 		r.dropped = append(r.dropped, data)
 	case eventTypeRejected:
 		r.rejected = append(r.rejected, data)
+	case eventTypeStored:
+		r.stored = append(r.stored, data)
+	case eventTypeReplayed:
+		r.replayed = append(r.replayed, data)
+	case eventTypeEvicted:
+		r.evicted = append(r.evicted, data)
 	}
 }
@@ -366,6 +366,10 @@ func (w *DatadogStatsWriter) report() {
 	_ = w.statsd.Count("datadog.trace_agent.stats_writer.retries", w.stats.Retries.Swap(0), nil, 1)
 	_ = w.statsd.Count("datadog.trace_agent.stats_writer.splits", w.stats.Splits.Swap(0), nil, 1)
 	_ = w.statsd.Count("datadog.trace_agent.stats_writer.errors", w.stats.Errors.Swap(0), nil, 1)
+	if size, ok := diskQueueSize(w.senders); ok {
+		w.stats.DiskBytes.Store(size)
+		_ = w.statsd.Gauge("datadog.trace_agent.stats_writer.disk_retry.bytes", float64(size), nil, 1)
+	}
 }
 
 // recordEvent implements eventRecorder.
@@ -393,5 +397,22 @@ func (w *DatadogStatsWriter) recordEvent(t eventType, data *eventData) {
 		w.easylog.Warn("Stats writer queue full. Payload dropped (%.2fKB).", float64(data.bytes)/1024)
 		_ = w.statsd.Count("datadog.trace_agent.stats_writer.dropped", 1, nil, 1)
 		_ = w.statsd.Count("datadog.trace_agent.stats_writer.dropped_bytes", int64(data.bytes), nil, 1)
+
+	case eventTypeStored:
+		w.easylog.Warn("Stats writer payload stored on disk to be retried later (%.2fKB); error: %v", float64(data.bytes)/1024, data.err)
+		w.stats.DiskStored.Inc()
+		_ = w.statsd.Count("datadog.trace_agent.stats_writer.disk_retry.stored", 1, nil, 1)
+
+	case eventTypeReplayed:
+		log.Debugf("Retrying stats payload stored on disk (%.2fKB)", float64(data.bytes)/1024)
+		w.stats.DiskReplayed.Inc()
+		_ = w.statsd.Count("datadog.trace_agent.stats_writer.disk_retry.replayed", 1, nil, 1)
+
+	case eventTypeEvicted:
+		w.easylog.Warn("Disk retry queue full. %d stats payloads dropped (%.2fKB).", data.count, float64(data.bytes)/1024)
+		w.stats.DiskEvicted.Add(int64(data.count))
+		_ = w.statsd.Count("datadog.trace_agent.stats_writer.disk_retry.evicted", int64(data.count), nil, 1)
+		_ = w.statsd.Count("datadog.trace_agent.stats_writer.dropped", int64(data.count), nil, 1)
+		_ = w.statsd.Count("datadog.trace_agent.stats_writer.dropped_bytes", int64(data.bytes), nil, 1)
 	}
 }
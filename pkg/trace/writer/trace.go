@@ -326,6 +326,10 @@ func (w *TraceWriter) report() {
 	_ = w.statsd.Count("datadog.trace_agent.trace_writer.traces", w.stats.Traces.Swap(0), nil, 1)
 	_ = w.statsd.Count("datadog.trace_agent.trace_writer.events", w.stats.Events.Swap(0), nil, 1)
 	_ = w.statsd.Count("datadog.trace_agent.trace_writer.spans", w.stats.Spans.Swap(0), nil, 1)
+	if size, ok := diskQueueSize(w.senders); ok {
+		w.stats.DiskBytes.Store(size)
+		_ = w.statsd.Gauge("datadog.trace_agent.trace_writer.disk_retry.bytes", float64(size), nil, 1)
+	}
 }
 
 var _ eventRecorder = (*TraceWriter)(nil)
@@ -358,5 +362,22 @@ func (w *TraceWriter) recordEvent(t eventType, data *eventData) {
 		w.easylog.Warn("Trace Payload dropped (%.2fKB).", float64(data.bytes)/1024)
 		_ = w.statsd.Count("datadog.trace_agent.trace_writer.dropped", 1, nil, 1)
 		_ = w.statsd.Count("datadog.trace_agent.trace_writer.dropped_bytes", int64(data.bytes), nil, 1)
+
+	case eventTypeStored:
+		w.easylog.Warn("Trace Payload stored on disk to be retried later (%.2fKB); error: %v", float64(data.bytes)/1024, data.err)
+		w.stats.DiskStored.Inc()
+		_ = w.statsd.Count("datadog.trace_agent.trace_writer.disk_retry.stored", 1, nil, 1)
+
+	case eventTypeReplayed:
+		log.Debugf("Retrying trace payload stored on disk (%.2fKB)", float64(data.bytes)/1024)
+		w.stats.DiskReplayed.Inc()
+		_ = w.statsd.Count("datadog.trace_agent.trace_writer.disk_retry.replayed", 1, nil, 1)
+
+	case eventTypeEvicted:
+		w.easylog.Warn("Disk retry queue full. %d trace payloads dropped (%.2fKB).", data.count, float64(data.bytes)/1024)
+		w.stats.DiskEvicted.Add(int64(data.count))
+		_ = w.statsd.Count("datadog.trace_agent.trace_writer.disk_retry.evicted", int64(data.count), nil, 1)
+		_ = w.statsd.Count("datadog.trace_agent.trace_writer.dropped", int64(data.count), nil, 1)
+		_ = w.statsd.Count("datadog.trace_agent.trace_writer.dropped_bytes", int64(data.bytes), nil, 1)
 	}
 }
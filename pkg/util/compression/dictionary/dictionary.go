@@ -0,0 +1,285 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// Package dictionary provides the training of zstd dictionaries from recent payloads
+package dictionary
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+
+	"github.com/DataDog/datadog-agent/pkg/util/compression"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	// dictionary IDs below 32768 and above 2^31 are reserved by the zstd format
+	minDictionaryID = 1 << 15
+	maxDictionaryID = 1 << 31
+
+	// DefaultMaxSamples is the default number of payloads sampled before training a dictionary
+	DefaultMaxSamples = 64
+	// DefaultMaxSampleSize is the default maximum number of bytes kept from each sampled payload
+	DefaultMaxSampleSize = 64 * 1024
+	// DefaultMaxDictionarySize is the default maximum size of a trained dictionary
+	DefaultMaxDictionarySize = 112 * 1024
+)
+
+// Train builds a zstd dictionary from sample payloads, tuned for the given compression level. The content of the
+// dictionary is taken from the oldest half of the samples, and its statistics from compressing the most recent half
+// with it, like the payloads that will be compressed with the dictionary. The content is truncated to maxSize.
+func Train(samples [][]byte, level int, maxSize int) (dictionary []byte, err error) {
+	// the zstd library panics on some degenerate samples, like the ones entirely made of content found in the history
+	defer func() {
+		if r := recover(); r != nil {
+			dictionary, err = nil, fmt.Errorf("could not build the dictionary: %v", r)
+		}
+	}()
+
+	if len(samples) < 2 {
+		return nil, errors.New("not enough samples to train the dictionary from")
+	}
+
+	half := len(samples) / 2
+	history := bytes.Join(samples[:half], nil)
+	if len(history) > maxSize {
+		history = history[len(history)-maxSize:]
+	}
+
+	return zstd.BuildDict(zstd.BuildDictOptions{
+		ID:       dictionaryID(history),
+		Contents: samples[half:],
+		History:  history,
+		// default repeat offsets of the zstd format
+		Offsets: [3]int{1, 4, 8},
+		Level:   zstd.EncoderLevelFromZstd(level),
+	})
+}
+
+// dictionaryID returns an ID derived from the content of the dictionary, so that the same dictionary always gets
+// the same ID.
+func dictionaryID(content []byte) uint32 {
+	h := fnv.New32a()
+	_, _ = h.Write(content)
+	return minDictionaryID + h.Sum32()%(maxDictionaryID-minDictionaryID)
+}
+
+// ID returns the ID of a zstd dictionary, or 0 if it isn't a valid one.
+func ID(dictionary []byte) uint32 {
+	d, err := zstd.InspectDictionary(dictionary)
+	if err != nil {
+		return 0
+	}
+	return d.ID()
+}
+
+// TrainingOptions configures a TrainingCompressor
+type TrainingOptions struct {
+	// Level is the compression level the dictionary is tuned for
+	Level int
+	// MaxSamples is the number of payloads sampled before training the dictionary
+	MaxSamples int
+	// MaxSampleSize is the maximum number of bytes kept from each sampled payload
+	MaxSampleSize int
+	// MaxDictionarySize is the maximum size of the dictionary
+	MaxDictionarySize int
+	// OnTrained is called with the dictionary once it's trained, before the compressor starts using it
+	OnTrained func(dictionary []byte)
+}
+
+// TrainingCompressor is a compression.Compressor that samples the payloads it compresses, trains a dictionary from
+// them, and then compresses with that dictionary.
+type TrainingCompressor struct {
+	opts          TrainingOptions
+	newCompressor func(dictionary []byte) compression.Compressor
+
+	mu      sync.RWMutex
+	current compression.Compressor
+	samples [][]byte
+	trained bool
+}
+
+// NewTrainingCompressor returns a compressor that compresses with base until enough payloads are sampled to train a
+// dictionary, and then with the compressor returned by newCompressor for that dictionary.
+func NewTrainingCompressor(base compression.Compressor, newCompressor func(dictionary []byte) compression.Compressor, opts TrainingOptions) *TrainingCompressor {
+	if opts.MaxSamples <= 0 {
+		opts.MaxSamples = DefaultMaxSamples
+	}
+	if opts.MaxSampleSize <= 0 {
+		opts.MaxSampleSize = DefaultMaxSampleSize
+	}
+	if opts.MaxDictionarySize <= 0 {
+		opts.MaxDictionarySize = DefaultMaxDictionarySize
+	}
+	return &TrainingCompressor{
+		opts:          opts,
+		newCompressor: newCompressor,
+		current:       base,
+	}
+}
+
+func (t *TrainingCompressor) get() (compression.Compressor, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.current, t.trained
+}
+
+// Trained returns whether the dictionary is trained and in use
+func (t *TrainingCompressor) Trained() bool {
+	_, trained := t.get()
+	return trained
+}
+
+// addSample records a sampled payload, and trains the dictionary once there are enough of them
+func (t *TrainingCompressor) addSample(sample []byte) {
+	if len(sample) == 0 {
+		return
+	}
+
+	t.mu.Lock()
+	if t.trained {
+		t.mu.Unlock()
+		return
+	}
+	t.samples = append(t.samples, sample)
+	if len(t.samples) < t.opts.MaxSamples {
+		t.mu.Unlock()
+		return
+	}
+	samples := t.samples
+	t.samples = nil
+	t.mu.Unlock()
+
+	// training is CPU intensive, so it's done without holding the lock. Payloads keep being sampled meanwhile, and
+	// a new training starts if it fails.
+	dictionary, err := Train(samples, t.opts.Level, t.opts.MaxDictionarySize)
+	if err != nil {
+		log.Warnf("Could not train a compression dictionary from %d payloads: %v", len(samples), err)
+		return
+	}
+	compressor := t.newCompressor(dictionary)
+	if compressor == nil {
+		return
+	}
+	if t.opts.OnTrained != nil {
+		t.opts.OnTrained(dictionary)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.trained {
+		return
+	}
+	log.Infof("Trained a compression dictionary of %d bytes (ID %d) from %d payloads", len(dictionary), ID(dictionary), len(samples))
+	t.current = compressor
+	t.trained = true
+	t.samples = nil
+}
+
+func (t *TrainingCompressor) truncate(src []byte) []byte {
+	if len(src) > t.opts.MaxSampleSize {
+		src = src[:t.opts.MaxSampleSize]
+	}
+	return bytes.Clone(src)
+}
+
+// Compress compresses the data with the current compressor, sampling it while the dictionary isn't trained
+func (t *TrainingCompressor) Compress(src []byte) ([]byte, error) {
+	current, trained := t.get()
+	if !trained {
+		t.addSample(t.truncate(src))
+	}
+	return current.Compress(src)
+}
+
+// Decompress decompresses the data with the current compressor
+func (t *TrainingCompressor) Decompress(src []byte) ([]byte, error) {
+	current, _ := t.get()
+	return current.Decompress(src)
+}
+
+// CompressBound returns the worst case size needed for a destination buffer with the current compressor
+func (t *TrainingCompressor) CompressBound(sourceLen int) int {
+	current, _ := t.get()
+	return current.CompressBound(sourceLen)
+}
+
+// ContentEncoding returns the content encoding of the current compressor
+func (t *TrainingCompressor) ContentEncoding() string {
+	current, _ := t.get()
+	return current.ContentEncoding()
+}
+
+// NewStreamCompressor returns a stream compressor of the current compressor, sampling what's written to it while the
+// dictionary isn't trained
+func (t *TrainingCompressor) NewStreamCompressor(output *bytes.Buffer) compression.StreamCompressor {
+	current, trained := t.get()
+	zipper := current.NewStreamCompressor(output)
+	if trained || zipper == nil {
+		return zipper
+	}
+	return &samplingStreamCompressor{StreamCompressor: zipper, trainer: t}
+}
+
+// samplingStreamCompressor keeps the beginning of the data written to a stream compressor, to sample it on Close
+type samplingStreamCompressor struct {
+	compression.StreamCompressor
+	trainer *TrainingCompressor
+	sample  bytes.Buffer
+}
+
+func (s *samplingStreamCompressor) Write(p []byte) (int, error) {
+	if room := s.trainer.opts.MaxSampleSize - s.sample.Len(); room > 0 {
+		s.sample.Write(p[:min(room, len(p))])
+	}
+	return s.StreamCompressor.Write(p)
+}
+
+func (s *samplingStreamCompressor) Close() error {
+	err := s.StreamCompressor.Close()
+	if err == nil {
+		s.trainer.addSample(s.sample.Bytes())
+	}
+	return err
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[uint32][]byte{}
+)
+
+// Register makes a dictionary available to decompress the payloads compressed with it, like when they're re-encoded
+// for an intake that doesn't have the dictionary. It returns the ID of the dictionary.
+func Register(dictionary []byte) uint32 {
+	id := ID(dictionary)
+	if id == 0 {
+		return 0
+	}
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry[id] = dictionary
+	return id
+}
+
+// Lookup returns the registered dictionary with the given ID, or nil if there isn't any
+func Lookup(id uint32) []byte {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	return registry[id]
+}
+
+// FrameID returns the ID of the dictionary a zstd payload is compressed with, or 0 if it's compressed without any
+func FrameID(payload []byte) uint32 {
+	var header zstd.Header
+	if err := header.Decode(payload); err != nil {
+		return 0
+	}
+	return header.DictionaryID
+}
@@ -0,0 +1,127 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package dictionary
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/util/compression"
+	zstdimpl "github.com/DataDog/datadog-agent/pkg/util/compression/impl-zstd-nocgo"
+)
+
+func payload(i int) []byte {
+	var b bytes.Buffer
+	for j := 0; j < 20; j++ {
+		fmt.Fprintf(&b, `{"metric":"system.cpu.user","points":[[%d,%d]],"tags":["env:prod","service:web-%d","availability-zone:us-east-1a","kube_namespace:default"],"host":"i-0123456789"}`, 1700000000+i, i*j, j%3)
+	}
+	return b.Bytes()
+}
+
+func newZstd(dictionary []byte) compression.Compressor {
+	return zstdimpl.New(zstdimpl.Requires{Level: 3, Dictionary: dictionary})
+}
+
+func TestTrain(t *testing.T) {
+	samples := [][]byte{}
+	for i := 0; i < 16; i++ {
+		samples = append(samples, payload(i))
+	}
+
+	dictionary, err := Train(samples, 3, 4096)
+	require.NoError(t, err)
+	assert.NotZero(t, ID(dictionary))
+
+	// the same samples always give the same dictionary
+	again, err := Train(samples, 3, 4096)
+	require.NoError(t, err)
+	assert.Equal(t, ID(dictionary), ID(again))
+
+	withDict := newZstd(dictionary)
+	withoutDict := newZstd(nil)
+
+	data := payload(42)
+	compressed, err := withDict.Compress(data)
+	require.NoError(t, err)
+	compressedWithoutDict, err := withoutDict.Compress(data)
+	require.NoError(t, err)
+	assert.Less(t, len(compressed), len(compressedWithoutDict))
+
+	decompressed, err := withDict.Decompress(compressed)
+	require.NoError(t, err)
+	assert.Equal(t, data, decompressed)
+
+	_, err = Train(nil, 3, 4096)
+	assert.Error(t, err)
+	_, err = Train([][]byte{payload(1)}, 3, 4096)
+	assert.Error(t, err)
+	assert.Zero(t, ID([]byte("not a dictionary")))
+}
+
+func TestTrainingCompressor(t *testing.T) {
+	var trainedDictionary []byte
+	c := NewTrainingCompressor(newZstd(nil), newZstd, TrainingOptions{
+		Level:      3,
+		MaxSamples: 4,
+		OnTrained:  func(dictionary []byte) { trainedDictionary = dictionary },
+	})
+
+	compressStream := func(data []byte) []byte {
+		var output bytes.Buffer
+		zipper := c.NewStreamCompressor(&output)
+		_, err := zipper.Write(data)
+		require.NoError(t, err)
+		require.NoError(t, zipper.Close())
+		return output.Bytes()
+	}
+
+	for i := 0; i < 3; i++ {
+		compressStream(payload(i))
+		assert.False(t, c.Trained())
+	}
+	_, err := c.Compress(payload(3))
+	require.NoError(t, err)
+	require.True(t, c.Trained())
+	require.NotNil(t, trainedDictionary)
+
+	data := payload(42)
+	compressed := compressStream(data)
+	decompressed, err := newZstd(trainedDictionary).Decompress(compressed)
+	require.NoError(t, err)
+	assert.Equal(t, data, decompressed)
+
+	// payloads compressed with the dictionary can't be decompressed without it
+	_, err = newZstd(nil).Decompress(compressed)
+	assert.Error(t, err)
+}
+
+func TestRegisterAndFrameID(t *testing.T) {
+	samples := [][]byte{}
+	for i := 0; i < 16; i++ {
+		samples = append(samples, payload(i))
+	}
+	dictionary, err := Train(samples, 3, 4096)
+	require.NoError(t, err)
+
+	id := Register(dictionary)
+	assert.Equal(t, ID(dictionary), id)
+	assert.Equal(t, dictionary, Lookup(id))
+	assert.Nil(t, Lookup(id+1))
+	assert.Zero(t, Register([]byte("not a dictionary")))
+
+	// the ID of the dictionary is in the header of the zstd frames compressed with it
+	compressed, err := newZstd(dictionary).Compress(payload(42))
+	require.NoError(t, err)
+	assert.Equal(t, id, FrameID(compressed))
+	compressed, err = newZstd(nil).Compress(payload(42))
+	require.NoError(t, err)
+	assert.Zero(t, FrameID(compressed))
+	assert.Zero(t, FrameID([]byte("not zstd")))
+}
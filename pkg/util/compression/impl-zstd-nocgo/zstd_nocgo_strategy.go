@@ -20,12 +20,15 @@ import (
 // Requires contains the compression level for zstd compression
 type Requires struct {
 	Level compression.ZstdCompressionLevel
+	// Dictionary is an optional zstd dictionary to compress with. The receiving end needs it to decompress.
+	Dictionary []byte
 }
 
 // ZstdNoCgoStrategy can be manually selected via component - it's not used by any selector / config option
 type ZstdNoCgoStrategy struct {
-	level   int
-	encoder *zstd.Encoder
+	level      int
+	dictionary []byte
+	encoder    *zstd.Encoder
 }
 
 // New returns a new ZstdNoCgoStrategy
@@ -44,19 +47,25 @@ func New(reqs Requires) compression.Compressor {
 	}
 	log.Debugf("native zstd concurrency %d", conc)
 	log.Debugf("native zstd window size %d", window)
-	encoder, err := zstd.NewWriter(nil,
+	opts := []zstd.EOption{
 		zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(level)),
 		zstd.WithEncoderConcurrency(conc),
 		zstd.WithLowerEncoderMem(true),
-		zstd.WithWindowSize(window))
+		zstd.WithWindowSize(window),
+	}
+	if len(reqs.Dictionary) > 0 {
+		opts = append(opts, zstd.WithEncoderDict(reqs.Dictionary))
+	}
+	encoder, err := zstd.NewWriter(nil, opts...)
 	if err != nil {
 		_ = log.Errorf("Error creating zstd encoder: %v", err)
 		return nil
 	}
 
 	return &ZstdNoCgoStrategy{
-		level:   level,
-		encoder: encoder,
+		level:      level,
+		dictionary: reqs.Dictionary,
+		encoder:    encoder,
 	}
 }
 
@@ -67,7 +76,15 @@ func (s *ZstdNoCgoStrategy) Compress(src []byte) ([]byte, error) {
 
 // Decompress will decompress the data with zstd
 func (s *ZstdNoCgoStrategy) Decompress(src []byte) ([]byte, error) {
-	decoder, _ := zstd.NewReader(nil)
+	var opts []zstd.DOption
+	if len(s.dictionary) > 0 {
+		opts = append(opts, zstd.WithDecoderDicts(s.dictionary))
+	}
+	decoder, err := zstd.NewReader(nil, opts...)
+	if err != nil {
+		return nil, err
+	}
+	defer decoder.Close()
 	return decoder.DecodeAll(src, nil)
 }
 
@@ -83,6 +100,10 @@ func (s *ZstdNoCgoStrategy) ContentEncoding() string {
 
 // NewStreamCompressor returns a new zstd Writer
 func (s *ZstdNoCgoStrategy) NewStreamCompressor(output *bytes.Buffer) compression.StreamCompressor {
-	writer, _ := zstd.NewWriter(output, zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(s.level)))
+	opts := []zstd.EOption{zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(s.level))}
+	if len(s.dictionary) > 0 {
+		opts = append(opts, zstd.WithEncoderDict(s.dictionary))
+	}
+	writer, _ := zstd.NewWriter(output, opts...)
 	return writer
 }
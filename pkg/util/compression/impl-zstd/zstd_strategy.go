@@ -12,32 +12,53 @@ import (
 	"github.com/DataDog/zstd"
 
 	"github.com/DataDog/datadog-agent/pkg/util/compression"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
 
 // Requires contains the compression level for zstd compression
 type Requires struct {
 	Level compression.ZstdCompressionLevel
+	// Dictionary is an optional zstd dictionary to compress with. The receiving end needs it to decompress.
+	Dictionary []byte
 }
 
 // ZstdStrategy is the strategy for when serializer_compressor_kind is zstd
 type ZstdStrategy struct {
-	level int
+	level      int
+	dictionary []byte
+	bulk       *zstd.BulkProcessor
 }
 
 // New returns a new ZstdStrategy
 func New(reqs Requires) compression.Compressor {
-	return &ZstdStrategy{
+	s := &ZstdStrategy{
 		level: int(reqs.Level),
 	}
+	if len(reqs.Dictionary) > 0 {
+		bulk, err := zstd.NewBulkProcessor(reqs.Dictionary, s.level)
+		if err != nil {
+			_ = log.Errorf("Error loading zstd dictionary, compressing without it: %v", err)
+			return s
+		}
+		s.dictionary = reqs.Dictionary
+		s.bulk = bulk
+	}
+	return s
 }
 
 // Compress will compress the data with zstd
 func (s *ZstdStrategy) Compress(src []byte) ([]byte, error) {
+	if s.bulk != nil {
+		return s.bulk.Compress(nil, src)
+	}
 	return zstd.CompressLevel(nil, src, s.level)
 }
 
 // Decompress will decompress the data with zstd
 func (s *ZstdStrategy) Decompress(src []byte) ([]byte, error) {
+	if s.bulk != nil {
+		return s.bulk.Decompress(nil, src)
+	}
 	return zstd.Decompress(nil, src)
 }
 
@@ -53,5 +74,8 @@ func (s *ZstdStrategy) ContentEncoding() string {
 
 // NewStreamCompressor returns a new zstd Writer
 func (s *ZstdStrategy) NewStreamCompressor(output *bytes.Buffer) compression.StreamCompressor {
+	if s.dictionary != nil {
+		return zstd.NewWriterLevelDict(output, s.level, s.dictionary)
+	}
 	return zstd.NewWriterLevel(output, s.level)
 }
@@ -7,8 +7,12 @@
 package selector
 
 import (
+	"os"
+
 	"github.com/DataDog/datadog-agent/comp/core/config"
 	common "github.com/DataDog/datadog-agent/pkg/util/compression"
+	"github.com/DataDog/datadog-agent/pkg/util/compression/dictionary"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
 
 // FromConfig will return the compression algorithm specified in the provided config
@@ -29,3 +33,77 @@ func FromConfig(cfg config.Reader) common.Compressor {
 
 	return NewCompressor(kind, level)
 }
+
+// FromConfigForPayload returns the compression algorithm to use for a kind of payload, like "series" or "sketches",
+// which each have their own intake endpoint. The settings under `serializer_compression.<payload kind>` override the
+// global ones, and allow compressing with a zstd dictionary, read from a file or trained from the first payloads.
+// The default compressor is returned when no setting is overridden.
+func FromConfigForPayload(cfg config.Reader, payloadKind string, defaultCompressor common.Compressor) common.Compressor {
+	prefix := "serializer_compression." + payloadKind + "."
+	if !cfg.IsSet(prefix+"compressor_kind") && !cfg.IsSet(prefix+"zstd_compressor_level") &&
+		!cfg.IsSet(prefix+"zstd_dictionary_path") && !cfg.GetBool(prefix+"zstd_dictionary_training") {
+		return defaultCompressor
+	}
+
+	kind, level := KindAndLevelForPayload(cfg, payloadKind)
+	if kind != common.ZstdKind {
+		return NewCompressor(kind, level)
+	}
+
+	dictionaryPath := cfg.GetString(prefix + "zstd_dictionary_path")
+	if dictionaryPath != "" {
+		dict, err := os.ReadFile(dictionaryPath)
+		if err == nil {
+			log.Infof("Compressing %s payloads with the zstd dictionary %s (ID %d)", payloadKind, dictionaryPath, dictionary.Register(dict))
+			return NewCompressorWithDictionary(kind, level, dict)
+		}
+		if !os.IsNotExist(err) || !cfg.GetBool(prefix+"zstd_dictionary_training") {
+			log.Errorf("Could not read the zstd dictionary for %s payloads, compressing without it: %v", payloadKind, err)
+			return NewCompressor(kind, level)
+		}
+	}
+	if !cfg.GetBool(prefix + "zstd_dictionary_training") {
+		return NewCompressor(kind, level)
+	}
+
+	log.Infof("Training a zstd dictionary from the first %s payloads", payloadKind)
+	return dictionary.NewTrainingCompressor(
+		NewCompressor(kind, level),
+		func(dict []byte) common.Compressor { return NewCompressorWithDictionary(kind, level, dict) },
+		dictionary.TrainingOptions{
+			Level: level,
+			OnTrained: func(dict []byte) {
+				// the payloads compressed with the dictionary are re-encoded for the intakes which don't have it
+				dictionary.Register(dict)
+				if dictionaryPath == "" {
+					return
+				}
+				// save the dictionary, for the intake to decompress the payloads and to reuse it after a restart
+				if err := os.WriteFile(dictionaryPath, dict, 0o600); err != nil {
+					log.Errorf("Could not save the zstd dictionary for %s payloads: %v", payloadKind, err)
+				}
+			},
+		},
+	)
+}
+
+// KindAndLevelForPayload returns the compressor kind and level of a kind of payload, like "series" or "sketches",
+// taken from the settings under `serializer_compression.<payload kind>` or else from the global ones.
+func KindAndLevelForPayload(cfg config.Reader, payloadKind string) (string, int) {
+	prefix := "serializer_compression." + payloadKind + "."
+	kind := cfg.GetString("serializer_compressor_kind")
+	if cfg.IsSet(prefix + "compressor_kind") {
+		kind = cfg.GetString(prefix + "compressor_kind")
+	}
+	var level int
+	switch kind {
+	case common.ZstdKind:
+		level = cfg.GetInt("serializer_zstd_compressor_level")
+		if cfg.IsSet(prefix + "zstd_compressor_level") {
+			level = cfg.GetInt(prefix + "zstd_compressor_level")
+		}
+	case common.GzipKind:
+		level = 6
+	}
+	return kind, level
+}
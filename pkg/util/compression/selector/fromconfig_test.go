@@ -0,0 +1,94 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build test && zlib && zstd
+
+package selector
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/comp/core/config"
+	common "github.com/DataDog/datadog-agent/pkg/util/compression"
+	"github.com/DataDog/datadog-agent/pkg/util/compression/dictionary"
+)
+
+func payload(i int) []byte {
+	return []byte(fmt.Sprintf(`{"metric":"system.cpu.user","points":[[%d,%d]],"tags":["env:prod","service:web-%d"],"host":"i-0123456789"}`, 1700000000+i, i*7, i%3))
+}
+
+func TestFromConfigForPayloadDefault(t *testing.T) {
+	cfg := config.NewMock(t)
+	defaultCompressor := FromConfig(cfg)
+
+	assert.Same(t, defaultCompressor, FromConfigForPayload(cfg, "series", defaultCompressor))
+
+	cfg.SetWithoutSource("serializer_compression.series.compressor_kind", common.GzipKind)
+	assert.Equal(t, common.GzipEncoding, FromConfigForPayload(cfg, "series", defaultCompressor).ContentEncoding())
+	assert.Same(t, defaultCompressor, FromConfigForPayload(cfg, "sketches", defaultCompressor))
+}
+
+func TestFromConfigForPayloadDictionary(t *testing.T) {
+	samples := [][]byte{}
+	for i := 0; i < 16; i++ {
+		samples = append(samples, payload(i))
+	}
+	dict, err := dictionary.Train(samples, 3, 4096)
+	require.NoError(t, err)
+	dictionaryPath := filepath.Join(t.TempDir(), "series.dict")
+	require.NoError(t, os.WriteFile(dictionaryPath, dict, 0o600))
+
+	cfg := config.NewMock(t)
+	cfg.SetWithoutSource("serializer_compressor_kind", common.ZlibKind)
+	cfg.SetWithoutSource("serializer_compression.series.compressor_kind", common.ZstdKind)
+	cfg.SetWithoutSource("serializer_compression.series.zstd_dictionary_path", dictionaryPath)
+	compressor := FromConfigForPayload(cfg, "series", FromConfig(cfg))
+	assert.Equal(t, common.ZstdEncoding, compressor.ContentEncoding())
+
+	compressed, err := compressor.Compress(payload(42))
+	require.NoError(t, err)
+	_, err = NewCompressor(common.ZstdKind, 1).Decompress(compressed)
+	assert.Error(t, err)
+	decompressed, err := NewCompressorWithDictionary(common.ZstdKind, 1, dict).Decompress(compressed)
+	require.NoError(t, err)
+	assert.Equal(t, payload(42), decompressed)
+}
+
+func TestFromConfigForPayloadTraining(t *testing.T) {
+	dictionaryPath := filepath.Join(t.TempDir(), "sketches.dict")
+
+	cfg := config.NewMock(t)
+	cfg.SetWithoutSource("serializer_compressor_kind", common.ZstdKind)
+	cfg.SetWithoutSource("serializer_compression.sketches.zstd_dictionary_path", dictionaryPath)
+	cfg.SetWithoutSource("serializer_compression.sketches.zstd_dictionary_training", true)
+	compressor := FromConfigForPayload(cfg, "sketches", FromConfig(cfg))
+	require.IsType(t, &dictionary.TrainingCompressor{}, compressor)
+
+	for i := 0; !compressor.(*dictionary.TrainingCompressor).Trained(); i++ {
+		require.Less(t, i, dictionary.DefaultMaxSamples)
+		_, err := compressor.Compress(payload(i))
+		require.NoError(t, err)
+	}
+
+	// the trained dictionary is saved, and used after a restart
+	dict, err := os.ReadFile(dictionaryPath)
+	require.NoError(t, err)
+	assert.NotZero(t, dictionary.ID(dict))
+	compressor = FromConfigForPayload(cfg, "sketches", FromConfig(cfg))
+	_, training := compressor.(*dictionary.TrainingCompressor)
+	assert.False(t, training)
+
+	compressed, err := compressor.Compress(payload(42))
+	require.NoError(t, err)
+	decompressed, err := NewCompressorWithDictionary(common.ZstdKind, 1, dict).Decompress(compressed)
+	require.NoError(t, err)
+	assert.Equal(t, payload(42), decompressed)
+}
@@ -31,6 +31,16 @@ func NewCompressor(kind string, level int) common.Compressor {
 	}
 }
 
+// NewCompressorWithDictionary returns a new Compressor based on serializer_compressor_kind. Dictionaries are only
+// supported by zstd, so they're ignored.
+// This function is called only when there is no zlib or zstd tag
+func NewCompressorWithDictionary(kind string, level int, dictionary []byte) common.Compressor {
+	if len(dictionary) > 0 {
+		log.Warn("zstd build tag not included. compressing without dictionary")
+	}
+	return NewCompressor(kind, level)
+}
+
 // NewNoopCompressor returns a new Noop Compressor. It does not do any
 // compression, but can be used to create a compressor that does at a later
 // point.
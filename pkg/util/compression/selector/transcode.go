@@ -0,0 +1,58 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package selector
+
+import (
+	"fmt"
+
+	common "github.com/DataDog/datadog-agent/pkg/util/compression"
+	"github.com/DataDog/datadog-agent/pkg/util/compression/dictionary"
+)
+
+// identityEncoding is the content encoding of uncompressed payloads
+const identityEncoding = "identity"
+
+// KindOfEncoding returns the compressor kind of a content encoding, and whether it's a known one
+func KindOfEncoding(contentEncoding string) (string, bool) {
+	switch contentEncoding {
+	case common.ZstdEncoding:
+		return common.ZstdKind, true
+	case common.GzipEncoding:
+		return common.GzipKind, true
+	case common.ZlibEncoding:
+		return common.ZlibKind, true
+	case identityEncoding, "":
+		return common.NoneKind, true
+	default:
+		return "", false
+	}
+}
+
+// Transcode decompresses a payload compressed with the given content encoding, and compresses it again with the
+// compressor. zstd payloads compressed with a dictionary need it to be registered in the dictionary package.
+func Transcode(payload []byte, contentEncoding string, compressor common.Compressor) ([]byte, error) {
+	kind, ok := KindOfEncoding(contentEncoding)
+	if !ok {
+		return nil, fmt.Errorf("unsupported content encoding %q", contentEncoding)
+	}
+
+	decompressor := NewCompressor(kind, 0)
+	if kind == common.ZstdKind {
+		if id := dictionary.FrameID(payload); id != 0 {
+			dict := dictionary.Lookup(id)
+			if dict == nil {
+				return nil, fmt.Errorf("the payload is compressed with the unknown zstd dictionary %d", id)
+			}
+			decompressor = NewCompressorWithDictionary(kind, 0, dict)
+		}
+	}
+
+	raw, err := decompressor.Decompress(payload)
+	if err != nil {
+		return nil, err
+	}
+	return compressor.Compress(raw)
+}
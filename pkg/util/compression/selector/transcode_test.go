@@ -0,0 +1,58 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build test && zlib && zstd
+
+package selector
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	common "github.com/DataDog/datadog-agent/pkg/util/compression"
+	"github.com/DataDog/datadog-agent/pkg/util/compression/dictionary"
+)
+
+func TestTranscode(t *testing.T) {
+	gzip := NewCompressor(common.GzipKind, 6)
+
+	compressed, err := NewCompressor(common.ZlibKind, 0).Compress(payload(1))
+	require.NoError(t, err)
+	transcoded, err := Transcode(compressed, common.ZlibEncoding, gzip)
+	require.NoError(t, err)
+	decompressed, err := gzip.Decompress(transcoded)
+	require.NoError(t, err)
+	assert.Equal(t, payload(1), decompressed)
+
+	_, err = Transcode(compressed, "br", gzip)
+	assert.Error(t, err)
+}
+
+func TestTranscodeDictionary(t *testing.T) {
+	// the samples differ from the other tests, since the dictionaries are registered globally
+	samples := [][]byte{}
+	for i := 100; i < 116; i++ {
+		samples = append(samples, payload(i))
+	}
+	dict, err := dictionary.Train(samples, 3, 4096)
+	require.NoError(t, err)
+	compressed, err := NewCompressorWithDictionary(common.ZstdKind, 3, dict).Compress(payload(42))
+	require.NoError(t, err)
+	zstd := NewCompressor(common.ZstdKind, 3)
+
+	// the dictionary is needed to decompress the payload
+	_, err = Transcode(compressed, common.ZstdEncoding, zstd)
+	assert.Error(t, err)
+
+	dictionary.Register(dict)
+	transcoded, err := Transcode(compressed, common.ZstdEncoding, zstd)
+	require.NoError(t, err)
+	assert.Zero(t, dictionary.FrameID(transcoded))
+	decompressed, err := zstd.Decompress(transcoded)
+	require.NoError(t, err)
+	assert.Equal(t, payload(42), decompressed)
+}
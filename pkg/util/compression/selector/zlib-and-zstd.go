@@ -39,6 +39,22 @@ func NewCompressor(kind string, level int) common.Compressor {
 	}
 }
 
+// NewCompressorWithDictionary returns a new Compressor based on serializer_compressor_kind, compressing with the
+// given dictionary when the kind is zstd.
+// This function is called only when the zlib and zstd build tags are included
+func NewCompressorWithDictionary(kind string, level int, dictionary []byte) common.Compressor {
+	if kind == common.ZstdKind {
+		return implzstd.New(implzstd.Requires{
+			Level:      common.ZstdCompressionLevel(level),
+			Dictionary: dictionary,
+		})
+	}
+	if len(dictionary) > 0 {
+		log.Warnf("compression dictionaries are only supported by zstd, compressing with %s without it", kind)
+	}
+	return NewCompressor(kind, level)
+}
+
 // NewNoopCompressor returns a new Noop Compressor. It does not do any
 // compression, but can be used to create a compressor that does at a later
 // point.
@@ -619,6 +619,33 @@ func TestAuthorization(t *testing.T) {
 		`  authorization: "********"`)
 }
 
+func TestHashKey(t *testing.T) {
+	assertClean(t,
+		`hash_key: s3cr3t`,
+		`hash_key: "********"`)
+	assertClean(t,
+		`
+logs:
+  - type: file
+    log_processing_rules:
+      - type: hash_attribute
+        name: hash_emails
+        attribute: user.email
+        hash_key: s3cr3t`,
+		`
+logs:
+  - type: file
+    log_processing_rules:
+      - type: hash_attribute
+        name: hash_emails
+        attribute: user.email
+        hash_key: "********"`)
+
+	scrubbed, err := ScrubJSON([]byte(`{"type":"hash_attribute","attribute":"user.email","hash_key":"s3cr3t"}`))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"type":"hash_attribute","attribute":"user.email","hash_key":"********"}`, string(scrubbed))
+}
+
 func TestScrubCommandsEnv(t *testing.T) {
 	testCases := []struct {
 		name     string